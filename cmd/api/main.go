@@ -2,16 +2,25 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"log"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"user-management/internal/application/services"
-	"user-management/internal/infrastructure/http/handlers"
+	"user-management/internal/infrastructure/http/docs"
 	"user-management/internal/infrastructure/http/middlewares"
+	"user-management/internal/infrastructure/http/server"
+	"user-management/internal/infrastructure/notifications"
+	"user-management/internal/infrastructure/persistence/cache"
 	"user-management/internal/infrastructure/persistence/memory"
 	"user-management/internal/infrastructure/workers"
+	"user-management/pkg/jwt"
 	// "user-management/internal/infrastructure/storage"
 )
 
@@ -24,59 +33,101 @@ func main() {
 	// Inicializar dependencias
 	userRepo := memory.NewUserRepository()
 	orderRepo := memory.NewOrderRepository()
+	unitOfWork := memory.NewUnitOfWork(userRepo, orderRepo)
 
-	worker := workers.NewWorkerPool(5, 100)
-	go worker.Start(context.Background())
-	defer worker.Stop(context.Background())
-
-	userService := services.NewUserService(userRepo)
-	orderService := services.NewOrderService(orderRepo, worker)
-
-	// Crear router
-	router := gin.Default()
-
-	// Middlewares globales
-	router.Use(middlewares.LoggingMiddleware())
-	router.Use(gin.Recovery()) // Recupera de panics
+	// Los servicios leen a través de la caché; la unidad de trabajo opera
+	// sobre los repositorios reales
+	cacheOptions := cache.Options{Capacity: 1000, TTL: time.Minute}
+	cachedUsers := cache.NewUserRepository(userRepo, cacheOptions)
+	cachedOrders := cache.NewOrderRepository(orderRepo, cacheOptions)
 
-	// CORS básico
-	router.Use(func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	worker := workers.NewWorkerPool(5, 100)
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	// Tokens firmados (acceso y verificación de email) y de un solo uso
+	signer := jwt.HS256(tokenSecret())
+	tokenStore := memory.NewOneTimeTokenStore()
+	rateLimiter := memory.NewRateLimiter()
+
+	verificationService := services.NewEmailVerificationService(cachedUsers, tokenStore, rateLimiter,
+		notifications.LogNotifier{}, signer, services.EmailVerificationOptions{VerifyURL: os.Getenv("EMAIL_VERIFY_URL")})
+	passwordService := services.NewPasswordService(cachedUsers, tokenStore, rateLimiter,
+		notifications.LogNotifier{}, services.PasswordOptions{ResetURL: os.Getenv("PASSWORD_RESET_URL")})
+	// Los fallos de login se frenan por cuenta y por IP; los bloqueos quedan
+	// en la auditoría
+	auditLog := memory.NewAuditLog(10000)
+	loginGuard := services.NewLoginGuard(memory.NewLoginAttemptStore(), cachedUsers, auditLog, services.LoginGuardOptions{})
+	// Cada login abre una sesión que se renueva con su refresh token
+	sessionService := services.NewSessionService(memory.NewSessionStore(), cachedUsers, auditLog, services.SessionOptions{
+		RefreshTokenTTL: durationFromEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+	})
+	authService := services.NewAuthService(cachedUsers, rateLimiter, loginGuard, sessionService, signer, services.AuthOptions{
+		RequireVerifiedEmail: os.Getenv("REQUIRE_EMAIL_VERIFICATION") == "true",
+	})
 
-		c.Next()
+	// Los administradores pueden actuar como un usuario con un token corto;
+	// cada petición queda en la auditoría
+	impersonationService := services.NewImpersonationService(cachedUsers, signer, auditLog, services.ImpersonationOptions{
+		TokenTTL: durationFromEnv("IMPERSONATION_TTL", 15*time.Minute),
 	})
-	router.Use(gin.Recovery())
-	router.Use(gin.Logger())
 
-	// Rutas públicas
-	public := router.Group("/api/v1")
-	{
-		healthHandler := handlers.NewHealthHandler()
-		healthHandler.RegisterRoutes(public)
-	}
+	mfaService := services.NewMFAService(cachedUsers, services.MFAOptions{Issuer: os.Getenv("MFA_ISSUER")})
+	apiKeyService := services.NewAPIKeyService(memory.NewAPIKeyRepository(), cachedUsers, auditLog)
 
-	// Rutas protegidas (con autenticación)
-	api := router.Group("/api/v1")
-	api.Use(middlewares.AuthMiddleware()) // Middleware de auth
-	{
-		// Users
-		userHandler := handlers.NewUserHandler(userService)
-		userHandler.RegisterRoutes(api)
+	// Proveedor OpenID Connect para otras aplicaciones; sin OIDC_ISSUER no
+	// se publica
+	var oidcService *services.OIDCService
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		oidcService = services.NewOIDCService(memory.NewOAuthClientRepository(), cachedUsers, tokenStore,
+			memory.NewOIDCSessionStore(), oidcSigningKey(), services.OIDCOptions{Issuer: issuer})
+	}
 
-		// Orders
-		orderHandler := handlers.NewOrderHandler(orderService)
-		orderHandler.RegisterRoutes(api)
+	userService := services.NewUserService(cachedUsers, services.WithEmailVerification(verificationService))
+	orderService := services.NewOrderService(cachedOrders, cachedUsers, worker, unitOfWork)
+	if err := orderService.Start(context.Background()); err != nil {
+		log.Fatal("Error iniciando el worker de órdenes:", err)
 	}
+	defer worker.Stop(context.Background())
 
-	// Servir documentación
-	router.Static("/docs", "./docs")
+	// Los usuarios borrados se purgan, junto con sus órdenes, al superar la
+	// ventana de retención
+	retentionService := services.NewUserRetentionService(cachedUsers, cachedOrders, unitOfWork,
+		durationFromEnv("USER_RETENTION", 30*24*time.Hour))
+	purgeScheduler := workers.NewPurgeScheduler(retentionService, durationFromEnv("USER_PURGE_INTERVAL", time.Hour))
+	if err := purgeScheduler.Start(context.Background()); err != nil {
+		log.Fatal("Error iniciando la purga de usuarios:", err)
+	}
+	defer purgeScheduler.Stop(context.Background())
+
+	router, err := server.NewRouter(server.Services{
+		Users:         userService,
+		Orders:        orderService,
+		Auth:          authService,
+		Verification:  verificationService,
+		Passwords:     passwordService,
+		MFA:           mfaService,
+		APIKeys:       apiKeyService,
+		Sessions:      sessionService,
+		LoginGuard:    loginGuard,
+		Impersonation: impersonationService,
+		OIDC:          oidcService,
+		AuditLog:      auditLog,
+		Idempotency:   memory.NewIdempotencyStore(),
+	}, server.Config{
+		AdminToken: os.Getenv("ADMIN_TOKEN"),
+		// Sin SCIM_TOKEN no se publica SCIM
+		SCIMToken:      os.Getenv("SCIM_TOKEN"),
+		IdempotencyTTL: durationFromEnv("IDEMPOTENCY_TTL", middlewares.DefaultIdempotencyTTL),
+		// Validación opcional contra la especificación OpenAPI
+		Contract: contractValidation(),
+		Metrics: map[string]func() any{
+			"cache": func() any {
+				return gin.H{"users": cachedUsers.Stats(), "orders": cachedOrders.Stats()}
+			},
+		},
+	})
+	if err != nil {
+		log.Fatal("Error cargando la especificación OpenAPI:", err)
+	}
 
 	// Iniciar servidor
 	port := os.Getenv("PORT")
@@ -91,3 +142,82 @@ func main() {
 		log.Fatal("Error iniciando servidor:", err)
 	}
 }
+
+// durationFromEnv lee una duración (p. ej. "720h") de la variable de entorno
+// name, usando def si no está definida o no es válida
+func durationFromEnv(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("%s inválido (%q), usando %s", name, value, def)
+		return def
+	}
+	return d
+}
+
+// contractValidation devuelve el validador OpenAPI si OPENAPI_VALIDATION es
+// "true"; en modo test de Gin comprueba también las respuestas
+func contractValidation() []gin.HandlerFunc {
+	if os.Getenv("OPENAPI_VALIDATION") != "true" {
+		return nil
+	}
+
+	doc, err := docs.Document()
+	if err != nil {
+		log.Fatal("Error cargando la especificación OpenAPI:", err)
+	}
+	return []gin.HandlerFunc{middlewares.OpenAPIValidator(doc, middlewares.OpenAPIOptions{
+		ValidateResponses: gin.Mode() == gin.TestMode,
+	})}
+}
+
+// tokenSecret lee la clave de firma de TOKEN_SECRET. Sin ella se genera una
+// aleatoria y los tokens emitidos dejan de valer al reiniciar.
+func tokenSecret() []byte {
+	if secret := os.Getenv("TOKEN_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+
+	log.Printf("TOKEN_SECRET no definido, se usa una clave aleatoria")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatal("Error generando la clave de firma:", err)
+	}
+	return secret
+}
+
+// oidcSigningKey lee de OIDC_SIGNING_KEY la clave RSA, en PEM (PKCS #1 o
+// PKCS #8), con la que se firman los ID tokens. Sin ella se genera una
+// aleatoria y los clientes deben volver a leer el JWKS al reiniciar.
+func oidcSigningKey() *rsa.PrivateKey {
+	encoded := os.Getenv("OIDC_SIGNING_KEY")
+	if encoded == "" {
+		log.Printf("OIDC_SIGNING_KEY no definido, se usa una clave aleatoria")
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			log.Fatal("Error generando la clave de firma OIDC:", err)
+		}
+		return key
+	}
+
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		log.Fatal("OIDC_SIGNING_KEY no es un PEM válido")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		log.Fatal("Error leyendo OIDC_SIGNING_KEY:", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		log.Fatal("OIDC_SIGNING_KEY no es una clave RSA")
+	}
+	return key
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// apiKeyPrefix distingue las API keys de otros secretos (p. ej. en
+	// escáneres de secretos filtrados)
+	apiKeyPrefix = "umk_"
+	// apiKeyShownPrefix son los caracteres de la key que se guardan en claro
+	// para reconocerla en los listados
+	apiKeyShownPrefix = len(apiKeyPrefix) + 8
+	// apiKeyUsageInterval evita escribir la key en cada petición: el último
+	// uso se actualiza como mucho una vez por intervalo
+	apiKeyUsageInterval = time.Minute
+)
+
+var (
+	ErrAPIKeyRejected     = errs.New(errs.Unauthorized, "api_key_rejected", "API key is invalid, expired or revoked")
+	ErrNoPrincipal        = errs.New(errs.Unauthorized, "no_principal", "this operation requires authentication")
+	ErrScopeNotAllowed    = errs.New(errs.Forbidden, "scope_not_allowed", "scope cannot be granted by this account")
+	ErrAPIKeyNotPermitted = errs.New(errs.Forbidden, "api_key_not_permitted", "API keys cannot manage API keys")
+)
+
+// APIKeyService emite API keys para clientes máquina a máquina. La key sólo
+// se muestra al crearla; se guarda su hash SHA-256, que basta porque es un
+// valor aleatorio de 256 bits.
+type APIKeyService struct {
+	keys  output.APIKeyRepository
+	users output.UserRepository
+	audit output.AuditLog
+}
+
+var _ input.APIKeyService = (*APIKeyService)(nil)
+
+func NewAPIKeyService(keys output.APIKeyRepository, users output.UserRepository, audit output.AuditLog) *APIKeyService {
+	return &APIKeyService{keys: keys, users: users, audit: audit}
+}
+
+// Create implements [input.APIKeyService]. Los scopes de administración
+// sólo los conceden administradores, y la key hereda el rol de quien la
+// crea.
+func (s *APIKeyService) Create(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (*input.IssuedAPIKey, error) {
+	owner, err := keyOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := denyImpersonation(ctx); err != nil {
+		return nil, err
+	}
+	if owner.Role != entities.RoleAdmin &&
+		(slices.Contains(scopes, entities.ScopeAdminRead) || slices.Contains(scopes, entities.ScopeAdminWrite)) {
+		return nil, ErrScopeNotAllowed
+	}
+
+	secret := apiKeyPrefix + newSecret()
+	key, err := entities.NewAPIKey(owner.UserID, owner.Role, name, scopes, expiresAt,
+		secret[:apiKeyShownPrefix], hashToken(secret))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.keys.Save(ctx, key); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, entities.NewAuditEntry(entities.AuditAPIKeyCreated, owner.UserID, "api_key:"+key.ID.String()))
+	return &input.IssuedAPIKey{Key: key, Secret: secret}, nil
+}
+
+// List implements [input.APIKeyService].
+func (s *APIKeyService) List(ctx context.Context) ([]*entities.APIKey, error) {
+	owner, err := keyOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.keys.FindByOwner(ctx, owner.UserID)
+}
+
+// Revoke implements [input.APIKeyService]. Las keys de otros usuarios se
+// tratan como inexistentes para no revelar sus identificadores.
+func (s *APIKeyService) Revoke(ctx context.Context, id uuid.UUID) error {
+	owner, err := keyOwner(ctx)
+	if err != nil {
+		return err
+	}
+	if err := denyImpersonation(ctx); err != nil {
+		return err
+	}
+
+	key, err := s.keys.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if owner.Role != entities.RoleAdmin && key.UserID != owner.UserID {
+		return output.ErrAPIKeyNotFound
+	}
+	if err := key.Revoke(time.Now()); err != nil {
+		return err
+	}
+	if err := s.keys.Update(ctx, key); err != nil {
+		return err
+	}
+
+	s.record(ctx, entities.NewAuditEntry(entities.AuditAPIKeyRevoked, owner.UserID, "api_key:"+key.ID.String()))
+	return nil
+}
+
+// AuthenticateAPIKey implements [input.APIKeyAuthenticator]. Las keys de un
+// usuario dejan de valer si se desactiva o se borra.
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, secret string, client input.ClientInfo) (*entities.Principal, error) {
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, ErrAPIKeyRejected
+	}
+	key, err := s.keys.FindByHash(ctx, hashToken(secret))
+	if errors.Is(err, output.ErrAPIKeyNotFound) {
+		return nil, ErrAPIKeyRejected
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !key.IsActive(now) {
+		return nil, ErrAPIKeyRejected
+	}
+	if key.UserID != uuid.Nil {
+		user, err := s.users.FindByID(ctx, key.UserID)
+		if errors.Is(err, output.ErrUserNotFound) {
+			return nil, ErrAPIKeyRejected
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !user.Active {
+			return nil, ErrAPIKeyRejected
+		}
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyUsageInterval || key.LastUsedIP != client.IP {
+		// El registro de uso es informativo: si falla, la key sigue valiendo.
+		// Sólo se escribe el uso; guardar key entera podría deshacer una
+		// revocación hecha mientras tanto.
+		if err := s.keys.TouchLastUsed(ctx, key.ID, now, client.IP); err != nil {
+			log.Printf("no se pudo registrar el uso de la API key %s: %v", key.ID, err)
+		}
+	}
+
+	return &entities.Principal{UserID: key.UserID, Role: key.Role, Scopes: key.Scopes, APIKeyID: key.ID}, nil
+}
+
+// keyOwner devuelve el principal de ctx; una API key no puede gestionar
+// otras, o una key filtrada serviría para emitir keys nuevas
+func keyOwner(ctx context.Context) (*entities.Principal, error) {
+	principal, ok := entities.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, ErrNoPrincipal
+	}
+	if principal.APIKeyID != uuid.Nil {
+		return nil, ErrAPIKeyNotPermitted
+	}
+	return principal, nil
+}
+
+// record escribe en la auditoría; como en LoginGuard, un fallo sólo se anota
+// en el log
+func (s *APIKeyService) record(ctx context.Context, entry *entities.AuditEntry) {
+	if err := s.audit.Record(ctx, entry); err != nil {
+		log.Printf("no se pudo registrar %s de %s en la auditoría: %v", entry.Action, entry.Target, err)
+	}
+}
@@ -0,0 +1,195 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"user-management/internal/application/services"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type apiKeyFixture struct {
+	keys    *mocks.APIKeyRepositoryFake
+	audit   *mocks.AuditLogFake
+	service *services.APIKeyService
+	user    *entities.User
+	// ctx lleva al usuario como principal
+	ctx context.Context
+}
+
+func newAPIKeyFixture(t *testing.T) *apiKeyFixture {
+	user, err := entities.NewUser("John Doe", "john@example.com", 30, "Password123!")
+	require.NoError(t, err)
+
+	repo := new(mocks.MockUserRepository)
+	repo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+	repo.On("FindByID", mock.Anything, mock.Anything).Return(nil, output.ErrUserNotFound)
+
+	f := &apiKeyFixture{keys: &mocks.APIKeyRepositoryFake{}, audit: &mocks.AuditLogFake{}, user: user}
+	f.service = services.NewAPIKeyService(f.keys, repo, f.audit)
+	f.ctx = entities.ContextWithPrincipal(context.Background(),
+		&entities.Principal{UserID: user.ID, Role: entities.RoleUser})
+	return f
+}
+
+func TestAPIKeyService_Create(t *testing.T) {
+	t.Run("the key is returned once and only its hash is stored", func(t *testing.T) {
+		f := newAPIKeyFixture(t)
+
+		issued, err := f.service.Create(f.ctx, "ci", []string{entities.ScopeUsersRead}, nil)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(issued.Secret, "umk_"))
+		assert.True(t, strings.HasPrefix(issued.Secret, issued.Key.Prefix))
+		assert.Equal(t, f.user.ID, issued.Key.UserID)
+		assert.Equal(t, entities.RoleUser, issued.Key.Role)
+
+		stored, err := f.keys.FindByID(f.ctx, issued.Key.ID)
+		require.NoError(t, err)
+		assert.NotContains(t, stored.Hash, issued.Secret)
+		assert.NotEqual(t, issued.Secret, stored.Hash)
+
+		entries := f.audit.Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, entities.AuditAPIKeyCreated, entries[0].Action)
+	})
+
+	t.Run("users cannot grant admin scopes", func(t *testing.T) {
+		f := newAPIKeyFixture(t)
+
+		_, err := f.service.Create(f.ctx, "ci", []string{entities.ScopeAdminRead}, nil)
+		assert.ErrorIs(t, err, services.ErrScopeNotAllowed)
+
+		admin := entities.ContextWithPrincipal(context.Background(), &entities.Principal{Role: entities.RoleAdmin})
+		issued, err := f.service.Create(admin, "ops", []string{entities.ScopeAdminRead}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, entities.RoleAdmin, issued.Key.Role)
+	})
+
+	t.Run("invalid input reports every field", func(t *testing.T) {
+		f := newAPIKeyFixture(t)
+		past := time.Now().Add(-time.Hour)
+
+		_, err := f.service.Create(f.ctx, " ", []string{"account:write"}, &past)
+		var verr *errs.ValidationError
+		require.ErrorAs(t, err, &verr)
+		assert.Contains(t, verr.Fields, "name")
+		assert.Contains(t, verr.Fields, "scopes")
+		assert.Contains(t, verr.Fields, "expires_at")
+	})
+
+	t.Run("API keys cannot create keys", func(t *testing.T) {
+		f := newAPIKeyFixture(t)
+		ctx := entities.ContextWithPrincipal(context.Background(), &entities.Principal{
+			UserID: f.user.ID, Role: entities.RoleUser, Scopes: []string{entities.ScopeUsersWrite}, APIKeyID: uuid.New(),
+		})
+
+		_, err := f.service.Create(ctx, "ci", []string{entities.ScopeUsersRead}, nil)
+		assert.ErrorIs(t, err, services.ErrAPIKeyNotPermitted)
+	})
+}
+
+func TestAPIKeyService_AuthenticateAPIKey(t *testing.T) {
+	client := input.ClientInfo{IP: "10.0.0.1"}
+
+	t.Run("a valid key authenticates its owner with its scopes", func(t *testing.T) {
+		f := newAPIKeyFixture(t)
+		issued, err := f.service.Create(f.ctx, "ci", []string{entities.ScopeOrdersRead}, nil)
+		require.NoError(t, err)
+
+		principal, err := f.service.AuthenticateAPIKey(context.Background(), issued.Secret, client)
+		require.NoError(t, err)
+		assert.Equal(t, f.user.ID, principal.UserID)
+		assert.Equal(t, issued.Key.ID, principal.APIKeyID)
+		assert.True(t, principal.Allows(entities.ScopeOrdersRead))
+		assert.False(t, principal.Allows(entities.ScopeOrdersWrite))
+	})
+
+	t.Run("last use is recorded at most once a minute per IP", func(t *testing.T) {
+		f := newAPIKeyFixture(t)
+		issued, err := f.service.Create(f.ctx, "ci", []string{entities.ScopeOrdersRead}, nil)
+		require.NoError(t, err)
+
+		for range 3 {
+			_, err = f.service.AuthenticateAPIKey(context.Background(), issued.Secret, client)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 1, f.keys.Touches)
+		assert.Zero(t, f.keys.Updates)
+
+		stored, err := f.keys.FindByID(f.ctx, issued.Key.ID)
+		require.NoError(t, err)
+		require.NotNil(t, stored.LastUsedAt)
+		assert.Equal(t, "10.0.0.1", stored.LastUsedIP)
+
+		_, err = f.service.AuthenticateAPIKey(context.Background(), issued.Secret, input.ClientInfo{IP: "10.0.0.2"})
+		require.NoError(t, err)
+		assert.Equal(t, 2, f.keys.Touches)
+	})
+
+	t.Run("revoked, expired and unknown keys are rejected", func(t *testing.T) {
+		f := newAPIKeyFixture(t)
+		revoked, err := f.service.Create(f.ctx, "revoked", []string{entities.ScopeUsersRead}, nil)
+		require.NoError(t, err)
+		require.NoError(t, f.service.Revoke(f.ctx, revoked.Key.ID))
+
+		soon := time.Now().Add(50 * time.Millisecond)
+		expired, err := f.service.Create(f.ctx, "expired", []string{entities.ScopeUsersRead}, &soon)
+		require.NoError(t, err)
+		time.Sleep(60 * time.Millisecond)
+
+		for _, secret := range []string{revoked.Secret, expired.Secret, "umk_unknown", "not-a-key"} {
+			_, err := f.service.AuthenticateAPIKey(context.Background(), secret, client)
+			assert.ErrorIs(t, err, services.ErrAPIKeyRejected, secret)
+		}
+	})
+
+	t.Run("keys of disabled users are rejected", func(t *testing.T) {
+		f := newAPIKeyFixture(t)
+		issued, err := f.service.Create(f.ctx, "ci", []string{entities.ScopeUsersRead}, nil)
+		require.NoError(t, err)
+		f.user.Active = false
+
+		_, err = f.service.AuthenticateAPIKey(context.Background(), issued.Secret, client)
+		assert.ErrorIs(t, err, services.ErrAPIKeyRejected)
+	})
+}
+
+func TestAPIKeyService_Revoke(t *testing.T) {
+	t.Run("users cannot see or revoke other users' keys", func(t *testing.T) {
+		f := newAPIKeyFixture(t)
+		issued, err := f.service.Create(f.ctx, "ci", []string{entities.ScopeUsersRead}, nil)
+		require.NoError(t, err)
+
+		other := entities.ContextWithPrincipal(context.Background(), &entities.Principal{UserID: uuid.New(), Role: entities.RoleUser})
+		keys, err := f.service.List(other)
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+		assert.ErrorIs(t, f.service.Revoke(other, issued.Key.ID), output.ErrAPIKeyNotFound)
+	})
+
+	t.Run("admins can revoke any key, once", func(t *testing.T) {
+		f := newAPIKeyFixture(t)
+		issued, err := f.service.Create(f.ctx, "ci", []string{entities.ScopeUsersRead}, nil)
+		require.NoError(t, err)
+
+		admin := entities.ContextWithPrincipal(context.Background(), &entities.Principal{Role: entities.RoleAdmin})
+		require.NoError(t, f.service.Revoke(admin, issued.Key.ID))
+		assert.ErrorIs(t, f.service.Revoke(admin, issued.Key.ID), entities.ErrAPIKeyRevoked)
+
+		keys, err := f.service.List(f.ctx)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		assert.NotNil(t, keys[0].RevokedAt)
+		assert.Equal(t, entities.AuditAPIKeyRevoked, f.audit.Entries()[1].Action)
+	})
+}
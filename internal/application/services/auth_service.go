@@ -0,0 +1,318 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/internal/domain/valueobjects"
+	"user-management/pkg/jwt"
+
+	"github.com/google/uuid"
+)
+
+const (
+	purposeAccess       = "access"
+	purposeMFAChallenge = "mfa_challenge"
+)
+
+// dummyPassword se compara cuando el email no existe para que la respuesta
+// tarde lo mismo y no delate qué cuentas hay
+var dummyPassword, _ = valueobjects.NewPasswordHash("$2a$10$iv0qmabvBx5kabV9mxAgceDiFu9bOTPhbegqak7deDpqmfQ6Kygzy")
+
+var (
+	ErrInvalidCredentials = errs.New(errs.Unauthorized, "invalid_credentials", "invalid email or password")
+	ErrEmailNotVerified   = errs.New(errs.Forbidden, "email_not_verified", "email address has not been verified")
+	ErrAccountDisabled    = errs.New(errs.Forbidden, "account_disabled", "account is disabled")
+	ErrInvalidAccessToken = errs.New(errs.Unauthorized, "invalid_access_token", "access token is invalid or expired")
+	ErrInvalidChallenge   = errs.New(errs.Unauthorized, "invalid_mfa_challenge", "MFA challenge is invalid or expired")
+)
+
+// AuthOptions configura AuthService; los campos a cero toman los valores
+// por defecto
+type AuthOptions struct {
+	AccessTokenTTL time.Duration // 15m
+	// RequireVerifiedEmail impide iniciar sesión hasta verificar el email
+	RequireVerifiedEmail bool
+	// MFAChallengeTTL es lo que tiene el usuario para introducir el código
+	// de su segundo factor
+	MFAChallengeTTL time.Duration // 5m
+	// MFAAttempts son los códigos que puede probar cada usuario en
+	// MFAChallengeTTL
+	MFAAttempts int // 5
+}
+
+func (o *AuthOptions) defaults() {
+	if o.AccessTokenTTL <= 0 {
+		o.AccessTokenTTL = 15 * time.Minute
+	}
+	if o.MFAChallengeTTL <= 0 {
+		o.MFAChallengeTTL = 5 * time.Minute
+	}
+	if o.MFAAttempts <= 0 {
+		o.MFAAttempts = 5
+	}
+}
+
+// accessClaims son el contenido de los tokens de acceso. Version es el
+// TokenVersion del usuario al emitirlo.
+type accessClaims struct {
+	jwt.Claims
+	Purpose string `json:"purpose"`
+	Role    string `json:"role"`
+	Version int    `json:"ver"`
+	// SessionID es la sesión que emitió el token; vacío si no se guardan
+	// sesiones
+	SessionID string `json:"sid,omitempty"`
+	// Actor es el administrador que suplanta al usuario
+	Actor *actorClaim `json:"act,omitempty"`
+}
+
+// AuthService emite tokens de acceso firmados a cambio de credenciales y
+// los valida en cada petición. Los usuarios con MFA reciben antes un reto,
+// también firmado, que canjean junto con un código de su segundo factor.
+type AuthService struct {
+	users    output.UserRepository
+	limiter  output.RateLimiter
+	guard    *LoginGuard
+	sessions *SessionService
+	signer   jwt.Signer
+	opts     AuthOptions
+}
+
+var _ input.AuthService = (*AuthService)(nil)
+
+// NewAuthService crea el servicio; con guard nil no se limitan los fallos de
+// contraseña y con sessions nil los tokens de acceso no llevan sesión ni
+// refresh token
+func NewAuthService(users output.UserRepository, limiter output.RateLimiter, guard *LoginGuard, sessions *SessionService,
+	signer jwt.Signer, opts AuthOptions) *AuthService {
+	opts.defaults()
+	return &AuthService{users: users, limiter: limiter, guard: guard, sessions: sessions, signer: signer, opts: opts}
+}
+
+// Login implements [input.AuthService]. Los errores no distinguen un email
+// desconocido de una contraseña incorrecta; el estado de la cuenta sólo se
+// revela a quien conoce la contraseña.
+func (s *AuthService) Login(ctx context.Context, email, password string, client input.ClientInfo) (*input.LoginResult, error) {
+	if s.guard != nil {
+		if err := s.guard.Attempt(ctx, email, client); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := s.users.FindByEmail(ctx, email)
+	if errors.Is(err, output.ErrUserNotFound) {
+		dummyPassword.Matches(password)
+		return nil, s.failed(ctx, email, client)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.Password.Matches(password) {
+		return nil, s.failed(ctx, email, client)
+	}
+	if s.guard != nil {
+		if err := s.guard.Succeeded(ctx, email, client); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case !user.Active:
+		return nil, ErrAccountDisabled
+	case s.opts.RequireVerifiedEmail && !user.EmailVerified:
+		return nil, ErrEmailNotVerified
+	}
+
+	if user.MFAEnabled {
+		challenge, err := s.challenge(user)
+		if err != nil {
+			return nil, err
+		}
+		return &input.LoginResult{Challenge: challenge}, nil
+	}
+	token, err := s.login(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &input.LoginResult{AccessToken: token}, nil
+}
+
+// failed anota el fallo de contraseña y devuelve el error para el cliente
+func (s *AuthService) failed(ctx context.Context, email string, client input.ClientInfo) error {
+	if s.guard != nil {
+		if err := s.guard.Failed(ctx, email, client); err != nil {
+			return err
+		}
+	}
+	return ErrInvalidCredentials
+}
+
+// challenge emite el reto de MFA; como los tokens de acceso, deja de valer
+// si se revocan las sesiones del usuario
+func (s *AuthService) challenge(user *entities.User) (*input.MFAChallenge, error) {
+	claims := accessClaims{
+		Claims:  jwt.NewClaims(user.ID.String(), s.opts.MFAChallengeTTL),
+		Purpose: purposeMFAChallenge,
+		Version: user.TokenVersion,
+	}
+	token, err := jwt.Encode(s.signer, claims)
+	if err != nil {
+		return nil, err
+	}
+	return &input.MFAChallenge{Token: token, ExpiresAt: time.Unix(claims.ExpiresAt, 0)}, nil
+}
+
+// VerifyMFA implements [input.AuthService]. Los intentos se limitan por
+// usuario, no por reto, para que pedir retos nuevos no dé más intentos.
+func (s *AuthService) VerifyMFA(ctx context.Context, challenge, code string, client input.ClientInfo) (*input.AccessToken, error) {
+	var claims accessClaims
+	if err := jwt.Decode(s.signer, challenge, &claims); err != nil || claims.Purpose != purposeMFAChallenge {
+		return nil, ErrInvalidChallenge
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+	if err := allow(ctx, s.limiter, "mfa-verify:user:"+userID.String(), s.opts.MFAAttempts, s.opts.MFAChallengeTTL); err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, output.ErrUserNotFound) {
+		return nil, ErrInvalidChallenge
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active || !user.MFAEnabled || user.TokenVersion != claims.Version {
+		return nil, ErrInvalidChallenge
+	}
+
+	if !verifySecondFactor(user, code, time.Now()) {
+		return nil, ErrInvalidMFACode
+	}
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return s.login(ctx, user, client)
+}
+
+// Refresh implements [input.AuthService].
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string, client input.ClientInfo) (*input.AccessToken, error) {
+	if s.sessions == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	session, user, next, err := s.sessions.refresh(ctx, refreshToken, client)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(user, session.ID, next)
+}
+
+// login abre la sesión, si se guardan, y emite el primer token de acceso
+func (s *AuthService) login(ctx context.Context, user *entities.User, client input.ClientInfo) (*input.AccessToken, error) {
+	if s.sessions == nil {
+		return s.issue(user, uuid.Nil, "")
+	}
+	session, refreshToken, err := s.sessions.start(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(user, session.ID, refreshToken)
+}
+
+func (s *AuthService) issue(user *entities.User, sessionID uuid.UUID, refreshToken string) (*input.AccessToken, error) {
+	claims := accessClaims{
+		Claims:  jwt.NewClaims(user.ID.String(), s.opts.AccessTokenTTL),
+		Purpose: purposeAccess,
+		Role:    entities.RoleUser,
+		Version: user.TokenVersion,
+	}
+	if sessionID != uuid.Nil {
+		claims.SessionID = sessionID.String()
+	}
+	token, err := jwt.Encode(s.signer, claims)
+	if err != nil {
+		return nil, err
+	}
+	return &input.AccessToken{Token: token, ExpiresAt: time.Unix(claims.ExpiresAt, 0), RefreshToken: refreshToken}, nil
+}
+
+// Authenticate implements [input.Authenticator]. Además de la firma
+// comprueba que la cuenta siga activa y que sus sesiones no se hayan revocado
+// después de emitir el token.
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*entities.Principal, error) {
+	var claims accessClaims
+	if err := jwt.Decode(s.signer, token, &claims); err != nil || claims.Purpose != purposeAccess {
+		return nil, ErrInvalidAccessToken
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidAccessToken
+	}
+
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, output.ErrUserNotFound) {
+		return nil, ErrInvalidAccessToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active || user.TokenVersion != claims.Version {
+		return nil, ErrInvalidAccessToken
+	}
+
+	principal := &entities.Principal{UserID: userID, Role: claims.Role}
+	if claims.SessionID != "" {
+		sessionID, err := uuid.Parse(claims.SessionID)
+		if err != nil || s.sessions == nil {
+			return nil, ErrInvalidAccessToken
+		}
+		if err := s.sessions.check(ctx, user, sessionID); err != nil {
+			return nil, err
+		}
+		principal.SessionID = sessionID
+	}
+	if claims.Actor != nil {
+		if principal.Actor, err = s.actor(ctx, claims.Actor); err != nil {
+			return nil, err
+		}
+	}
+	return principal, nil
+}
+
+// actor resuelve el claim act de un token de suplantación. Un administrador
+// con usuario propio tiene que seguir activo y no haber revocado sus tokens
+// desde que lo emitió.
+func (s *AuthService) actor(ctx context.Context, claim *actorClaim) (*entities.Principal, error) {
+	actorID, err := parseActor(claim.Subject)
+	if err != nil || claim.Role == "" {
+		return nil, ErrInvalidAccessToken
+	}
+	if actorID != uuid.Nil {
+		actor, err := s.users.FindByID(ctx, actorID)
+		if errors.Is(err, output.ErrUserNotFound) {
+			return nil, ErrInvalidAccessToken
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !actor.Active || actor.TokenVersion != claim.Version {
+			return nil, ErrInvalidAccessToken
+		}
+	}
+	return &entities.Principal{UserID: actorID, Role: claim.Role}, nil
+}
+
+// parseActor lee el sujeto del claim act; vacío es uuid.Nil
+func parseActor(subject string) (uuid.UUID, error) {
+	if subject == "" {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(subject)
+}
@@ -0,0 +1,114 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"user-management/internal/application/services"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_Login(t *testing.T) {
+	ctx := context.Background()
+	client := input.ClientInfo{IP: "10.0.0.1"}
+
+	newService := func(t *testing.T, opts services.AuthOptions) (*services.AuthService, *entities.User) {
+		user, err := entities.NewUser("John Doe", "john@example.com", 30, "Password123!")
+		require.NoError(t, err)
+
+		repo := new(mocks.MockUserRepository)
+		repo.On("FindByEmail", mock.Anything, "john@example.com").Return(user, nil)
+		repo.On("FindByEmail", mock.Anything, mock.Anything).Return(nil, output.ErrUserNotFound)
+		repo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+		return services.NewAuthService(repo, mocks.AllowAll(), nil, nil, testSigner, opts), user
+	}
+
+	t.Run("issues an access token that authenticates the user", func(t *testing.T) {
+		service, user := newService(t, services.AuthOptions{AccessTokenTTL: time.Minute})
+
+		login, err := service.Login(ctx, "john@example.com", "Password123!", client)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), login.AccessToken.ExpiresAt, 2*time.Second)
+
+		principal, err := service.Authenticate(ctx, login.AccessToken.Token)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, principal.UserID)
+		assert.Equal(t, entities.RoleUser, principal.Role)
+	})
+
+	t.Run("tokens stop working when sessions are revoked or the account is disabled", func(t *testing.T) {
+		service, user := newService(t, services.AuthOptions{})
+		login, err := service.Login(ctx, "john@example.com", "Password123!", client)
+		require.NoError(t, err)
+
+		user.RevokeSessions(time.Now())
+		_, err = service.Authenticate(ctx, login.AccessToken.Token)
+		assert.ErrorIs(t, err, services.ErrInvalidAccessToken)
+
+		login, err = service.Login(ctx, "john@example.com", "Password123!", client)
+		require.NoError(t, err)
+		user.Active = false
+		_, err = service.Authenticate(ctx, login.AccessToken.Token)
+		assert.ErrorIs(t, err, services.ErrInvalidAccessToken)
+	})
+
+	t.Run("rejects wrong passwords and unknown emails alike", func(t *testing.T) {
+		service, _ := newService(t, services.AuthOptions{})
+
+		_, err := service.Login(ctx, "john@example.com", "wrong-password", client)
+		assert.ErrorIs(t, err, services.ErrInvalidCredentials)
+		_, err = service.Login(ctx, "nobody@example.com", "Password123!", client)
+		assert.ErrorIs(t, err, services.ErrInvalidCredentials)
+	})
+
+	t.Run("blocks unverified emails when required", func(t *testing.T) {
+		service, user := newService(t, services.AuthOptions{RequireVerifiedEmail: true})
+
+		_, err := service.Login(ctx, "john@example.com", "Password123!", client)
+		assert.ErrorIs(t, err, services.ErrEmailNotVerified)
+
+		user.VerifyEmail(time.Now())
+		_, err = service.Login(ctx, "john@example.com", "Password123!", client)
+		assert.NoError(t, err)
+	})
+
+	t.Run("allows unverified emails by default", func(t *testing.T) {
+		service, _ := newService(t, services.AuthOptions{})
+
+		_, err := service.Login(ctx, "john@example.com", "Password123!", client)
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects disabled accounts", func(t *testing.T) {
+		service, user := newService(t, services.AuthOptions{})
+		user.Active = false
+
+		_, err := service.Login(ctx, "john@example.com", "Password123!", client)
+		assert.ErrorIs(t, err, services.ErrAccountDisabled)
+	})
+}
+
+func TestAuthService_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	service := services.NewAuthService(new(mocks.MockUserRepository), mocks.AllowAll(), nil, nil, testSigner, services.AuthOptions{})
+
+	t.Run("rejects tokens issued for other purposes", func(t *testing.T) {
+		f := newVerificationFixture(t, mocks.AllowAll())
+		require.NoError(t, f.service.SendVerification(ctx, f.user))
+
+		_, err := service.Authenticate(ctx, f.lastToken(t))
+		assert.ErrorIs(t, err, services.ErrInvalidAccessToken)
+	})
+
+	t.Run("rejects garbage", func(t *testing.T) {
+		_, err := service.Authenticate(ctx, "garbage")
+		assert.ErrorIs(t, err, services.ErrInvalidAccessToken)
+	})
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/internal/domain/valueobjects"
+	"user-management/pkg/jwt"
+
+	"github.com/google/uuid"
+)
+
+// PurposeEmailVerification identifica los tokens de verificación en el
+// almacén de tokens de un solo uso
+const PurposeEmailVerification = "email_verification"
+
+// EmailVerificationOptions configura EmailVerificationService; los campos a
+// cero toman los valores por defecto
+type EmailVerificationOptions struct {
+	TokenTTL time.Duration // 24h
+	// VerifyURL es el enlace que recibe el usuario, al que se añade
+	// ?token=...; si está vacío se envía sólo el token
+	VerifyURL string
+	// Reenvíos permitidos por email y por IP en cada ventana
+	ResendPerEmail int           // 3
+	ResendPerIP    int           // 10
+	ResendWindow   time.Duration // 1h
+}
+
+func (o *EmailVerificationOptions) defaults() {
+	if o.TokenTTL <= 0 {
+		o.TokenTTL = 24 * time.Hour
+	}
+	if o.ResendPerEmail <= 0 {
+		o.ResendPerEmail = 3
+	}
+	if o.ResendPerIP <= 0 {
+		o.ResendPerIP = 10
+	}
+	if o.ResendWindow <= 0 {
+		o.ResendWindow = time.Hour
+	}
+}
+
+// verificationClaims van firmados en el token. Email lo ata a la dirección
+// para la que se emitió: si el usuario la cambia, el token deja de valer.
+type verificationClaims struct {
+	jwt.Claims
+	Purpose string `json:"purpose"`
+	Email   string `json:"email"`
+}
+
+// EmailVerificationService emite tokens firmados de un solo uso: la firma
+// evita consultar el almacén con tokens falsificados y el almacén (que sólo
+// guarda el hash del jti) garantiza que cada token se use una vez.
+type EmailVerificationService struct {
+	users    output.UserRepository
+	tokens   output.OneTimeTokenStore
+	limiter  output.RateLimiter
+	notifier entities.Notifier
+	signer   jwt.Signer
+	opts     EmailVerificationOptions
+}
+
+var _ input.EmailVerificationService = (*EmailVerificationService)(nil)
+
+func NewEmailVerificationService(users output.UserRepository, tokens output.OneTimeTokenStore, limiter output.RateLimiter,
+	notifier entities.Notifier, signer jwt.Signer, opts EmailVerificationOptions) *EmailVerificationService {
+	opts.defaults()
+	return &EmailVerificationService{
+		users:    users,
+		tokens:   tokens,
+		limiter:  limiter,
+		notifier: notifier,
+		signer:   signer,
+		opts:     opts,
+	}
+}
+
+// SendVerification implements [input.EmailVerificationService].
+func (s *EmailVerificationService) SendVerification(ctx context.Context, user *entities.User) error {
+	if err := s.tokens.RevokeAll(ctx, PurposeEmailVerification, user.ID); err != nil {
+		return err
+	}
+
+	claims := verificationClaims{
+		Claims:  jwt.NewClaims(user.ID.String(), s.opts.TokenTTL),
+		Purpose: PurposeEmailVerification,
+		Email:   valueobjects.NormalizeEmail(user.Email),
+	}
+	claims.ID = newSecret()
+
+	token, err := jwt.Encode(s.signer, claims)
+	if err != nil {
+		return err
+	}
+	err = s.tokens.Save(ctx, output.OneTimeToken{
+		Hash:      hashToken(claims.ID),
+		Purpose:   PurposeEmailVerification,
+		UserID:    user.ID,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.notifier.Send(&entities.Notification{
+		Email:   user.Email,
+		Title:   "Verify your email address",
+		Message: "Confirm your email address with this link: " + s.link(token),
+		Type:    PurposeEmailVerification,
+	})
+}
+
+func (s *EmailVerificationService) link(token string) string {
+	if s.opts.VerifyURL == "" {
+		return token
+	}
+	return s.opts.VerifyURL + "?token=" + url.QueryEscape(token)
+}
+
+// VerifyEmail implements [input.EmailVerificationService].
+func (s *EmailVerificationService) VerifyEmail(ctx context.Context, token string) (*entities.User, error) {
+	var claims verificationClaims
+	if err := jwt.Decode(s.signer, token, &claims); err != nil || claims.Purpose != PurposeEmailVerification {
+		return nil, output.ErrTokenNotFound
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, output.ErrTokenNotFound
+	}
+
+	if _, err := s.tokens.Consume(ctx, PurposeEmailVerification, hashToken(claims.ID)); err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, output.ErrUserNotFound) {
+		return nil, output.ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if valueobjects.NormalizeEmail(user.Email) != claims.Email {
+		return nil, output.ErrTokenNotFound
+	}
+	if user.EmailVerified {
+		return user, nil
+	}
+
+	user.VerifyEmail(time.Now())
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ResendVerification implements [input.EmailVerificationService].
+func (s *EmailVerificationService) ResendVerification(ctx context.Context, email string, client input.ClientInfo) error {
+	email = valueobjects.NormalizeEmail(email)
+	if err := allow(ctx, s.limiter, "verify-resend:email:"+email, s.opts.ResendPerEmail, s.opts.ResendWindow); err != nil {
+		return err
+	}
+	if err := allow(ctx, s.limiter, "verify-resend:ip:"+client.IP, s.opts.ResendPerIP, s.opts.ResendWindow); err != nil {
+		return err
+	}
+
+	user, err := s.users.FindByEmail(ctx, email)
+	if errors.Is(err, output.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return nil
+	}
+	return s.SendVerification(ctx, user)
+}
@@ -0,0 +1,172 @@
+package services_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"user-management/internal/application/services"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/pkg/jwt"
+	"user-management/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testSigner = jwt.HS256([]byte("test-secret"))
+
+type verificationFixture struct {
+	repo     *mocks.MockUserRepository
+	tokens   *mocks.OneTimeTokenStoreFake
+	notifier *mocks.NotifierMock
+	service  *services.EmailVerificationService
+	user     *entities.User
+}
+
+func newVerificationFixture(t *testing.T, limiter *mocks.RateLimiterMock) *verificationFixture {
+	user, err := entities.NewUser("John Doe", "john@example.com", 30, "Password123!")
+	require.NoError(t, err)
+
+	f := &verificationFixture{
+		repo:     new(mocks.MockUserRepository),
+		tokens:   mocks.NewOneTimeTokenStoreFake(),
+		notifier: &mocks.NotifierMock{},
+		user:     user,
+	}
+	f.service = services.NewEmailVerificationService(f.repo, f.tokens, limiter, f.notifier, testSigner,
+		services.EmailVerificationOptions{VerifyURL: "https://app.example.com/verify"})
+
+	f.repo.On("FindByID", mock.Anything, user.ID).Return(user, nil).Maybe()
+	f.repo.On("Update", mock.Anything, user).Return(nil).Maybe()
+	return f
+}
+
+// lastToken extrae el token del enlace de la última notificación enviada
+func (f *verificationFixture) lastToken(t *testing.T) string {
+	sent := f.notifier.Sent()
+	require.NotEmpty(t, sent)
+
+	message := sent[len(sent)-1].Message
+	link, err := url.Parse(message[strings.LastIndex(message, " ")+1:])
+	require.NoError(t, err)
+	return link.Query().Get("token")
+}
+
+func TestEmailVerificationService_VerifyEmail(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("a sent token verifies the email once", func(t *testing.T) {
+		f := newVerificationFixture(t, mocks.AllowAll())
+		require.NoError(t, f.service.SendVerification(ctx, f.user))
+
+		sent := f.notifier.Sent()
+		require.Len(t, sent, 1)
+		assert.Equal(t, "john@example.com", sent[0].Email)
+		assert.Equal(t, services.PurposeEmailVerification, sent[0].Type)
+
+		token := f.lastToken(t)
+		user, err := f.service.VerifyEmail(ctx, token)
+		require.NoError(t, err)
+		assert.True(t, user.EmailVerified)
+		assert.NotNil(t, user.EmailVerifiedAt)
+		f.repo.AssertCalled(t, "Update", mock.Anything, f.user)
+
+		_, err = f.service.VerifyEmail(ctx, token)
+		assert.ErrorIs(t, err, output.ErrTokenNotFound)
+	})
+
+	t.Run("sending again invalidates the previous token", func(t *testing.T) {
+		f := newVerificationFixture(t, mocks.AllowAll())
+		require.NoError(t, f.service.SendVerification(ctx, f.user))
+		first := f.lastToken(t)
+		require.NoError(t, f.service.SendVerification(ctx, f.user))
+
+		_, err := f.service.VerifyEmail(ctx, first)
+		assert.ErrorIs(t, err, output.ErrTokenNotFound)
+		_, err = f.service.VerifyEmail(ctx, f.lastToken(t))
+		assert.NoError(t, err)
+	})
+
+	t.Run("a token stops working when the email changes", func(t *testing.T) {
+		f := newVerificationFixture(t, mocks.AllowAll())
+		require.NoError(t, f.service.SendVerification(ctx, f.user))
+		require.NoError(t, f.user.Update(f.user.Name, "other@example.com", f.user.Age, true))
+
+		_, err := f.service.VerifyEmail(ctx, f.lastToken(t))
+		assert.ErrorIs(t, err, output.ErrTokenNotFound)
+		assert.False(t, f.user.EmailVerified)
+	})
+
+	t.Run("rejects forged and unrelated tokens", func(t *testing.T) {
+		f := newVerificationFixture(t, mocks.AllowAll())
+		forged, err := jwt.Encode(jwt.HS256([]byte("other")), jwt.NewClaims(f.user.ID.String(), time.Hour))
+		require.NoError(t, err)
+
+		for _, token := range []string{"", "garbage", forged} {
+			_, err := f.service.VerifyEmail(ctx, token)
+			assert.ErrorIs(t, err, output.ErrTokenNotFound)
+		}
+	})
+}
+
+func TestEmailVerificationService_ResendVerification(t *testing.T) {
+	ctx := context.Background()
+	client := input.ClientInfo{IP: "10.0.0.1"}
+
+	t.Run("resends to unverified accounts", func(t *testing.T) {
+		f := newVerificationFixture(t, mocks.AllowAll())
+		f.repo.On("FindByEmail", mock.Anything, "john@example.com").Return(f.user, nil)
+
+		require.NoError(t, f.service.ResendVerification(ctx, " John@Example.com ", client))
+		assert.Len(t, f.notifier.Sent(), 1)
+	})
+
+	t.Run("does not reveal unknown or verified accounts", func(t *testing.T) {
+		f := newVerificationFixture(t, mocks.AllowAll())
+		f.user.VerifyEmail(time.Now())
+		f.repo.On("FindByEmail", mock.Anything, "john@example.com").Return(f.user, nil)
+		f.repo.On("FindByEmail", mock.Anything, "nobody@example.com").Return(nil, output.ErrUserNotFound)
+
+		assert.NoError(t, f.service.ResendVerification(ctx, "john@example.com", client))
+		assert.NoError(t, f.service.ResendVerification(ctx, "nobody@example.com", client))
+		assert.Empty(t, f.notifier.Sent())
+	})
+
+	t.Run("is rate limited per email and per IP", func(t *testing.T) {
+		limiter := new(mocks.RateLimiterMock)
+		limiter.On("Allow", mock.Anything, "verify-resend:email:john@example.com", 3, time.Hour).Return(true, time.Duration(0), nil)
+		limiter.On("Allow", mock.Anything, "verify-resend:ip:10.0.0.1", 10, time.Hour).Return(false, 30*time.Second, nil)
+		f := newVerificationFixture(t, limiter)
+
+		err := f.service.ResendVerification(ctx, "john@example.com", client)
+
+		assert.ErrorIs(t, err, services.ErrTooManyRequests)
+		assert.True(t, errs.Is(err, errs.RateLimited))
+		after, ok := errs.RetryAfter(err)
+		assert.True(t, ok)
+		assert.Equal(t, 30*time.Second, after)
+		assert.Empty(t, f.notifier.Sent())
+	})
+}
+
+func TestUserService_RegisterUser_SendsVerification(t *testing.T) {
+	ctx := context.Background()
+	f := newVerificationFixture(t, mocks.AllowAll())
+	f.repo.On("Save", ctx, mock.AnythingOfType("entities.User")).Return(nil)
+	service := services.NewUserService(f.repo, services.WithEmailVerification(f.service))
+
+	user, err := service.RegisterUser(ctx, "Jane Doe", "jane@example.com", 28, "Password123!")
+
+	require.NoError(t, err)
+	assert.False(t, user.EmailVerified)
+	sent := f.notifier.Sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "jane@example.com", sent[0].Email)
+	assert.Equal(t, 1, f.tokens.Len())
+}
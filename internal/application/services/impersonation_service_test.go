@@ -0,0 +1,186 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"user-management/internal/application/services"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+	"user-management/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type impersonationFixture struct {
+	repo          *mocks.MockUserRepository
+	audit         *mocks.AuditLogFake
+	impersonation *services.ImpersonationService
+	auth          *services.AuthService
+	user          *entities.User
+	// admin lleva como principal a un administrador con usuario propio,
+	// adminUser
+	admin     context.Context
+	adminID   uuid.UUID
+	adminUser *entities.User
+}
+
+func newImpersonationFixture(t *testing.T) *impersonationFixture {
+	user, err := entities.NewUser("John Doe", "john@example.com", 30, "Password123!")
+	require.NoError(t, err)
+
+	adminUser, err := entities.NewUser("Jane Admin", "jane@example.com", 40, "Password123!")
+	require.NoError(t, err)
+
+	repo := new(mocks.MockUserRepository)
+	repo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+	repo.On("FindByID", mock.Anything, adminUser.ID).Return(adminUser, nil)
+	repo.On("FindByID", mock.Anything, mock.Anything).Return(nil, output.ErrUserNotFound)
+
+	f := &impersonationFixture{repo: repo, audit: &mocks.AuditLogFake{}, user: user, adminID: adminUser.ID, adminUser: adminUser}
+	f.impersonation = services.NewImpersonationService(repo, testSigner, f.audit, services.ImpersonationOptions{})
+	f.auth = services.NewAuthService(repo, mocks.AllowAll(), nil, nil, testSigner, services.AuthOptions{})
+	f.admin = entities.ContextWithPrincipal(context.Background(),
+		&entities.Principal{UserID: f.adminID, Role: entities.RoleAdmin})
+	return f
+}
+
+// impersonated devuelve un contexto con el principal del token de
+// suplantación, como lo deja AuthMiddleware
+func (f *impersonationFixture) impersonated(t *testing.T) context.Context {
+	token, err := f.impersonation.Impersonate(f.admin, f.user.ID, "TICKET-1")
+	require.NoError(t, err)
+	principal, err := f.auth.Authenticate(context.Background(), token.Token)
+	require.NoError(t, err)
+	return entities.ContextWithPrincipal(context.Background(), principal)
+}
+
+func TestImpersonationService_Impersonate(t *testing.T) {
+	t.Run("the token acts as the user and carries the admin as actor", func(t *testing.T) {
+		f := newImpersonationFixture(t)
+
+		token, err := f.impersonation.Impersonate(f.admin, f.user.ID, "TICKET-1")
+		require.NoError(t, err)
+		assert.Empty(t, token.RefreshToken)
+
+		principal, err := f.auth.Authenticate(context.Background(), token.Token)
+		require.NoError(t, err)
+		assert.Equal(t, f.user.ID, principal.UserID)
+		assert.Equal(t, entities.RoleUser, principal.Role)
+		require.True(t, principal.Impersonated())
+		assert.Equal(t, f.adminID, principal.Actor.UserID)
+
+		entries := f.audit.Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, entities.AuditImpersonationStarted, entries[0].Action)
+		assert.Equal(t, f.adminID, entries[0].ActorID)
+		assert.Equal(t, "TICKET-1", entries[0].Details["reason"])
+	})
+
+	t.Run("the static admin token impersonates as a nil actor", func(t *testing.T) {
+		f := newImpersonationFixture(t)
+		admin := entities.ContextWithPrincipal(context.Background(), &entities.Principal{Role: entities.RoleAdmin})
+
+		token, err := f.impersonation.Impersonate(admin, f.user.ID, "TICKET-1")
+		require.NoError(t, err)
+		principal, err := f.auth.Authenticate(context.Background(), token.Token)
+		require.NoError(t, err)
+		require.True(t, principal.Impersonated())
+		assert.Equal(t, uuid.Nil, principal.Actor.UserID)
+	})
+
+	t.Run("the token stops working when the admin is disabled or revokes its tokens", func(t *testing.T) {
+		f := newImpersonationFixture(t)
+		token, err := f.impersonation.Impersonate(f.admin, f.user.ID, "TICKET-1")
+		require.NoError(t, err)
+
+		f.adminUser.TokenVersion++
+		_, err = f.auth.Authenticate(context.Background(), token.Token)
+		assert.ErrorIs(t, err, services.ErrInvalidAccessToken)
+
+		f = newImpersonationFixture(t)
+		token, err = f.impersonation.Impersonate(f.admin, f.user.ID, "TICKET-1")
+		require.NoError(t, err)
+
+		f.adminUser.Active = false
+		_, err = f.auth.Authenticate(context.Background(), token.Token)
+		assert.ErrorIs(t, err, services.ErrInvalidAccessToken)
+	})
+
+	t.Run("the actor keeps the role it impersonated with", func(t *testing.T) {
+		f := newImpersonationFixture(t)
+		support := entities.ContextWithPrincipal(context.Background(), &entities.Principal{UserID: f.adminID, Role: "support"})
+
+		token, err := f.impersonation.Impersonate(support, f.user.ID, "TICKET-1")
+		require.NoError(t, err)
+		principal, err := f.auth.Authenticate(context.Background(), token.Token)
+		require.NoError(t, err)
+		assert.Equal(t, "support", principal.Actor.Role)
+	})
+
+	t.Run("disabled users, unknown users and chains are rejected", func(t *testing.T) {
+		f := newImpersonationFixture(t)
+
+		_, err := f.impersonation.Impersonate(f.impersonated(t), f.user.ID, "again")
+		assert.ErrorIs(t, err, services.ErrImpersonationRestricted)
+
+		_, err = f.impersonation.Impersonate(f.admin, uuid.New(), "TICKET-1")
+		assert.ErrorIs(t, err, output.ErrUserNotFound)
+
+		f.user.Active = false
+		_, err = f.impersonation.Impersonate(f.admin, f.user.ID, "TICKET-1")
+		assert.ErrorIs(t, err, services.ErrCannotImpersonate)
+	})
+}
+
+func TestImpersonationService_RestrictsDangerousActions(t *testing.T) {
+	f := newImpersonationFixture(t)
+	ctx := f.impersonated(t)
+
+	passwords := services.NewPasswordService(f.repo, mocks.NewOneTimeTokenStoreFake(), mocks.AllowAll(),
+		&mocks.NotifierMock{}, services.PasswordOptions{})
+	mfa := services.NewMFAService(f.repo, services.MFAOptions{})
+	keys := services.NewAPIKeyService(&mocks.APIKeyRepositoryFake{}, f.repo, f.audit)
+	sessions := services.NewSessionService(mocks.NewSessionStoreFake(), f.repo, f.audit, services.SessionOptions{})
+	users := services.NewUserService(f.repo)
+
+	actions := map[string]func() error{
+		"change password": func() error {
+			return passwords.ChangePassword(ctx, f.user.ID, "Password123!", "NewPassword456!")
+		},
+		"enroll MFA":  func() error { _, err := mfa.Enroll(ctx, f.user.ID); return err },
+		"disable MFA": func() error { return mfa.Disable(ctx, f.user.ID, "Password123!", "123456") },
+		"create API key": func() error {
+			_, err := keys.Create(ctx, "ci", []string{entities.ScopeUsersRead}, nil)
+			return err
+		},
+		"revoke session":  func() error { return sessions.RevokeSession(ctx, f.user.ID, uuid.New()) },
+		"delete account":  func() error { return users.DeleteUser(ctx, f.user.ID) },
+		"revoke sessions": func() error { return sessions.RevokeAllSessions(ctx, f.user.ID) },
+		"change email": func() error {
+			user := f.user.Clone()
+			require.NoError(t, user.Update(user.Name, "other@example.com", user.Age, user.Active))
+			return users.UpdateProfile(ctx, user)
+		},
+	}
+	for name, action := range actions {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, action(), services.ErrImpersonationRestricted)
+		})
+	}
+
+	t.Run("reading is allowed", func(t *testing.T) {
+		_, err := users.GetUserProfile(ctx, f.user.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("editing the rest of the profile is allowed", func(t *testing.T) {
+		f.repo.On("Update", mock.Anything, mock.AnythingOfType("*entities.User")).Return(nil).Once()
+		user := f.user.Clone()
+		require.NoError(t, user.Update("Johnny Doe", " JOHN@example.com", user.Age, user.Active))
+
+		assert.NoError(t, users.UpdateProfile(ctx, user))
+	})
+}
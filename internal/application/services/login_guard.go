@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/internal/domain/valueobjects"
+
+	"github.com/google/uuid"
+)
+
+var ErrAccountLocked = errs.New(errs.RateLimited, "account_locked", "too many failed attempts, try again later")
+
+// LockoutPolicy define cómo se frenan los fallos de login de una clave
+type LockoutPolicy struct {
+	// FreeFailures son los fallos que no obligan a esperar
+	FreeFailures int
+	// A partir de ahí cada fallo exige esperar el doble que el anterior,
+	// empezando en BaseDelay y hasta MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockAfter fallos bloquean la clave durante LockFor
+	LockAfter int
+	LockFor   time.Duration
+	// Window es lo que se recuerda un fallo si no hay otros después
+	Window time.Duration
+}
+
+// delay es la espera que exigen failures fallos
+func (p LockoutPolicy) delay(failures int) time.Duration {
+	if failures <= p.FreeFailures {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := p.FreeFailures + 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// LoginGuardOptions configura LoginGuard; una política a cero toma la de
+// DefaultAccountPolicy o DefaultIPPolicy
+type LoginGuardOptions struct {
+	Account LockoutPolicy
+	IP      LockoutPolicy
+}
+
+var (
+	// DefaultAccountPolicy bloquea una cuenta 15 minutos tras 10 fallos
+	DefaultAccountPolicy = LockoutPolicy{
+		FreeFailures: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     30 * time.Second,
+		LockAfter:    10,
+		LockFor:      15 * time.Minute,
+		Window:       time.Hour,
+	}
+	// DefaultIPPolicy es más permisiva porque varias personas pueden
+	// compartir IP
+	DefaultIPPolicy = LockoutPolicy{
+		FreeFailures: 10,
+		BaseDelay:    time.Second,
+		MaxDelay:     30 * time.Second,
+		LockAfter:    50,
+		LockFor:      15 * time.Minute,
+		Window:       time.Hour,
+	}
+)
+
+// LoginGuard lleva la cuenta de los fallos de login por cuenta y por IP:
+// pasados unos fallos libres exige esperas crecientes entre intentos y al
+// llegar al umbral bloquea temporalmente, dejando constancia en la
+// auditoría. Las cuentas se identifican por su email normalizado, exista o
+// no, para no revelar cuáles están registradas.
+type LoginGuard struct {
+	attempts output.LoginAttemptStore
+	users    output.UserRepository
+	audit    output.AuditLog
+	opts     LoginGuardOptions
+}
+
+var _ input.LockoutService = (*LoginGuard)(nil)
+
+func NewLoginGuard(attempts output.LoginAttemptStore, users output.UserRepository, audit output.AuditLog, opts LoginGuardOptions) *LoginGuard {
+	if opts.Account == (LockoutPolicy{}) {
+		opts.Account = DefaultAccountPolicy
+	}
+	if opts.IP == (LockoutPolicy{}) {
+		opts.IP = DefaultIPPolicy
+	}
+	return &LoginGuard{attempts: attempts, users: users, audit: audit, opts: opts}
+}
+
+func accountKey(email string) string {
+	return "login:account:" + valueobjects.NormalizeEmail(email)
+}
+
+func ipKey(ip string) string {
+	return "login:ip:" + ip
+}
+
+// Attempt cuenta un intento de login de la IP y de la cuenta antes de
+// comprobar la contraseña, para que varios intentos a la vez no puedan
+// pasar con la misma cuenta de fallos. Devuelve ErrAccountLocked si la IP o
+// la cuenta están bloqueadas, o ErrTooManyRequests si aún no ha pasado la
+// espera desde el último fallo; ambos llevan el tiempo que falta y no
+// cuentan el intento. La IP va primero: desde una IP frenada no se pueden
+// sumar intentos a las cuentas de otros.
+func (g *LoginGuard) Attempt(ctx context.Context, email string, client input.ClientInfo) error {
+	if client.IP != "" {
+		if err := g.attempt(ctx, ipKey(client.IP), g.opts.IP); err != nil {
+			return err
+		}
+	}
+	return g.attempt(ctx, accountKey(email), g.opts.Account)
+}
+
+func (g *LoginGuard) attempt(ctx context.Context, key string, policy LockoutPolicy) error {
+	_, err := g.attempts.RecordAttempt(ctx, key, policy.Window, func(attempts output.LoginAttempts) error {
+		now := time.Now()
+		if now.Before(attempts.LockedUntil) {
+			return errs.WithRetryAfter(ErrAccountLocked, attempts.LockedUntil.Sub(now))
+		}
+		if wait := attempts.LastFailure.Add(policy.delay(attempts.Failures)); now.Before(wait) {
+			return errs.WithRetryAfter(ErrTooManyRequests, wait.Sub(now))
+		}
+		return nil
+	})
+	return err
+}
+
+// Failed cierra un intento fallido, que Attempt ya contó, y bloquea la
+// cuenta y la IP si llegan al umbral
+func (g *LoginGuard) Failed(ctx context.Context, email string, client input.ClientInfo) error {
+	email = valueobjects.NormalizeEmail(email)
+	if err := g.fail(ctx, accountKey(email), g.opts.Account, entities.AuditAccountLocked, "email:"+email, client); err != nil {
+		return err
+	}
+	if client.IP == "" {
+		return nil
+	}
+	return g.fail(ctx, ipKey(client.IP), g.opts.IP, entities.AuditIPLocked, "ip:"+client.IP, client)
+}
+
+func (g *LoginGuard) fail(ctx context.Context, key string, policy LockoutPolicy, action, target string, client input.ClientInfo) error {
+	attempts, err := g.attempts.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if attempts.Failures < policy.LockAfter {
+		return nil
+	}
+
+	until := time.Now().Add(policy.LockFor)
+	if err := g.attempts.Lock(ctx, key, until); err != nil {
+		return err
+	}
+
+	entry := entities.NewAuditEntry(action, uuid.Nil, target)
+	entry.IP = client.IP
+	entry.Details = map[string]string{
+		"failures":     strconv.Itoa(attempts.Failures),
+		"locked_until": until.UTC().Format(time.RFC3339),
+	}
+	g.record(ctx, entry)
+	return nil
+}
+
+// Succeeded borra los fallos de la cuenta. De la IP sólo se descuenta este
+// intento: un atacante no debe poder reiniciar sus fallos entrando en su
+// propia cuenta.
+func (g *LoginGuard) Succeeded(ctx context.Context, email string, client input.ClientInfo) error {
+	if err := g.attempts.Reset(ctx, accountKey(email)); err != nil {
+		return err
+	}
+	if client.IP == "" {
+		return nil
+	}
+	return g.attempts.ForgetAttempt(ctx, ipKey(client.IP))
+}
+
+// UnlockAccount implements [input.LockoutService]. El actor es el
+// principal de ctx.
+func (g *LoginGuard) UnlockAccount(ctx context.Context, userID uuid.UUID) error {
+	user, err := g.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := g.attempts.Reset(ctx, accountKey(user.Email)); err != nil {
+		return err
+	}
+
+	var actor uuid.UUID
+	if principal, ok := entities.PrincipalFromContext(ctx); ok {
+		actor = principal.UserID
+	}
+	g.record(ctx, entities.NewAuditEntry(entities.AuditAccountUnlocked, actor, "user:"+user.ID.String()))
+	return nil
+}
+
+// record escribe en la auditoría; un fallo del registro no debe impedir
+// bloquear ni desbloquear, así que sólo se anota en el log
+func (g *LoginGuard) record(ctx context.Context, entry *entities.AuditEntry) {
+	if err := g.audit.Record(ctx, entry); err != nil {
+		log.Printf("no se pudo registrar %s de %s en la auditoría: %v", entry.Action, entry.Target, err)
+	}
+}
@@ -0,0 +1,168 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"user-management/internal/application/services"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicy = services.LockoutPolicy{
+	FreeFailures: 2,
+	BaseDelay:    time.Minute,
+	MaxDelay:     4 * time.Minute,
+	LockAfter:    5,
+	LockFor:      time.Hour,
+	Window:       24 * time.Hour,
+}
+
+type guardFixture struct {
+	attempts *mocks.LoginAttemptStoreFake
+	audit    *mocks.AuditLogFake
+	auth     *services.AuthService
+	guard    *services.LoginGuard
+	user     *entities.User
+}
+
+func newGuardFixture(t *testing.T) *guardFixture {
+	user, err := entities.NewUser("John Doe", "john@example.com", 30, "Password123!")
+	require.NoError(t, err)
+
+	repo := new(mocks.MockUserRepository)
+	repo.On("FindByEmail", mock.Anything, "john@example.com").Return(user, nil)
+	repo.On("FindByEmail", mock.Anything, mock.Anything).Return(nil, output.ErrUserNotFound)
+	repo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+
+	f := &guardFixture{attempts: mocks.NewLoginAttemptStoreFake(), audit: &mocks.AuditLogFake{}, user: user}
+	f.guard = services.NewLoginGuard(f.attempts, repo, f.audit, services.LoginGuardOptions{
+		Account: testPolicy,
+		IP:      services.LockoutPolicy{FreeFailures: 100, LockAfter: 100, Window: time.Hour},
+	})
+	f.auth = services.NewAuthService(repo, mocks.AllowAll(), f.guard, nil, testSigner, services.AuthOptions{})
+	return f
+}
+
+func TestLoginGuard(t *testing.T) {
+	ctx := context.Background()
+	client := input.ClientInfo{IP: "10.0.0.1"}
+	const account = "login:account:john@example.com"
+
+	login := func(f *guardFixture, password string) error {
+		_, err := f.auth.Login(ctx, "john@example.com", password, client)
+		return err
+	}
+	retryAfter := func(err error) time.Duration {
+		d, _ := errs.RetryAfter(err)
+		return d
+	}
+
+	t.Run("delays grow after the free failures", func(t *testing.T) {
+		f := newGuardFixture(t)
+		for range testPolicy.FreeFailures {
+			assert.ErrorIs(t, login(f, "wrong"), services.ErrInvalidCredentials)
+		}
+
+		assert.ErrorIs(t, login(f, "wrong"), services.ErrInvalidCredentials)
+		err := login(f, "Password123!")
+		assert.ErrorIs(t, err, services.ErrTooManyRequests, "ni la contraseña correcta entra durante la espera")
+		assert.InDelta(t, time.Minute, retryAfter(err), float64(time.Second))
+
+		f.attempts.Age(account, time.Minute)
+		assert.ErrorIs(t, login(f, "wrong"), services.ErrInvalidCredentials)
+		assert.InDelta(t, 2*time.Minute, retryAfter(login(f, "wrong")), float64(time.Second))
+	})
+
+	t.Run("parallel guesses are throttled like sequential ones", func(t *testing.T) {
+		f := newGuardFixture(t)
+		const guesses = 10
+
+		var wg sync.WaitGroup
+		results := make(chan error, guesses)
+		for range guesses {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results <- login(f, "wrong")
+			}()
+		}
+		wg.Wait()
+		close(results)
+
+		checked := 0
+		for err := range results {
+			if errors.Is(err, services.ErrInvalidCredentials) {
+				checked++
+			} else {
+				assert.ErrorIs(t, err, services.ErrTooManyRequests)
+			}
+		}
+		assert.Equal(t, testPolicy.FreeFailures+1, checked, "sólo se comprueban las contraseñas que no exigen esperar")
+	})
+
+	t.Run("a success clears the account failures", func(t *testing.T) {
+		f := newGuardFixture(t)
+		assert.ErrorIs(t, login(f, "wrong"), services.ErrInvalidCredentials)
+		require.NoError(t, login(f, "Password123!"))
+
+		attempts, _ := f.attempts.Get(ctx, account)
+		assert.Zero(t, attempts.Failures)
+		attempts, _ = f.attempts.Get(ctx, "login:ip:10.0.0.1")
+		assert.Equal(t, 1, attempts.Failures, "los fallos de la IP se mantienen")
+	})
+
+	t.Run("the threshold locks the account and is audited", func(t *testing.T) {
+		f := newGuardFixture(t)
+		for range testPolicy.LockAfter {
+			f.attempts.Age(account, time.Hour)
+			assert.ErrorIs(t, login(f, "wrong"), services.ErrInvalidCredentials)
+		}
+
+		err := login(f, "Password123!")
+		assert.ErrorIs(t, err, services.ErrAccountLocked)
+		assert.InDelta(t, time.Hour, retryAfter(err), float64(time.Second))
+
+		entries := f.audit.Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, entities.AuditAccountLocked, entries[0].Action)
+		assert.Equal(t, "email:john@example.com", entries[0].Target)
+		assert.Equal(t, "10.0.0.1", entries[0].IP)
+		assert.Equal(t, "5", entries[0].Details["failures"])
+	})
+
+	t.Run("unknown emails are throttled like real ones", func(t *testing.T) {
+		f := newGuardFixture(t)
+		for range testPolicy.FreeFailures + 1 {
+			_, err := f.auth.Login(ctx, "nobody@example.com", "wrong", client)
+			assert.ErrorIs(t, err, services.ErrInvalidCredentials)
+		}
+		_, err := f.auth.Login(ctx, "nobody@example.com", "wrong", client)
+		assert.ErrorIs(t, err, services.ErrTooManyRequests)
+	})
+
+	t.Run("admins can unlock accounts", func(t *testing.T) {
+		f := newGuardFixture(t)
+		require.NoError(t, f.attempts.Lock(ctx, account, time.Now().Add(time.Hour)))
+		assert.ErrorIs(t, login(f, "Password123!"), services.ErrAccountLocked)
+
+		admin := &entities.Principal{UserID: f.user.ID, Role: entities.RoleAdmin}
+		require.NoError(t, f.guard.UnlockAccount(entities.ContextWithPrincipal(ctx, admin), f.user.ID))
+		assert.NoError(t, login(f, "Password123!"))
+
+		entries := f.audit.Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, entities.AuditAccountUnlocked, entries[0].Action)
+		assert.Equal(t, f.user.ID, entries[0].ActorID)
+		assert.Equal(t, "user:"+f.user.ID.String(), entries[0].Target)
+	})
+}
@@ -0,0 +1,16 @@
+package services_test
+
+import (
+	"os"
+	"testing"
+	"user-management/internal/domain/valueobjects"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMain(m *testing.M) {
+	// Cada fixture crea usuarios con contraseña; el coste real de bcrypt sólo
+	// ralentiza los tests
+	valueobjects.PasswordCost = bcrypt.MinCost
+	os.Exit(m.Run())
+}
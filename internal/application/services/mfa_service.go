@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/pkg/totp"
+
+	"github.com/google/uuid"
+)
+
+// mfaDrift son los pasos de 30 s de desfase de reloj que se admiten a cada
+// lado al comprobar un código
+const mfaDrift = 1
+
+var ErrInvalidMFACode = errs.New(errs.Forbidden, "invalid_mfa_code", "invalid authentication code")
+
+// MFAOptions configura MFAService; los campos a cero toman los valores por
+// defecto
+type MFAOptions struct {
+	Issuer        string // "User Management"; aparece en la app del usuario
+	RecoveryCodes int    // 10
+}
+
+// MFAService da de alta y de baja el segundo factor TOTP. Los códigos de
+// recuperación sólo se guardan como hash.
+type MFAService struct {
+	users output.UserRepository
+	opts  MFAOptions
+}
+
+var _ input.MFAService = (*MFAService)(nil)
+
+func NewMFAService(users output.UserRepository, opts MFAOptions) *MFAService {
+	if opts.Issuer == "" {
+		opts.Issuer = "User Management"
+	}
+	if opts.RecoveryCodes <= 0 {
+		opts.RecoveryCodes = 10
+	}
+	return &MFAService{users: users, opts: opts}
+}
+
+// Enroll implements [input.MFAService].
+func (s *MFAService) Enroll(ctx context.Context, userID uuid.UUID) (*input.MFAEnrollment, error) {
+	if err := denyImpersonation(ctx); err != nil {
+		return nil, err
+	}
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := user.StartMFAEnrollment(secret, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return &input.MFAEnrollment{Secret: secret, URI: totp.URI(s.opts.Issuer, user.Email, secret)}, nil
+}
+
+// Confirm implements [input.MFAService].
+func (s *MFAService) Confirm(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if err := denyImpersonation(ctx); err != nil {
+		return nil, err
+	}
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, entities.ErrMFAAlreadyEnabled
+	}
+	if user.MFA.PendingSecret == "" {
+		return nil, entities.ErrMFANotEnrolled
+	}
+
+	step, ok := totp.Validate(user.MFA.PendingSecret, code, time.Now(), mfaDrift)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes := make([]string, s.opts.RecoveryCodes)
+	hashes := make([]string, len(codes))
+	for i := range codes {
+		codes[i] = newRecoveryCode()
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	if err := user.EnableMFA(hashes, step, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable implements [input.MFAService].
+func (s *MFAService) Disable(ctx context.Context, userID uuid.UUID, password, code string) error {
+	if err := denyImpersonation(ctx); err != nil {
+		return err
+	}
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled {
+		return entities.ErrMFANotEnabled
+	}
+	if !user.Password.Matches(password) {
+		return ErrWrongPassword
+	}
+	if !verifySecondFactor(user, code, time.Now()) {
+		return ErrInvalidMFACode
+	}
+
+	if err := user.DisableMFA(time.Now()); err != nil {
+		return err
+	}
+	return s.users.Update(ctx, user)
+}
+
+// verifySecondFactor comprueba code como código TOTP o, si no tiene su
+// forma, como código de recuperación, y lo marca como usado en user; quien
+// llama debe guardar el usuario si devuelve true
+func verifySecondFactor(user *entities.User, code string, now time.Time) bool {
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(user.MFA.Secret, code, now, mfaDrift)
+		return ok && user.AcceptMFAStep(step, now)
+	}
+	return user.UseRecoveryCode(hashRecoveryCode(code), now)
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCode devuelve un código de 50 bits con la forma xxxxx-xxxxx
+func newRecoveryCode() string {
+	b := make([]byte, 7)
+	_, _ = rand.Read(b)
+	code := strings.ToLower(recoveryEncoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:]
+}
+
+// hashRecoveryCode normaliza el código (mayúsculas, guiones y espacios no
+// cuentan) antes de calcular su hash. Los códigos son aleatorios, así que
+// basta con SHA-256.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return hashToken(code)
+}
@@ -0,0 +1,176 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"user-management/internal/application/services"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/pkg/totp"
+	"user-management/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mfaFixture struct {
+	repo    *mocks.MockUserRepository
+	service *services.MFAService
+	user    *entities.User
+}
+
+func newMFAFixture(t *testing.T) *mfaFixture {
+	user, err := entities.NewUser("John Doe", "john@example.com", 30, "Password123!")
+	require.NoError(t, err)
+
+	f := &mfaFixture{repo: new(mocks.MockUserRepository), user: user}
+	f.service = services.NewMFAService(f.repo, services.MFAOptions{Issuer: "Acme"})
+	f.repo.On("FindByID", mock.Anything, user.ID).Return(user, nil).Maybe()
+	f.repo.On("FindByEmail", mock.Anything, "john@example.com").Return(user, nil).Maybe()
+	f.repo.On("Update", mock.Anything, user).Return(nil).Maybe()
+	return f
+}
+
+// code devuelve el código TOTP del secreto activo desplazado steps pasos
+func code(t *testing.T, secret string, steps int64) string {
+	c, err := totp.Code(secret, totp.Step(time.Now())+steps)
+	require.NoError(t, err)
+	return c
+}
+
+// enable da de alta MFA con el código actual y devuelve los códigos de
+// recuperación
+func (f *mfaFixture) enable(t *testing.T) []string {
+	ctx := context.Background()
+	enrollment, err := f.service.Enroll(ctx, f.user.ID)
+	require.NoError(t, err)
+	codes, err := f.service.Confirm(ctx, f.user.ID, code(t, enrollment.Secret, 0))
+	require.NoError(t, err)
+	return codes
+}
+
+func TestMFAService_Enrollment(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("returns an otpauth URI and requires confirmation", func(t *testing.T) {
+		f := newMFAFixture(t)
+
+		enrollment, err := f.service.Enroll(ctx, f.user.ID)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/Acme:john@example.com?"))
+		assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+		assert.False(t, f.user.MFAEnabled)
+
+		_, err = f.service.Confirm(ctx, f.user.ID, "000000")
+		assert.ErrorIs(t, err, services.ErrInvalidMFACode)
+		assert.False(t, f.user.MFAEnabled)
+
+		codes, err := f.service.Confirm(ctx, f.user.ID, code(t, enrollment.Secret, 0))
+		require.NoError(t, err)
+		assert.True(t, f.user.MFAEnabled)
+		assert.Equal(t, enrollment.Secret, f.user.MFA.Secret)
+		assert.Len(t, codes, 10)
+		assert.Len(t, f.user.MFA.RecoveryCodes, 10)
+		for _, c := range codes {
+			assert.NotContains(t, f.user.MFA.RecoveryCodes, c, "los códigos se guardan como hash")
+		}
+	})
+
+	t.Run("cannot confirm without enrolling or enroll twice", func(t *testing.T) {
+		f := newMFAFixture(t)
+
+		_, err := f.service.Confirm(ctx, f.user.ID, "123456")
+		assert.ErrorIs(t, err, entities.ErrMFANotEnrolled)
+
+		f.enable(t)
+		_, err = f.service.Enroll(ctx, f.user.ID)
+		assert.ErrorIs(t, err, entities.ErrMFAAlreadyEnabled)
+	})
+
+	t.Run("disabling requires the password and a code", func(t *testing.T) {
+		f := newMFAFixture(t)
+		codes := f.enable(t)
+
+		err := f.service.Disable(ctx, f.user.ID, "wrong-password", codes[0])
+		assert.ErrorIs(t, err, services.ErrWrongPassword)
+		err = f.service.Disable(ctx, f.user.ID, "Password123!", "000000")
+		assert.ErrorIs(t, err, services.ErrInvalidMFACode)
+
+		require.NoError(t, f.service.Disable(ctx, f.user.ID, "Password123!", codes[0]))
+		assert.False(t, f.user.MFAEnabled)
+		assert.Empty(t, f.user.MFA.Secret)
+
+		err = f.service.Disable(ctx, f.user.ID, "Password123!", codes[1])
+		assert.ErrorIs(t, err, entities.ErrMFANotEnabled)
+	})
+}
+
+func TestAuthService_VerifyMFA(t *testing.T) {
+	ctx := context.Background()
+	client := input.ClientInfo{IP: "10.0.0.1"}
+
+	setup := func(t *testing.T, limiter output.RateLimiter) (*mfaFixture, *services.AuthService, []string, string) {
+		f := newMFAFixture(t)
+		codes := f.enable(t)
+		auth := services.NewAuthService(f.repo, limiter, nil, nil, testSigner, services.AuthOptions{})
+
+		login, err := auth.Login(ctx, "john@example.com", "Password123!", client)
+		require.NoError(t, err)
+		require.Nil(t, login.AccessToken)
+		require.NotNil(t, login.Challenge)
+		return f, auth, codes, login.Challenge.Token
+	}
+
+	t.Run("the challenge is not an access token", func(t *testing.T) {
+		_, auth, _, challenge := setup(t, mocks.AllowAll())
+
+		_, err := auth.Authenticate(ctx, challenge)
+		assert.ErrorIs(t, err, services.ErrInvalidAccessToken)
+	})
+
+	t.Run("a TOTP code completes the login once", func(t *testing.T) {
+		f, auth, _, challenge := setup(t, mocks.AllowAll())
+		next := code(t, f.user.MFA.Secret, 1)
+
+		token, err := auth.VerifyMFA(ctx, challenge, next, client)
+		require.NoError(t, err)
+		principal, err := auth.Authenticate(ctx, token.Token)
+		require.NoError(t, err)
+		assert.Equal(t, f.user.ID, principal.UserID)
+
+		_, err = auth.VerifyMFA(ctx, challenge, next, client)
+		assert.ErrorIs(t, err, services.ErrInvalidMFACode, "un código no se puede reutilizar")
+	})
+
+	t.Run("recovery codes work once in any case", func(t *testing.T) {
+		f, auth, codes, challenge := setup(t, mocks.AllowAll())
+
+		_, err := auth.VerifyMFA(ctx, challenge, strings.ToUpper(codes[3]), client)
+		require.NoError(t, err)
+		assert.Len(t, f.user.MFA.RecoveryCodes, 9)
+
+		_, err = auth.VerifyMFA(ctx, challenge, codes[3], client)
+		assert.ErrorIs(t, err, services.ErrInvalidMFACode)
+	})
+
+	t.Run("rejects invalid challenges", func(t *testing.T) {
+		f, auth, _, _ := setup(t, mocks.AllowAll())
+
+		_, err := auth.VerifyMFA(ctx, "garbage", code(t, f.user.MFA.Secret, 1), client)
+		assert.ErrorIs(t, err, services.ErrInvalidChallenge)
+	})
+
+	t.Run("limits attempts per user", func(t *testing.T) {
+		limiter := new(mocks.RateLimiterMock)
+		limiter.On("Allow", mock.Anything, mock.Anything, 5, 5*time.Minute).Return(false, time.Minute, nil)
+		f, auth, _, challenge := setup(t, limiter)
+
+		_, err := auth.VerifyMFA(ctx, challenge, code(t, f.user.MFA.Secret, 1), client)
+		assert.ErrorIs(t, err, services.ErrTooManyRequests)
+		limiter.AssertCalled(t, "Allow", mock.Anything, "mfa-verify:user:"+f.user.ID.String(), 5, 5*time.Minute)
+	})
+}
@@ -0,0 +1,524 @@
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/pkg/jwt"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// PurposeAuthorizationCode identifica los códigos de autorización en el
+	// almacén de tokens de un solo uso
+	PurposeAuthorizationCode = "oidc_authorization_code"
+	// purposeOIDCAccess distingue los tokens de acceso para userinfo de los
+	// de la API, que además se firman con otra clave
+	purposeOIDCAccess = "oidc_access"
+
+	// anonymousSessionTTL es lo que dura la sesión de un navegador que aún
+	// no ha iniciado sesión: lo justo para rellenar el formulario
+	anonymousSessionTTL = 15 * time.Minute
+
+	grantAuthorizationCode = "authorization_code"
+	responseTypeCode       = "code"
+	codeChallengeS256      = "S256"
+)
+
+// Errores del endpoint de tokens y de userinfo (RFC 6749, sección 5.2, y
+// RFC 6750, sección 3.1); el código es el error OAuth que recibe el cliente
+var (
+	ErrInvalidOAuthRequest  = errs.New(errs.BadRequest, "invalid_request", "request is missing a required parameter")
+	ErrInvalidClient        = errs.New(errs.Unauthorized, "invalid_client", "client authentication failed")
+	ErrInvalidGrant         = errs.New(errs.BadRequest, "invalid_grant", "authorization code is invalid or already used")
+	ErrUnsupportedGrantType = errs.New(errs.BadRequest, "unsupported_grant_type", "only the authorization_code grant is supported")
+	ErrInvalidOIDCToken     = errs.New(errs.Unauthorized, "invalid_token", "access token is invalid or expired")
+)
+
+// Errores de autorización que no se pueden devolver al cliente: redirigir a
+// una URI no registrada entregaría la respuesta a un tercero
+var (
+	ErrUnknownOAuthClient = errs.New(errs.BadRequest, "unknown_client", "client_id is not registered")
+	ErrRedirectNotAllowed = errs.New(errs.BadRequest, "redirect_uri_not_allowed", "redirect_uri is not registered for this client")
+)
+
+// OIDCOptions configura OIDCService; los campos a cero toman los valores por
+// defecto
+type OIDCOptions struct {
+	// Issuer es la URL pública del proveedor, sin barra final; va en el
+	// claim iss y es la base de los endpoints
+	Issuer         string
+	CodeTTL        time.Duration // 1m
+	AccessTokenTTL time.Duration // 1h
+	IDTokenTTL     time.Duration // 1h
+	// SessionTTL es lo que dura la sesión del navegador en el formulario de
+	// autorización una vez iniciada
+	SessionTTL time.Duration // 8h
+}
+
+func (o *OIDCOptions) defaults() {
+	o.Issuer = strings.TrimSuffix(o.Issuer, "/")
+	if o.CodeTTL <= 0 {
+		o.CodeTTL = time.Minute
+	}
+	if o.AccessTokenTTL <= 0 {
+		o.AccessTokenTTL = time.Hour
+	}
+	if o.IDTokenTTL <= 0 {
+		o.IDTokenTTL = time.Hour
+	}
+	if o.SessionTTL <= 0 {
+		o.SessionTTL = 8 * time.Hour
+	}
+}
+
+// authorizationCodeClaims guardan en el código todo lo que se aprobó en la
+// autorización; Audience es el client_id al que se emitió
+type authorizationCodeClaims struct {
+	jwt.Claims
+	Purpose       string `json:"purpose"`
+	RedirectURI   string `json:"redirect_uri"`
+	Scope         string `json:"scope"`
+	Nonce         string `json:"nonce,omitempty"`
+	CodeChallenge string `json:"code_challenge"`
+	AuthTime      int64  `json:"auth_time"`
+}
+
+// idTokenClaims son el contenido del ID token (OIDC Core, sección 2)
+type idTokenClaims struct {
+	jwt.Claims
+	AuthTime int64  `json:"auth_time"`
+	Nonce    string `json:"nonce,omitempty"`
+	entities.UserClaims
+}
+
+// oidcAccessClaims son el contenido de los tokens de acceso para userinfo.
+// Version es el TokenVersion del usuario, como en los tokens de la API.
+type oidcAccessClaims struct {
+	jwt.Claims
+	Purpose string `json:"purpose"`
+	Scope   string `json:"scope"`
+	Version int    `json:"ver"`
+}
+
+// OIDCService hace del servicio un proveedor OpenID Connect. Sólo admite el
+// flujo de código de autorización, con PKCE S256 obligatorio también para
+// los clientes confidenciales. Los códigos son tokens firmados de un solo
+// uso, como los de verificación de email, y todo se firma con RS256 para
+// que los clientes verifiquen los ID tokens con la clave pública.
+type OIDCService struct {
+	clients  output.OAuthClientRepository
+	users    output.UserRepository
+	tokens   output.OneTimeTokenStore
+	sessions output.OIDCSessionStore
+	signer   jwt.Signer
+	key      jwt.JWK
+	opts     OIDCOptions
+}
+
+var _ input.OIDCService = (*OIDCService)(nil)
+
+func NewOIDCService(clients output.OAuthClientRepository, users output.UserRepository, tokens output.OneTimeTokenStore,
+	sessions output.OIDCSessionStore, key *rsa.PrivateKey, opts OIDCOptions) *OIDCService {
+	opts.defaults()
+	return &OIDCService{
+		clients:  clients,
+		users:    users,
+		tokens:   tokens,
+		sessions: sessions,
+		signer:   jwt.RS256(key),
+		key:      jwt.NewRSAJWK(&key.PublicKey),
+		opts:     opts,
+	}
+}
+
+// Issuer implements [input.OIDCService].
+func (s *OIDCService) Issuer() string {
+	return s.opts.Issuer
+}
+
+// Keys implements [input.OIDCService].
+func (s *OIDCService) Keys() jwt.JWKS {
+	return jwt.JWKS{Keys: []jwt.JWK{s.key}}
+}
+
+// Authorize implements [input.OIDCService]. El usuario es el principal de
+// ctx; no se emiten códigos con API keys ni durante una suplantación.
+func (s *OIDCService) Authorize(ctx context.Context, req input.AuthorizationRequest) (string, error) {
+	principal, err := keyOwner(ctx)
+	if err != nil {
+		return "", err
+	}
+	if principal.UserID == uuid.Nil {
+		return "", ErrNoPrincipal
+	}
+	if err := denyImpersonation(ctx); err != nil {
+		return "", err
+	}
+
+	client, err := s.redirectClient(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	// A partir de aquí los errores se devuelven al cliente en la redirección
+	redirect := func(params url.Values) string {
+		if req.State != "" {
+			params.Set("state", req.State)
+		}
+		separator := "?"
+		if strings.Contains(req.RedirectURI, "?") {
+			separator = "&"
+		}
+		return req.RedirectURI + separator + params.Encode()
+	}
+	deny := func(code, description string) string {
+		return redirect(url.Values{"error": {code}, "error_description": {description}})
+	}
+
+	if req.ResponseType != responseTypeCode {
+		return deny("unsupported_response_type", "only response_type=code is supported"), nil
+	}
+	scopes := grantedScopes(req.Scope)
+	if !slices.Contains(scopes, entities.ScopeOpenID) {
+		return deny("invalid_scope", "scope must include openid"), nil
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != codeChallengeS256 {
+		return deny("invalid_request", "PKCE with code_challenge_method=S256 is required"), nil
+	}
+
+	claims := authorizationCodeClaims{
+		Claims:        jwt.NewClaims(principal.UserID.String(), s.opts.CodeTTL),
+		Purpose:       PurposeAuthorizationCode,
+		RedirectURI:   req.RedirectURI,
+		Scope:         strings.Join(scopes, " "),
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		AuthTime:      time.Now().Unix(),
+	}
+	claims.Issuer = s.opts.Issuer
+	claims.Audience = client.ID.String()
+	claims.ID = newSecret()
+
+	code, err := jwt.Encode(s.signer, claims)
+	if err != nil {
+		return "", err
+	}
+	err = s.tokens.Save(ctx, output.OneTimeToken{
+		Hash:      hashToken(claims.ID),
+		Purpose:   PurposeAuthorizationCode,
+		UserID:    principal.UserID,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	})
+	if err != nil {
+		return "", err
+	}
+	return redirect(url.Values{"code": {code}}), nil
+}
+
+// ValidateAuthorization implements [input.OIDCService].
+func (s *OIDCService) ValidateAuthorization(ctx context.Context, req input.AuthorizationRequest) error {
+	_, err := s.redirectClient(ctx, req)
+	return err
+}
+
+// redirectClient devuelve el cliente de req si está registrado y admite su
+// redirect_uri
+func (s *OIDCService) redirectClient(ctx context.Context, req input.AuthorizationRequest) (*entities.OAuthClient, error) {
+	client, err := s.findClient(ctx, req.ClientID)
+	if errors.Is(err, output.ErrOAuthClientNotFound) {
+		return nil, ErrUnknownOAuthClient
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !client.AllowsRedirect(req.RedirectURI) {
+		return nil, ErrRedirectNotAllowed
+	}
+	return client, nil
+}
+
+// BrowserSession implements [input.OIDCService]. Como los tokens de la API,
+// la sesión deja de valer si el usuario se desactiva o cierra todas sus
+// sesiones.
+func (s *OIDCService) BrowserSession(ctx context.Context, id string) (*input.OIDCSession, error) {
+	if id == "" {
+		return s.openSession(ctx, output.OIDCSession{}, anonymousSessionTTL)
+	}
+
+	session, err := s.sessions.Find(ctx, hashToken(id))
+	if errors.Is(err, output.ErrSessionNotFound) {
+		return s.openSession(ctx, output.OIDCSession{}, anonymousSessionTTL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &input.OIDCSession{ID: id, CSRFToken: session.CSRFToken, ExpiresAt: session.ExpiresAt}
+	if session.UserID == uuid.Nil {
+		return result, nil
+	}
+	user, err := s.users.FindByID(ctx, session.UserID)
+	if err != nil && !errors.Is(err, output.ErrUserNotFound) {
+		return nil, err
+	}
+	if err != nil || !user.Active || user.TokenVersion != session.TokenVersion {
+		if err := s.sessions.Delete(ctx, session.Hash); err != nil {
+			return nil, err
+		}
+		return s.openSession(ctx, output.OIDCSession{}, anonymousSessionTTL)
+	}
+	result.Principal = &entities.Principal{UserID: session.UserID, Role: session.Role}
+	return result, nil
+}
+
+// SignIn implements [input.OIDCService]. Cambiar de identificador impide
+// que quien fijó la cookie antes del login herede la sesión.
+func (s *OIDCService) SignIn(ctx context.Context, id string) (*input.OIDCSession, error) {
+	principal, err := keyOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if principal.UserID == uuid.Nil {
+		return nil, ErrNoPrincipal
+	}
+	if err := denyImpersonation(ctx); err != nil {
+		return nil, err
+	}
+	user, err := s.users.FindByID(ctx, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if id != "" {
+		if err := s.sessions.Delete(ctx, hashToken(id)); err != nil {
+			return nil, err
+		}
+	}
+	result, err := s.openSession(ctx, output.OIDCSession{
+		UserID:       user.ID,
+		Role:         principal.Role,
+		TokenVersion: user.TokenVersion,
+	}, s.opts.SessionTTL)
+	if err != nil {
+		return nil, err
+	}
+	result.Principal = &entities.Principal{UserID: user.ID, Role: principal.Role}
+	return result, nil
+}
+
+// openSession guarda session con un identificador y un token CSRF nuevos
+func (s *OIDCService) openSession(ctx context.Context, session output.OIDCSession, ttl time.Duration) (*input.OIDCSession, error) {
+	id := newSecret()
+	session.Hash = hashToken(id)
+	session.CSRFToken = newSecret()
+	session.ExpiresAt = time.Now().Add(ttl)
+	if err := s.sessions.Save(ctx, session); err != nil {
+		return nil, err
+	}
+	return &input.OIDCSession{ID: id, CSRFToken: session.CSRFToken, ExpiresAt: session.ExpiresAt}, nil
+}
+
+// grantedScopes devuelve los scopes de OpenID Connect pedidos en scope, sin
+// repetir; los desconocidos se ignoran (OIDC Core, sección 3.1.2.1)
+func grantedScopes(scope string) []string {
+	requested := strings.Fields(scope)
+	var granted []string
+	for _, s := range entities.OIDCScopes {
+		if slices.Contains(requested, s) {
+			granted = append(granted, s)
+		}
+	}
+	return granted
+}
+
+// Exchange implements [input.OIDCService]. El código se consume antes de
+// comprobar redirect_uri y el verificador PKCE: un intento fallido también
+// lo invalida.
+func (s *OIDCService) Exchange(ctx context.Context, req input.TokenRequest) (*input.OIDCTokens, error) {
+	if req.GrantType != grantAuthorizationCode {
+		return nil, ErrUnsupportedGrantType
+	}
+	if req.Code == "" || req.RedirectURI == "" || req.CodeVerifier == "" {
+		return nil, ErrInvalidOAuthRequest
+	}
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims authorizationCodeClaims
+	if err := jwt.Decode(s.signer, req.Code, &claims); err != nil || claims.Purpose != PurposeAuthorizationCode ||
+		claims.Issuer != s.opts.Issuer || claims.Audience != client.ID.String() {
+		return nil, ErrInvalidGrant
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidGrant
+	}
+	if _, err := s.tokens.Consume(ctx, PurposeAuthorizationCode, hashToken(claims.ID)); err != nil {
+		if errors.Is(err, output.ErrTokenNotFound) {
+			return nil, ErrInvalidGrant
+		}
+		return nil, err
+	}
+	if claims.RedirectURI != req.RedirectURI || !verifyCodeChallenge(req.CodeVerifier, claims.CodeChallenge) {
+		return nil, ErrInvalidGrant
+	}
+
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, output.ErrUserNotFound) {
+		return nil, ErrInvalidGrant
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active {
+		return nil, ErrInvalidGrant
+	}
+	return s.issue(user, client, claims)
+}
+
+// authenticateClient comprueba las credenciales del cliente: los
+// confidenciales deben presentar su secreto y los públicos ninguno
+func (s *OIDCService) authenticateClient(ctx context.Context, clientID, secret string) (*entities.OAuthClient, error) {
+	client, err := s.findClient(ctx, clientID)
+	if errors.Is(err, output.ErrOAuthClientNotFound) {
+		return nil, ErrInvalidClient
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.Confidential {
+		if secret != "" {
+			return nil, ErrInvalidClient
+		}
+		return client, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(client.SecretHash)) != 1 {
+		return nil, ErrInvalidClient
+	}
+	return client, nil
+}
+
+// verifyCodeChallenge comprueba el verificador PKCE con el método S256
+// (RFC 7636, sección 4.6)
+func verifyCodeChallenge(verifier, challenge string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+func (s *OIDCService) issue(user *entities.User, client *entities.OAuthClient, code authorizationCodeClaims) (*input.OIDCTokens, error) {
+	scopes := strings.Fields(code.Scope)
+
+	idToken := idTokenClaims{
+		Claims:     jwt.NewClaims(user.ID.String(), s.opts.IDTokenTTL),
+		AuthTime:   code.AuthTime,
+		Nonce:      code.Nonce,
+		UserClaims: user.Claims(scopes),
+	}
+	idToken.Issuer = s.opts.Issuer
+	idToken.Audience = client.ID.String()
+
+	access := oidcAccessClaims{
+		Claims:  jwt.NewClaims(user.ID.String(), s.opts.AccessTokenTTL),
+		Purpose: purposeOIDCAccess,
+		Scope:   code.Scope,
+		Version: user.TokenVersion,
+	}
+	access.Issuer = s.opts.Issuer
+	access.Audience = client.ID.String()
+
+	signedID, err := jwt.EncodeWithKeyID(s.signer, s.key.KeyID, idToken)
+	if err != nil {
+		return nil, err
+	}
+	signedAccess, err := jwt.EncodeWithKeyID(s.signer, s.key.KeyID, access)
+	if err != nil {
+		return nil, err
+	}
+	return &input.OIDCTokens{
+		AccessToken: signedAccess,
+		IDToken:     signedID,
+		ExpiresAt:   time.Unix(access.ExpiresAt, 0),
+		Scope:       code.Scope,
+	}, nil
+}
+
+// UserInfo implements [input.OIDCService]. Como los tokens de la API, deja
+// de valer si el usuario se desactiva o cierra todas sus sesiones.
+func (s *OIDCService) UserInfo(ctx context.Context, accessToken string) (*input.UserInfo, error) {
+	var claims oidcAccessClaims
+	if err := jwt.Decode(s.signer, accessToken, &claims); err != nil || claims.Purpose != purposeOIDCAccess ||
+		claims.Issuer != s.opts.Issuer {
+		return nil, ErrInvalidOIDCToken
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidOIDCToken
+	}
+
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, output.ErrUserNotFound) {
+		return nil, ErrInvalidOIDCToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active || user.TokenVersion != claims.Version {
+		return nil, ErrInvalidOIDCToken
+	}
+	return &input.UserInfo{Subject: user.ID.String(), UserClaims: user.Claims(strings.Fields(claims.Scope))}, nil
+}
+
+// RegisterClient implements [input.OIDCService]. Del secreto sólo se guarda
+// el hash SHA-256, como en las API keys.
+func (s *OIDCService) RegisterClient(ctx context.Context, name string, redirectURIs []string, confidential bool) (*input.RegisteredOAuthClient, error) {
+	var secret, secretHash string
+	if confidential {
+		secret = newSecret()
+		secretHash = hashToken(secret)
+	}
+
+	client, err := entities.NewOAuthClient(name, redirectURIs, secretHash)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.clients.Save(ctx, client); err != nil {
+		return nil, err
+	}
+	return &input.RegisteredOAuthClient{Client: client, Secret: secret}, nil
+}
+
+// ListClients implements [input.OIDCService].
+func (s *OIDCService) ListClients(ctx context.Context) ([]*entities.OAuthClient, error) {
+	return s.clients.FindAll(ctx)
+}
+
+// DeleteClient implements [input.OIDCService]. Los tokens ya emitidos al
+// cliente siguen valiendo hasta que caducan.
+func (s *OIDCService) DeleteClient(ctx context.Context, id uuid.UUID) error {
+	return s.clients.Delete(ctx, id)
+}
+
+func (s *OIDCService) findClient(ctx context.Context, clientID string) (*entities.OAuthClient, error) {
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		return nil, output.ErrOAuthClientNotFound
+	}
+	return s.clients.FindByID(ctx, id)
+}
@@ -0,0 +1,370 @@
+package services_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"sync"
+	"testing"
+	"user-management/internal/application/services"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/pkg/jwt"
+	"user-management/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIssuer      = "https://id.example.com"
+	testRedirectURI = "https://app.example.com/callback"
+	testVerifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+// La clave se genera una vez: RSA de 2048 bits tarda lo bastante como para
+// notarse en cada test
+var oidcTestKey = sync.OnceValue(func() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+})
+
+type oidcFixture struct {
+	repo     *mocks.MockUserRepository
+	sessions *mocks.OIDCSessionStoreFake
+	service  *services.OIDCService
+	user     *entities.User
+	client   *input.RegisteredOAuthClient
+	ctx      context.Context
+}
+
+func newOIDCFixture(t *testing.T, confidential bool) *oidcFixture {
+	user, err := entities.NewUser("John Doe", "john@example.com", 30, "Password123!")
+	require.NoError(t, err)
+
+	f := &oidcFixture{
+		repo:     new(mocks.MockUserRepository),
+		sessions: mocks.NewOIDCSessionStoreFake(),
+		user:     user,
+		ctx:      entities.ContextWithPrincipal(context.Background(), &entities.Principal{UserID: user.ID, Role: entities.RoleUser}),
+	}
+	f.service = services.NewOIDCService(&mocks.OAuthClientRepositoryFake{}, f.repo, mocks.NewOneTimeTokenStoreFake(),
+		f.sessions, oidcTestKey(), services.OIDCOptions{Issuer: testIssuer + "/"})
+	f.repo.On("FindByID", mock.Anything, user.ID).Return(user, nil).Maybe()
+
+	f.client, err = f.service.RegisterClient(context.Background(), "App", []string{testRedirectURI}, confidential)
+	require.NoError(t, err)
+	return f
+}
+
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (f *oidcFixture) authorizationRequest() input.AuthorizationRequest {
+	return input.AuthorizationRequest{
+		ClientID:            f.client.Client.ID.String(),
+		RedirectURI:         testRedirectURI,
+		ResponseType:        "code",
+		Scope:               "openid email",
+		State:               "xyz",
+		Nonce:               "n-0S6_WzA2Mj",
+		CodeChallenge:       challenge(testVerifier),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+// authorize devuelve los parámetros de la redirección
+func (f *oidcFixture) authorize(t *testing.T, req input.AuthorizationRequest) url.Values {
+	location, err := f.service.Authorize(f.ctx, req)
+	require.NoError(t, err)
+	redirect, err := url.Parse(location)
+	require.NoError(t, err)
+	return redirect.Query()
+}
+
+func (f *oidcFixture) tokenRequest(code string) input.TokenRequest {
+	return input.TokenRequest{
+		GrantType:    "authorization_code",
+		Code:         code,
+		RedirectURI:  testRedirectURI,
+		CodeVerifier: testVerifier,
+		ClientID:     f.client.Client.ID.String(),
+		ClientSecret: f.client.Secret,
+	}
+}
+
+func TestOIDCService_AuthorizationCodeFlow(t *testing.T) {
+	f := newOIDCFixture(t, true)
+	assert.Equal(t, testIssuer, f.service.Issuer())
+
+	params := f.authorize(t, f.authorizationRequest())
+	assert.Equal(t, "xyz", params.Get("state"))
+	require.NotEmpty(t, params.Get("code"))
+
+	tokens, err := f.service.Exchange(context.Background(), f.tokenRequest(params.Get("code")))
+	require.NoError(t, err)
+	assert.Equal(t, "openid email", tokens.Scope)
+
+	// El ID token se verifica con la clave publicada
+	keys := f.service.Keys().Keys
+	require.Len(t, keys, 1)
+	public, err := keys[0].PublicKey()
+	require.NoError(t, err)
+
+	var idToken struct {
+		jwt.Claims
+		Nonce         string `json:"nonce"`
+		Name          string `json:"name"`
+		Email         string `json:"email"`
+		EmailVerified *bool  `json:"email_verified"`
+	}
+	require.NoError(t, jwt.Decode(jwt.RS256PublicKey(public), tokens.IDToken, &idToken))
+	assert.Equal(t, testIssuer, idToken.Issuer)
+	assert.Equal(t, f.user.ID.String(), idToken.Subject)
+	assert.Equal(t, f.client.Client.ID.String(), idToken.Audience)
+	assert.Equal(t, "n-0S6_WzA2Mj", idToken.Nonce)
+	assert.Equal(t, "john@example.com", idToken.Email)
+	assert.NotNil(t, idToken.EmailVerified)
+	assert.Empty(t, idToken.Name, "profile no se pidió")
+
+	info, err := f.service.UserInfo(context.Background(), tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, f.user.ID.String(), info.Subject)
+	assert.Equal(t, "john@example.com", info.Email)
+
+	t.Run("codes are single use", func(t *testing.T) {
+		_, err := f.service.Exchange(context.Background(), f.tokenRequest(params.Get("code")))
+		assert.ErrorIs(t, err, services.ErrInvalidGrant)
+	})
+
+	t.Run("userinfo rejects tokens after a global logout", func(t *testing.T) {
+		f.user.TokenVersion++
+		defer func() { f.user.TokenVersion-- }()
+		_, err := f.service.UserInfo(context.Background(), tokens.AccessToken)
+		assert.ErrorIs(t, err, services.ErrInvalidOIDCToken)
+	})
+
+	t.Run("userinfo rejects the ID token", func(t *testing.T) {
+		_, err := f.service.UserInfo(context.Background(), tokens.IDToken)
+		assert.ErrorIs(t, err, services.ErrInvalidOIDCToken)
+	})
+}
+
+func TestOIDCService_Authorize(t *testing.T) {
+	f := newOIDCFixture(t, false)
+
+	t.Run("errors that cannot be redirected", func(t *testing.T) {
+		req := f.authorizationRequest()
+		req.ClientID = uuid.NewString()
+		_, err := f.service.Authorize(f.ctx, req)
+		assert.ErrorIs(t, err, services.ErrUnknownOAuthClient)
+		assert.ErrorIs(t, f.service.ValidateAuthorization(context.Background(), req), services.ErrUnknownOAuthClient)
+
+		req = f.authorizationRequest()
+		req.RedirectURI = testRedirectURI + "/other"
+		_, err = f.service.Authorize(f.ctx, req)
+		assert.ErrorIs(t, err, services.ErrRedirectNotAllowed)
+		assert.ErrorIs(t, f.service.ValidateAuthorization(context.Background(), req), services.ErrRedirectNotAllowed)
+
+		assert.NoError(t, f.service.ValidateAuthorization(context.Background(), f.authorizationRequest()))
+	})
+
+	t.Run("errors are redirected to the client", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			modify func(*input.AuthorizationRequest)
+			error  string
+		}{
+			{"implicit flow", func(r *input.AuthorizationRequest) { r.ResponseType = "token" }, "unsupported_response_type"},
+			{"missing openid scope", func(r *input.AuthorizationRequest) { r.Scope = "email profile" }, "invalid_scope"},
+			{"missing PKCE", func(r *input.AuthorizationRequest) { r.CodeChallenge = "" }, "invalid_request"},
+			{"plain PKCE", func(r *input.AuthorizationRequest) { r.CodeChallengeMethod = "plain" }, "invalid_request"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req := f.authorizationRequest()
+				tt.modify(&req)
+				params := f.authorize(t, req)
+				assert.Equal(t, tt.error, params.Get("error"))
+				assert.Equal(t, "xyz", params.Get("state"))
+				assert.Empty(t, params.Get("code"))
+			})
+		}
+	})
+
+	t.Run("requires a user", func(t *testing.T) {
+		tests := []struct {
+			principal *entities.Principal
+			err       error
+		}{
+			{&entities.Principal{Role: entities.RoleAdmin}, services.ErrNoPrincipal},
+			{&entities.Principal{UserID: f.user.ID, APIKeyID: uuid.New()}, services.ErrAPIKeyNotPermitted},
+			{&entities.Principal{UserID: f.user.ID, Actor: &entities.Principal{Role: entities.RoleAdmin}}, services.ErrImpersonationRestricted},
+		}
+		for _, tt := range tests {
+			ctx := entities.ContextWithPrincipal(context.Background(), tt.principal)
+			_, err := f.service.Authorize(ctx, f.authorizationRequest())
+			assert.ErrorIs(t, err, tt.err)
+		}
+		_, err := f.service.Authorize(context.Background(), f.authorizationRequest())
+		assert.ErrorIs(t, err, services.ErrNoPrincipal)
+	})
+
+	t.Run("unknown scopes are ignored", func(t *testing.T) {
+		req := f.authorizationRequest()
+		req.Scope = "openid offline_access profile openid"
+		tokens, err := f.service.Exchange(context.Background(), f.tokenRequest(f.authorize(t, req).Get("code")))
+		require.NoError(t, err)
+		assert.Equal(t, "openid profile", tokens.Scope)
+	})
+}
+
+func TestOIDCService_BrowserSession(t *testing.T) {
+	t.Run("opens an anonymous session for unknown identifiers", func(t *testing.T) {
+		f := newOIDCFixture(t, false)
+
+		for _, id := range []string{"", "forged"} {
+			session, err := f.service.BrowserSession(context.Background(), id)
+			require.NoError(t, err)
+			assert.NotEqual(t, id, session.ID)
+			assert.NotEmpty(t, session.CSRFToken)
+			assert.Nil(t, session.Principal)
+
+			again, err := f.service.BrowserSession(context.Background(), session.ID)
+			require.NoError(t, err)
+			assert.Equal(t, session, again)
+		}
+	})
+
+	t.Run("signing in replaces the session", func(t *testing.T) {
+		f := newOIDCFixture(t, false)
+		anonymous, err := f.service.BrowserSession(context.Background(), "")
+		require.NoError(t, err)
+
+		signedIn, err := f.service.SignIn(f.ctx, anonymous.ID)
+		require.NoError(t, err)
+		assert.NotEqual(t, anonymous.ID, signedIn.ID)
+		assert.NotEqual(t, anonymous.CSRFToken, signedIn.CSRFToken)
+		assert.Equal(t, 1, f.sessions.Len(), "the anonymous session is closed")
+
+		session, err := f.service.BrowserSession(context.Background(), signedIn.ID)
+		require.NoError(t, err)
+		require.NotNil(t, session.Principal)
+		assert.Equal(t, f.user.ID, session.Principal.UserID)
+		assert.Equal(t, signedIn.CSRFToken, session.CSRFToken)
+
+		stale, err := f.service.BrowserSession(context.Background(), anonymous.ID)
+		require.NoError(t, err)
+		assert.NotEqual(t, anonymous.ID, stale.ID)
+	})
+
+	t.Run("ends when the user signs out everywhere", func(t *testing.T) {
+		f := newOIDCFixture(t, false)
+		signedIn, err := f.service.SignIn(f.ctx, "")
+		require.NoError(t, err)
+
+		f.user.TokenVersion++
+		session, err := f.service.BrowserSession(context.Background(), signedIn.ID)
+		require.NoError(t, err)
+		assert.Nil(t, session.Principal)
+		assert.NotEqual(t, signedIn.ID, session.ID)
+	})
+
+	t.Run("sign in requires a user", func(t *testing.T) {
+		f := newOIDCFixture(t, false)
+
+		_, err := f.service.SignIn(context.Background(), "")
+		assert.ErrorIs(t, err, services.ErrNoPrincipal)
+
+		ctx := entities.ContextWithPrincipal(context.Background(),
+			&entities.Principal{UserID: f.user.ID, Actor: &entities.Principal{Role: entities.RoleAdmin}})
+		_, err = f.service.SignIn(ctx, "")
+		assert.ErrorIs(t, err, services.ErrImpersonationRestricted)
+		assert.Zero(t, f.sessions.Len())
+	})
+}
+
+func TestOIDCService_Exchange(t *testing.T) {
+	tests := []struct {
+		name         string
+		confidential bool
+		modify       func(*input.TokenRequest)
+		err          error
+	}{
+		{"public client", false, func(*input.TokenRequest) {}, nil},
+		{"wrong grant type", true, func(r *input.TokenRequest) { r.GrantType = "password" }, services.ErrUnsupportedGrantType},
+		{"missing verifier", true, func(r *input.TokenRequest) { r.CodeVerifier = "" }, services.ErrInvalidOAuthRequest},
+		{"wrong verifier", true, func(r *input.TokenRequest) { r.CodeVerifier = testVerifier + "x" }, services.ErrInvalidGrant},
+		{"wrong redirect URI", true, func(r *input.TokenRequest) { r.RedirectURI = "https://app.example.com/other" }, services.ErrInvalidGrant},
+		{"wrong secret", true, func(r *input.TokenRequest) { r.ClientSecret = "wrong" }, services.ErrInvalidClient},
+		{"missing secret", true, func(r *input.TokenRequest) { r.ClientSecret = "" }, services.ErrInvalidClient},
+		{"secret for a public client", false, func(r *input.TokenRequest) { r.ClientSecret = "secret" }, services.ErrInvalidClient},
+		{"unknown client", true, func(r *input.TokenRequest) { r.ClientID = uuid.NewString() }, services.ErrInvalidClient},
+		{"forged code", true, func(r *input.TokenRequest) { r.Code += "x" }, services.ErrInvalidGrant},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOIDCFixture(t, tt.confidential)
+			req := f.tokenRequest(f.authorize(t, f.authorizationRequest()).Get("code"))
+			tt.modify(&req)
+
+			tokens, err := f.service.Exchange(context.Background(), req)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, tokens.IDToken)
+		})
+	}
+
+	t.Run("code issued to another client", func(t *testing.T) {
+		f := newOIDCFixture(t, false)
+		code := f.authorize(t, f.authorizationRequest()).Get("code")
+
+		other, err := f.service.RegisterClient(context.Background(), "Other", []string{testRedirectURI}, false)
+		require.NoError(t, err)
+		req := f.tokenRequest(code)
+		req.ClientID = other.Client.ID.String()
+		_, err = f.service.Exchange(context.Background(), req)
+		assert.ErrorIs(t, err, services.ErrInvalidGrant)
+	})
+
+	t.Run("disabled user", func(t *testing.T) {
+		f := newOIDCFixture(t, false)
+		code := f.authorize(t, f.authorizationRequest()).Get("code")
+		f.user.Active = false
+
+		_, err := f.service.Exchange(context.Background(), f.tokenRequest(code))
+		assert.ErrorIs(t, err, services.ErrInvalidGrant)
+	})
+}
+
+func TestOIDCService_Clients(t *testing.T) {
+	f := newOIDCFixture(t, true)
+	assert.NotEmpty(t, f.client.Secret)
+	assert.NotEqual(t, f.client.Secret, f.client.Client.SecretHash)
+	assert.True(t, f.client.Client.Confidential)
+
+	_, err := f.service.RegisterClient(context.Background(), "Bad", []string{"http://app.example.com/cb"}, false)
+	assert.ErrorIs(t, err, entities.ErrInvalidOAuthClient)
+
+	clients, err := f.service.ListClients(context.Background())
+	require.NoError(t, err)
+	require.Len(t, clients, 1)
+
+	require.NoError(t, f.service.DeleteClient(context.Background(), f.client.Client.ID))
+	assert.ErrorIs(t, f.service.DeleteClient(context.Background(), f.client.Client.ID), output.ErrOAuthClientNotFound)
+}
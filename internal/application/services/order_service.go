@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
 	"user-management/internal/domain/ports/input"
 	"user-management/internal/domain/ports/output"
 	"user-management/internal/domain/valueobjects"
@@ -10,32 +11,53 @@ import (
 	"github.com/google/uuid"
 )
 
+var (
+	ErrInvalidOrderID = errs.New(errs.BadRequest, "invalid_order_id", "invalid order ID")
+
+	// ErrOrderWorkerUnavailable indica que el pool de workers se detuvo sin
+	// devolver el resultado de la tarea
+	ErrOrderWorkerUnavailable = errs.New(errs.Unavailable, "order_worker_unavailable", "order worker unavailable")
+)
+
 type OrderService struct {
 	repo   output.OrderRepository
+	users  output.UserRepository
 	worker output.OrderWorker
+	uow    output.UnitOfWork
 }
 
 var _ input.OrderService = (*OrderService)(nil)
 
-func NewOrderService(repo output.OrderRepository, worker output.OrderWorker) input.OrderService {
-	return &OrderService{repo: repo, worker: worker}
+func NewOrderService(repo output.OrderRepository, users output.UserRepository, worker output.OrderWorker, uow output.UnitOfWork) *OrderService {
+	return &OrderService{repo: repo, users: users, worker: worker, uow: uow}
+}
+
+// Start arranca el worker que procesa los cambios de estado. Quien lo llama
+// es responsable de detenerlo al salir.
+func (o *OrderService) Start(ctx context.Context) error {
+	return o.worker.Start(ctx)
 }
 
 // CancelOrder implements [input.OrderService].
-func (o *OrderService) CancelOrder(ctx context.Context, id uuid.UUID) error {
-	order, err := o.repo.FindByID(ctx, id)
+func (o *OrderService) CancelOrder(ctx context.Context, id uuid.UUID, version int) (*entities.Order, error) {
+	var order *entities.Order
+	err := o.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if order, err = o.repo.FindByID(ctx, id); err != nil {
+			return err
+		}
+		if order.Version != version {
+			return output.ErrConcurrentModification
+		}
+
+		order.Status = valueobjects.OrderStatus(entities.StatusCancelled)
+		return o.repo.Update(ctx, order)
+	})
 	if err != nil {
-		return err
-	}
-	if order == nil {
-		return nil
+		return nil, err
 	}
 
-	order.Status = valueobjects.OrderStatus(entities.StatusCancelled)
-	if err := o.repo.Update(ctx, order); err != nil {
-		return err
-	}
-	return nil
+	return order, nil
 }
 
 // GetAllOrders implements [input.OrderService].
@@ -47,10 +69,72 @@ func (o *OrderService) GetAllOrders(ctx context.Context) ([]*entities.Order, err
 	return orders, nil
 }
 
+// ListOrders implements [input.OrderService].
+func (o *OrderService) ListOrders(ctx context.Context, query output.OrderQuery) (*output.OrderPage, error) {
+	query, err := normalizeOrderQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.repo.FindOrders(ctx, query)
+}
+
+// ListUserOrders implements [input.OrderService].
+// Devuelve el error del repositorio de usuarios si el usuario no existe o
+// está borrado, en lugar de una lista vacía.
+func (o *OrderService) ListUserOrders(ctx context.Context, userID uuid.UUID, query output.OrderQuery) (*output.UserOrderPage, error) {
+	query, err := normalizeOrderQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := o.users.FindByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return o.repo.FindByUserID(ctx, userID, query)
+}
+
+// normalizeOrderQuery aplica los valores por defecto (orden por fecha de
+// creación, DefaultPageSize) y rechaza estados desconocidos o rangos
+// incoherentes.
+func normalizeOrderQuery(query output.OrderQuery) (output.OrderQuery, error) {
+	if query.Sort == "" {
+		query.Sort = output.OrderSortCreatedAt
+	}
+	if !query.Sort.Valid() {
+		return query, errs.Errorf(ErrInvalidQuery, "unknown sort field %q", query.Sort)
+	}
+
+	limit, err := pageLimit(query.Limit)
+	if err != nil {
+		return query, err
+	}
+	query.Limit = limit
+
+	f := query.Filter
+	for _, status := range f.Statuses {
+		if !status.Valid() {
+			return query, errs.Errorf(ErrInvalidQuery, "unknown status %q", status)
+		}
+	}
+	if f.MinTotal != nil && f.MaxTotal != nil && *f.MinTotal > *f.MaxTotal {
+		return query, errs.Errorf(ErrInvalidQuery, "min_total is greater than max_total")
+	}
+	if f.CreatedFrom != nil && f.CreatedTo != nil && f.CreatedFrom.After(*f.CreatedTo) {
+		return query, errs.Errorf(ErrInvalidQuery, "created_from is after created_to")
+	}
+	if f.CompletedFrom != nil && f.CompletedTo != nil && f.CompletedFrom.After(*f.CompletedTo) {
+		return query, errs.Errorf(ErrInvalidQuery, "completed_from is after completed_to")
+	}
+
+	return query, nil
+}
+
 // GetOrderByID implements [input.OrderService].
 func (o *OrderService) GetOrderByID(ctx context.Context, orderID uuid.UUID) (*entities.Order, error) {
 	if orderID == uuid.Nil {
-		return nil, nil
+		return nil, ErrInvalidOrderID
 	}
 
 	order, err := o.repo.FindByID(ctx, orderID)
@@ -66,8 +150,18 @@ func (o *OrderService) PlaceOrder(ctx context.Context, userID uuid.UUID, items [
 	if err != nil {
 		return nil, err
 	}
-
-	if err := o.repo.Save(ctx, *order); err != nil {
+	// El ID se asigna aquí para poder devolverlo con la orden creada
+	order.ID = int(uuid.New().ID())
+
+	// El usuario se comprueba dentro de la misma transacción en la que se
+	// guarda la orden para no dejar órdenes de usuarios inexistentes
+	err = o.uow.Do(ctx, func(ctx context.Context) error {
+		if _, err := o.users.FindByID(ctx, userID); err != nil {
+			return err
+		}
+		return o.repo.Save(ctx, *order)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -80,9 +174,6 @@ func (o *OrderService) UpdateOrderStatus(ctx context.Context, id uuid.UUID, stat
 	if err != nil {
 		return err
 	}
-	if order == nil {
-		return nil
-	}
 
 	statusVO := valueobjects.OrderStatus(status)
 	if err := o.worker.Submit(ctx, order, "updateStatus", &statusVO); err != nil {
@@ -94,8 +185,8 @@ func (o *OrderService) UpdateOrderStatus(ctx context.Context, id uuid.UUID, stat
 	select {
 	case updatedOrder, ok := <-resultsChan:
 		if !ok {
-			// Canal cerrado sin resultados
-			return nil
+			// Canal cerrado sin resultados: el pool se detuvo
+			return ErrOrderWorkerUnavailable
 		}
 		if updatedOrder == nil {
 			// Resultado nil
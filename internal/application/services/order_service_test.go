@@ -2,9 +2,11 @@ package services
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"user-management/internal/domain/entities"
 	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
 	"user-management/internal/domain/valueobjects"
 	"user-management/tests/mocks"
 
@@ -72,7 +74,7 @@ func TestOrderService_UpdateOrderStatus(t *testing.T) {
 		worker.AssertExpectations(t)
 	})
 
-	t.Run("returns nil when order not found", func(t *testing.T) {
+	t.Run("returns not found when order does not exist", func(t *testing.T) {
 		// Arrange
 		repo := new(mocks.OrderRepositoryMock)
 		worker := mocks.NewWorkerPoolMock()
@@ -85,13 +87,13 @@ func TestOrderService_UpdateOrderStatus(t *testing.T) {
 		ctx := context.Background()
 		orderID := uuid.New()
 
-		repo.On("FindByID", mock.Anything, orderID).Return(nil, nil)
+		repo.On("FindByID", mock.Anything, orderID).Return(nil, output.ErrOrderNotFound)
 
 		// Act
 		err := service.UpdateOrderStatus(ctx, orderID, "processing")
 
 		// Assert
-		assert.NoError(t, err)
+		assert.ErrorIs(t, err, output.ErrOrderNotFound)
 		repo.AssertCalled(t, "FindByID", mock.Anything, orderID)
 		worker.AssertNotCalled(t, "Submit", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 		worker.AssertNotCalled(t, "GetResults", mock.Anything)
@@ -218,14 +220,9 @@ func TestOrderService_UpdateOrderStatus(t *testing.T) {
 		err := service.UpdateOrderStatus(ctx, orderID, "processing")
 
 		// Assert
-		// Cuando el canal está cerrado y vacío, la lectura devuelte nil
-		// Esto podría causar un panic, así que necesitamos manejarlo
-		// En realidad, UpdateOrderStatus podría bloquearse aquí
-		// Este test muestra un caso límite que debería manejarse
-		if err == nil {
-			// Si no hay error, verificar que no se llamó a Update
-			repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
-		}
+		// Un canal cerrado y vacío significa que el pool se detuvo
+		assert.ErrorIs(t, err, ErrOrderWorkerUnavailable)
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
 	})
 }
 
@@ -234,10 +231,7 @@ func TestNewOrderService(t *testing.T) {
 		repo := new(mocks.OrderRepositoryMock)
 		worker := mocks.NewWorkerPoolMock()
 
-		// Configurar worker.Start
-		worker.On("Start", mock.Anything).Return(nil)
-
-		service := NewOrderService(repo, worker)
+		service := NewOrderService(repo, new(mocks.MockUserRepository), worker, new(mocks.UnitOfWorkMock))
 
 		assert.NotNil(t, service)
 		assert.IsType(t, &OrderService{}, service)
@@ -247,7 +241,28 @@ func TestNewOrderService(t *testing.T) {
 		_, ok := iface.(input.OrderService)
 		assert.True(t, ok, "OrderService debe implementar la interfaz input.OrderService")
 
-		worker.AssertCalled(t, "Start", mock.Anything)
+		worker.AssertNotCalled(t, "Start", mock.Anything)
+	})
+}
+
+func TestOrderService_Start(t *testing.T) {
+	t.Run("starts the worker", func(t *testing.T) {
+		worker := mocks.NewWorkerPoolMock()
+		worker.On("Start", mock.Anything).Return(nil)
+
+		service := NewOrderService(new(mocks.OrderRepositoryMock), new(mocks.MockUserRepository), worker, new(mocks.UnitOfWorkMock))
+
+		require.NoError(t, service.Start(context.Background()))
+		worker.AssertExpectations(t)
+	})
+
+	t.Run("returns worker error", func(t *testing.T) {
+		worker := mocks.NewWorkerPoolMock()
+		worker.On("Start", mock.Anything).Return(assert.AnError)
+
+		service := NewOrderService(new(mocks.OrderRepositoryMock), new(mocks.MockUserRepository), worker, new(mocks.UnitOfWorkMock))
+
+		assert.Equal(t, assert.AnError, service.Start(context.Background()))
 	})
 }
 
@@ -257,10 +272,10 @@ func TestOrderService_PlaceOrder(t *testing.T) {
 		repo := new(mocks.OrderRepositoryMock)
 		worker := mocks.NewWorkerPoolMock()
 
-		// Configurar worker.Start
-		worker.On("Start", mock.Anything).Return(nil)
+		users := new(mocks.MockUserRepository)
+		uow := new(mocks.UnitOfWorkMock)
 
-		service := NewOrderService(repo, worker)
+		service := NewOrderService(repo, users, worker, uow)
 
 		ctx := context.Background()
 		userID := uuid.New()
@@ -268,6 +283,8 @@ func TestOrderService_PlaceOrder(t *testing.T) {
 			{ProductID: 1, Quantity: 2, Price: 10.0},
 		}
 
+		uow.SetupDo()
+		users.On("FindByID", mock.Anything, userID).Return(&entities.User{ID: userID}, nil)
 		repo.On("Save", mock.Anything, mock.MatchedBy(func(order entities.Order) bool {
 			return order.UserID == int(userID.ID()) &&
 				len(order.Items) == 1
@@ -280,5 +297,125 @@ func TestOrderService_PlaceOrder(t *testing.T) {
 		assert.Equal(t, int(userID.ID()), order.UserID)
 
 		repo.AssertExpectations(t)
+		uow.AssertExpectations(t)
+	})
+
+	t.Run("does not save order when user does not exist", func(t *testing.T) {
+		repo := new(mocks.OrderRepositoryMock)
+		users := new(mocks.MockUserRepository)
+		uow := new(mocks.UnitOfWorkMock)
+		worker := mocks.NewWorkerPoolMock()
+
+		service := NewOrderService(repo, users, worker, uow)
+
+		userID := uuid.New()
+		expectedErr := errors.New("user not found")
+
+		uow.SetupDo()
+		users.On("FindByID", mock.Anything, userID).Return(nil, expectedErr)
+
+		order, err := service.PlaceOrder(context.Background(), userID, []entities.OrderItem{
+			{ProductID: 1, Quantity: 1, Price: 10.0},
+		})
+
+		assert.Nil(t, order)
+		assert.Equal(t, expectedErr, err)
+		repo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns error when transaction cannot start", func(t *testing.T) {
+		repo := new(mocks.OrderRepositoryMock)
+		users := new(mocks.MockUserRepository)
+		uow := new(mocks.UnitOfWorkMock)
+		worker := mocks.NewWorkerPoolMock()
+
+		service := NewOrderService(repo, users, worker, uow)
+
+		uow.On("Do", mock.Anything).Return(assert.AnError)
+
+		order, err := service.PlaceOrder(context.Background(), uuid.New(), []entities.OrderItem{
+			{ProductID: 1, Quantity: 1, Price: 10.0},
+		})
+
+		assert.Nil(t, order)
+		assert.Equal(t, assert.AnError, err)
+		users.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrderService_ListOrders(t *testing.T) {
+	t.Run("applies default sort and limit", func(t *testing.T) {
+		repo := new(mocks.OrderRepositoryMock)
+		service := &OrderService{repo: repo}
+
+		ctx := context.Background()
+		expected := &output.OrderPage{}
+		repo.On("FindOrders", ctx, output.OrderQuery{Sort: output.OrderSortCreatedAt, Limit: DefaultPageSize}).
+			Return(expected, nil).
+			Once()
+
+		page, err := service.ListOrders(ctx, output.OrderQuery{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, page)
+		repo.AssertExpectations(t)
+	})
+
+	minTotal, maxTotal := 50.0, 10.0
+	invalid := map[string]output.OrderQuery{
+		"unknown sort field": {Sort: "user_id"},
+		"unknown status":     {Filter: output.OrderFilter{Statuses: []valueobjects.OrderStatus{"lost"}}},
+		"inverted totals":    {Filter: output.OrderFilter{MinTotal: &minTotal, MaxTotal: &maxTotal}},
+		"limit too large":    {Limit: MaxPageSize + 1},
+	}
+	for name, query := range invalid {
+		t.Run("rejects "+name, func(t *testing.T) {
+			repo := new(mocks.OrderRepositoryMock)
+			service := &OrderService{repo: repo}
+
+			_, err := service.ListOrders(context.Background(), query)
+
+			assert.ErrorIs(t, err, ErrInvalidQuery)
+			repo.AssertNotCalled(t, "FindOrders", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestOrderService_ListUserOrders(t *testing.T) {
+	t.Run("lists orders of an existing user", func(t *testing.T) {
+		repo := new(mocks.OrderRepositoryMock)
+		users := new(mocks.MockUserRepository)
+		service := &OrderService{repo: repo, users: users}
+
+		ctx := context.Background()
+		userID := uuid.New()
+		expected := &output.UserOrderPage{Stats: output.OrderStats{OrderCount: 2, LifetimeSpend: 30}}
+
+		users.On("FindByID", ctx, userID).Return(&entities.User{ID: userID}, nil).Once()
+		repo.On("FindByUserID", ctx, userID, output.OrderQuery{Sort: output.OrderSortCreatedAt, Limit: DefaultPageSize}).
+			Return(expected, nil).
+			Once()
+
+		page, err := service.ListUserOrders(ctx, userID, output.OrderQuery{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, page)
+		repo.AssertExpectations(t)
+		users.AssertExpectations(t)
+	})
+
+	t.Run("fails when the user does not exist", func(t *testing.T) {
+		repo := new(mocks.OrderRepositoryMock)
+		users := new(mocks.MockUserRepository)
+		service := &OrderService{repo: repo, users: users}
+
+		userID := uuid.New()
+		users.On("FindByID", mock.Anything, userID).Return(nil, output.ErrUserNotFound)
+
+		_, err := service.ListUserOrders(context.Background(), userID, output.OrderQuery{})
+
+		assert.ErrorIs(t, err, output.ErrUserNotFound)
+		repo.AssertNotCalled(t, "FindByUserID", mock.Anything, mock.Anything, mock.Anything)
 	})
 }
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/internal/domain/valueobjects"
+
+	"github.com/google/uuid"
+)
+
+// PurposePasswordReset identifica los tokens de recuperación de contraseña
+// en el almacén de tokens de un solo uso
+const PurposePasswordReset = "password_reset"
+
+var ErrWrongPassword = errs.New(errs.Forbidden, "wrong_password", "current password is incorrect")
+
+// PasswordOptions configura PasswordService; los campos a cero toman los
+// valores por defecto
+type PasswordOptions struct {
+	TokenTTL time.Duration // 1h
+	// ResetURL es el enlace que recibe el usuario, al que se añade
+	// ?token=...; si está vacío se envía sólo el token
+	ResetURL string
+	// Solicitudes permitidas por email y por IP en cada ventana
+	ForgotPerEmail int           // 3
+	ForgotPerIP    int           // 10
+	ForgotWindow   time.Duration // 1h
+}
+
+func (o *PasswordOptions) defaults() {
+	if o.TokenTTL <= 0 {
+		o.TokenTTL = time.Hour
+	}
+	if o.ForgotPerEmail <= 0 {
+		o.ForgotPerEmail = 3
+	}
+	if o.ForgotPerIP <= 0 {
+		o.ForgotPerIP = 10
+	}
+	if o.ForgotWindow <= 0 {
+		o.ForgotWindow = time.Hour
+	}
+}
+
+// PasswordService recupera contraseñas con tokens opacos de un solo uso: el
+// usuario recibe un valor aleatorio y el almacén sólo guarda su hash, que
+// caduca tras TokenTTL.
+type PasswordService struct {
+	users    output.UserRepository
+	tokens   output.OneTimeTokenStore
+	limiter  output.RateLimiter
+	notifier entities.Notifier
+	opts     PasswordOptions
+}
+
+var _ input.PasswordService = (*PasswordService)(nil)
+
+func NewPasswordService(users output.UserRepository, tokens output.OneTimeTokenStore, limiter output.RateLimiter,
+	notifier entities.Notifier, opts PasswordOptions) *PasswordService {
+	opts.defaults()
+	return &PasswordService{
+		users:    users,
+		tokens:   tokens,
+		limiter:  limiter,
+		notifier: notifier,
+		opts:     opts,
+	}
+}
+
+// ForgotPassword implements [input.PasswordService]. Cada solicitud
+// invalida los tokens enviados antes.
+func (s *PasswordService) ForgotPassword(ctx context.Context, email string, client input.ClientInfo) error {
+	email = valueobjects.NormalizeEmail(email)
+	if err := allow(ctx, s.limiter, "password-forgot:email:"+email, s.opts.ForgotPerEmail, s.opts.ForgotWindow); err != nil {
+		return err
+	}
+	if err := allow(ctx, s.limiter, "password-forgot:ip:"+client.IP, s.opts.ForgotPerIP, s.opts.ForgotWindow); err != nil {
+		return err
+	}
+
+	user, err := s.users.FindByEmail(ctx, email)
+	if errors.Is(err, output.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.tokens.RevokeAll(ctx, PurposePasswordReset, user.ID); err != nil {
+		return err
+	}
+	token := newSecret()
+	err = s.tokens.Save(ctx, output.OneTimeToken{
+		Hash:      hashToken(token),
+		Purpose:   PurposePasswordReset,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(s.opts.TokenTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.notifier.Send(&entities.Notification{
+		Email:   user.Email,
+		Title:   "Reset your password",
+		Message: "Choose a new password with this link: " + s.link(token),
+		Type:    PurposePasswordReset,
+	})
+}
+
+func (s *PasswordService) link(token string) string {
+	if s.opts.ResetURL == "" {
+		return token
+	}
+	return s.opts.ResetURL + "?token=" + url.QueryEscape(token)
+}
+
+// ResetPassword implements [input.PasswordService]. Si la contraseña no es
+// válida el token no se gasta y puede reintentarse.
+func (s *PasswordService) ResetPassword(ctx context.Context, token, password string) error {
+	if err := entities.ValidatePassword(password); err != nil {
+		return err
+	}
+
+	stored, err := s.tokens.Consume(ctx, PurposePasswordReset, hashToken(token))
+	if err != nil {
+		return err
+	}
+	user, err := s.users.FindByID(ctx, stored.UserID)
+	if errors.Is(err, output.ErrUserNotFound) {
+		return output.ErrTokenNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.setPassword(ctx, user, password)
+}
+
+// ChangePassword implements [input.PasswordService].
+func (s *PasswordService) ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, password string) error {
+	if err := denyImpersonation(ctx); err != nil {
+		return err
+	}
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.Password.Matches(currentPassword) {
+		return ErrWrongPassword
+	}
+	return s.setPassword(ctx, user, password)
+}
+
+// setPassword guarda la contraseña nueva, cierra las sesiones abiertas y
+// anula los tokens de recuperación pendientes
+func (s *PasswordService) setPassword(ctx context.Context, user *entities.User, password string) error {
+	now := time.Now()
+	if err := user.ChangePassword(password, now); err != nil {
+		return err
+	}
+	user.RevokeSessions(now)
+	if err := s.users.Update(ctx, user); err != nil {
+		return err
+	}
+	return s.tokens.RevokeAll(ctx, PurposePasswordReset, user.ID)
+}
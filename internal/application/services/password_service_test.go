@@ -0,0 +1,121 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"user-management/internal/application/services"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type passwordFixture struct {
+	repo     *mocks.MockUserRepository
+	tokens   *mocks.OneTimeTokenStoreFake
+	notifier *mocks.NotifierMock
+	service  *services.PasswordService
+	user     *entities.User
+}
+
+func newPasswordFixture(t *testing.T) *passwordFixture {
+	user, err := entities.NewUser("John Doe", "john@example.com", 30, "Password123!")
+	require.NoError(t, err)
+
+	f := &passwordFixture{
+		repo:     new(mocks.MockUserRepository),
+		tokens:   mocks.NewOneTimeTokenStoreFake(),
+		notifier: &mocks.NotifierMock{},
+		user:     user,
+	}
+	f.service = services.NewPasswordService(f.repo, f.tokens, mocks.AllowAll(), f.notifier, services.PasswordOptions{})
+
+	f.repo.On("FindByEmail", mock.Anything, "john@example.com").Return(user, nil).Maybe()
+	f.repo.On("FindByEmail", mock.Anything, mock.Anything).Return(nil, output.ErrUserNotFound).Maybe()
+	f.repo.On("FindByID", mock.Anything, user.ID).Return(user, nil).Maybe()
+	f.repo.On("Update", mock.Anything, user).Return(nil).Maybe()
+	return f
+}
+
+// forgot pide la recuperación y devuelve el token enviado
+func (f *passwordFixture) forgot(t *testing.T) string {
+	require.NoError(t, f.service.ForgotPassword(context.Background(), "john@example.com", input.ClientInfo{IP: "10.0.0.1"}))
+	sent := f.notifier.Sent()
+	require.NotEmpty(t, sent)
+	message := sent[len(sent)-1].Message
+	return message[strings.LastIndex(message, " ")+1:]
+}
+
+func TestPasswordService_ResetPassword(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("resets the password once and closes sessions", func(t *testing.T) {
+		f := newPasswordFixture(t)
+		token := f.forgot(t)
+		assert.Equal(t, services.PurposePasswordReset, f.notifier.Sent()[0].Type)
+
+		require.NoError(t, f.service.ResetPassword(ctx, token, "NewPassword456!"))
+		assert.True(t, f.user.Password.Matches("NewPassword456!"))
+		assert.Equal(t, 1, f.user.TokenVersion)
+
+		err := f.service.ResetPassword(ctx, token, "OtherPassword789!")
+		assert.ErrorIs(t, err, output.ErrTokenNotFound)
+	})
+
+	t.Run("a new request invalidates earlier tokens", func(t *testing.T) {
+		f := newPasswordFixture(t)
+		first := f.forgot(t)
+		second := f.forgot(t)
+
+		assert.ErrorIs(t, f.service.ResetPassword(ctx, first, "NewPassword456!"), output.ErrTokenNotFound)
+		assert.NoError(t, f.service.ResetPassword(ctx, second, "NewPassword456!"))
+	})
+
+	t.Run("an invalid password does not spend the token", func(t *testing.T) {
+		f := newPasswordFixture(t)
+		token := f.forgot(t)
+
+		err := f.service.ResetPassword(ctx, token, "short")
+		assert.True(t, errs.Is(err, errs.Validation))
+		assert.Equal(t, 1, f.tokens.Len())
+		assert.True(t, f.user.Password.Matches("Password123!"))
+	})
+
+	t.Run("unknown emails are accepted silently", func(t *testing.T) {
+		f := newPasswordFixture(t)
+
+		require.NoError(t, f.service.ForgotPassword(ctx, "nobody@example.com", input.ClientInfo{}))
+		assert.Empty(t, f.notifier.Sent())
+		assert.Zero(t, f.tokens.Len())
+	})
+}
+
+func TestPasswordService_ChangePassword(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("requires the current password", func(t *testing.T) {
+		f := newPasswordFixture(t)
+
+		err := f.service.ChangePassword(ctx, f.user.ID, "wrong-password", "NewPassword456!")
+		assert.ErrorIs(t, err, services.ErrWrongPassword)
+		f.repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("changes the password, closes sessions and drops pending resets", func(t *testing.T) {
+		f := newPasswordFixture(t)
+		token := f.forgot(t)
+
+		require.NoError(t, f.service.ChangePassword(ctx, f.user.ID, "Password123!", "NewPassword456!"))
+		assert.True(t, f.user.Password.Matches("NewPassword456!"))
+		assert.Equal(t, 1, f.user.TokenVersion)
+		assert.WithinDuration(t, time.Now(), f.user.UpdatedAt, time.Second)
+		assert.ErrorIs(t, f.service.ResetPassword(ctx, token, "OtherPassword789!"), output.ErrTokenNotFound)
+	})
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"user-management/internal/domain/errs"
+)
+
+var (
+	// ErrInvalidQuery indica parámetros de listado incoherentes o desconocidos
+	ErrInvalidQuery = errs.New(errs.BadRequest, "invalid_query", "invalid query")
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 100
+)
+
+// pageLimit aplica DefaultPageSize a un límite sin indicar y rechaza los que
+// están fuera de [1, MaxPageSize]
+func pageLimit(limit int) (int, error) {
+	switch {
+	case limit == 0:
+		return DefaultPageSize, nil
+	case limit < 0 || limit > MaxPageSize:
+		return 0, errs.Errorf(ErrInvalidQuery, "limit must be between 1 and %d", MaxPageSize)
+	}
+	return limit, nil
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+)
+
+// sessionSeenInterval evita escribir la sesión en cada petición: la última
+// actividad se actualiza como mucho una vez por intervalo
+const sessionSeenInterval = time.Minute
+
+var ErrInvalidRefreshToken = errs.New(errs.Unauthorized, "invalid_refresh_token", "refresh token is invalid or expired")
+
+// SessionOptions configura SessionService; los campos a cero toman los
+// valores por defecto
+type SessionOptions struct {
+	// RefreshTokenTTL es lo que dura una sesión sin renovarse
+	RefreshTokenTTL time.Duration // 30 días
+}
+
+func (o *SessionOptions) defaults() {
+	if o.RefreshTokenTTL <= 0 {
+		o.RefreshTokenTTL = 30 * 24 * time.Hour
+	}
+}
+
+// SessionService guarda una sesión por cada login, con su refresh token.
+// AuthService la abre al emitir el primer token de acceso y la comprueba en
+// cada petición, así que cerrar una sesión invalida también sus tokens de
+// acceso.
+type SessionService struct {
+	sessions output.SessionStore
+	users    output.UserRepository
+	audit    output.AuditLog
+	opts     SessionOptions
+}
+
+var _ input.SessionService = (*SessionService)(nil)
+
+func NewSessionService(sessions output.SessionStore, users output.UserRepository, audit output.AuditLog,
+	opts SessionOptions) *SessionService {
+	opts.defaults()
+	return &SessionService{sessions: sessions, users: users, audit: audit, opts: opts}
+}
+
+// start abre una sesión de user y devuelve su refresh token
+func (s *SessionService) start(ctx context.Context, user *entities.User, client input.ClientInfo) (*entities.Session, string, error) {
+	refreshToken := newSecret()
+	session := entities.NewSession(user, hashToken(refreshToken), client.UserAgent, client.IP, s.opts.RefreshTokenTTL)
+	if err := s.sessions.Save(ctx, session); err != nil {
+		return nil, "", err
+	}
+	return session, refreshToken, nil
+}
+
+// refresh canjea un refresh token: la sesión sigue, con otro refresh token.
+// Cada refresh token vale una sola vez; si uno ya canjeado vuelve a llegar,
+// alguien más lo tiene y se cierra la sesión.
+func (s *SessionService) refresh(ctx context.Context, refreshToken string, client input.ClientInfo) (*entities.Session, *entities.User, string, error) {
+	hash := hashToken(refreshToken)
+	session, err := s.sessions.FindByRefreshHash(ctx, hash)
+	if errors.Is(err, output.ErrSessionNotFound) {
+		return nil, nil, "", s.refreshReused(ctx, hash)
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	user, err := s.users.FindByID(ctx, session.UserID)
+	if errors.Is(err, output.ErrUserNotFound) {
+		return nil, nil, "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+	now := time.Now()
+	if !user.Active || !session.IsActive(user, now) {
+		return nil, nil, "", ErrInvalidRefreshToken
+	}
+
+	next := newSecret()
+	session.Rotate(hashToken(next), now, s.opts.RefreshTokenTTL)
+	session.Touch(now, client.IP)
+	err = s.sessions.Rotate(ctx, session, hash)
+	if errors.Is(err, output.ErrConcurrentModification) {
+		// Otra petición canjeó el mismo token a la vez
+		return nil, nil, "", s.revokeReused(ctx, session)
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return session, user, next, nil
+}
+
+// refreshReused cierra la sesión si hash es de un refresh token ya
+// canjeado. Devuelve siempre ErrInvalidRefreshToken salvo fallo del almacén.
+func (s *SessionService) refreshReused(ctx context.Context, hash string) error {
+	session, err := s.sessions.FindBySpentRefreshHash(ctx, hash)
+	if errors.Is(err, output.ErrSessionNotFound) {
+		return ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return err
+	}
+	return s.revokeReused(ctx, session)
+}
+
+// revokeReused cierra session, cuyo refresh token se ha canjeado dos veces
+func (s *SessionService) revokeReused(ctx context.Context, session *entities.Session) error {
+	if err := s.sessions.Delete(ctx, session.ID); err != nil && !errors.Is(err, output.ErrSessionNotFound) {
+		return err
+	}
+	entry := entities.NewAuditEntry(entities.AuditRefreshTokenReused, session.UserID, "session:"+session.ID.String())
+	if err := s.audit.Record(ctx, entry); err != nil {
+		log.Printf("no se pudo registrar %s de %s en la auditoría: %v", entry.Action, entry.Target, err)
+	}
+	return ErrInvalidRefreshToken
+}
+
+// check comprueba que la sesión de un token de acceso siga abierta y anota
+// la actividad
+func (s *SessionService) check(ctx context.Context, user *entities.User, sessionID uuid.UUID) error {
+	session, err := s.sessions.FindByID(ctx, sessionID)
+	if errors.Is(err, output.ErrSessionNotFound) {
+		return ErrInvalidAccessToken
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if !session.IsActive(user, now) {
+		return ErrInvalidAccessToken
+	}
+
+	if now.Sub(session.LastSeenAt) >= sessionSeenInterval {
+		// La actividad es informativa: si no se puede guardar, la sesión
+		// sigue valiendo. Sólo se escribe la actividad, para no deshacer una
+		// renovación hecha mientras tanto.
+		if err := s.sessions.Touch(ctx, session.ID, now, session.IP); err != nil {
+			log.Printf("no se pudo registrar la actividad de la sesión %s: %v", session.ID, err)
+		}
+	}
+	return nil
+}
+
+// ListSessions implements [input.SessionService]. No incluye las sesiones
+// que invalidó un cambio de contraseña.
+func (s *SessionService) ListSessions(ctx context.Context, userID uuid.UUID) ([]*entities.Session, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.sessions.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	active := sessions[:0]
+	for _, session := range sessions {
+		if session.IsActive(user, now) {
+			active = append(active, session)
+		}
+	}
+	return active, nil
+}
+
+// RevokeSession implements [input.SessionService]. Las sesiones de otros
+// usuarios se tratan como inexistentes.
+func (s *SessionService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if err := denyImpersonation(ctx); err != nil {
+		return err
+	}
+	session, err := s.sessions.FindByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return output.ErrSessionNotFound
+	}
+	return s.sessions.Delete(ctx, sessionID)
+}
+
+// RevokeAllSessions implements [input.SessionService]. Además de borrar las
+// sesiones incrementa el TokenVersion del usuario, así que tampoco valen los
+// tokens emitidos sin sesión.
+func (s *SessionService) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	if err := denyImpersonation(ctx); err != nil {
+		return err
+	}
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	user.RevokeSessions(time.Now())
+	if err := s.users.Update(ctx, user); err != nil {
+		return err
+	}
+	if err := s.sessions.DeleteByUser(ctx, userID); err != nil {
+		return err
+	}
+
+	var actor uuid.UUID
+	if principal, ok := entities.PrincipalFromContext(ctx); ok {
+		actor = principal.UserID
+	}
+	if err := s.audit.Record(ctx, entities.NewAuditEntry(entities.AuditSessionsRevoked, actor, "user:"+user.ID.String())); err != nil {
+		log.Printf("no se pudo registrar %s de user:%s en la auditoría: %v", entities.AuditSessionsRevoked, user.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package services_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"user-management/internal/application/services"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type sessionFixture struct {
+	store    *mocks.SessionStoreFake
+	audit    *mocks.AuditLogFake
+	sessions *services.SessionService
+	auth     *services.AuthService
+	user     *entities.User
+}
+
+func newSessionFixture(t *testing.T) *sessionFixture {
+	user, err := entities.NewUser("John Doe", "john@example.com", 30, "Password123!")
+	require.NoError(t, err)
+
+	repo := new(mocks.MockUserRepository)
+	repo.On("FindByEmail", mock.Anything, "john@example.com").Return(user, nil)
+	repo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+	repo.On("Update", mock.Anything, user).Return(nil)
+
+	f := &sessionFixture{store: mocks.NewSessionStoreFake(), audit: &mocks.AuditLogFake{}, user: user}
+	f.sessions = services.NewSessionService(f.store, repo, f.audit, services.SessionOptions{})
+	f.auth = services.NewAuthService(repo, mocks.AllowAll(), nil, f.sessions, testSigner, services.AuthOptions{})
+	return f
+}
+
+func (f *sessionFixture) login(t *testing.T, client input.ClientInfo) *input.AccessToken {
+	result, err := f.auth.Login(context.Background(), "john@example.com", "Password123!", client)
+	require.NoError(t, err)
+	require.NotEmpty(t, result.AccessToken.RefreshToken)
+	return result.AccessToken
+}
+
+func TestSessionService(t *testing.T) {
+	ctx := context.Background()
+	laptop := input.ClientInfo{IP: "10.0.0.1", UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"}
+	phone := input.ClientInfo{IP: "10.0.0.2", UserAgent: "Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537.36"}
+
+	t.Run("each login opens a session bound to its access tokens", func(t *testing.T) {
+		f := newSessionFixture(t)
+		token := f.login(t, laptop)
+		f.login(t, phone)
+
+		sessions, err := f.sessions.ListSessions(ctx, f.user.ID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		devices := []string{sessions[0].Device, sessions[1].Device}
+		assert.ElementsMatch(t, []string{"Firefox on Linux", "Chrome on Android"}, devices)
+
+		principal, err := f.auth.Authenticate(ctx, token.Token)
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, principal.SessionID)
+	})
+
+	t.Run("refresh rotates the refresh token", func(t *testing.T) {
+		f := newSessionFixture(t)
+		token := f.login(t, laptop)
+
+		refreshed, err := f.auth.Refresh(ctx, token.RefreshToken, phone)
+		require.NoError(t, err)
+		assert.NotEqual(t, token.RefreshToken, refreshed.RefreshToken)
+		_, err = f.auth.Authenticate(ctx, refreshed.Token)
+		require.NoError(t, err)
+
+		sessions, err := f.sessions.ListSessions(ctx, f.user.ID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, phone.IP, sessions[0].IP)
+	})
+
+	t.Run("reusing a spent refresh token closes the session and is audited", func(t *testing.T) {
+		f := newSessionFixture(t)
+		token, kept := f.login(t, laptop), f.login(t, phone)
+		refreshed, err := f.auth.Refresh(ctx, token.RefreshToken, laptop)
+		require.NoError(t, err)
+
+		_, err = f.auth.Refresh(ctx, token.RefreshToken, phone)
+		assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+
+		_, err = f.auth.Authenticate(ctx, refreshed.Token)
+		assert.ErrorIs(t, err, services.ErrInvalidAccessToken)
+		_, err = f.auth.Refresh(ctx, refreshed.RefreshToken, laptop)
+		assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+		_, err = f.auth.Authenticate(ctx, kept.Token)
+		assert.NoError(t, err)
+
+		entries := f.audit.Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, entities.AuditRefreshTokenReused, entries[0].Action)
+		assert.Equal(t, f.user.ID, entries[0].ActorID)
+	})
+
+	t.Run("concurrent refreshes with the same token leave one winner at most", func(t *testing.T) {
+		f := newSessionFixture(t)
+		token := f.login(t, laptop)
+
+		var wg sync.WaitGroup
+		results := make(chan error, 2)
+		for range 2 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := f.auth.Refresh(ctx, token.RefreshToken, laptop)
+				results <- err
+			}()
+		}
+		wg.Wait()
+		close(results)
+
+		succeeded := 0
+		for err := range results {
+			if err == nil {
+				succeeded++
+			} else {
+				assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+			}
+		}
+		assert.LessOrEqual(t, succeeded, 1)
+	})
+
+	t.Run("revoking a session invalidates its tokens only", func(t *testing.T) {
+		f := newSessionFixture(t)
+		revoked, kept := f.login(t, laptop), f.login(t, phone)
+
+		principal, err := f.auth.Authenticate(ctx, revoked.Token)
+		require.NoError(t, err)
+		require.NoError(t, f.sessions.RevokeSession(ctx, f.user.ID, principal.SessionID))
+
+		_, err = f.auth.Authenticate(ctx, revoked.Token)
+		assert.ErrorIs(t, err, services.ErrInvalidAccessToken)
+		_, err = f.auth.Refresh(ctx, revoked.RefreshToken, laptop)
+		assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+		_, err = f.auth.Authenticate(ctx, kept.Token)
+		assert.NoError(t, err)
+	})
+
+	t.Run("users cannot revoke other users' sessions", func(t *testing.T) {
+		f := newSessionFixture(t)
+		token := f.login(t, laptop)
+		principal, err := f.auth.Authenticate(ctx, token.Token)
+		require.NoError(t, err)
+
+		err = f.sessions.RevokeSession(ctx, uuid.New(), principal.SessionID)
+		assert.ErrorIs(t, err, output.ErrSessionNotFound)
+	})
+
+	t.Run("global logout closes every session and is audited", func(t *testing.T) {
+		f := newSessionFixture(t)
+		first, second := f.login(t, laptop), f.login(t, phone)
+
+		admin := entities.ContextWithPrincipal(ctx, &entities.Principal{Role: entities.RoleAdmin})
+		require.NoError(t, f.sessions.RevokeAllSessions(admin, f.user.ID))
+
+		for _, token := range []*input.AccessToken{first, second} {
+			_, err := f.auth.Authenticate(ctx, token.Token)
+			assert.ErrorIs(t, err, services.ErrInvalidAccessToken)
+			_, err = f.auth.Refresh(ctx, token.RefreshToken, laptop)
+			assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+		}
+		sessions, err := f.sessions.ListSessions(ctx, f.user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+
+		entries := f.audit.Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, entities.AuditSessionsRevoked, entries[0].Action)
+		assert.Equal(t, "user:"+f.user.ID.String(), entries[0].Target)
+	})
+
+	t.Run("sessions revoked through the user, as on password change, are hidden", func(t *testing.T) {
+		f := newSessionFixture(t)
+		f.login(t, laptop)
+		f.user.RevokeSessions(f.user.UpdatedAt)
+
+		sessions, err := f.sessions.ListSessions(ctx, f.user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/output"
+)
+
+// ErrTooManyRequests indica que se superó el límite de intentos; va envuelto
+// con errs.WithRetryAfter
+var ErrTooManyRequests = errs.New(errs.RateLimited, "too_many_requests", "too many requests, try again later")
+
+// newSecret devuelve un valor aleatorio de 256 bits apto para tokens
+func newSecret() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// hashToken es lo que se guarda de un token: quien lea el almacén no puede
+// reconstruirlo
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// allow consume un intento de key en limiter y devuelve ErrTooManyRequests,
+// con el tiempo de espera, si se superó el límite
+func allow(ctx context.Context, limiter output.RateLimiter, key string, limit int, window time.Duration) error {
+	allowed, retryAfter, err := limiter.Allow(ctx, key, limit, window)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errs.WithRetryAfter(ErrTooManyRequests, retryAfter)
+	}
+	return nil
+}
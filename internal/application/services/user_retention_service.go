@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+)
+
+type UserRetentionService struct {
+	users     output.UserRepository
+	orders    output.OrderRepository
+	uow       output.UnitOfWork
+	retention time.Duration
+}
+
+var _ input.UserRetentionService = (*UserRetentionService)(nil)
+
+func NewUserRetentionService(users output.UserRepository, orders output.OrderRepository, uow output.UnitOfWork, retention time.Duration) *UserRetentionService {
+	return &UserRetentionService{
+		users:     users,
+		orders:    orders,
+		uow:       uow,
+		retention: retention,
+	}
+}
+
+// PurgeDeletedUsers elimina los usuarios borrados hace más de la retención
+// configurada. Cada usuario se purga con sus órdenes en una transacción
+// propia; si uno falla se registra y se sigue con el resto. Devuelve cuántos
+// se eliminaron.
+func (s *UserRetentionService) PurgeDeletedUsers(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-s.retention)
+	expired, err := s.users.FindDeletedBefore(ctx, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, user := range expired {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+
+		removed, err := s.purge(ctx, user.ID, cutoff)
+		if err != nil {
+			log.Printf("no se pudo purgar el usuario %s: %v", user.ID, err)
+			continue
+		}
+		if removed {
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// purge elimina el usuario y sus órdenes si sigue borrado desde antes de
+// cutoff. Se vuelve a leer dentro de la transacción porque pudo restaurarse
+// o borrarse de nuevo después de la búsqueda.
+func (s *UserRetentionService) purge(ctx context.Context, id uuid.UUID, cutoff time.Time) (bool, error) {
+	removed := false
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		user, err := s.users.FindAnyByID(ctx, id)
+		if errors.Is(err, output.ErrUserNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !user.IsDeleted() || !user.DeletedAt.Before(cutoff) {
+			return nil
+		}
+
+		if err := s.orders.DeleteByUserID(ctx, id); err != nil {
+			return err
+		}
+		if err := s.users.Purge(ctx, id); err != nil {
+			return err
+		}
+		removed = true
+		return nil
+	})
+	return removed && err == nil, err
+}
@@ -0,0 +1,121 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"user-management/internal/application/services"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+	"user-management/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// deletedUser devuelve un usuario borrado lógicamente en deletedAt
+func deletedUser(id uuid.UUID, deletedAt time.Time) *entities.User {
+	return &entities.User{ID: id, DeletedAt: &deletedAt}
+}
+
+func TestUserRetentionService_PurgeDeletedUsers(t *testing.T) {
+	retention := 30 * 24 * time.Hour
+	expiredAt := time.Now().Add(-retention - time.Hour)
+
+	t.Run("purges expired users and their orders", func(t *testing.T) {
+		users := new(mocks.MockUserRepository)
+		orders := new(mocks.OrderRepositoryMock)
+		uow := new(mocks.UnitOfWorkMock)
+		service := services.NewUserRetentionService(users, orders, uow, retention)
+
+		ctx := context.Background()
+		first, second := uuid.New(), uuid.New()
+		before := time.Now().Add(-retention)
+
+		users.On("FindDeletedBefore", ctx, mock.MatchedBy(func(cutoff time.Time) bool {
+			return !cutoff.Before(before) && cutoff.Before(time.Now().Add(-retention+time.Minute))
+		})).Return([]*entities.User{{ID: first}, {ID: second}}, nil).Once()
+		uow.SetupDo().Twice()
+		users.On("FindAnyByID", ctx, first).Return(deletedUser(first, expiredAt), nil).Once()
+		users.On("FindAnyByID", ctx, second).Return(deletedUser(second, expiredAt), nil).Once()
+		orders.On("DeleteByUserID", ctx, first).Return(nil).Once()
+		orders.On("DeleteByUserID", ctx, second).Return(nil).Once()
+		users.On("Purge", ctx, first).Return(nil).Once()
+		users.On("Purge", ctx, second).Return(nil).Once()
+
+		purged, err := service.PurgeDeletedUsers(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, purged)
+		users.AssertExpectations(t)
+		orders.AssertExpectations(t)
+		uow.AssertExpectations(t)
+	})
+
+	t.Run("skips users restored or deleted again since the search", func(t *testing.T) {
+		users := new(mocks.MockUserRepository)
+		orders := new(mocks.OrderRepositoryMock)
+		uow := new(mocks.UnitOfWorkMock)
+		service := services.NewUserRetentionService(users, orders, uow, retention)
+
+		ctx := context.Background()
+		restored, redeleted, gone := uuid.New(), uuid.New(), uuid.New()
+
+		users.On("FindDeletedBefore", ctx, mock.Anything).
+			Return([]*entities.User{{ID: restored}, {ID: redeleted}, {ID: gone}}, nil).Once()
+		uow.SetupDo().Times(3)
+		users.On("FindAnyByID", ctx, restored).Return(&entities.User{ID: restored}, nil).Once()
+		users.On("FindAnyByID", ctx, redeleted).Return(deletedUser(redeleted, time.Now()), nil).Once()
+		users.On("FindAnyByID", ctx, gone).Return(nil, output.ErrUserNotFound).Once()
+
+		purged, err := service.PurgeDeletedUsers(ctx)
+
+		assert.NoError(t, err)
+		assert.Zero(t, purged)
+		orders.AssertNotCalled(t, "DeleteByUserID", mock.Anything, mock.Anything)
+		users.AssertNotCalled(t, "Purge", mock.Anything, mock.Anything)
+	})
+
+	t.Run("keeps the user when its orders cannot be deleted and goes on", func(t *testing.T) {
+		users := new(mocks.MockUserRepository)
+		orders := new(mocks.OrderRepositoryMock)
+		uow := new(mocks.UnitOfWorkMock)
+		service := services.NewUserRetentionService(users, orders, uow, retention)
+
+		ctx := context.Background()
+		failing, next := uuid.New(), uuid.New()
+
+		users.On("FindDeletedBefore", ctx, mock.Anything).
+			Return([]*entities.User{{ID: failing}, {ID: next}}, nil).Once()
+		uow.SetupDo().Twice()
+		users.On("FindAnyByID", ctx, failing).Return(deletedUser(failing, expiredAt), nil).Once()
+		users.On("FindAnyByID", ctx, next).Return(deletedUser(next, expiredAt), nil).Once()
+		orders.On("DeleteByUserID", ctx, failing).Return(assert.AnError).Once()
+		orders.On("DeleteByUserID", ctx, next).Return(nil).Once()
+		users.On("Purge", ctx, next).Return(nil).Once()
+
+		purged, err := service.PurgeDeletedUsers(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		users.AssertNotCalled(t, "Purge", mock.Anything, failing)
+		users.AssertExpectations(t)
+	})
+
+	t.Run("does nothing when no user expired", func(t *testing.T) {
+		users := new(mocks.MockUserRepository)
+		orders := new(mocks.OrderRepositoryMock)
+		uow := new(mocks.UnitOfWorkMock)
+		service := services.NewUserRetentionService(users, orders, uow, retention)
+
+		ctx := context.Background()
+		users.On("FindDeletedBefore", ctx, mock.Anything).Return([]*entities.User{}, nil).Once()
+
+		purged, err := service.PurgeDeletedUsers(ctx)
+
+		assert.NoError(t, err)
+		assert.Zero(t, purged)
+		uow.AssertNotCalled(t, "Do", mock.Anything)
+	})
+}
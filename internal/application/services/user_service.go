@@ -2,53 +2,80 @@ package services
 
 import (
 	"context"
-	"errors"
+	"log"
 	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
 	"user-management/internal/domain/ports/input"
 	"user-management/internal/domain/ports/output"
+	"user-management/internal/domain/valueobjects"
 
 	"github.com/google/uuid"
 )
 
 var (
-	ErrEmailAlreadyExists = errors.New("email already exists")
+	// ErrEmailAlreadyExists lo devuelve el repositorio al guardar un email
+	// cuya forma normalizada ya está registrada
+	ErrEmailAlreadyExists = output.ErrEmailAlreadyExists
+
+	ErrInvalidUserID = errs.New(errs.BadRequest, "invalid_user_id", "invalid user ID")
+	ErrUserRequired  = errs.New(errs.Validation, "user_required", "user cannot be nil")
 )
 
 type UserService struct {
-	repo output.UserRepository
+	repo         output.UserRepository
+	verification input.EmailVerificationService
 }
 
 var _ input.UserService = (*UserService)(nil)
 
-func NewUserService(repo output.UserRepository) input.UserService {
-	return &UserService{repo: repo}
-}
+// UserServiceOption activa funciones opcionales de UserService
+type UserServiceOption func(*UserService)
 
-func (s *UserService) RegisterUser(ctx context.Context, name, email string, age int, password string) (*entities.User, error) {
-	existing, err := s.repo.FindByEmail(ctx, email)
-	if err != nil {
-		return nil, err
+// WithEmailVerification envía un token de verificación a cada cuenta nueva
+func WithEmailVerification(verification input.EmailVerificationService) UserServiceOption {
+	return func(s *UserService) {
+		s.verification = verification
 	}
+}
 
-	if existing != nil {
-		return nil, ErrEmailAlreadyExists
+func NewUserService(repo output.UserRepository, opts ...UserServiceOption) input.UserService {
+	s := &UserService{repo: repo}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
+}
 
+// RegisterUser crea la cuenta, sin verificar; la unicidad del email la
+// garantiza el propio Save de forma atómica, sin una consulta previa que
+// pueda quedar obsoleta. Si el envío de la verificación falla la cuenta se
+// crea igualmente: el usuario puede pedir que se reenvíe.
+func (s *UserService) RegisterUser(ctx context.Context, name, email string, age int, password string,
+	opts ...input.RegisterOption) (*entities.User, error) {
 	user, err := entities.NewUser(name, email, age, password)
 	if err != nil {
 		return nil, err
 	}
+	for _, opt := range opts {
+		opt(user)
+	}
 
 	if err := s.repo.Save(ctx, *user); err != nil {
 		return nil, err
 	}
 
+	if s.verification != nil {
+		if err := s.verification.SendVerification(ctx, user); err != nil {
+			log.Printf("no se pudo enviar la verificación de email a %s: %v", user.ID, err)
+		}
+	}
+
 	return user, nil
 }
 
 func (s *UserService) GetUserProfile(ctx context.Context, id uuid.UUID) (*entities.User, error) {
 	if id == uuid.Nil {
-		return nil, errors.New("invalid user ID")
+		return nil, ErrInvalidUserID
 	}
 
 	user, err := s.repo.FindByID(ctx, id)
@@ -63,18 +90,86 @@ func (s *UserService) GetAllUsers(ctx context.Context) ([]*entities.User, error)
 	return s.repo.GetAllUsers(ctx)
 }
 
+// ListUsers aplica los valores por defecto de la consulta (orden por fecha
+// de creación, DefaultPageSize) y rechaza límites, desplazamientos o rangos
+// incoherentes.
+func (s *UserService) ListUsers(ctx context.Context, query output.UserQuery) (*output.UserPage, error) {
+	if query.Sort == "" {
+		query.Sort = output.UserSortCreatedAt
+	}
+	if !query.Sort.Valid() {
+		return nil, errs.Errorf(ErrInvalidQuery, "unknown sort field %q", query.Sort)
+	}
+
+	limit, err := pageLimit(query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	query.Limit = limit
+	if query.Offset < 0 {
+		return nil, errs.Errorf(ErrInvalidQuery, "offset is negative")
+	}
+	if query.Offset > 0 && query.Cursor != "" {
+		return nil, errs.Errorf(ErrInvalidQuery, "offset and cursor are exclusive")
+	}
+
+	f := query.Filter
+	if f.MinAge != nil && f.MaxAge != nil && *f.MinAge > *f.MaxAge {
+		return nil, errs.Errorf(ErrInvalidQuery, "min_age is greater than max_age")
+	}
+	if f.CreatedFrom != nil && f.CreatedTo != nil && f.CreatedFrom.After(*f.CreatedTo) {
+		return nil, errs.Errorf(ErrInvalidQuery, "created_from is after created_to")
+	}
+
+	return s.repo.FindUsers(ctx, query)
+}
+
 func (s *UserService) UpdateProfile(ctx context.Context, user *entities.User) error {
 	if user == nil {
-		return errors.New("user cannot be nil")
+		return ErrUserRequired
+	}
+	if err := s.denyEmailChange(ctx, user); err != nil {
+		return err
 	}
 
 	return s.repo.Update(ctx, user)
 }
 
-func (s *UserService) DeleteUser(ctx context.Context, id string) error {
-	uid, err := uuid.Parse(id)
+// denyEmailChange impide a quien suplanta cambiar el email, que da acceso a
+// la recuperación de la contraseña. Si otra escritura cambia el email entre
+// esta lectura y el guardado, Update lo detecta por la versión.
+func (s *UserService) denyEmailChange(ctx context.Context, user *entities.User) error {
+	if denyImpersonation(ctx) == nil {
+		return nil
+	}
+
+	current, err := s.repo.FindByID(ctx, user.ID)
 	if err != nil {
 		return err
 	}
-	return s.repo.Delete(ctx, uid)
+	if valueobjects.NormalizeEmail(current.Email) != valueobjects.NormalizeEmail(user.Email) {
+		return ErrImpersonationRestricted
+	}
+	return nil
+}
+
+// DeleteUser realiza un borrado lógico; el usuario puede restaurarse hasta
+// que se purga.
+func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	if id == uuid.Nil {
+		return ErrInvalidUserID
+	}
+	if err := denyImpersonation(ctx); err != nil {
+		return err
+	}
+
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *UserService) RestoreUser(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	if id == uuid.Nil {
+		return nil, ErrInvalidUserID
+	}
+
+	return s.repo.Restore(ctx, id)
 }
@@ -3,11 +3,11 @@ package services_test
 import (
 	"context"
 	"errors"
-	"log"
 	"testing"
 	"user-management/internal/application/services"
 	"user-management/internal/domain/entities"
 	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
 	"user-management/internal/domain/valueobjects"
 	"user-management/tests/mocks"
 
@@ -30,10 +30,6 @@ func TestUserService_RegisterUser(t *testing.T) {
 		password := "SecurePass123!"
 
 		// Configurar expectativas
-		mockRepo.On("FindByEmail", ctx, email).
-			Return((*entities.User)(nil), nil).
-			Once()
-
 		mockRepo.On("Save", ctx, mock.AnythingOfType("entities.User")).
 			Run(func(args mock.Arguments) {
 				user := args.Get(1).(entities.User)
@@ -61,63 +57,46 @@ func TestUserService_RegisterUser(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
-	t.Run("failure - email already exists", func(t *testing.T) {
-		// Arrange
+	t.Run("success - creates an inactive user in a single save", func(t *testing.T) {
 		mockRepo := new(mocks.MockUserRepository)
 		service := services.NewUserService(mockRepo)
-
 		ctx := context.Background()
-		existingUser := &entities.User{
-			ID:    uuid.New(),
-			Name:  "Existing User",
-			Email: "existing@example.com",
-			Age:   25,
-		}
 
-		mockRepo.On("FindByEmail", ctx, "existing@example.com").
-			Return(existingUser, nil).
+		mockRepo.On("Save", ctx, mock.MatchedBy(func(user entities.User) bool { return !user.Active })).
+			Return(nil).
 			Once()
 
-		// Act
-		user, err := service.RegisterUser(ctx, "New User", "existing@example.com", 30, "Password123!")
-
-		// Assert
-		assert.Error(t, err)
-		assert.Nil(t, user)
-		assert.Equal(t, services.ErrEmailAlreadyExists, err)
-		assert.Contains(t, err.Error(), "email already exists")
+		user, err := service.RegisterUser(ctx, "John Doe", "john@example.com", 30, "SecurePass123!", input.RegisterInactive())
 
+		require.NoError(t, err)
+		assert.False(t, user.Active)
 		mockRepo.AssertExpectations(t)
-		mockRepo.AssertNotCalled(t, "Save")
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
 	})
 
-	t.Run("failure - error finding email", func(t *testing.T) {
+	t.Run("failure - email already exists", func(t *testing.T) {
 		// Arrange
 		mockRepo := new(mocks.MockUserRepository)
 		service := services.NewUserService(mockRepo)
 
 		ctx := context.Background()
-		expectedErr := errors.New("database error")
 
-		mockRepo.On("FindByEmail", ctx, "test@example.com").
-			Return((*entities.User)(nil), expectedErr).
+		// El repositorio rechaza el duplicado de forma atómica en Save
+		mockRepo.On("Save", ctx, mock.AnythingOfType("entities.User")).
+			Return(services.ErrEmailAlreadyExists).
 			Once()
 
-		log.Println("Mock setup complete for FindByEmail with error")
-
 		// Act
-		user, err := service.RegisterUser(ctx, "Test User", "test@example.com", 30, "Password123!")
-
-		log.Println("RegisterUser called, checking results")
-		log.Printf("Received error: %v", err)
+		user, err := service.RegisterUser(ctx, "New User", "existing@example.com", 30, "Password123!")
 
 		// Assert
 		assert.Error(t, err)
 		assert.Nil(t, user)
-		assert.Equal(t, expectedErr, err)
+		assert.Equal(t, services.ErrEmailAlreadyExists, err)
+		assert.Contains(t, err.Error(), "email already exists")
 
 		mockRepo.AssertExpectations(t)
-		mockRepo.AssertNotCalled(t, "Save")
+		mockRepo.AssertNotCalled(t, "FindByEmail")
 	})
 
 	t.Run("failure - invalid user data", func(t *testing.T) {
@@ -128,9 +107,6 @@ func TestUserService_RegisterUser(t *testing.T) {
 		ctx := context.Background()
 
 		// Email inválido hará que entities.NewUser falle
-		mockRepo.On("FindByEmail", ctx, "invalid-email").
-			Return((*entities.User)(nil), nil).
-			Once()
 
 		// Act
 		user, err := service.RegisterUser(ctx, "Test User", "invalid-email", 30, "Password123!")
@@ -138,7 +114,8 @@ func TestUserService_RegisterUser(t *testing.T) {
 		// Assert
 		assert.Error(t, err)
 		assert.Nil(t, user)
-		assert.Contains(t, err.Error(), "invalid email")
+		assert.ErrorIs(t, err, entities.ErrInvalidUser)
+		assert.Contains(t, err.Error(), "email must be a valid email address")
 
 		mockRepo.AssertExpectations(t)
 		mockRepo.AssertNotCalled(t, "Save")
@@ -152,10 +129,6 @@ func TestUserService_RegisterUser(t *testing.T) {
 		ctx := context.Background()
 		expectedErr := errors.New("save failed")
 
-		mockRepo.On("FindByEmail", ctx, "test@example.com").
-			Return((*entities.User)(nil), nil).
-			Once()
-
 		mockRepo.On("Save", ctx, mock.AnythingOfType("entities.User")).
 			Return(expectedErr).
 			Once()
@@ -378,68 +351,155 @@ func TestUserService_UpdateProfile(t *testing.T) {
 	})
 }
 
-// func TestUserService_DeleteUser(t *testing.T) {
-// 	t.Run("success - deletes user", func(t *testing.T) {
-// 		// Arrange
-// 		mockRepo := new(mocks.MockUserRepository)
-// 		service := services.NewUserService(mockRepo)
+func TestUserService_DeleteUser(t *testing.T) {
+	t.Run("success - soft deletes user", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockUserRepository)
+		service := services.NewUserService(mockRepo)
+
+		ctx := context.Background()
+		userID := uuid.New()
+
+		mockRepo.On("Delete", ctx, userID).
+			Return(nil).
+			Once()
+
+		// Act
+		err := service.DeleteUser(ctx, userID)
+
+		// Assert
+		assert.NoError(t, err)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failure - nil UUID", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockUserRepository)
+		service := services.NewUserService(mockRepo)
+
+		ctx := context.Background()
+
+		// Act
+		err := service.DeleteUser(ctx, uuid.Nil)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid user ID")
+
+		mockRepo.AssertNotCalled(t, "Delete")
+	})
+
+	t.Run("failure - error deleting user", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockUserRepository)
+		service := services.NewUserService(mockRepo)
+
+		ctx := context.Background()
+		userID := uuid.New()
+		expectedErr := errors.New("delete failed")
+
+		mockRepo.On("Delete", ctx, userID).
+			Return(expectedErr).
+			Once()
+
+		// Act
+		err := service.DeleteUser(ctx, userID)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Equal(t, expectedErr, err)
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestUserService_RestoreUser(t *testing.T) {
+	t.Run("success - restores user", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockUserRepository)
+		service := services.NewUserService(mockRepo)
 
-// 		ctx := context.Background()
-// 		userID := uuid.New()
+		ctx := context.Background()
+		userID := uuid.New()
+		restored := &entities.User{ID: userID, Name: "John Doe"}
 
-// 		mockRepo.On("Delete", ctx, userID).
-// 			Return(nil).
-// 			Once()
+		mockRepo.On("Restore", ctx, userID).
+			Return(restored, nil).
+			Once()
 
-// 		// Act
-// 		err := service.DeleteUser(ctx, userID.String())
+		// Act
+		user, err := service.RestoreUser(ctx, userID)
 
-// 		// Assert
-// 		assert.NoError(t, err)
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, restored, user)
 
-// 		mockRepo.AssertExpectations(t)
-// 	})
+		mockRepo.AssertExpectations(t)
+	})
 
-// 	t.Run("failure - invalid UUID format", func(t *testing.T) {
-// 		// Arrange
-// 		mockRepo := new(mocks.MockUserRepository)
-// 		service := services.NewUserService(mockRepo)
+	t.Run("failure - nil UUID", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockUserRepository)
+		service := services.NewUserService(mockRepo)
 
-// 		ctx := context.Background()
+		// Act
+		user, err := service.RestoreUser(context.Background(), uuid.Nil)
 
-// 		// Act
-// 		err := service.DeleteUser(ctx, "invalid-uuid")
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, user)
 
-// 		// Assert
-// 		assert.Error(t, err)
-// 		assert.Contains(t, err.Error(), "invalid UUID")
+		mockRepo.AssertNotCalled(t, "Restore")
+	})
+}
 
-// 		mockRepo.AssertNotCalled(t, "Delete")
-// 	})
+func TestUserService_ListUsers(t *testing.T) {
+	t.Run("success - applies default sort and limit", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockUserRepository)
+		service := services.NewUserService(mockRepo)
 
-// 	t.Run("failure - error deleting user", func(t *testing.T) {
-// 		// Arrange
-// 		mockRepo := new(mocks.MockUserRepository)
-// 		service := services.NewUserService(mockRepo)
+		ctx := context.Background()
+		expected := &output.UserPage{Total: 0}
 
-// 		ctx := context.Background()
-// 		userID := uuid.New()
-// 		expectedErr := errors.New("delete failed")
+		mockRepo.On("FindUsers", ctx, output.UserQuery{Sort: output.UserSortCreatedAt, Limit: services.DefaultPageSize}).
+			Return(expected, nil).
+			Once()
 
-// 		mockRepo.On("Delete", ctx, userID).
-// 			Return(expectedErr).
-// 			Once()
+		// Act
+		page, err := service.ListUsers(ctx, output.UserQuery{})
 
-// 		// Act
-// 		err := service.DeleteUser(ctx, userID.String())
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expected, page)
 
-// 		// Assert
-// 		assert.Error(t, err)
-// 		assert.Equal(t, expectedErr, err)
+		mockRepo.AssertExpectations(t)
+	})
 
-// 		mockRepo.AssertExpectations(t)
-// 	})
-// }
+	invalid := map[string]output.UserQuery{
+		"unknown sort field": {Sort: "password"},
+		"limit too large":    {Limit: services.MaxPageSize + 1},
+		"negative limit":     {Limit: -1},
+		"negative offset":    {Offset: -1},
+		"offset and cursor":  {Offset: 10, Cursor: "next"},
+	}
+	for name, query := range invalid {
+		t.Run("failure - "+name, func(t *testing.T) {
+			// Arrange
+			mockRepo := new(mocks.MockUserRepository)
+			service := services.NewUserService(mockRepo)
+
+			// Act
+			_, err := service.ListUsers(context.Background(), query)
+
+			// Assert
+			assert.ErrorIs(t, err, services.ErrInvalidQuery)
+
+			mockRepo.AssertNotCalled(t, "FindUsers")
+		})
+	}
+}
 
 func TestUserService_InterfaceImplementation(t *testing.T) {
 	t.Run("service implements interface", func(t *testing.T) {
@@ -0,0 +1,131 @@
+package entities
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"user-management/internal/domain/errs"
+
+	"github.com/google/uuid"
+)
+
+// Scopes que se pueden conceder a una API key. Cada recurso tiene uno de
+// lectura (GET) y otro de escritura (el resto de métodos).
+const (
+	ScopeUsersRead   = "users:read"
+	ScopeUsersWrite  = "users:write"
+	ScopeOrdersRead  = "orders:read"
+	ScopeOrdersWrite = "orders:write"
+	// Los de admin sólo los conceden administradores
+	ScopeAdminRead  = "admin:read"
+	ScopeAdminWrite = "admin:write"
+)
+
+// APIKeyScopes son todos los scopes válidos
+var APIKeyScopes = []string{ScopeUsersRead, ScopeUsersWrite, ScopeOrdersRead, ScopeOrdersWrite, ScopeAdminRead, ScopeAdminWrite}
+
+var (
+	ErrInvalidAPIKey = errs.New(errs.Validation, "invalid_api_key", "invalid API key")
+	ErrAPIKeyRevoked = errs.New(errs.Conflict, "api_key_revoked", "API key is already revoked")
+)
+
+// APIKey es una credencial de larga duración para clientes máquina a
+// máquina. Del secreto sólo se guarda el hash; Prefix (el comienzo de la key)
+// permite reconocerla en los listados.
+type APIKey struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	// UserID es el dueño; uuid.Nil si la creó una credencial sin usuario
+	UserID     uuid.UUID  `json:"user_id"`
+	Role       string     `json:"role"`
+	Scopes     []string   `json:"scopes"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// NewAPIKey crea una API key de owner con rol role. Si los datos no son
+// válidos devuelve un *errs.ValidationError con todos los campos que fallan.
+func NewAPIKey(owner uuid.UUID, role, name string, scopes []string, expiresAt *time.Time, prefix, hash string) (*APIKey, error) {
+	now := time.Now()
+	fields := errs.Fields{}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		fields.Add("name", "required", "name is required")
+	} else if len(name) > 100 {
+		fields.Add("name", "max", "name must be at most %d characters", 100)
+	}
+	if len(scopes) == 0 {
+		fields.Add("scopes", "required", "scopes is required")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(APIKeyScopes, scope) {
+			fields.Add("scopes", "oneof", "unknown scope %s", scope)
+			break
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(now) {
+		fields.Add("expires_at", "future", "expires_at must be in the future")
+	}
+	if err := fields.Err(ErrInvalidAPIKey); err != nil {
+		return nil, err
+	}
+
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	return &APIKey{
+		ID:        uuid.New(),
+		Name:      name,
+		UserID:    owner,
+		Role:      role,
+		Scopes:    slices.Compact(scopes),
+		Prefix:    prefix,
+		Hash:      hash,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}, nil
+}
+
+// Clone devuelve una copia independiente de la key
+func (k *APIKey) Clone() *APIKey {
+	c := *k
+	c.Scopes = slices.Clone(k.Scopes)
+	if k.ExpiresAt != nil {
+		expiresAt := *k.ExpiresAt
+		c.ExpiresAt = &expiresAt
+	}
+	if k.LastUsedAt != nil {
+		lastUsedAt := *k.LastUsedAt
+		c.LastUsedAt = &lastUsedAt
+	}
+	if k.RevokedAt != nil {
+		revokedAt := *k.RevokedAt
+		c.RevokedAt = &revokedAt
+	}
+	return &c
+}
+
+// IsActive indica si la key se puede usar en el instante t
+func (k *APIKey) IsActive(t time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || t.Before(*k.ExpiresAt))
+}
+
+// Revoke invalida la key definitivamente
+func (k *APIKey) Revoke(t time.Time) error {
+	if k.RevokedAt != nil {
+		return ErrAPIKeyRevoked
+	}
+	k.RevokedAt = &t
+	return nil
+}
+
+// MarkUsed registra un uso de la key desde ip
+func (k *APIKey) MarkUsed(t time.Time, ip string) {
+	k.LastUsedAt = &t
+	k.LastUsedIP = ip
+}
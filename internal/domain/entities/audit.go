@@ -0,0 +1,49 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Acciones que quedan en el registro de auditoría
+const (
+	AuditAccountLocked   = "account.locked"
+	AuditAccountUnlocked = "account.unlocked"
+	AuditIPLocked        = "ip.locked"
+	AuditAPIKeyCreated   = "api_key.created"
+	AuditAPIKeyRevoked   = "api_key.revoked"
+	AuditSessionsRevoked = "sessions.revoked"
+	// AuditRefreshTokenReused es un refresh token canjeado dos veces: alguien
+	// más lo tiene, y se cierra su sesión
+	AuditRefreshTokenReused = "refresh_token.reused"
+	// Suplantación: el inicio y cada petición hecha con el token
+	AuditImpersonationStarted = "impersonation.started"
+	AuditImpersonatedRequest  = "impersonation.request"
+)
+
+// AuditEntry es un evento de seguridad registrado para su revisión
+type AuditEntry struct {
+	ID     uuid.UUID `json:"id"`
+	Action string    `json:"action"`
+	// ActorID es quien provocó el evento; uuid.Nil si fue el sistema o una
+	// credencial sin usuario
+	ActorID uuid.UUID `json:"actor_id"`
+	// Target identifica lo afectado, p. ej. "user:<id>", "email:<email>" o
+	// "ip:<ip>"
+	Target  string            `json:"target"`
+	IP      string            `json:"ip,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+	At      time.Time         `json:"at"`
+}
+
+// NewAuditEntry crea una entrada con identificador y fecha
+func NewAuditEntry(action string, actorID uuid.UUID, target string) *AuditEntry {
+	return &AuditEntry{
+		ID:      uuid.New(),
+		Action:  action,
+		ActorID: actorID,
+		Target:  target,
+		At:      time.Now(),
+	}
+}
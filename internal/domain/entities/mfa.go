@@ -0,0 +1,92 @@
+package entities
+
+import (
+	"slices"
+	"time"
+	"user-management/internal/domain/errs"
+)
+
+var (
+	ErrMFAAlreadyEnabled = errs.New(errs.Conflict, "mfa_already_enabled", "multi-factor authentication is already enabled")
+	ErrMFANotEnabled     = errs.New(errs.Conflict, "mfa_not_enabled", "multi-factor authentication is not enabled")
+	ErrMFANotEnrolled    = errs.New(errs.Conflict, "mfa_not_enrolled", "multi-factor enrollment has not been started")
+)
+
+// MFA es el segundo factor TOTP del usuario. Durante el alta el secreto
+// nuevo espera en PendingSecret hasta que el usuario demuestra haberlo
+// configurado con un código válido.
+type MFA struct {
+	Secret        string
+	PendingSecret string
+	// RecoveryCodes son los hashes de los códigos de recuperación sin usar
+	RecoveryCodes []string
+	// LastStep es el último paso TOTP aceptado; impide reutilizar un código
+	LastStep  int64
+	EnabledAt *time.Time
+}
+
+// StartMFAEnrollment guarda secret a la espera de confirmarlo con
+// EnableMFA. Un alta sin confirmar se sustituye por la nueva.
+func (u *User) StartMFAEnrollment(secret string, t time.Time) error {
+	if u.MFAEnabled {
+		return ErrMFAAlreadyEnabled
+	}
+	u.MFA.PendingSecret = secret
+	u.UpdatedAt = t
+	return nil
+}
+
+// EnableMFA activa el secreto pendiente. step es el paso del código con el
+// que se confirmó y recoveryCodes los hashes de los códigos de recuperación.
+func (u *User) EnableMFA(recoveryCodes []string, step int64, t time.Time) error {
+	if u.MFAEnabled {
+		return ErrMFAAlreadyEnabled
+	}
+	if u.MFA.PendingSecret == "" {
+		return ErrMFANotEnrolled
+	}
+
+	u.MFA = MFA{
+		Secret:        u.MFA.PendingSecret,
+		RecoveryCodes: recoveryCodes,
+		LastStep:      step,
+		EnabledAt:     &t,
+	}
+	u.MFAEnabled = true
+	u.UpdatedAt = t
+	return nil
+}
+
+// DisableMFA desactiva el segundo factor y descarta sus secretos
+func (u *User) DisableMFA(t time.Time) error {
+	if !u.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+	u.MFA = MFA{}
+	u.MFAEnabled = false
+	u.UpdatedAt = t
+	return nil
+}
+
+// AcceptMFAStep registra el uso del código del paso step. Devuelve false si
+// ya se aceptó un código de ese paso o de uno posterior.
+func (u *User) AcceptMFAStep(step int64, t time.Time) bool {
+	if step <= u.MFA.LastStep {
+		return false
+	}
+	u.MFA.LastStep = step
+	u.UpdatedAt = t
+	return true
+}
+
+// UseRecoveryCode gasta el código de recuperación con el hash dado.
+// Devuelve false si no existe o ya se usó.
+func (u *User) UseRecoveryCode(hash string, t time.Time) bool {
+	i := slices.Index(u.MFA.RecoveryCodes, hash)
+	if i < 0 {
+		return false
+	}
+	u.MFA.RecoveryCodes = slices.Delete(slices.Clone(u.MFA.RecoveryCodes), i, i+1)
+	u.UpdatedAt = t
+	return true
+}
@@ -0,0 +1,127 @@
+package entities
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"user-management/internal/domain/errs"
+
+	"github.com/google/uuid"
+)
+
+// Scopes de OpenID Connect que admite el proveedor. openid es obligatorio;
+// profile y email deciden qué datos del usuario van en el ID token y en
+// userinfo.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// OIDCScopes son los scopes de OpenID Connect reconocidos, en el orden en
+// que se conceden
+var OIDCScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail}
+
+var ErrInvalidOAuthClient = errs.New(errs.Validation, "invalid_oauth_client", "invalid OAuth client")
+
+// OAuthClient es una aplicación que usa el servicio como proveedor de
+// identidad. Los clientes confidenciales se autentican con un secreto, del
+// que sólo se guarda el hash; los públicos (SPA, apps nativas) no tienen
+// secreto y dependen únicamente de PKCE.
+type OAuthClient struct {
+	ID           uuid.UUID `json:"client_id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Confidential bool      `json:"confidential"`
+	SecretHash   string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// NewOAuthClient crea un cliente; secretHash vacío lo hace público. Las URIs
+// de redirección deben ser absolutas, sin fragmento y https, salvo las de
+// loopback (http://localhost...) que usan las apps nativas y el desarrollo.
+func NewOAuthClient(name string, redirectURIs []string, secretHash string) (*OAuthClient, error) {
+	fields := errs.Fields{}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		fields.Add("name", "required", "name is required")
+	} else if len(name) > 100 {
+		fields.Add("name", "max", "name must be at most %d characters", 100)
+	}
+	if len(redirectURIs) == 0 {
+		fields.Add("redirect_uris", "required", "redirect_uris is required")
+	}
+	for _, uri := range redirectURIs {
+		if !validRedirectURI(uri) {
+			fields.Add("redirect_uris", "uri", "invalid redirect URI %s", uri)
+			break
+		}
+	}
+	if err := fields.Err(ErrInvalidOAuthClient); err != nil {
+		return nil, err
+	}
+
+	return &OAuthClient{
+		ID:           uuid.New(),
+		Name:         name,
+		RedirectURIs: slices.Clone(redirectURIs),
+		Confidential: secretHash != "",
+		SecretHash:   secretHash,
+		CreatedAt:    time.Now(),
+	}, nil
+}
+
+func validRedirectURI(raw string) bool {
+	uri, err := url.Parse(raw)
+	if err != nil || !uri.IsAbs() || uri.Host == "" || strings.Contains(raw, "#") {
+		return false
+	}
+	switch uri.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := uri.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	}
+	return false
+}
+
+// Clone devuelve una copia independiente del cliente
+func (c *OAuthClient) Clone() *OAuthClient {
+	clone := *c
+	clone.RedirectURIs = slices.Clone(c.RedirectURIs)
+	return &clone
+}
+
+// AllowsRedirect indica si uri es una de las registradas. La comparación es
+// exacta: un prefijo o un parámetro de más bastarían para desviar el código
+// de autorización a otra página.
+func (c *OAuthClient) AllowsRedirect(uri string) bool {
+	return slices.Contains(c.RedirectURIs, uri)
+}
+
+// UserClaims son los datos del usuario que el proveedor OpenID Connect
+// publica en el ID token y en userinfo (OIDC Core, sección 5.1)
+type UserClaims struct {
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	UpdatedAt     int64  `json:"updated_at,omitempty"`
+}
+
+// Claims devuelve los datos del usuario que permiten los scopes concedidos
+func (u *User) Claims(scopes []string) UserClaims {
+	var claims UserClaims
+	if slices.Contains(scopes, ScopeProfile) {
+		claims.Name = u.Name
+		claims.UpdatedAt = u.UpdatedAt.Unix()
+	}
+	if slices.Contains(scopes, ScopeEmail) {
+		verified := u.EmailVerified
+		claims.Email = u.Email
+		claims.EmailVerified = &verified
+	}
+	return claims
+}
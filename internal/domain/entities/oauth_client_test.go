@@ -0,0 +1,71 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"user-management/internal/domain/errs"
+)
+
+func TestNewOAuthClient(t *testing.T) {
+	t.Run("public and confidential clients", func(t *testing.T) {
+		public, err := NewOAuthClient(" Wiki ", []string{"https://wiki.example.com/callback"}, "")
+		require.NoError(t, err)
+		assert.Equal(t, "Wiki", public.Name)
+		assert.False(t, public.Confidential)
+
+		confidential, err := NewOAuthClient("CI", []string{"http://localhost:8081/cb"}, "hash")
+		require.NoError(t, err)
+		assert.True(t, confidential.Confidential)
+		assert.NotEqual(t, public.ID, confidential.ID)
+	})
+
+	t.Run("validates name and redirect URIs", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			uris  []string
+			field string
+		}{
+			{"", []string{"https://app.example.com/cb"}, "name"},
+			{"App", nil, "redirect_uris"},
+			{"App", []string{"/callback"}, "redirect_uris"},
+			{"App", []string{"https://app.example.com/cb#fragment"}, "redirect_uris"},
+			{"App", []string{"http://app.example.com/cb"}, "redirect_uris"},
+			{"App", []string{"javascript:alert(1)"}, "redirect_uris"},
+		}
+		for _, tt := range tests {
+			_, err := NewOAuthClient(tt.name, tt.uris, "")
+			ve, ok := errs.AsValidation(err)
+			require.True(t, ok, "%v", tt.uris)
+			assert.Contains(t, ve.Fields, tt.field)
+		}
+	})
+}
+
+func TestOAuthClient_AllowsRedirect(t *testing.T) {
+	client, err := NewOAuthClient("App", []string{"https://app.example.com/cb", "http://127.0.0.1:9000/cb"}, "")
+	require.NoError(t, err)
+
+	assert.True(t, client.AllowsRedirect("https://app.example.com/cb"))
+	assert.True(t, client.AllowsRedirect("http://127.0.0.1:9000/cb"))
+	assert.False(t, client.AllowsRedirect("https://app.example.com/cb/../admin"))
+	assert.False(t, client.AllowsRedirect("https://app.example.com/cb?next=https://evil.example"))
+	assert.False(t, client.AllowsRedirect("https://app.example.com/"))
+}
+
+func TestUser_Claims(t *testing.T) {
+	updated := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	user := &User{Name: "Ann Smith", Email: "ann@example.com", EmailVerified: true, UpdatedAt: updated}
+
+	assert.Equal(t, UserClaims{}, user.Claims([]string{ScopeOpenID}))
+	assert.Equal(t, UserClaims{Name: "Ann Smith", UpdatedAt: updated.Unix()}, user.Claims([]string{ScopeOpenID, ScopeProfile}))
+
+	claims := user.Claims([]string{ScopeOpenID, ScopeEmail})
+	assert.Equal(t, "ann@example.com", claims.Email)
+	require.NotNil(t, claims.EmailVerified)
+	assert.True(t, *claims.EmailVerified)
+	assert.Empty(t, claims.Name)
+}
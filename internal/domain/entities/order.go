@@ -1,19 +1,26 @@
 package entities
 
 import (
-	"errors"
+	"fmt"
 	"time"
+	"user-management/internal/domain/errs"
 	"user-management/internal/domain/valueobjects"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrInvalidOrder          = errs.New(errs.Validation, "invalid_order", "invalid order")
+	ErrOrderAlreadyCompleted = errs.New(errs.Conflict, "order_already_completed", "order already completed")
+)
+
 type Order struct {
 	ID          int                      `json:"id"`
 	UserID      int                      `json:"user_id"`
 	Items       []OrderItem              `json:"items"`
 	Total       float64                  `json:"total"`
 	Status      valueobjects.OrderStatus `json:"status"`
+	Version     int                      `json:"version"` // Control de concurrencia optimista
 	CreatedAt   time.Time                `json:"created_at"`
 	CompletedAt time.Time                `json:"completed_at,omitempty"`
 }
@@ -23,20 +30,30 @@ func NewOrder(userID uuid.UUID, items []OrderItem) (*Order, error) {
 		UserID:    int(userID.ID()),
 		Items:     items,
 		Status:    valueobjects.StatusPending,
+		Version:   1,
 		CreatedAt: time.Now(),
 	}
 
-	if err := order.CalculateTotal(); err != nil {
+	// Validar antes de calcular el total para informar de todos los items
+	// inválidos a la vez
+	if err := order.Validate(); err != nil {
 		return nil, err
 	}
 
-	if err := order.Validate(); err != nil {
+	if err := order.CalculateTotal(); err != nil {
 		return nil, err
 	}
 
 	return order, nil
 }
 
+// Clone devuelve una copia de la orden que no comparte sus items
+func (o *Order) Clone() *Order {
+	c := *o
+	c.Items = append([]OrderItem(nil), o.Items...)
+	return &c
+}
+
 func (o *Order) CalculateTotal() error {
 	total := 0.0
 	for _, item := range o.Items {
@@ -69,26 +86,33 @@ func (o *Order) AddItem(item OrderItem) error {
 	return o.CalculateTotal()
 }
 
+// Validate comprueba la orden y sus items y devuelve un
+// *errs.ValidationError con todos los campos que fallan; los de los items se
+// nombran items[i].campo
 func (o *Order) Validate() error {
+	fields := errs.Fields{}
 	if o.UserID <= 0 {
-		return errors.New("invalid user ID")
+		fields.Add("user_id", "gt", "invalid user ID")
 	}
 	if len(o.Items) == 0 {
-		return errors.New("order must have at least one item")
+		fields.Add("items", "min", "order must have at least one item")
+	}
+	for i := range o.Items {
+		fields.Merge(fmt.Sprintf("items[%d]", i), o.Items[i].Validate())
 	}
 	if o.Total < 0 {
-		return errors.New("total cannot be negative")
+		fields.Add("total", "gte", "total cannot be negative")
 	}
 	if !o.CreatedAt.IsZero() && !o.CompletedAt.IsZero() &&
 		o.CompletedAt.Before(o.CreatedAt) {
-		return errors.New("completed date cannot be before creation date")
+		fields.Add("completed_at", "gtefield", "completed date cannot be before creation date")
 	}
-	return nil
+	return fields.Err(ErrInvalidOrder)
 }
 
 func (o *Order) Complete() error {
 	if o.Status == valueobjects.StatusCompleted {
-		return errors.New("order already completed")
+		return ErrOrderAlreadyCompleted
 	}
 	o.Status = valueobjects.StatusCompleted
 	o.CompletedAt = time.Now()
@@ -1,6 +1,8 @@
 package entities
 
-import "errors"
+import "user-management/internal/domain/errs"
+
+var ErrInvalidOrderItem = errs.New(errs.Validation, "invalid_order_item", "invalid order item")
 
 type OrderItem struct {
 	ProductID int     `json:"product_id"`
@@ -9,18 +11,20 @@ type OrderItem struct {
 	Price     float64 `json:"price"`
 }
 
-// Comportamiento de OrderItem
+// Validate comprueba todos los campos del item y devuelve un
+// *errs.ValidationError con cada uno de los que fallan
 func (i *OrderItem) Validate() error {
+	fields := errs.Fields{}
 	if i.ProductID <= 0 {
-		return errors.New("invalid product ID")
+		fields.Add("product_id", "gt", "invalid product ID")
 	}
 	if i.Quantity <= 0 {
-		return errors.New("quantity must be positive")
+		fields.Add("quantity", "gt", "quantity must be positive")
 	}
 	if i.Price < 0 {
-		return errors.New("price cannot be negative")
+		fields.Add("price", "gte", "price cannot be negative")
 	}
-	return nil
+	return fields.Err(ErrInvalidOrderItem)
 }
 
 func (i *OrderItem) Subtotal() (float64, error) {
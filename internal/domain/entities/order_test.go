@@ -3,6 +3,7 @@ package entities
 import (
 	"testing"
 	"time"
+	"user-management/internal/domain/errs"
 	"user-management/internal/domain/valueobjects"
 
 	"github.com/google/uuid"
@@ -38,6 +39,7 @@ func TestOrder_NewOrder(t *testing.T) {
 		assert.Equal(t, int(userID.ID()), order.UserID)
 		assert.Equal(t, items, order.Items)
 		assert.Equal(t, valueobjects.StatusPending, order.Status)
+		assert.Equal(t, 1, order.Version)
 		assert.WithinDuration(t, time.Now(), order.CreatedAt, time.Second)
 		expectedTotal := 2*10.0 + 1*5.0
 		assert.Equal(t, expectedTotal, order.Total)
@@ -54,6 +56,25 @@ func TestOrder_NewOrder(t *testing.T) {
 		require.Nil(t, order)
 		assert.Contains(t, err.Error(), "quantity must be positive")
 	})
+
+	t.Run("failure - reports every invalid field at once", func(t *testing.T) {
+		items := []OrderItem{
+			{ProductID: 1, Quantity: 1, Price: 10.0},
+			{ProductID: 0, Quantity: 0, Price: -1.0},
+		}
+
+		order, err := NewOrder(uuid.Nil, items)
+		require.Nil(t, order)
+		require.ErrorIs(t, err, ErrInvalidOrder)
+
+		ve, ok := errs.AsValidation(err)
+		require.True(t, ok)
+		assert.Len(t, ve.Fields, 4)
+		assert.Equal(t, "gt", ve.Fields["items[1].product_id"].Rule)
+		assert.Equal(t, "gt", ve.Fields["items[1].quantity"].Rule)
+		assert.Equal(t, "gte", ve.Fields["items[1].price"].Rule)
+		assert.Contains(t, ve.Fields, "user_id")
+	})
 }
 
 func (s *OrderTestSuite) TestOrder_CalculateTotal() {
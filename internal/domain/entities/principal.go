@@ -0,0 +1,56 @@
+package entities
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// Roles de los principales
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Principal es quien realiza una petición autenticada. UserID es uuid.Nil
+// cuando la credencial no corresponde a un usuario concreto (p. ej. el token
+// estático de administración).
+type Principal struct {
+	UserID uuid.UUID
+	Role   string
+	// Scopes limita lo que puede hacer la credencial (p. ej. una API key);
+	// nil significa sin restricciones
+	Scopes []string
+	// APIKeyID es la API key usada, si la hay
+	APIKeyID uuid.UUID
+	// SessionID es la sesión del token de acceso, si la hay
+	SessionID uuid.UUID
+	// Actor es el administrador que suplanta al usuario; nil si no hay
+	// suplantación
+	Actor *Principal
+}
+
+// Allows indica si la credencial tiene el scope dado
+func (p *Principal) Allows(scope string) bool {
+	return p.Scopes == nil || slices.Contains(p.Scopes, scope)
+}
+
+// Impersonated indica si la petición la hace un administrador en nombre del
+// usuario
+func (p *Principal) Impersonated() bool {
+	return p.Actor != nil
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal devuelve una copia de ctx que lleva p
+func ContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext devuelve el principal de la petición, si la hay
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
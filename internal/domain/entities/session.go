@@ -0,0 +1,106 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session es un inicio de sesión de un usuario en un dispositivo. Se
+// mantiene con un refresh token, del que sólo se guarda el hash y que cambia
+// en cada renovación.
+type Session struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	RefreshHash string    `json:"-"`
+	// TokenVersion es el del usuario al iniciar la sesión; si cambia, la
+	// sesión deja de valer
+	TokenVersion int       `json:"-"`
+	Device       string    `json:"device"`
+	UserAgent    string    `json:"user_agent"`
+	IP           string    `json:"ip"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastSeenAt   time.Time `json:"last_seen_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// NewSession inicia una sesión de user que caduca si no se renueva en ttl
+func NewSession(user *User, refreshHash, userAgent, ip string, ttl time.Duration) *Session {
+	now := time.Now()
+	return &Session{
+		ID:           uuid.New(),
+		UserID:       user.ID,
+		RefreshHash:  refreshHash,
+		TokenVersion: user.TokenVersion,
+		Device:       DeviceName(userAgent),
+		UserAgent:    userAgent,
+		IP:           ip,
+		CreatedAt:    now,
+		LastSeenAt:   now,
+		ExpiresAt:    now.Add(ttl),
+	}
+}
+
+// IsActive indica si la sesión sigue valiendo para user en el instante t
+func (s *Session) IsActive(user *User, t time.Time) bool {
+	return user.ID == s.UserID && user.TokenVersion == s.TokenVersion && t.Before(s.ExpiresAt)
+}
+
+// Touch anota actividad de la sesión desde ip
+func (s *Session) Touch(t time.Time, ip string) {
+	s.LastSeenAt = t
+	s.IP = ip
+}
+
+// Rotate sustituye el refresh token y alarga la sesión otros ttl
+func (s *Session) Rotate(refreshHash string, t time.Time, ttl time.Duration) {
+	s.RefreshHash = refreshHash
+	s.ExpiresAt = t.Add(ttl)
+}
+
+// DeviceName resume un User-Agent en algo reconocible para el usuario, como
+// "Firefox on Windows". Es orientativo: el User-Agent lo elige el cliente.
+func DeviceName(userAgent string) string {
+	browser := ""
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+		{"Go-http-client/", "Go"},
+	} {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	os := ""
+	for _, o := range []struct{ token, name string }{
+		{"Windows", "Windows"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Mac OS X", "macOS"},
+		{"Android", "Android"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, o.token) {
+			os = o.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	default:
+		return "Unknown device"
+	}
+}
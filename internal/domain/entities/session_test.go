@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceName(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:121.0) Gecko/20100101 Firefox/121.0", "Firefox on Windows"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", "Chrome on macOS"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0", "Edge on Windows"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1", "Safari on iOS"},
+		{"curl/8.4.0", "curl"},
+		{"", "Unknown device"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, DeviceName(tt.userAgent))
+		})
+	}
+}
+
+func TestSession_IsActive(t *testing.T) {
+	user, err := NewUser("John Doe", "john@example.com", 30, "Password123!")
+	require.NoError(t, err)
+	session := NewSession(user, "hash", "curl/8.4.0", "10.0.0.1", time.Hour)
+	now := time.Now()
+
+	assert.True(t, session.IsActive(user, now))
+	assert.False(t, session.IsActive(user, now.Add(2*time.Hour)), "caducada")
+
+	session.Rotate("other", now.Add(30*time.Minute), time.Hour)
+	assert.True(t, session.IsActive(user, now.Add(80*time.Minute)), "renovar la alarga")
+
+	user.RevokeSessions(now)
+	assert.False(t, session.IsActive(user, now), "revocada con el usuario")
+}
@@ -3,71 +3,186 @@ package entities
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
+	"user-management/internal/domain/errs"
 	"user-management/internal/domain/valueobjects"
 
 	"github.com/google/uuid"
 )
 
 var (
-	ErrInvalidEmail = errors.New("invalid email format")
-	ErrWeakPassword = errors.New("password too weak")
+	ErrInvalidEmail = valueobjects.ErrInvalidEmail
+	ErrWeakPassword = valueobjects.ErrInvalidPassword
+	ErrInvalidUser  = errs.New(errs.Validation, "invalid_user", "invalid user")
 )
 
 type User struct {
-	ID        uuid.UUID                 `json:"id"`
-	Name      string                    `json:"name"`
-	Email     string                    `json:"email"`
-	Password  valueobjects.PasswordHash `json:"-"`
-	Age       int                       `json:"age"`
-	Active    bool                      `json:"active"`
-	CreatedAt time.Time                 `json:"created_at"`
-	UpdatedAt time.Time                 `json:"updated_at"`
+	ID       uuid.UUID                 `json:"id"`
+	Name     string                    `json:"name"`
+	Email    string                    `json:"email"`
+	Password valueobjects.PasswordHash `json:"-"`
+	Age      int                       `json:"age"`
+	Active   bool                      `json:"active"`
+	// EmailVerified indica que el usuario demostró controlar su email; se
+	// pierde al cambiarlo
+	EmailVerified   bool       `json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	// MFAEnabled exige un código TOTP además de la contraseña al iniciar
+	// sesión
+	MFAEnabled bool `json:"mfa_enabled"`
+	MFA        MFA  `json:"-"`
+	// TokenVersion va en los tokens de acceso; al incrementarlo dejan de
+	// valer todas las sesiones abiertas
+	TokenVersion int        `json:"-"`
+	Version      int        `json:"version"` // Control de concurrencia optimista
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"` // Borrado lógico
 }
 
+// NewUser crea un usuario activo. Si los datos no son válidos devuelve un
+// *errs.ValidationError con todos los campos que fallan, no sólo el primero.
 func NewUser(name, email string, age int, password string) (usr *User, err error) {
-	emailVO, err := valueobjects.NewEmail(email)
-	if err != nil {
-		return nil, err
-	}
+	fields := errs.Fields{}
+
+	passwordHash, err := valueobjects.HashPassword(password)
+	addPasswordError(fields, err)
 
-	passwordHash, err := valueobjects.NewPasswordHash(password)
-	if err != nil {
-		return nil, err
-	}
 	user := &User{
 		ID:        uuid.New(),
 		Name:      name,
-		Email:     emailVO.Value(),
+		Email:     strings.TrimSpace(email),
 		Password:  passwordHash,
 		Age:       age,
 		Active:    true,
+		Version:   1,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
+	user.validateInto(fields)
 
-	if err := user.Validate(); err != nil {
+	if err := fields.Err(ErrInvalidUser); err != nil {
 		return nil, err
 	}
-
 	return user, nil
 }
 
+// addPasswordError traduce el error de HashPassword a un error del campo
+// password
+func addPasswordError(fields errs.Fields, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, valueobjects.ErrPasswordRequired):
+		fields.Add("password", "required", "password is required")
+	case errors.Is(err, valueobjects.ErrPasswordTooLong):
+		fields.Add("password", "max", "password must be at most 72 bytes")
+	default:
+		fields.Add("password", "min", "password must be at least 8 characters")
+	}
+}
+
+// Clone devuelve una copia independiente del usuario
+func (u *User) Clone() *User {
+	c := *u
+	if u.DeletedAt != nil {
+		deletedAt := *u.DeletedAt
+		c.DeletedAt = &deletedAt
+	}
+	if u.EmailVerifiedAt != nil {
+		verifiedAt := *u.EmailVerifiedAt
+		c.EmailVerifiedAt = &verifiedAt
+	}
+	c.MFA.RecoveryCodes = slices.Clone(u.MFA.RecoveryCodes)
+	if u.MFA.EnabledAt != nil {
+		enabledAt := *u.MFA.EnabledAt
+		c.MFA.EnabledAt = &enabledAt
+	}
+	return &c
+}
+
+// VerifyEmail marca el email actual como verificado en el instante t
+func (u *User) VerifyEmail(t time.Time) {
+	u.EmailVerified = true
+	u.EmailVerifiedAt = &t
+	u.UpdatedAt = t
+}
+
+// ValidatePassword comprueba una contraseña en claro y devuelve un
+// *errs.ValidationError del campo password si no es válida
+func ValidatePassword(password string) error {
+	fields := errs.Fields{}
+	addPasswordError(fields, valueobjects.ValidatePassword(password))
+	return fields.Err(ErrInvalidUser)
+}
+
+// ChangePassword sustituye la contraseña. Si no es válida devuelve el error
+// de ValidatePassword y el usuario no cambia.
+func (u *User) ChangePassword(password string, t time.Time) error {
+	if err := ValidatePassword(password); err != nil {
+		return err
+	}
+	hash, err := valueobjects.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	u.Password = hash
+	u.UpdatedAt = t
+	return nil
+}
+
+// RevokeSessions invalida todos los tokens de acceso emitidos hasta ahora
+func (u *User) RevokeSessions(t time.Time) {
+	u.TokenVersion++
+	u.UpdatedAt = t
+}
+
+// IsDeleted indica si el usuario está borrado lógicamente
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != nil
+}
+
+// SoftDelete marca el usuario como borrado en el instante t
+func (u *User) SoftDelete(t time.Time) {
+	u.DeletedAt = &t
+	u.UpdatedAt = t
+}
+
+// Restore deshace un borrado lógico
+func (u *User) Restore(t time.Time) {
+	u.DeletedAt = nil
+	u.UpdatedAt = t
+}
+
 func (u *User) SetUpdatedAt(t time.Time) {
 	u.UpdatedAt = t
 }
 
+// Validate comprueba todos los campos y devuelve un *errs.ValidationError
+// con cada uno de los que fallan
 func (u *User) Validate() error {
-	if u.Name == "" || len(u.Name) < 2 {
-		return fmt.Errorf("name must be at least 2 characters")
+	fields := errs.Fields{}
+	u.validateInto(fields)
+	return fields.Err(ErrInvalidUser)
+}
+
+func (u *User) validateInto(fields errs.Fields) {
+	if len(u.Name) < 2 {
+		fields.Add("name", "min", "name must be at least 2 characters")
 	}
-	if u.Age < 0 || u.Age > 120 {
-		return fmt.Errorf("age must be between 0 and 120")
+	if u.Age < 0 {
+		fields.Add("age", "gte", "age must be between 0 and 120")
+	}
+	if u.Age > 120 {
+		fields.Add("age", "lte", "age must be between 0 and 120")
 	}
 	if u.Email == "" {
-		return fmt.Errorf("email cannot be empty")
+		fields.Add("email", "required", "email cannot be empty")
+	} else if _, err := valueobjects.NewEmail(u.Email); err != nil {
+		fields.Add("email", "email", "email must be a valid email address")
 	}
-	return nil
 }
 
 func (u *User) String() string {
@@ -86,14 +201,27 @@ func (u *User) String() string {
 }
 
 func (u *User) Update(name, email string, age int, active bool) error {
+	fields := errs.Fields{}
 	if name == "" {
-		return fmt.Errorf("name cannot be empty")
+		fields.Add("name", "required", "name cannot be empty")
+	}
+	var emailVO valueobjects.Email
+	if email != "" {
+		var err error
+		if emailVO, err = valueobjects.NewEmail(email); err != nil {
+			fields.Add("email", "email", "email must be a valid email address")
+		}
+	}
+	if err := fields.Err(ErrInvalidUser); err != nil {
+		return err
 	}
 
+	u.Name = name
 	if email != "" {
-		emailVO, err := valueobjects.NewEmail(email)
-		if err != nil {
-			return err
+		if emailVO.Normalized() != valueobjects.NormalizeEmail(u.Email) {
+			// Un email nuevo hay que volver a verificarlo
+			u.EmailVerified = false
+			u.EmailVerifiedAt = nil
 		}
 		u.Email = emailVO.Value()
 	}
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/suite"
@@ -42,7 +43,7 @@ func (s *UserTestSuite) TestNewUser() {
 			userName:    "John",
 			password:    "password123",
 			wantErr:     true,
-			errContains: "invalid email format",
+			errContains: "email must be a valid email address",
 		},
 		{
 			name:        "fail - short username",
@@ -73,6 +74,7 @@ func (s *UserTestSuite) TestNewUser() {
 				s.Equal(tt.age, user.Age)
 				s.False(user.CreatedAt.IsZero())
 				s.NotEqual(uuid.Nil, user.ID)
+				s.Equal(1, user.Version)
 			}
 		})
 	}
@@ -97,6 +99,21 @@ func (s *UserTestSuite) TestUser_UpdateProfile() {
 	s.Contains(err.Error(), "name cannot be empty")
 }
 
+func (s *UserTestSuite) TestNewUser_ReportsEveryInvalidField() {
+	user, err := entities.NewUser("J", "not-an-email", 150, "short")
+	s.Nil(user)
+	s.ErrorIs(err, entities.ErrInvalidUser)
+
+	ve, ok := errs.AsValidation(err)
+	s.Require().True(ok)
+	s.Equal(errs.Fields{
+		"name":     {Rule: "min", Message: "name must be at least 2 characters"},
+		"email":    {Rule: "email", Message: "email must be a valid email address"},
+		"age":      {Rule: "lte", Message: "age must be between 0 and 120"},
+		"password": {Rule: "min", Message: "password must be at least 8 characters"},
+	}, ve.Fields)
+}
+
 func (s *UserTestSuite) TestUser_Validate() {
 	tests := []struct {
 		name        string
@@ -189,3 +206,52 @@ func (s *UserTestSuite) TestUser_SetUpdatedAt() {
 
 	s.Equal(newTime, user.UpdatedAt)
 }
+
+func (s *UserTestSuite) TestUser_VerifyEmail() {
+	user, err := entities.NewUser("Carol", "carol@example.com", 30, "Password123!")
+	s.Require().NoError(err)
+	s.False(user.EmailVerified)
+	s.True(user.Password.Matches("Password123!"))
+	s.False(user.Password.Matches("password123!"))
+
+	verifiedAt := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	user.VerifyEmail(verifiedAt)
+	s.True(user.EmailVerified)
+	s.Equal(verifiedAt, *user.EmailVerifiedAt)
+
+	s.Require().NoError(user.Update("Carol", " CAROL@example.com ", 31, true))
+	s.True(user.EmailVerified, "el mismo email normalizado conserva la verificación")
+
+	s.Require().NoError(user.Update("Carol", "carol@example.org", 31, true))
+	s.False(user.EmailVerified)
+	s.Nil(user.EmailVerifiedAt)
+}
+
+func (s *UserTestSuite) TestUser_MFA() {
+	user, err := entities.NewUser("Dave", "dave@example.com", 30, "Password123!")
+	s.Require().NoError(err)
+	now := time.Now()
+
+	s.ErrorIs(user.EnableMFA(nil, 1, now), entities.ErrMFANotEnrolled)
+	s.Require().NoError(user.StartMFAEnrollment("SECRET", now))
+	s.Require().NoError(user.EnableMFA([]string{"hash-1", "hash-2"}, 10, now))
+	s.True(user.MFAEnabled)
+	s.Equal("SECRET", user.MFA.Secret)
+	s.Empty(user.MFA.PendingSecret)
+	s.ErrorIs(user.StartMFAEnrollment("OTHER", now), entities.ErrMFAAlreadyEnabled)
+
+	s.False(user.AcceptMFAStep(10, now), "el paso de la confirmación ya está usado")
+	s.True(user.AcceptMFAStep(11, now))
+	s.False(user.AcceptMFAStep(11, now))
+
+	clone := user.Clone()
+	s.True(user.UseRecoveryCode("hash-1", now))
+	s.False(user.UseRecoveryCode("hash-1", now))
+	s.Equal([]string{"hash-2"}, user.MFA.RecoveryCodes)
+	s.Equal([]string{"hash-1", "hash-2"}, clone.MFA.RecoveryCodes, "la copia no comparte los códigos")
+
+	s.Require().NoError(user.DisableMFA(now))
+	s.False(user.MFAEnabled)
+	s.Equal(entities.MFA{}, user.MFA)
+	s.ErrorIs(user.DisableMFA(now), entities.ErrMFANotEnabled)
+}
@@ -0,0 +1,163 @@
+// Package errs define los errores tipados del dominio. Cada error tiene una
+// clase (Kind) que indica cómo debe tratarlo quien lo recibe y un código
+// estable que los clientes pueden usar sin depender del mensaje.
+//
+// Los errores concretos se declaran como variables en el paquete que los
+// produce y se envuelven con fmt.Errorf("%w: ...") para añadir detalle sin
+// perder la clase ni el código.
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kind clasifica un error según la respuesta que merece
+type Kind string
+
+const (
+	Internal     Kind = "internal"
+	BadRequest   Kind = "bad_request" // la petición no se puede interpretar
+	Validation   Kind = "validation"  // se entiende, pero sus datos no son válidos
+	NotFound     Kind = "not_found"
+	Conflict     Kind = "conflict"
+	Precondition Kind = "precondition_failed"
+	Unauthorized Kind = "unauthorized"
+	Forbidden    Kind = "forbidden"
+	Unavailable  Kind = "unavailable"
+	Unsupported  Kind = "unsupported_media_type"
+	RateLimited  Kind = "rate_limited"
+)
+
+// Error es un error de dominio con clase y código estable
+type Error struct {
+	Kind    Kind
+	Code    string // estable, p. ej. "user_not_found"
+	Message string
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// As devuelve el primer *Error de la cadena de err
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+// KindOf devuelve la clase de err, o Internal si no es un error tipado
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+	return Internal
+}
+
+// Is indica si err es de la clase kind
+func Is(err error, kind Kind) bool {
+	return err != nil && KindOf(err) == kind
+}
+
+// WithRetryAfter marca err como reintentable pasado after; quien lo recibe
+// puede recuperarlo con RetryAfter
+func WithRetryAfter(err error, after time.Duration) error {
+	return &retryable{err: err, after: after}
+}
+
+// RetryAfter devuelve cuánto debe esperarse para reintentar, si err lo indica
+func RetryAfter(err error) (time.Duration, bool) {
+	var r *retryable
+	if errors.As(err, &r) {
+		return r.after, true
+	}
+	return 0, false
+}
+
+type retryable struct {
+	err   error
+	after time.Duration
+}
+
+func (r *retryable) Error() string {
+	return r.err.Error()
+}
+
+func (r *retryable) Unwrap() error {
+	return r.err
+}
+
+// Translator traduce un formato de mensaje al idioma del destinatario y lo
+// completa con args. Los mensajes del dominio se escriben en inglés y son la
+// clave de los catálogos.
+type Translator interface {
+	Translate(format string, args ...any) string
+}
+
+type untranslated struct{}
+
+func (untranslated) Translate(format string, args ...any) string {
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Untranslated deja los mensajes en su idioma original
+var Untranslated Translator = untranslated{}
+
+// Localizer lo implementan los errores que saben mostrarse en otro idioma
+type Localizer interface {
+	error
+	Localize(t Translator) string
+}
+
+func (e *Error) Localize(t Translator) string {
+	return t.Translate(e.Message)
+}
+
+// Errorf añade a base un detalle traducible. A diferencia de
+// fmt.Errorf("%w: ...") el detalle se puede mostrar en otro idioma.
+func Errorf(base *Error, format string, args ...any) error {
+	return &detailed{base: base, format: format, args: args}
+}
+
+type detailed struct {
+	base   *Error
+	format string
+	args   []any
+}
+
+func (d *detailed) Error() string {
+	return d.Localize(Untranslated)
+}
+
+func (d *detailed) Localize(t Translator) string {
+	return d.base.Localize(t) + ": " + t.Translate(d.format, d.args...)
+}
+
+func (d *detailed) Unwrap() error {
+	return d.base
+}
+
+// Localize muestra err en el idioma de t. Si err envuelve un Localizer con
+// fmt.Errorf("%w: ...") se traduce su parte y el resto se deja tal cual.
+func Localize(err error, t Translator) string {
+	message := err.Error()
+
+	var l Localizer
+	if !errors.As(err, &l) {
+		return message
+	}
+	if rest, ok := strings.CutPrefix(message, l.Localize(Untranslated)); ok {
+		return l.Localize(t) + rest
+	}
+	return message
+}
@@ -0,0 +1,110 @@
+package errs_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"user-management/internal/domain/errs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKindOf(t *testing.T) {
+	notFound := errs.New(errs.NotFound, "thing_not_found", "thing not found")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected errs.Kind
+	}{
+		{"typed error", notFound, errs.NotFound},
+		{"wrapped typed error", fmt.Errorf("%w: 42", notFound), errs.NotFound},
+		{"doubly wrapped typed error", fmt.Errorf("loading: %w", fmt.Errorf("%w: 42", notFound)), errs.NotFound},
+		{"plain error", errors.New("boom"), errs.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, errs.KindOf(tt.err))
+		})
+	}
+}
+
+func TestWrappedErrorKeepsIdentityAndCode(t *testing.T) {
+	conflict := errs.New(errs.Conflict, "email_taken", "email already exists")
+	wrapped := fmt.Errorf("%w: juan@test.com", conflict)
+
+	assert.ErrorIs(t, wrapped, conflict)
+	assert.Equal(t, "email already exists: juan@test.com", wrapped.Error())
+
+	e, ok := errs.As(wrapped)
+	assert.True(t, ok)
+	assert.Equal(t, "email_taken", e.Code)
+	assert.True(t, errs.Is(wrapped, errs.Conflict))
+	assert.False(t, errs.Is(nil, errs.Internal))
+}
+
+func TestFields(t *testing.T) {
+	invalid := errs.New(errs.Validation, "invalid_thing", "invalid thing")
+
+	t.Run("no fields means no error", func(t *testing.T) {
+		assert.NoError(t, errs.Fields{}.Err(invalid))
+	})
+
+	t.Run("collects every field and keeps the first rule of each", func(t *testing.T) {
+		fields := errs.Fields{}
+		fields.Add("name", "required", "name is required")
+		fields.Add("name", "min", "name is too short")
+		fields.Add("age", "gte", "age must not be negative")
+
+		err := fields.Err(invalid)
+		assert.ErrorIs(t, err, invalid)
+		assert.Equal(t, "invalid thing: age must not be negative; name is required", err.Error())
+		assert.True(t, errs.Is(err, errs.Validation))
+
+		ve, ok := errs.AsValidation(fmt.Errorf("saving: %w", err))
+		assert.True(t, ok)
+		assert.Equal(t, errs.FieldError{Rule: "required", Message: "name is required"}, ve.Fields["name"])
+	})
+
+	t.Run("merges nested errors under a prefix", func(t *testing.T) {
+		nested := errs.Fields{}
+		nested.Add("quantity", "gt", "quantity must be positive")
+
+		fields := errs.Fields{}
+		assert.True(t, fields.Merge("items[1]", nested.Err(invalid)))
+		assert.False(t, fields.Merge("items[2]", errors.New("boom")))
+		assert.Equal(t, "gt", fields["items[1].quantity"].Rule)
+		assert.Len(t, fields, 1)
+	})
+}
+
+type upper struct{}
+
+func (upper) Translate(format string, args ...any) string {
+	return strings.ToUpper(fmt.Sprintf(format, args...))
+}
+
+func TestLocalize(t *testing.T) {
+	invalid := errs.New(errs.BadRequest, "invalid_query", "invalid query")
+
+	t.Run("detailed errors translate base and detail", func(t *testing.T) {
+		err := errs.Errorf(invalid, "unknown field %q", "x")
+
+		assert.ErrorIs(t, err, invalid)
+		assert.Equal(t, `invalid query: unknown field "x"`, err.Error())
+		assert.Equal(t, `INVALID QUERY: UNKNOWN FIELD "X"`, errs.Localize(err, upper{}))
+	})
+
+	t.Run("errors wrapped with fmt keep their suffix", func(t *testing.T) {
+		err := fmt.Errorf("%w: juan@test.com", invalid)
+		assert.Equal(t, "INVALID QUERY: juan@test.com", errs.Localize(err, upper{}))
+	})
+
+	t.Run("errors with a prefix and untyped errors are left as is", func(t *testing.T) {
+		prefixed := fmt.Errorf("loading: %w", invalid)
+		assert.Equal(t, "loading: invalid query", errs.Localize(prefixed, upper{}))
+		assert.Equal(t, "boom", errs.Localize(errors.New("boom"), upper{}))
+	})
+}
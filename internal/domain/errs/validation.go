@@ -0,0 +1,105 @@
+package errs
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// FieldError explica por qué un campo no supera la validación. Rule es un
+// identificador estable ("required", "min", "email"...) y Message un texto
+// para personas que puede llevar verbos de fmt; Args los completa al
+// mostrarlo con Localize.
+type FieldError struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Args    []any  `json:"-"`
+}
+
+// Localize devuelve el mensaje en el idioma de t
+func (e FieldError) Localize(t Translator) string {
+	return t.Translate(e.Message, e.Args...)
+}
+
+// Fields acumula los errores de validación por campo. Sólo se conserva el
+// primer error de cada campo, de modo que una validación puede comprobar
+// todas sus reglas sin preocuparse de los solapes.
+type Fields map[string]FieldError
+
+// Add registra un error para field si aún no tiene ninguno. message se
+// completa con args como en fmt.Sprintf.
+func (f Fields) Add(field, rule, message string, args ...any) {
+	if _, exists := f[field]; !exists {
+		f[field] = FieldError{Rule: rule, Message: message, Args: args}
+	}
+}
+
+// Localize devuelve una copia con los mensajes ya traducidos por t
+func (f Fields) Localize(t Translator) Fields {
+	localized := make(Fields, len(f))
+	for field, fe := range f {
+		localized[field] = FieldError{Rule: fe.Rule, Message: fe.Localize(t)}
+	}
+	return localized
+}
+
+// Merge copia los campos de un *ValidationError anidado bajo prefix
+// (p. ej. "items[0]"). Devuelve false si err no trae errores por campo.
+func (f Fields) Merge(prefix string, err error) bool {
+	nested, ok := AsValidation(err)
+	if !ok {
+		return false
+	}
+	for field, fe := range nested.Fields {
+		if _, exists := f[prefix+"."+field]; !exists {
+			f[prefix+"."+field] = fe
+		}
+	}
+	return true
+}
+
+// Err devuelve nil si no hay errores o un *ValidationError con la clase y
+// el código de base
+func (f Fields) Err(base *Error) error {
+	if len(f) == 0 {
+		return nil
+	}
+	return &ValidationError{Base: base, Fields: f}
+}
+
+// ValidationError es un error de validación con el detalle de cada campo
+// inválido. Se comporta como Base frente a errors.Is y As.
+type ValidationError struct {
+	Base   *Error
+	Fields Fields
+}
+
+func (e *ValidationError) Error() string {
+	return e.Localize(Untranslated)
+}
+
+// Localize devuelve el mensaje con los de cada campo, por orden alfabético
+func (e *ValidationError) Localize(t Translator) string {
+	names := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	messages := make([]string, 0, len(names))
+	for _, field := range names {
+		messages = append(messages, e.Fields[field].Localize(t))
+	}
+	return e.Base.Localize(t) + ": " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Base
+}
+
+// AsValidation devuelve el primer *ValidationError de la cadena de err
+func AsValidation(err error) (*ValidationError, bool) {
+	var e *ValidationError
+	ok := errors.As(err, &e)
+	return e, ok
+}
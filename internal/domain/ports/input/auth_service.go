@@ -0,0 +1,237 @@
+package input
+
+import (
+	"context"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/pkg/jwt"
+
+	"github.com/google/uuid"
+)
+
+// ClientInfo describe desde dónde se hace una petición; sirve para limitar
+// intentos por origen
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// AccessToken es una credencial emitida tras autenticarse. RefreshToken
+// permite renovarla sin volver a iniciar sesión; está vacío si no se
+// guardan sesiones.
+type AccessToken struct {
+	Token        string
+	ExpiresAt    time.Time
+	RefreshToken string
+}
+
+// MFAChallenge es la credencial provisional que devuelve Login a los
+// usuarios con MFA; sólo sirve para canjearla, junto con un código, por un
+// AccessToken
+type MFAChallenge struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// LoginResult lleva el token de acceso o, si el usuario tiene MFA, el reto
+// que hay que completar
+type LoginResult struct {
+	AccessToken *AccessToken
+	Challenge   *MFAChallenge
+}
+
+// Authenticator resuelve una credencial Bearer en el principal que la usa
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*entities.Principal, error)
+}
+
+type AuthService interface {
+	Authenticator
+	Login(ctx context.Context, email, password string, client ClientInfo) (*LoginResult, error)
+	// VerifyMFA completa un login con MFA; code es un código TOTP o uno de
+	// recuperación
+	VerifyMFA(ctx context.Context, challenge, code string, client ClientInfo) (*AccessToken, error)
+	// Refresh canjea un refresh token por un token de acceso nuevo y otro
+	// refresh token; el anterior deja de valer
+	Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*AccessToken, error)
+}
+
+// EmailVerificationService emite y canjea los tokens de verificación de
+// email
+type EmailVerificationService interface {
+	// SendVerification envía al usuario un token nuevo e invalida los
+	// anteriores
+	SendVerification(ctx context.Context, user *entities.User) error
+	VerifyEmail(ctx context.Context, token string) (*entities.User, error)
+	// ResendVerification reenvía el token si email corresponde a una cuenta
+	// sin verificar. No revela si la cuenta existe.
+	ResendVerification(ctx context.Context, email string, client ClientInfo) error
+}
+
+// PasswordService recupera y cambia contraseñas. Ambas operaciones cierran
+// todas las sesiones abiertas del usuario.
+type PasswordService interface {
+	// ForgotPassword envía un token de recuperación si email corresponde a
+	// una cuenta. No revela si la cuenta existe.
+	ForgotPassword(ctx context.Context, email string, client ClientInfo) error
+	// ResetPassword canjea el token por una contraseña nueva
+	ResetPassword(ctx context.Context, token, password string) error
+	// ChangePassword exige la contraseña actual
+	ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, password string) error
+}
+
+// MFAEnrollment es lo que el usuario configura en su app de autenticación
+type MFAEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// MFAService gestiona el segundo factor TOTP del usuario autenticado
+type MFAService interface {
+	// Enroll genera un secreto nuevo; no se exige hasta confirmarlo
+	Enroll(ctx context.Context, userID uuid.UUID) (*MFAEnrollment, error)
+	// Confirm activa MFA con un código del secreto pendiente y devuelve los
+	// códigos de recuperación, que no se vuelven a mostrar
+	Confirm(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	// Disable exige la contraseña y un código TOTP o de recuperación
+	Disable(ctx context.Context, userID uuid.UUID, password, code string) error
+}
+
+// LockoutService administra los bloqueos por intentos fallidos de login
+type LockoutService interface {
+	// UnlockAccount levanta el bloqueo de la cuenta y borra sus fallos
+	UnlockAccount(ctx context.Context, userID uuid.UUID) error
+}
+
+// IssuedAPIKey es una API key recién creada; Secret es la key completa y no
+// se vuelve a mostrar
+type IssuedAPIKey struct {
+	Key    *entities.APIKey
+	Secret string
+}
+
+// APIKeyAuthenticator resuelve una API key en el principal que la usa
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string, client ClientInfo) (*entities.Principal, error)
+}
+
+// APIKeyService gestiona las API keys del principal de ctx
+type APIKeyService interface {
+	APIKeyAuthenticator
+	// Create emite una key con los scopes dados; expiresAt nil no caduca
+	Create(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (*IssuedAPIKey, error)
+	// List devuelve las keys del principal, también las revocadas
+	List(ctx context.Context) ([]*entities.APIKey, error)
+	// Revoke invalida una key del principal; los administradores pueden
+	// revocar cualquiera
+	Revoke(ctx context.Context, id uuid.UUID) error
+}
+
+// SessionService muestra y cierra las sesiones abiertas de un usuario
+type SessionService interface {
+	// ListSessions devuelve las sesiones vigentes, la más reciente primero
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]*entities.Session, error)
+	// RevokeSession cierra una sesión del usuario
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	// RevokeAllSessions cierra todas las sesiones del usuario e invalida sus
+	// tokens de acceso; el actor es el principal de ctx
+	RevokeAllSessions(ctx context.Context, userID uuid.UUID) error
+}
+
+// ImpersonationService permite a un administrador actuar como otro usuario
+type ImpersonationService interface {
+	// Impersonate emite un token de acceso de corta duración del usuario
+	// que lleva al administrador de ctx como actor; reason queda en la
+	// auditoría
+	Impersonate(ctx context.Context, userID uuid.UUID, reason string) (*AccessToken, error)
+}
+
+// AuthorizationRequest son los parámetros de una petición de autorización
+// de OpenID Connect (OIDC Core, sección 3.1.2.1)
+type AuthorizationRequest struct {
+	ClientID            string
+	RedirectURI         string
+	ResponseType        string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// TokenRequest es una petición al endpoint de tokens. Las credenciales del
+// cliente pueden llegar en el cuerpo o con autenticación Basic.
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	ClientID     string
+	ClientSecret string
+}
+
+// OIDCTokens es lo que recibe el cliente al canjear un código: el ID token
+// y un token de acceso que sólo sirve para userinfo
+type OIDCTokens struct {
+	AccessToken string
+	IDToken     string
+	ExpiresAt   time.Time
+	Scope       string
+}
+
+// UserInfo es la respuesta de userinfo: el usuario y los datos que permiten
+// los scopes del token
+type UserInfo struct {
+	Subject string `json:"sub"`
+	entities.UserClaims
+}
+
+// OIDCSession es la sesión del navegador en el formulario de autorización.
+// ID es el valor opaco de la cookie y CSRFToken el que deben devolver sus
+// formularios; Principal es nil hasta que el usuario inicia sesión.
+type OIDCSession struct {
+	ID        string
+	CSRFToken string
+	ExpiresAt time.Time
+	Principal *entities.Principal
+}
+
+// RegisteredOAuthClient es un cliente recién registrado; Secret sólo lo
+// tienen los confidenciales y no se vuelve a mostrar
+type RegisteredOAuthClient struct {
+	Client *entities.OAuthClient
+	Secret string
+}
+
+// OIDCService es el proveedor OpenID Connect: flujo de código de
+// autorización con PKCE y gestión de los clientes registrados
+type OIDCService interface {
+	// Issuer identifica al proveedor; es la base de sus endpoints
+	Issuer() string
+	// Keys son las claves públicas con las que se verifican los ID tokens
+	Keys() jwt.JWKS
+	// Authorize emite un código para el usuario de ctx y devuelve la URL de
+	// redirección del cliente, con el código o con el error OAuth. Si no se
+	// puede redirigir (cliente desconocido, redirect_uri no registrada)
+	// devuelve el error.
+	Authorize(ctx context.Context, req AuthorizationRequest) (string, error)
+	// ValidateAuthorization comprueba el cliente y la redirect_uri de req
+	// antes de mostrar nada al usuario; devuelve los mismos errores que
+	// Authorize cuando no se puede redirigir
+	ValidateAuthorization(ctx context.Context, req AuthorizationRequest) error
+	// BrowserSession devuelve la sesión del navegador id o, si no está
+	// vigente, abre otra sin usuario
+	BrowserSession(ctx context.Context, id string) (*OIDCSession, error)
+	// SignIn cierra la sesión id y abre otra para el usuario de ctx, con
+	// identificador y token CSRF nuevos
+	SignIn(ctx context.Context, id string) (*OIDCSession, error)
+	// Exchange canjea un código de autorización; cada código vale una vez
+	Exchange(ctx context.Context, req TokenRequest) (*OIDCTokens, error)
+	UserInfo(ctx context.Context, accessToken string) (*UserInfo, error)
+	// RegisterClient da de alta un cliente; los confidenciales reciben un
+	// secreto
+	RegisterClient(ctx context.Context, name string, redirectURIs []string, confidential bool) (*RegisteredOAuthClient, error)
+	// ListClients devuelve los clientes, el más antiguo primero
+	ListClients(ctx context.Context) ([]*entities.OAuthClient, error)
+	DeleteClient(ctx context.Context, id uuid.UUID) error
+}
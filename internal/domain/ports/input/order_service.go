@@ -3,6 +3,7 @@ package input
 import (
 	"context"
 	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
 
 	"github.com/google/uuid"
 )
@@ -11,6 +12,10 @@ type OrderService interface {
 	PlaceOrder(ctx context.Context, userID uuid.UUID, items []entities.OrderItem) (*entities.Order, error)
 	GetOrderByID(ctx context.Context, id uuid.UUID) (*entities.Order, error)
 	GetAllOrders(ctx context.Context) ([]*entities.Order, error)
-	CancelOrder(ctx context.Context, id uuid.UUID) error
+	ListOrders(ctx context.Context, query output.OrderQuery) (*output.OrderPage, error)
+	ListUserOrders(ctx context.Context, userID uuid.UUID, query output.OrderQuery) (*output.UserOrderPage, error)
+	// CancelOrder cancela la orden si sigue en version y devuelve la orden
+	// cancelada; output.ErrConcurrentModification si cambió entretanto
+	CancelOrder(ctx context.Context, id uuid.UUID, version int) (*entities.Order, error)
 	UpdateOrderStatus(ctx context.Context, id uuid.UUID, status string) error
 }
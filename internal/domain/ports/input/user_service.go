@@ -3,13 +3,34 @@ package input
 import (
 	"context"
 	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
 
 	"github.com/google/uuid"
 )
 
 type UserService interface {
-	RegisterUser(ctx context.Context, name string, email string, age int, password string) (*entities.User, error)
+	RegisterUser(ctx context.Context, name string, email string, age int, password string, opts ...RegisterOption) (*entities.User, error)
 	GetUserProfile(ctx context.Context, id uuid.UUID) (*entities.User, error)
 	UpdateProfile(ctx context.Context, user *entities.User) error
 	GetAllUsers(ctx context.Context) ([]*entities.User, error)
+	ListUsers(ctx context.Context, query output.UserQuery) (*output.UserPage, error)
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	RestoreUser(ctx context.Context, id uuid.UUID) (*entities.User, error)
+}
+
+// RegisterOption ajusta la cuenta que crea RegisterUser antes de guardarla
+type RegisterOption func(*entities.User)
+
+// RegisterInactive crea la cuenta ya desactivada, en la misma escritura que
+// la da de alta
+func RegisterInactive() RegisterOption {
+	return func(user *entities.User) {
+		user.Active = false
+	}
+}
+
+// UserRetentionService elimina definitivamente los usuarios cuyo borrado
+// lógico supera la ventana de retención, junto con sus órdenes.
+type UserRetentionService interface {
+	PurgeDeletedUsers(ctx context.Context) (int, error)
 }
@@ -0,0 +1,26 @@
+package output
+
+import (
+	"context"
+	"time"
+	"user-management/internal/domain/entities"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyRepository es el puerto de almacenamiento de las API keys. Las keys
+// revocadas se conservan para poder auditarlas.
+type APIKeyRepository interface {
+	Save(ctx context.Context, key *entities.APIKey) error
+	// FindByID y FindByHash devuelven ErrAPIKeyNotFound si no existe
+	FindByID(ctx context.Context, id uuid.UUID) (*entities.APIKey, error)
+	FindByHash(ctx context.Context, hash string) (*entities.APIKey, error)
+	// FindByOwner devuelve las keys de userID, de la más antigua a la más
+	// reciente
+	FindByOwner(ctx context.Context, userID uuid.UUID) ([]*entities.APIKey, error)
+	Update(ctx context.Context, key *entities.APIKey) error
+	// TouchLastUsed anota el último uso de la key id sin escribir el resto
+	// de campos, para que registrar un uso con una copia antigua no deshaga
+	// una revocación concurrente. Devuelve ErrAPIKeyNotFound si no existe.
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time, ip string) error
+}
@@ -0,0 +1,12 @@
+package output
+
+import (
+	"context"
+	"user-management/internal/domain/entities"
+)
+
+// AuditLog es el puerto del registro de auditoría. Las entradas no se
+// modifican ni se borran una vez escritas.
+type AuditLog interface {
+	Record(ctx context.Context, entry *entities.AuditEntry) error
+}
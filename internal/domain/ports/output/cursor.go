@@ -0,0 +1,45 @@
+package output
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"user-management/internal/domain/errs"
+)
+
+// ErrInvalidCursor indica que el cursor de paginación no se puede decodificar
+// o fue emitido para otra ordenación
+var ErrInvalidCursor = errs.New(errs.BadRequest, "invalid_cursor", "invalid pagination cursor")
+
+// Cursor identifica la última fila de una página en una paginación por
+// clave (keyset): el valor del campo de ordenación y el ID como desempate.
+// Los clientes lo reciben codificado y deben tratarlo como opaco.
+type Cursor struct {
+	Sort string `json:"s"` // ordenación para la que se emitió, p. ej. "-created_at"
+	Key  string `json:"k"`
+	ID   string `json:"i"`
+}
+
+// Encode devuelve la representación opaca del cursor
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor interpreta un cursor emitido para la ordenación sort. Un
+// cursor vacío es válido y representa la primera página.
+func DecodeCursor(encoded, sort string) (*Cursor, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Sort != sort || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
@@ -0,0 +1,32 @@
+package output
+
+import "user-management/internal/domain/errs"
+
+var (
+	// ErrEmailAlreadyExists indica que otra cuenta ya usa el mismo email en su
+	// forma normalizada. Los repositorios lo devuelven desde Save y Update.
+	ErrEmailAlreadyExists = errs.New(errs.Conflict, "email_already_exists", "email already exists")
+
+	// ErrUserAlreadyExists lo devuelve Save cuando ya hay un usuario con el
+	// mismo ID: Save sólo da de alta y los cambios van por Update.
+	ErrUserAlreadyExists = errs.New(errs.Conflict, "user_already_exists", "user already exists")
+
+	// ErrConcurrentModification indica que la entidad cambió desde que se leyó
+	// (su versión ya no coincide) y la escritura se rechaza para no perder cambios.
+	ErrConcurrentModification = errs.New(errs.Conflict, "concurrent_modification", "resource was modified concurrently")
+
+	// ErrUserNotFound indica que el usuario no existe o está borrado lógicamente
+	ErrUserNotFound = errs.New(errs.NotFound, "user_not_found", "user not found")
+
+	// ErrOrderNotFound indica que la orden no existe
+	ErrOrderNotFound = errs.New(errs.NotFound, "order_not_found", "order not found")
+
+	// ErrAPIKeyNotFound indica que la API key no existe
+	ErrAPIKeyNotFound = errs.New(errs.NotFound, "api_key_not_found", "API key not found")
+
+	// ErrOAuthClientNotFound indica que el cliente OAuth no está registrado
+	ErrOAuthClientNotFound = errs.New(errs.NotFound, "oauth_client_not_found", "OAuth client not found")
+
+	// ErrUserNotDeleted lo devuelve Restore cuando el usuario no está borrado
+	ErrUserNotDeleted = errs.New(errs.Conflict, "user_not_deleted", "user is not deleted")
+)
@@ -0,0 +1,31 @@
+package output
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRecord guarda la primera respuesta a una petición con
+// Idempotency-Key. Mientras la petición original se está procesando
+// Completed es false y no hay respuesta.
+type IdempotencyRecord struct {
+	Key         string // clave del cliente ya acotada al llamador
+	Fingerprint string // huella de método, ruta y cuerpo
+	Completed   bool
+	Status      int
+	Header      map[string][]string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// IdempotencyStore es el puerto de almacenamiento de las claves de
+// idempotencia. Los registros caducados deben tratarse como inexistentes.
+type IdempotencyStore interface {
+	// Reserve guarda record si no hay otro vigente con su clave. Si lo hay,
+	// lo devuelve sin modificarlo y reserved es false.
+	Reserve(ctx context.Context, record IdempotencyRecord) (existing *IdempotencyRecord, reserved bool, err error)
+	// Complete sustituye la reserva por el registro con la respuesta
+	Complete(ctx context.Context, record IdempotencyRecord) error
+	// Release borra la reserva para que la petición se pueda reintentar
+	Release(ctx context.Context, key string) error
+}
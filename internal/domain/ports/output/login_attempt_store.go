@@ -0,0 +1,36 @@
+package output
+
+import (
+	"context"
+	"time"
+)
+
+// LoginAttempts son los fallos recientes de login de una clave (una cuenta
+// o una IP). Los intentos se cuentan al empezar, así que Failures incluye
+// los que aún se están comprobando.
+type LoginAttempts struct {
+	Failures    int
+	LastFailure time.Time
+	// LockedUntil, si es posterior a ahora, bloquea la clave
+	LockedUntil time.Time
+}
+
+// LoginAttemptStore es el puerto de almacenamiento de los fallos de login.
+// Una clave sin fallos nuevos durante la ventana con la que se registró el
+// último, y sin bloqueo vigente, vuelve a estar a cero.
+type LoginAttemptStore interface {
+	// Get devuelve los intentos de key, a cero si no hay
+	Get(ctx context.Context, key string) (LoginAttempts, error)
+	// RecordAttempt suma ahora un intento a key, antes de saber si falla, y
+	// devuelve el resultado. Antes pasa los intentos que había a allow: si
+	// devuelve un error, el intento no se cuenta y se devuelve ese error.
+	// Comprobar y contar es atómico, así que dos intentos simultáneos no
+	// pueden pasar con el mismo valor.
+	RecordAttempt(ctx context.Context, key string, window time.Duration, allow func(LoginAttempts) error) (LoginAttempts, error)
+	// ForgetAttempt descuenta un intento de key que no fue un fallo
+	ForgetAttempt(ctx context.Context, key string) error
+	// Lock bloquea key hasta until
+	Lock(ctx context.Context, key string, until time.Time) error
+	// Reset borra los fallos y el bloqueo de key
+	Reset(ctx context.Context, key string) error
+}
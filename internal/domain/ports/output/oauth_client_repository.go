@@ -0,0 +1,20 @@
+package output
+
+import (
+	"context"
+	"user-management/internal/domain/entities"
+
+	"github.com/google/uuid"
+)
+
+// OAuthClientRepository es el puerto de almacenamiento de los clientes
+// registrados en el proveedor OpenID Connect
+type OAuthClientRepository interface {
+	Save(ctx context.Context, client *entities.OAuthClient) error
+	// FindByID devuelve ErrOAuthClientNotFound si no existe
+	FindByID(ctx context.Context, id uuid.UUID) (*entities.OAuthClient, error)
+	// FindAll devuelve los clientes del más antiguo al más reciente
+	FindAll(ctx context.Context) ([]*entities.OAuthClient, error)
+	// Delete devuelve ErrOAuthClientNotFound si no existe
+	Delete(ctx context.Context, id uuid.UUID) error
+}
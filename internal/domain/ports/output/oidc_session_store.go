@@ -0,0 +1,32 @@
+package output
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OIDCSession es la sesión del navegador en el formulario de autorización
+// del proveedor OIDC. Es independiente de las sesiones de la API: sólo se
+// guarda el hash de su identificador, que viaja en una cookie, y no sirve
+// como credencial fuera de /oauth. UserID es uuid.Nil hasta que el usuario
+// inicia sesión; CSRFToken protege los formularios.
+type OIDCSession struct {
+	Hash         string
+	UserID       uuid.UUID
+	Role         string
+	TokenVersion int
+	CSRFToken    string
+	ExpiresAt    time.Time
+}
+
+// OIDCSessionStore es el puerto de almacenamiento de las sesiones del
+// navegador en la autorización OIDC. Las caducadas deben tratarse como
+// inexistentes.
+type OIDCSessionStore interface {
+	Save(ctx context.Context, session OIDCSession) error
+	// Find devuelve ErrSessionNotFound si la sesión no está vigente
+	Find(ctx context.Context, hash string) (*OIDCSession, error)
+	Delete(ctx context.Context, hash string) error
+}
@@ -0,0 +1,35 @@
+package output
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"user-management/internal/domain/errs"
+)
+
+// ErrTokenNotFound indica que el token no existe, ya se usó o caducó
+var ErrTokenNotFound = errs.New(errs.BadRequest, "invalid_token", "token is invalid, expired or already used")
+
+// OneTimeToken es un token de un solo uso (verificación de email,
+// recuperación de contraseña...). Sólo se guarda su hash: quien lea el
+// almacén no puede usarlo.
+type OneTimeToken struct {
+	Hash      string
+	Purpose   string
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+}
+
+// OneTimeTokenStore es el puerto de almacenamiento de los tokens de un solo
+// uso. Los tokens caducados deben tratarse como inexistentes.
+type OneTimeTokenStore interface {
+	Save(ctx context.Context, token OneTimeToken) error
+	// Consume borra el token y lo devuelve; ErrTokenNotFound si no está
+	// vigente. Dos llamadas concurrentes con el mismo hash no pueden
+	// obtenerlo ambas.
+	Consume(ctx context.Context, purpose, hash string) (*OneTimeToken, error)
+	// RevokeAll borra todos los tokens de purpose del usuario
+	RevokeAll(ctx context.Context, purpose string, userID uuid.UUID) error
+}
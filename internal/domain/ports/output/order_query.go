@@ -0,0 +1,82 @@
+package output
+
+import (
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/valueobjects"
+
+	"github.com/google/uuid"
+)
+
+// OrderSortField es un campo por el que se pueden ordenar las órdenes. El ID
+// se usa siempre como desempate para que el orden sea estable.
+type OrderSortField string
+
+const (
+	OrderSortCreatedAt   OrderSortField = "created_at"
+	OrderSortCompletedAt OrderSortField = "completed_at"
+	OrderSortTotal       OrderSortField = "total"
+)
+
+// Valid indica si el campo es una ordenación soportada
+func (f OrderSortField) Valid() bool {
+	switch f {
+	case OrderSortCreatedAt, OrderSortCompletedAt, OrderSortTotal:
+		return true
+	}
+	return false
+}
+
+// OrderFilter restringe las órdenes devueltas. Los campos a cero no filtran;
+// los rangos incluyen el mínimo, excluyen el máximo de fechas e incluyen el
+// máximo de importes. Un rango de finalización excluye las órdenes sin
+// completar.
+type OrderFilter struct {
+	UserID        *uuid.UUID
+	Statuses      []valueobjects.OrderStatus // cualquiera de ellos
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time
+	CompletedFrom *time.Time
+	CompletedTo   *time.Time
+	MinTotal      *float64
+	MaxTotal      *float64
+}
+
+// OrderQuery describe una página de órdenes. Cursor es el NextCursor de la
+// página anterior y sólo es válido con la misma ordenación.
+type OrderQuery struct {
+	Filter OrderFilter
+	Sort   OrderSortField
+	Desc   bool
+	Limit  int
+	Cursor string
+}
+
+// SortKey identifica la ordenación de la consulta dentro de un Cursor
+func (q OrderQuery) SortKey() string {
+	if q.Desc {
+		return "-" + string(q.Sort)
+	}
+	return string(q.Sort)
+}
+
+// OrderPage es el resultado de FindOrders. Total cuenta todas las órdenes
+// que cumplen el filtro; NextCursor está vacío en la última página.
+type OrderPage struct {
+	Orders     []*entities.Order
+	NextCursor string
+	Total      int
+}
+
+// OrderStats agrega todas las órdenes de un usuario, sin aplicar filtros ni
+// paginación. LifetimeSpend excluye las órdenes canceladas.
+type OrderStats struct {
+	OrderCount    int
+	LifetimeSpend float64
+}
+
+// UserOrderPage es el resultado de FindByUserID
+type UserOrderPage struct {
+	OrderPage
+	Stats OrderStats
+}
@@ -13,4 +13,12 @@ type OrderRepository interface {
 	Update(ctx context.Context, order *entities.Order) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetAllOrders(ctx context.Context) ([]*entities.Order, error)
+	// FindOrders devuelve una página de órdenes filtradas y ordenadas;
+	// ErrInvalidCursor si el cursor no corresponde a la consulta
+	FindOrders(ctx context.Context, query OrderQuery) (*OrderPage, error)
+	// FindByUserID es FindOrders restringido a las órdenes de userID (se
+	// ignora query.Filter.UserID) junto con los agregados del usuario
+	FindByUserID(ctx context.Context, userID uuid.UUID, query OrderQuery) (*UserOrderPage, error)
+	// DeleteByUserID elimina todas las órdenes del usuario
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
 }
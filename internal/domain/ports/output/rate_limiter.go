@@ -0,0 +1,14 @@
+package output
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimiter cuenta intentos por clave en ventanas de tiempo fijas
+type RateLimiter interface {
+	// Allow registra un intento para key y devuelve si está dentro de limit
+	// intentos por window; si no lo está, retryAfter es lo que falta para
+	// que empiece la siguiente ventana.
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, retryAfter time.Duration, err error)
+}
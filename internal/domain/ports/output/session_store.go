@@ -0,0 +1,40 @@
+package output
+
+import (
+	"context"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+
+	"github.com/google/uuid"
+)
+
+// ErrSessionNotFound indica que la sesión no existe, caducó o se cerró
+var ErrSessionNotFound = errs.New(errs.NotFound, "session_not_found", "session not found")
+
+// SessionStore es el puerto de almacenamiento de las sesiones. Las sesiones
+// caducadas deben tratarse como inexistentes.
+type SessionStore interface {
+	Save(ctx context.Context, session *entities.Session) error
+	// FindByID y FindByRefreshHash devuelven ErrSessionNotFound si no está
+	// vigente
+	FindByID(ctx context.Context, id uuid.UUID) (*entities.Session, error)
+	FindByRefreshHash(ctx context.Context, hash string) (*entities.Session, error)
+	// FindByUser devuelve las sesiones vigentes del usuario, de la más
+	// reciente a la más antigua
+	FindByUser(ctx context.Context, userID uuid.UUID) ([]*entities.Session, error)
+	// FindBySpentRefreshHash devuelve la sesión de un refresh token que ya
+	// se canjeó, para detectar su reutilización, o ErrSessionNotFound
+	FindBySpentRefreshHash(ctx context.Context, hash string) (*entities.Session, error)
+	// Touch anota la actividad de la sesión id sin escribir el resto de
+	// campos
+	Touch(ctx context.Context, id uuid.UUID, at time.Time, ip string) error
+	// Rotate guarda session con su refresh token nuevo sólo si el vigente
+	// sigue siendo previousHash; si otra renovación se adelantó devuelve
+	// ErrConcurrentModification. previousHash queda gastado mientras dure
+	// la sesión.
+	Rotate(ctx context.Context, session *entities.Session, previousHash string) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// DeleteByUser cierra todas las sesiones del usuario
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+}
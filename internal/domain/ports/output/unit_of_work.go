@@ -0,0 +1,63 @@
+package output
+
+import (
+	"context"
+	"sync"
+)
+
+// UnitOfWork es un puerto para ejecutar operaciones sobre varios repositorios
+// de forma atómica. El adaptador propaga la transacción a través del contexto
+// recibido por fn, por lo que los repositorios deben usar ese contexto.
+type UnitOfWork interface {
+	// Do ejecuta fn dentro de una transacción. Si fn devuelve error (o hace
+	// panic) se descartan todos los cambios; en caso contrario se confirman.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txHooksKey struct{}
+
+// txHooks acumula las funciones a ejecutar cuando la transacción se confirme
+type txHooks struct {
+	mu          sync.Mutex
+	afterCommit []func()
+}
+
+// WithTransaction marca ctx como perteneciente a una transacción. Los
+// adaptadores de UnitOfWork deben llamar a la función devuelta sólo después
+// de confirmar, para ejecutar los hooks registrados con AfterCommit.
+func WithTransaction(ctx context.Context) (context.Context, func()) {
+	hooks := &txHooks{}
+	committed := func() {
+		hooks.mu.Lock()
+		fns := hooks.afterCommit
+		hooks.afterCommit = nil
+		hooks.mu.Unlock()
+
+		for _, fn := range fns {
+			fn()
+		}
+	}
+	return context.WithValue(ctx, txHooksKey{}, hooks), committed
+}
+
+// InTransaction indica si ctx pertenece a una transacción en curso
+func InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txHooksKey{}).(*txHooks)
+	return ok
+}
+
+// AfterCommit ejecuta fn cuando la transacción de ctx se confirme, o de
+// inmediato si no hay ninguna. Si la transacción se descarta, fn no se ejecuta.
+// Sirve a decoradores (p. ej. cachés) que no deben reaccionar a cambios que
+// todavía pueden deshacerse.
+func AfterCommit(ctx context.Context, fn func()) {
+	hooks, ok := ctx.Value(txHooksKey{}).(*txHooks)
+	if !ok {
+		fn()
+		return
+	}
+
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	hooks.afterCommit = append(hooks.afterCommit, fn)
+}
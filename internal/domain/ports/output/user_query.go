@@ -0,0 +1,68 @@
+package output
+
+import (
+	"time"
+	"user-management/internal/domain/entities"
+)
+
+// UserSortField es un campo por el que se pueden ordenar los usuarios. El ID
+// se usa siempre como desempate para que el orden sea estable.
+type UserSortField string
+
+const (
+	UserSortCreatedAt UserSortField = "created_at"
+	UserSortName      UserSortField = "name"
+	UserSortEmail     UserSortField = "email"
+	UserSortAge       UserSortField = "age"
+)
+
+// Valid indica si el campo es una ordenación soportada
+func (f UserSortField) Valid() bool {
+	switch f {
+	case UserSortCreatedAt, UserSortName, UserSortEmail, UserSortAge:
+		return true
+	}
+	return false
+}
+
+// UserFilter restringe los usuarios devueltos. Los campos a cero no filtran;
+// los rangos incluyen el mínimo y excluyen el máximo de fechas.
+type UserFilter struct {
+	Active      *bool
+	MinAge      *int
+	MaxAge      *int
+	NamePrefix  string // sin distinguir mayúsculas
+	Email       string // exacto, sobre el email normalizado
+	EmailPrefix string // sobre el email normalizado
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+// UserQuery describe una página de usuarios. Cursor es el NextCursor de la
+// página anterior y sólo es válido con la misma ordenación. Offset salta
+// ese número de usuarios, para los clientes que paginan por posición como
+// SCIM, y no se combina con Cursor.
+type UserQuery struct {
+	Filter UserFilter
+	Sort   UserSortField
+	Desc   bool
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+// SortKey identifica la ordenación de la consulta dentro de un Cursor
+func (q UserQuery) SortKey() string {
+	if q.Desc {
+		return "-" + string(q.Sort)
+	}
+	return string(q.Sort)
+}
+
+// UserPage es el resultado de FindUsers. Total cuenta todos los usuarios que
+// cumplen el filtro; NextCursor está vacío en la última página.
+type UserPage struct {
+	Users      []*entities.User
+	NextCursor string
+	Total      int
+}
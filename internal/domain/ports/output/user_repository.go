@@ -2,17 +2,39 @@ package output
 
 import (
 	"context"
+	"time"
 	"user-management/internal/domain/entities"
 
 	"github.com/google/uuid"
 )
 
 // PORT (interfaz que define el contrato)
+// Los emails se comparan por su forma normalizada: Save y Update devuelven
+// ErrEmailAlreadyExists si otra cuenta ya la usa y FindByEmail la aplica al
+// email recibido. Save sólo da de alta: con el ID de un usuario que ya
+// existe, aunque esté borrado lógicamente, devuelve ErrUserAlreadyExists.
+//
+// Los usuarios borrados lógicamente no aparecen en FindByID, FindByEmail,
+// GetAllUsers ni Update (devuelven ErrUserNotFound), pero conservan su email
+// hasta que se purgan para que Restore no pueda entrar en conflicto.
 type UserRepository interface {
 	Save(ctx context.Context, user entities.User) error
 	FindByID(ctx context.Context, id uuid.UUID) (*entities.User, error)
 	FindByEmail(ctx context.Context, email string) (*entities.User, error)
 	Update(ctx context.Context, user *entities.User) error
+	// Delete realiza un borrado lógico
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetAllUsers(ctx context.Context) ([]*entities.User, error)
+	// FindUsers devuelve una página de usuarios filtrados y ordenados;
+	// ErrInvalidCursor si el cursor no corresponde a la consulta
+	FindUsers(ctx context.Context, query UserQuery) (*UserPage, error)
+
+	// Restore deshace el borrado lógico; ErrUserNotDeleted si no lo estaba
+	Restore(ctx context.Context, id uuid.UUID) (*entities.User, error)
+	// FindDeletedBefore devuelve los usuarios borrados antes de cutoff
+	FindDeletedBefore(ctx context.Context, cutoff time.Time) ([]*entities.User, error)
+	// FindAnyByID es FindByID incluyendo los usuarios borrados lógicamente
+	FindAnyByID(ctx context.Context, id uuid.UUID) (*entities.User, error)
+	// Purge elimina definitivamente el usuario, esté borrado o no
+	Purge(ctx context.Context, id uuid.UUID) error
 }
@@ -1,7 +1,8 @@
 package valueobjects
 
 import (
-	"errors"
+	"strings"
+	"user-management/internal/domain/errs"
 	"user-management/pkg/utils"
 )
 
@@ -10,7 +11,7 @@ type Email struct {
 }
 
 var (
-	ErrInvalidEmail = errors.New("invalid email format")
+	ErrInvalidEmail = errs.New(errs.Validation, "invalid_email", "invalid email format")
 	isValidEmail    = defaultIsValidEmail
 )
 
@@ -20,6 +21,8 @@ func defaultIsValidEmail(email string) bool {
 }
 
 func NewEmail(value string) (Email, error) {
+	value = strings.TrimSpace(value)
+
 	// Validación de negocio
 	if !isValidEmail(value) {
 		return Email{}, ErrInvalidEmail
@@ -27,10 +30,24 @@ func NewEmail(value string) (Email, error) {
 	return Email{value: value}, nil
 }
 
+// Value devuelve el email tal como lo escribió el usuario
 func (e Email) Value() string {
 	return e.value
 }
 
+// Normalized devuelve la forma canónica del email, la que debe usarse para
+// comparar direcciones e indexarlas de forma única.
+func (e Email) Normalized() string {
+	return NormalizeEmail(e.value)
+}
+
+// NormalizeEmail calcula la forma canónica de una dirección sin validarla:
+// sin espacios alrededor y en minúsculas, de modo que Juan@Test.com y
+// juan@test.com se consideran la misma cuenta.
+func NormalizeEmail(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
 func setEmailValidator(fn func(string) bool) func() {
 	original := isValidEmail
 	isValidEmail = fn
@@ -38,3 +38,32 @@ func TestNewEmail(t *testing.T) {
 		})
 	}
 }
+
+func TestEmail_Normalized(t *testing.T) {
+	tests := []struct {
+		name  string
+		email string
+		want  string
+	}{
+		{"already canonical", "juan@test.com", "juan@test.com"},
+		{"mixed case", "Juan@Test.COM", "juan@test.com"},
+		{"surrounding spaces", "  juan@test.com ", "juan@test.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emailVO, err := NewEmail(tt.email)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, emailVO.Normalized())
+			assert.Equal(t, tt.want, NormalizeEmail(tt.email))
+		})
+	}
+
+	t.Run("value keeps the original case", func(t *testing.T) {
+		emailVO, err := NewEmail(" Juan@Test.com ")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Juan@Test.com", emailVO.Value())
+	})
+}
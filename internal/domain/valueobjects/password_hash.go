@@ -1,21 +1,74 @@
 package valueobjects
 
-import "errors"
+import (
+	"golang.org/x/crypto/bcrypt"
+
+	"user-management/internal/domain/errs"
+)
+
+var (
+	ErrPasswordRequired = errs.New(errs.Validation, "password_required", "password hash cannot be empty")
+	ErrInvalidPassword  = errs.New(errs.Validation, "invalid_password", "invalid format")
+	ErrPasswordTooLong  = errs.New(errs.Validation, "password_too_long", "password must be at most 72 bytes")
+)
+
+const (
+	// MinPasswordLength es la longitud mínima de una contraseña en claro
+	MinPasswordLength = 8
+	// MaxPasswordLength es lo máximo que bcrypt tiene en cuenta
+	MaxPasswordLength = 72
+)
+
+// PasswordCost es el coste de bcrypt para los hashes nuevos. Los tests que
+// crean muchos usuarios pueden bajarlo a bcrypt.MinCost.
+var PasswordCost = bcrypt.DefaultCost
 
 type PasswordHash struct {
 	value string
 }
 
+// NewPasswordHash envuelve un hash ya calculado (p. ej. leído de la base de
+// datos)
 func NewPasswordHash(value string) (pass PasswordHash, err error) {
 	if value == "" {
-		return PasswordHash{}, errors.New("password hash cannot be empty")
+		return PasswordHash{}, ErrPasswordRequired
 	}
 	if len(value) < 8 {
-		return PasswordHash{}, errors.New("invalid format")
+		return PasswordHash{}, ErrInvalidPassword
 	}
 	return PasswordHash{value: value}, nil
 }
 
+// ValidatePassword comprueba la longitud de una contraseña en claro
+func ValidatePassword(plain string) error {
+	switch {
+	case plain == "":
+		return ErrPasswordRequired
+	case len(plain) < MinPasswordLength:
+		return ErrInvalidPassword
+	case len(plain) > MaxPasswordLength:
+		return ErrPasswordTooLong
+	}
+	return nil
+}
+
+// HashPassword valida una contraseña en claro y calcula su hash bcrypt
+func HashPassword(plain string) (PasswordHash, error) {
+	if err := ValidatePassword(plain); err != nil {
+		return PasswordHash{}, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(plain), PasswordCost)
+	if err != nil {
+		return PasswordHash{}, err
+	}
+	return PasswordHash{value: string(hash)}, nil
+}
+
 func (p PasswordHash) Value() string {
 	return p.value
 }
+
+// Matches indica si plain es la contraseña de la que se obtuvo el hash
+func (p PasswordHash) Matches(plain string) bool {
+	return p.value != "" && bcrypt.CompareHashAndPassword([]byte(p.value), []byte(plain)) == nil
+}
@@ -1,6 +1,7 @@
 package valueobjects
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -48,3 +49,20 @@ func TestNewPasswordHash_WithValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("Password123!")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "Password123!", hash.Value())
+	assert.True(t, hash.Matches("Password123!"))
+	assert.False(t, hash.Matches("password123!"))
+
+	_, err = HashPassword("")
+	assert.ErrorIs(t, err, ErrPasswordRequired)
+	_, err = HashPassword("short")
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+	_, err = HashPassword(strings.Repeat("x", 73))
+	assert.ErrorIs(t, err, ErrPasswordTooLong)
+
+	assert.False(t, PasswordHash{}.Matches(""))
+}
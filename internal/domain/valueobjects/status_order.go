@@ -10,3 +10,12 @@ const (
 	StatusCompleted  OrderStatus = "completed"
 	StatusCancelled  OrderStatus = "cancelled"
 )
+
+// Valid indica si el estado es uno de los definidos
+func (s OrderStatus) Valid() bool {
+	switch s {
+	case StatusPending, StatusProcessing, StatusShipped, StatusReceived, StatusCompleted, StatusCancelled:
+		return true
+	}
+	return false
+}
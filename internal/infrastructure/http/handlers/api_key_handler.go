@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/input"
+)
+
+type APIKeyHandler struct {
+	keys input.APIKeyService
+}
+
+func NewAPIKeyHandler(keys input.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys}
+}
+
+// RegisterAuthenticatedRoutes registra la gestión de las API keys del
+// principal; router debe llevar AuthMiddleware
+func (h *APIKeyHandler) RegisterAuthenticatedRoutes(router *gin.RouterGroup) {
+	router.POST("/api-keys", h.CreateAPIKey)
+	router.GET("/api-keys", h.ListAPIKeys)
+	router.DELETE("/api-keys/:id", h.RevokeAPIKey)
+}
+
+type createAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// createdAPIKeyResponse añade la key completa, que sólo se devuelve al
+// crearla
+type createdAPIKeyResponse struct {
+	*entities.APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKey emite una API key; la respuesta es la única vez que se
+// muestra
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, requestError(err))
+		return
+	}
+
+	issued, err := h.keys.Create(c.Request.Context(), req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Data:    createdAPIKeyResponse{APIKey: issued.Key, Key: issued.Secret},
+		Message: "Store this key now, it will not be shown again",
+	})
+}
+
+// ListAPIKeys devuelve las keys del principal sin su secreto
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.keys.List(c.Request.Context())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	SuccessResponse(c, keys)
+}
+
+// RevokeAPIKey invalida una key; deja de aceptarse en la siguiente petición
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		HandleError(c, badRequest(err))
+		return
+	}
+
+	if err := h.keys.Revoke(c.Request.Context(), id); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Success: true, Message: "API key revoked successfully"})
+}
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/input"
+)
+
+// ErrNotAuthenticated indica una operación que necesita saber qué usuario
+// llama y la credencial no corresponde a ninguno (p. ej. el token de admin)
+var ErrNotAuthenticated = errs.New(errs.Unauthorized, "not_authenticated", "this operation requires a user account")
+
+// ErrNotAccountOwner indica que el usuario autenticado intenta modificar o
+// consultar una cuenta que no es la suya sin ser administrador
+var ErrNotAccountOwner = errs.New(errs.Forbidden, "not_account_owner", "only the account owner or an administrator can access this account")
+
+type AuthHandler struct {
+	authService  input.AuthService
+	verification input.EmailVerificationService
+	passwords    input.PasswordService
+}
+
+func NewAuthHandler(authService input.AuthService, verification input.EmailVerificationService,
+	passwords input.PasswordService) *AuthHandler {
+	return &AuthHandler{authService: authService, verification: verification, passwords: passwords}
+}
+
+// RegisterRoutes registra las rutas de autenticación; van en un grupo
+// público, sin AuthMiddleware
+func (h *AuthHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/auth/login", h.Login)
+	router.POST("/auth/mfa/verify", h.VerifyMFA)
+	router.POST("/auth/refresh", h.Refresh)
+	router.POST("/auth/verify-email", h.VerifyEmail)
+	router.POST("/auth/verify-email/resend", h.ResendVerification)
+	router.POST("/auth/password/forgot", h.ForgotPassword)
+	router.POST("/auth/password/reset", h.ResetPassword)
+}
+
+// RegisterAuthenticatedRoutes registra las rutas que actúan sobre el usuario
+// autenticado; router debe llevar AuthMiddleware
+func (h *AuthHandler) RegisterAuthenticatedRoutes(router *gin.RouterGroup) {
+	router.POST("/auth/password/change", h.ChangePassword)
+	router.PUT("/me/password", h.ChangePassword)
+}
+
+type loginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// tokenResponse sigue el formato de respuesta de token de OAuth 2.0
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	// RefreshToken renueva el token en /auth/refresh; cambia en cada uso
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+func newTokenResponse(token *input.AccessToken) tokenResponse {
+	return tokenResponse{
+		AccessToken:  token.Token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(time.Until(token.ExpiresAt).Seconds()),
+		RefreshToken: token.RefreshToken,
+	}
+}
+
+// mfaChallengeResponse sustituye a tokenResponse cuando el usuario tiene
+// MFA; MFAToken se canjea en /auth/mfa/verify
+type mfaChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Login cambia email y contraseña por un token de acceso o, si el usuario
+// tiene MFA, por el reto que completa VerifyMFA
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, requestError(err))
+		return
+	}
+
+	result, err := h.authService.Login(c.Request.Context(), req.Email, req.Password, clientInfo(c))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if result.Challenge != nil {
+		SuccessResponse(c, mfaChallengeResponse{
+			MFARequired: true,
+			MFAToken:    result.Challenge.Token,
+			ExpiresIn:   int(time.Until(result.Challenge.ExpiresAt).Seconds()),
+		})
+		return
+	}
+	SuccessResponse(c, newTokenResponse(result.AccessToken))
+}
+
+type verifyMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// VerifyMFA completa el login con un código TOTP o de recuperación
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req verifyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, requestError(err))
+		return
+	}
+
+	token, err := h.authService.VerifyMFA(c.Request.Context(), req.MFAToken, req.Code, clientInfo(c))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	SuccessResponse(c, newTokenResponse(token))
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Refresh renueva el token de acceso sin pedir la contraseña; el refresh
+// token usado deja de valer
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, requestError(err))
+		return
+	}
+
+	token, err := h.authService.Refresh(c.Request.Context(), req.RefreshToken, clientInfo(c))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	SuccessResponse(c, newTokenResponse(token))
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// VerifyEmail canjea el token recibido por email
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req verifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, requestError(err))
+		return
+	}
+
+	user, err := h.verification.VerifyEmail(c.Request.Context(), req.Token)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    user,
+		Message: "Email verified successfully",
+	})
+}
+
+type resendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResendVerification responde igual exista o no la cuenta, para no revelar
+// qué emails están registrados
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var req resendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, requestError(err))
+		return
+	}
+
+	if err := h.verification.ResendVerification(c.Request.Context(), req.Email, clientInfo(c)); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, Response{
+		Success: true,
+		Message: "If the account exists and is not verified, a new email has been sent",
+	})
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ForgotPassword responde igual exista o no la cuenta, para no revelar qué
+// emails están registrados
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req forgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, requestError(err))
+		return
+	}
+
+	if err := h.passwords.ForgotPassword(c.Request.Context(), req.Email, clientInfo(c)); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, Response{
+		Success: true,
+		Message: "If the account exists, a password reset email has been sent",
+	})
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ResetPassword canjea el token recibido por email por una contraseña nueva
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req resetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, requestError(err))
+		return
+	}
+
+	if err := h.passwords.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Success: true, Message: "Password reset successfully"})
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	Password        string `json:"password" binding:"required"`
+}
+
+// ChangePassword cambia la contraseña del usuario autenticado y cierra sus
+// sesiones; el cliente debe volver a iniciar sesión
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	var req changePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, requestError(err))
+		return
+	}
+
+	if err := h.passwords.ChangePassword(c.Request.Context(), userID, req.CurrentPassword, req.Password); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Success: true, Message: "Password changed successfully"})
+}
+
+// currentUserID devuelve el usuario del principal que dejó AuthMiddleware
+func currentUserID(c *gin.Context) (uuid.UUID, error) {
+	principal, ok := entities.PrincipalFromContext(c.Request.Context())
+	if !ok || principal.UserID == uuid.Nil {
+		return uuid.Nil, ErrNotAuthenticated
+	}
+	return principal.UserID, nil
+}
+
+// authorizeUser exige que quien llama sea el dueño de la cuenta id o un
+// administrador
+func authorizeUser(c *gin.Context, id uuid.UUID) error {
+	principal, ok := entities.PrincipalFromContext(c.Request.Context())
+	if !ok {
+		return ErrNotAuthenticated
+	}
+	if principal.Role != entities.RoleAdmin && principal.UserID != id {
+		return ErrNotAccountOwner
+	}
+	return nil
+}
+
+// isAdmin indica si quien llama es administrador
+func isAdmin(c *gin.Context) bool {
+	principal, ok := entities.PrincipalFromContext(c.Request.Context())
+	return ok && principal.Role == entities.RoleAdmin
+}
+
+func clientInfo(c *gin.Context) input.ClientInfo {
+	return input.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
@@ -12,6 +12,14 @@ type Response struct {
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
 	Message string      `json:"message,omitempty"`
+	Meta    *PageMeta   `json:"meta,omitempty"`
+}
+
+// PageMeta acompaña a las respuestas paginadas. NextCursor se omite en la
+// última página.
+type PageMeta struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int    `json:"total"`
 }
 
 func SuccessResponse(c *gin.Context, data interface{}) {
@@ -21,17 +29,10 @@ func SuccessResponse(c *gin.Context, data interface{}) {
 	})
 }
 
-func ErrorResponse(c *gin.Context, status int, err error) {
-	c.JSON(status, Response{
-		Success: false,
-		Error:   err.Error(),
-	})
-}
-
-func ValidationErrorResponse(c *gin.Context, errors map[string]string) {
-	c.JSON(http.StatusBadRequest, Response{
-		Success: false,
-		Error:   "validation_error",
-		Data:    errors,
+func PagedResponse(c *gin.Context, data interface{}, meta PageMeta) {
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    data,
+		Meta:    &meta,
 	})
 }
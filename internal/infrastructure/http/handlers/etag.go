@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"user-management/internal/domain/errs"
+)
+
+var ErrPreconditionFailed = errs.New(errs.Precondition, "precondition_failed", "resource version does not match If-Match")
+
+// etag construye el ETag fuerte de una entidad a partir de su versión
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// setETag publica la versión actual de la entidad en la respuesta
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", etag(version))
+}
+
+// hasIfMatch indica si la petición trae una precondición If-Match
+func hasIfMatch(c *gin.Context) bool {
+	return c.GetHeader("If-Match") != ""
+}
+
+// checkIfMatch evalúa la cabecera If-Match contra la versión actual. Si no
+// se cumple responde 412 y devuelve false; sin cabecera siempre se cumple.
+func checkIfMatch(c *gin.Context, version int) bool {
+	if ifMatch(c, version) {
+		return true
+	}
+
+	setETag(c, version)
+	HandleError(c, ErrPreconditionFailed)
+	return false
+}
+
+// ifMatch indica si la cabecera If-Match se cumple para version, sin
+// responder nada
+func ifMatch(c *gin.Context, version int) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" || strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	current := etag(version)
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimSpace(candidate) == current {
+			return true
+		}
+	}
+	return false
+}
@@ -7,10 +7,17 @@ import (
 )
 
 type HealthHandler struct {
+	metrics map[string]func() any
 }
 
 func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+	return &HealthHandler{metrics: make(map[string]func() any)}
+}
+
+// AddMetrics publica en /metrics, bajo name, el valor que devuelva source
+// en cada petición. Debe llamarse antes de registrar las rutas.
+func (h *HealthHandler) AddMetrics(name string, source func() any) {
+	h.metrics[name] = source
 }
 
 func (h *HealthHandler) RegisterRoutes(router *gin.RouterGroup) {
@@ -32,7 +39,7 @@ func (h *HealthHandler) SystemMetrics(c *gin.Context) {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 
-	SuccessResponse(c, gin.H{
+	metrics := gin.H{
 		"memory": gin.H{
 			"alloc":       m.Alloc,
 			"total_alloc": m.TotalAlloc,
@@ -40,5 +47,10 @@ func (h *HealthHandler) SystemMetrics(c *gin.Context) {
 			"num_gc":      m.NumGC,
 		},
 		"goroutines": runtime.NumGoroutine(),
-	})
+	}
+	for name, source := range h.metrics {
+		metrics[name] = source()
+	}
+
+	SuccessResponse(c, metrics)
 }
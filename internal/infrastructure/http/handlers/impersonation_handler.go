@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"user-management/internal/domain/ports/input"
+)
+
+type ImpersonationHandler struct {
+	impersonation input.ImpersonationService
+}
+
+func NewImpersonationHandler(impersonation input.ImpersonationService) *ImpersonationHandler {
+	return &ImpersonationHandler{impersonation: impersonation}
+}
+
+// RegisterAdminRoutes registra las rutas reservadas a administradores; el
+// grupo recibido debe venir ya protegido.
+func (h *ImpersonationHandler) RegisterAdminRoutes(router *gin.RouterGroup) {
+	router.POST("/users/:id/impersonate", h.Impersonate)
+}
+
+type impersonateRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// Impersonate emite un token de corta duración para actuar como el usuario;
+// el motivo queda en la auditoría
+func (h *ImpersonationHandler) Impersonate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		HandleError(c, badRequest(err))
+		return
+	}
+
+	var req impersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, requestError(err))
+		return
+	}
+
+	token, err := h.impersonation.Impersonate(c.Request.Context(), id, req.Reason)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	SuccessResponse(c, newTokenResponse(token))
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"user-management/internal/domain/ports/input"
+)
+
+type LockoutHandler struct {
+	lockouts input.LockoutService
+}
+
+func NewLockoutHandler(lockouts input.LockoutService) *LockoutHandler {
+	return &LockoutHandler{lockouts: lockouts}
+}
+
+// RegisterAdminRoutes registra las rutas reservadas a administradores; el
+// grupo recibido debe venir ya protegido.
+func (h *LockoutHandler) RegisterAdminRoutes(router *gin.RouterGroup) {
+	router.POST("/users/:id/unlock", h.UnlockAccount)
+}
+
+// UnlockAccount levanta el bloqueo por intentos fallidos de la cuenta
+func (h *LockoutHandler) UnlockAccount(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		HandleError(c, badRequest(err))
+		return
+	}
+
+	if err := h.lockouts.UnlockAccount(c.Request.Context(), id); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Success: true, Message: "Account unlocked successfully"})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"user-management/internal/domain/ports/input"
+)
+
+type MFAHandler struct {
+	mfa input.MFAService
+}
+
+func NewMFAHandler(mfa input.MFAService) *MFAHandler {
+	return &MFAHandler{mfa: mfa}
+}
+
+// RegisterAuthenticatedRoutes registra el alta y la baja del segundo factor
+// del usuario autenticado; router debe llevar AuthMiddleware
+func (h *MFAHandler) RegisterAuthenticatedRoutes(router *gin.RouterGroup) {
+	router.POST("/auth/mfa/enroll", h.Enroll)
+	router.POST("/auth/mfa/confirm", h.Confirm)
+	router.POST("/auth/mfa/disable", h.Disable)
+}
+
+type mfaEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// Enroll genera un secreto TOTP nuevo; MFA no se exige hasta confirmarlo
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	enrollment, err := h.mfa.Enroll(c.Request.Context(), userID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	SuccessResponse(c, mfaEnrollmentResponse{Secret: enrollment.Secret, OTPAuthURI: enrollment.URI})
+}
+
+type confirmMFARequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// Confirm activa MFA y devuelve los códigos de recuperación, que sólo se
+// muestran esta vez
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	var req confirmMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, requestError(err))
+		return
+	}
+
+	codes, err := h.mfa.Confirm(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	SuccessResponse(c, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+type disableMFARequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// Disable desactiva MFA; exige la contraseña y un código
+func (h *MFAHandler) Disable(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	var req disableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, requestError(err))
+		return
+	}
+
+	if err := h.mfa.Disable(c.Request.Context(), userID, req.Password, req.Code); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Success: true, Message: "Multi-factor authentication disabled"})
+}
@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/input"
+)
+
+// Rutas del proveedor OpenID Connect, relativas al issuer
+const (
+	oidcDiscoveryPath = "/.well-known/openid-configuration"
+	oidcAuthorizePath = "/oauth/authorize"
+	oidcTokenPath     = "/oauth/token"
+	oidcUserInfoPath  = "/oauth/userinfo"
+	oidcJWKSPath      = "/oauth/jwks"
+)
+
+// OIDCHandler publica el proveedor. auth autentica a los usuarios en la
+// autorización, tanto con un token Bearer como con el formulario de login.
+type OIDCHandler struct {
+	oidc input.OIDCService
+	auth input.AuthService
+}
+
+func NewOIDCHandler(oidc input.OIDCService, auth input.AuthService) *OIDCHandler {
+	return &OIDCHandler{oidc: oidc, auth: auth}
+}
+
+// RegisterRoutes registra los endpoints públicos del proveedor. Van en la
+// raíz del servidor, fuera de /api/v1: los clientes los encuentran a partir
+// del issuer y sus respuestas siguen OAuth 2.0, no problem+json. La
+// autorización no lleva AuthMiddleware: el navegador del usuario llega sin
+// token y se autentica en el propio endpoint.
+func (h *OIDCHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET(oidcDiscoveryPath, h.Discovery)
+	router.GET(oidcJWKSPath, h.JWKS)
+	router.GET(oidcAuthorizePath, h.Authorize)
+	router.POST(oidcAuthorizePath, h.Authorize)
+	router.POST(oidcTokenPath, h.Token)
+	router.GET(oidcUserInfoPath, h.UserInfo)
+	router.POST(oidcUserInfoPath, h.UserInfo)
+}
+
+// RegisterAdminRoutes registra el registro de clientes; el grupo recibido
+// debe venir ya protegido
+func (h *OIDCHandler) RegisterAdminRoutes(router *gin.RouterGroup) {
+	router.POST("/oauth/clients", h.CreateClient)
+	router.GET("/oauth/clients", h.ListClients)
+	router.DELETE("/oauth/clients/:id", h.DeleteClient)
+}
+
+// discoveryDocument son los metadatos del proveedor (OpenID Connect
+// Discovery 1.0, sección 3)
+type discoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// Discovery publica los endpoints y capacidades del proveedor
+func (h *OIDCHandler) Discovery(c *gin.Context) {
+	issuer := h.oidc.Issuer()
+	c.JSON(http.StatusOK, discoveryDocument{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + oidcAuthorizePath,
+		TokenEndpoint:                     issuer + oidcTokenPath,
+		UserInfoEndpoint:                  issuer + oidcUserInfoPath,
+		JWKSURI:                           issuer + oidcJWKSPath,
+		ScopesSupported:                   entities.OIDCScopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported: []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce",
+			"name", "email", "email_verified", "updated_at"},
+	})
+}
+
+// JWKS publica las claves con las que se verifican los ID tokens
+func (h *OIDCHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, h.oidc.Keys())
+}
+
+type authorizeRequest struct {
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	ResponseType        string `form:"response_type"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+}
+
+// Authorize emite un código de autorización y redirige al cliente. Los
+// parámetros llegan en la query (GET) o en un formulario (POST). Quien
+// llama con un token Bearer recibe el código directamente; un navegador
+// sin él pasa antes por el login y el consentimiento (ver oidc_login.go).
+// Si no se puede redirigir responde con el problema.
+func (h *OIDCHandler) Authorize(c *gin.Context) {
+	var req authorizeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		HandleError(c, badRequest(err))
+		return
+	}
+
+	token, isBearer := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !isBearer {
+		h.authorizeBrowser(c, req)
+		return
+	}
+
+	principal, err := h.auth.Authenticate(c.Request.Context(), token)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	h.issueCode(c, req, principal)
+}
+
+// issueCode emite el código para principal y redirige al cliente
+func (h *OIDCHandler) issueCode(c *gin.Context, req authorizeRequest, principal *entities.Principal) {
+	ctx := entities.ContextWithPrincipal(c.Request.Context(), principal)
+	location, err := h.oidc.Authorize(ctx, input.AuthorizationRequest(req))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, location)
+}
+
+type tokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// oidcTokenResponse es la respuesta del endpoint de tokens (OIDC Core,
+// sección 3.1.3.3)
+type oidcTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	IDToken     string `json:"id_token"`
+	Scope       string `json:"scope"`
+}
+
+// Token canjea un código de autorización. El cliente se autentica con
+// client_secret_basic, con client_secret_post o, si es público, sólo con su
+// client_id.
+func (h *OIDCHandler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req tokenRequest
+	if c.ContentType() != "application/x-www-form-urlencoded" || c.ShouldBind(&req) != nil {
+		oauthError(c, errs.Errorf(oauthInvalidRequest, "token request must be form-encoded"))
+		return
+	}
+
+	basic := false
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		// RFC 6749, sección 2.3.1: las credenciales van codificadas como
+		// en un formulario antes de Basic
+		id, idErr := url.QueryUnescape(id)
+		secret, secretErr := url.QueryUnescape(secret)
+		if idErr != nil || secretErr != nil || req.ClientSecret != "" || (req.ClientID != "" && req.ClientID != id) {
+			oauthError(c, errs.Errorf(oauthInvalidRequest, "client credentials must be sent once"))
+			return
+		}
+		req.ClientID, req.ClientSecret, basic = id, secret, true
+	}
+
+	tokens, err := h.oidc.Exchange(c.Request.Context(), input.TokenRequest(req))
+	if err != nil {
+		if basic && errs.Is(err, errs.Unauthorized) {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		oauthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, oidcTokenResponse{
+		AccessToken: tokens.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(time.Until(tokens.ExpiresAt).Seconds()),
+		IDToken:     tokens.IDToken,
+		Scope:       tokens.Scope,
+	})
+}
+
+// UserInfo devuelve los datos del usuario que permiten los scopes del token
+// de acceso
+func (h *OIDCHandler) UserInfo(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		// RFC 6750, sección 3.1: sin credencial no se indica error
+		c.Header("WWW-Authenticate", `Bearer realm="oauth"`)
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	info, err := h.oidc.UserInfo(c.Request.Context(), token)
+	if err != nil {
+		if errs.Is(err, errs.Unauthorized) {
+			c.Header("WWW-Authenticate", `Bearer realm="oauth", error="invalid_token"`)
+		}
+		oauthError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, info)
+}
+
+// oauthInvalidRequest es el error OAuth de una petición que no se puede
+// leer; el código coincide con el del servicio
+var oauthInvalidRequest = errs.New(errs.BadRequest, "invalid_request", "invalid request")
+
+// oauthErrorResponse es el cuerpo de error de OAuth 2.0 (RFC 6749, sección
+// 5.2)
+type oauthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// oauthError responde con el error OAuth correspondiente a err. Como en
+// HandleError, los errores no tipados se registran y no se exponen.
+func oauthError(c *gin.Context, err error) {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		err = errTimeout
+	}
+
+	e, ok := errs.As(err)
+	if !ok || e.Kind == errs.Internal || e.Kind == errs.Unavailable {
+		log.Printf("error interno en %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, oauthErrorResponse{Error: "server_error"})
+		return
+	}
+	c.AbortWithStatusJSON(statusByKind[e.Kind], oauthErrorResponse{
+		Error:            e.Code,
+		ErrorDescription: errs.Localize(err, requestLocale(c)),
+	})
+}
+
+type createOAuthClientRequest struct {
+	Name         string   `json:"name" binding:"required,max=100"`
+	RedirectURIs []string `json:"redirect_uris" binding:"required,min=1"`
+	Confidential bool     `json:"confidential"`
+}
+
+// createdOAuthClientResponse añade el secreto de los clientes
+// confidenciales, que sólo se devuelve al registrarlos
+type createdOAuthClientResponse struct {
+	*entities.OAuthClient
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// CreateClient registra un cliente del proveedor; la respuesta es la única
+// vez que se muestra su secreto
+func (h *OIDCHandler) CreateClient(c *gin.Context) {
+	var req createOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, requestError(err))
+		return
+	}
+
+	registered, err := h.oidc.RegisterClient(c.Request.Context(), req.Name, req.RedirectURIs, req.Confidential)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Data:    createdOAuthClientResponse{OAuthClient: registered.Client, ClientSecret: registered.Secret},
+		Message: "OAuth client registered successfully",
+	})
+}
+
+// ListClients devuelve los clientes registrados sin su secreto
+func (h *OIDCHandler) ListClients(c *gin.Context) {
+	clients, err := h.oidc.ListClients(c.Request.Context())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	SuccessResponse(c, clients)
+}
+
+// DeleteClient da de baja un cliente; deja de poder autorizar y canjear
+// códigos
+func (h *OIDCHandler) DeleteClient(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		HandleError(c, badRequest(err))
+		return
+	}
+
+	if err := h.oidc.DeleteClient(c.Request.Context(), id); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Success: true, Message: "OAuth client deleted successfully"})
+}
@@ -0,0 +1,593 @@
+package handlers_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"user-management/internal/application/services"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/infrastructure/http/handlers"
+	"user-management/internal/infrastructure/http/middlewares"
+	"user-management/internal/infrastructure/persistence/memory"
+	"user-management/pkg/jwt"
+	"user-management/pkg/totp"
+)
+
+const (
+	oidcAdminToken = "admin-token"
+	oidcEmail      = "alice@example.com"
+	oidcPassword   = "Password123!"
+	// oidcMFAEmail es un usuario con MFA y la misma contraseña
+	oidcMFAEmail = "bob@example.com"
+)
+
+// newOIDCProvider arranca el proveedor en un servidor local montado como en
+// cmd/api/main.go. El issuer es la URL del servidor, así que los clientes lo
+// descubren como en producción. Devuelve también el token de acceso de un
+// usuario que ya ha iniciado sesión y el secreto TOTP de oidcMFAEmail.
+func newOIDCProvider(t *testing.T) (issuer, userToken, mfaSecret string) {
+	gin.SetMode(gin.TestMode)
+	server := httptest.NewUnstartedServer(nil)
+	issuer = "http://" + server.Listener.Addr().String()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	users := memory.NewUserRepository()
+	user, err := entities.NewUser("Alice Smith", oidcEmail, 30, oidcPassword)
+	require.NoError(t, err)
+	require.NoError(t, users.Save(t.Context(), *user))
+
+	mfaUser, err := entities.NewUser("Bob Jones", oidcMFAEmail, 40, oidcPassword)
+	require.NoError(t, err)
+	require.NoError(t, users.Save(t.Context(), *mfaUser))
+	mfa := services.NewMFAService(users, services.MFAOptions{})
+	enrollment, err := mfa.Enroll(t.Context(), mfaUser.ID)
+	require.NoError(t, err)
+	_, err = mfa.Confirm(t.Context(), mfaUser.ID, totpCode(t, enrollment.Secret, 0))
+	require.NoError(t, err)
+
+	authService := services.NewAuthService(users, memory.NewRateLimiter(), nil, nil, jwt.HS256([]byte("test-secret")),
+		services.AuthOptions{})
+	oidc := services.NewOIDCService(memory.NewOAuthClientRepository(), users, memory.NewOneTimeTokenStore(),
+		memory.NewOIDCSessionStore(), key, services.OIDCOptions{Issuer: issuer})
+
+	router := gin.New()
+	authenticated := middlewares.AuthMiddleware(oidcAdminToken, authService)
+	oidcHandler := handlers.NewOIDCHandler(oidc, authService)
+	oidcHandler.RegisterRoutes(router.Group(""))
+	oidcHandler.RegisterAdminRoutes(router.Group("/api/v1/admin", authenticated, middlewares.RequireRole(middlewares.RoleAdmin)))
+
+	server.Config.Handler = router
+	server.Start()
+	t.Cleanup(server.Close)
+
+	login, err := authService.Login(t.Context(), oidcEmail, oidcPassword, input.ClientInfo{IP: "127.0.0.1"})
+	require.NoError(t, err)
+	return issuer, login.AccessToken.Token, enrollment.Secret
+}
+
+// totpCode devuelve el código TOTP actual desplazado steps pasos; cada paso
+// sólo se acepta una vez
+func totpCode(t *testing.T, secret string, steps int64) string {
+	code, err := totp.Code(secret, totp.Step(time.Now())+steps)
+	require.NoError(t, err)
+	return code
+}
+
+// oidcTestClient es una aplicación que usa el proveedor como cualquier
+// cliente OpenID Connect: descubre los endpoints, envía al usuario a
+// autorizar con PKCE, recibe el código en su propio callback y verifica el
+// ID token con las claves publicadas
+type oidcTestClient struct {
+	t           *testing.T
+	issuer      string
+	config      oidcDiscovery
+	callback    *httptest.Server
+	redirectURI string
+	id          string
+	secret      string
+
+	mu       sync.Mutex
+	received url.Values
+}
+
+type oidcDiscovery struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	UserInfoEndpoint      string   `json:"userinfo_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	CodeChallengeMethods  []string `json:"code_challenge_methods_supported"`
+	SigningAlgorithms     []string `json:"id_token_signing_alg_values_supported"`
+}
+
+type oidcTokens struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	IDToken     string `json:"id_token"`
+	Scope       string `json:"scope"`
+}
+
+type oidcIDToken struct {
+	jwt.Claims
+	AuthTime      int64  `json:"auth_time"`
+	Nonce         string `json:"nonce"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	EmailVerified *bool  `json:"email_verified"`
+}
+
+// authorization es lo que el cliente guarda entre la redirección y el
+// callback
+type authorization struct {
+	verifier string
+	state    string
+	nonce    string
+}
+
+// newOIDCTestClient descubre el proveedor y registra el cliente con el API
+// de administración
+func newOIDCTestClient(t *testing.T, issuer string, confidential bool) *oidcTestClient {
+	c := &oidcTestClient{t: t, issuer: issuer}
+	c.callback = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		c.received = r.URL.Query()
+		c.mu.Unlock()
+		_, _ = w.Write([]byte("signed in"))
+	}))
+	t.Cleanup(c.callback.Close)
+	c.redirectURI = c.callback.URL + "/callback"
+
+	resp := c.do("GET", issuer+"/.well-known/openid-configuration", "", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	decodeJSON(t, resp, &c.config)
+	require.Equal(t, issuer, c.config.Issuer)
+
+	body, _ := json.Marshal(map[string]any{"name": "Test app", "redirect_uris": []string{c.redirectURI}, "confidential": confidential})
+	resp = c.do("POST", issuer+"/api/v1/admin/oauth/clients", "application/json", strings.NewReader(string(body)),
+		"Authorization", "Bearer "+oidcAdminToken)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var registered struct {
+		Data struct {
+			ClientID     string `json:"client_id"`
+			ClientSecret string `json:"client_secret"`
+		} `json:"data"`
+	}
+	decodeJSON(t, resp, &registered)
+	c.id, c.secret = registered.Data.ClientID, registered.Data.ClientSecret
+	return c
+}
+
+// do hace una petición sin seguir redirecciones; headers son pares
+// nombre, valor
+func (c *oidcTestClient) do(method, target, contentType string, body io.Reader, headers ...string) *http.Response {
+	c.t.Helper()
+	req, err := http.NewRequest(method, target, body)
+	require.NoError(c.t, err)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Do(req)
+	require.NoError(c.t, err)
+	c.t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func decodeJSON(t *testing.T, resp *http.Response, v any) {
+	t.Helper()
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+}
+
+func randomString(t *testing.T) string {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// authorize hace de navegador del usuario: pide la autorización con su
+// sesión y sigue la redirección hasta el callback del cliente, que recibe
+// los parámetros
+func (c *oidcTestClient) authorize(userToken, scope string) (authorization, url.Values) {
+	auth := authorization{verifier: randomString(c.t), state: randomString(c.t), nonce: randomString(c.t)}
+	query := c.authorizeQuery(auth, scope)
+
+	resp := c.do("GET", c.config.AuthorizationEndpoint+"?"+query.Encode(), "", nil, "Authorization", "Bearer "+userToken)
+	require.Equal(c.t, http.StatusFound, resp.StatusCode)
+	location := resp.Header.Get("Location")
+	require.True(c.t, strings.HasPrefix(location, c.redirectURI+"?"), location)
+
+	require.Equal(c.t, http.StatusOK, c.do("GET", location, "", nil).StatusCode)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	require.Equal(c.t, auth.state, c.received.Get("state"))
+	return auth, c.received
+}
+
+// exchange canjea el código; los clientes confidenciales se autentican con
+// client_secret_basic
+func (c *oidcTestClient) exchange(code, verifier string) *http.Response {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.redirectURI},
+		"code_verifier": {verifier},
+	}
+	var headers []string
+	if c.secret != "" {
+		credentials := url.QueryEscape(c.id) + ":" + url.QueryEscape(c.secret)
+		headers = []string{"Authorization", "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))}
+	} else {
+		form.Set("client_id", c.id)
+	}
+	return c.do("POST", c.config.TokenEndpoint, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()), headers...)
+}
+
+// verifyIDToken comprueba el ID token con la clave del JWKS que indica su
+// kid, como exige OIDC Core, sección 3.1.3.7
+func (c *oidcTestClient) verifyIDToken(token string, auth authorization) oidcIDToken {
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	segment, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+	require.NoError(c.t, err)
+	require.NoError(c.t, json.Unmarshal(segment, &header))
+	require.Contains(c.t, c.config.SigningAlgorithms, header.Algorithm)
+
+	resp := c.do("GET", c.config.JWKSURI, "", nil)
+	require.Equal(c.t, http.StatusOK, resp.StatusCode)
+	var keys jwt.JWKS
+	decodeJSON(c.t, resp, &keys)
+
+	var claims oidcIDToken
+	for _, key := range keys.Keys {
+		if key.KeyID != header.KeyID {
+			continue
+		}
+		public, err := key.PublicKey()
+		require.NoError(c.t, err)
+		require.NoError(c.t, jwt.Decode(jwt.RS256PublicKey(public), token, &claims))
+
+		assert.Equal(c.t, c.issuer, claims.Issuer)
+		assert.Equal(c.t, c.id, claims.Audience)
+		assert.Equal(c.t, auth.nonce, claims.Nonce)
+		assert.NotZero(c.t, claims.AuthTime)
+		return claims
+	}
+	c.t.Fatalf("el JWKS no tiene la clave %q", header.KeyID)
+	return claims
+}
+
+func oauthErrorCode(t *testing.T, resp *http.Response) string {
+	var body struct {
+		Error string `json:"error"`
+	}
+	decodeJSON(t, resp, &body)
+	return body.Error
+}
+
+func TestOIDCHandler_AuthorizationCodeFlow(t *testing.T) {
+	issuer, userToken, _ := newOIDCProvider(t)
+
+	for _, confidential := range []bool{true, false} {
+		name := map[bool]string{true: "confidential client", false: "public client"}[confidential]
+		t.Run(name, func(t *testing.T) {
+			client := newOIDCTestClient(t, issuer, confidential)
+			assert.Equal(t, confidential, client.secret != "")
+			assert.Contains(t, client.config.CodeChallengeMethods, "S256")
+
+			auth, params := client.authorize(userToken, "openid profile email")
+			resp := client.exchange(params.Get("code"), auth.verifier)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+			var tokens oidcTokens
+			decodeJSON(t, resp, &tokens)
+			assert.Equal(t, "Bearer", tokens.TokenType)
+			assert.Equal(t, "openid profile email", tokens.Scope)
+			assert.Positive(t, tokens.ExpiresIn)
+
+			idToken := client.verifyIDToken(tokens.IDToken, auth)
+			assert.Equal(t, "Alice Smith", idToken.Name)
+			assert.Equal(t, oidcEmail, idToken.Email)
+			require.NotNil(t, idToken.EmailVerified)
+			assert.False(t, *idToken.EmailVerified)
+
+			resp = client.do("GET", client.config.UserInfoEndpoint, "", nil, "Authorization", "Bearer "+tokens.AccessToken)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			var info map[string]any
+			decodeJSON(t, resp, &info)
+			assert.Equal(t, idToken.Subject, info["sub"])
+			assert.Equal(t, oidcEmail, info["email"])
+			assert.Equal(t, "Alice Smith", info["name"])
+
+			// Un código sólo vale una vez
+			resp = client.exchange(params.Get("code"), auth.verifier)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			assert.Equal(t, "invalid_grant", oauthErrorCode(t, resp))
+		})
+	}
+}
+
+// authorizeQuery devuelve los parámetros de una autorización con PKCE
+func (c *oidcTestClient) authorizeQuery(auth authorization, scope string) url.Values {
+	challenge := sha256.Sum256([]byte(auth.verifier))
+	return url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.id},
+		"redirect_uri":          {c.redirectURI},
+		"scope":                 {scope},
+		"state":                 {auth.state},
+		"nonce":                 {auth.nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+}
+
+// oidcBrowser rellena el formulario de autorización como un navegador:
+// guarda la cookie de sesión y devuelve el token CSRF de cada página
+type oidcBrowser struct {
+	client *oidcTestClient
+	query  url.Values
+	cookie *http.Cookie
+}
+
+// do envía la petición con la cookie actual y guarda la que se reciba
+func (b *oidcBrowser) do(method string, fields url.Values) (*http.Response, string) {
+	var headers []string
+	if b.cookie != nil {
+		headers = append(headers, "Cookie", b.cookie.Name+"="+b.cookie.Value)
+	}
+
+	var resp *http.Response
+	if method == "GET" {
+		resp = b.client.do("GET", b.client.config.AuthorizationEndpoint+"?"+b.query.Encode(), "", nil, headers...)
+	} else {
+		for name, values := range b.query {
+			fields[name] = values
+		}
+		resp = b.client.do("POST", b.client.config.AuthorizationEndpoint, "application/x-www-form-urlencoded",
+			strings.NewReader(fields.Encode()), headers...)
+	}
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "oidc_session" {
+			b.cookie = cookie
+		}
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+// csrfToken es el token CSRF del formulario de body
+func csrfToken(t *testing.T, body string) string {
+	t.Helper()
+	match := regexp.MustCompile(`name="csrf_token" value="([^"]+)"`).FindStringSubmatch(body)
+	require.Len(t, match, 2, "the form carries a CSRF token")
+	return match[1]
+}
+
+func TestOIDCHandler_BrowserLogin(t *testing.T) {
+	issuer, _, mfaSecret := newOIDCProvider(t)
+	client := newOIDCTestClient(t, issuer, false)
+	auth := authorization{verifier: randomString(t), state: randomString(t), nonce: randomString(t)}
+	query := client.authorizeQuery(auth, "openid email")
+
+	// codeFrom comprueba que resp redirige al callback con un código
+	codeFrom := func(t *testing.T, resp *http.Response) string {
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		location, err := url.Parse(resp.Header.Get("Location"))
+		require.NoError(t, err)
+		assert.Equal(t, auth.state, location.Query().Get("state"))
+		require.NotEmpty(t, location.Query().Get("code"))
+		return location.Query().Get("code")
+	}
+
+	browser := &oidcBrowser{client: client, query: query}
+	resp, body := browser.do("GET", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "DENY", resp.Header.Get("X-Frame-Options"))
+	assert.Contains(t, body, `name="state" value="`+auth.state+`"`, "the form keeps the request")
+	require.NotNil(t, browser.cookie, "the form opens a session")
+	anonymous := *browser.cookie
+	csrf := csrfToken(t, body)
+
+	t.Run("wrong credentials show the form again", func(t *testing.T) {
+		resp, _ := browser.do("POST", url.Values{"csrf_token": {csrf}, "email": {oidcEmail}, "password": {"wrong"}})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Location"))
+		assert.Empty(t, resp.Cookies())
+	})
+
+	t.Run("forms without the session's CSRF token are rejected", func(t *testing.T) {
+		for _, token := range []string{"", "forged"} {
+			resp, body := browser.do("POST", url.Values{"csrf_token": {token}, "email": {oidcEmail}, "password": {oidcPassword}})
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+			assert.Empty(t, resp.Header.Get("Location"))
+			assert.Contains(t, body, `name="password"`)
+		}
+
+		// Otro sitio no conoce la cookie: su token no vale para otra sesión
+		other := &oidcBrowser{client: client, query: query}
+		resp, _ := other.do("POST", url.Values{"csrf_token": {csrf}, "email": {oidcEmail}, "password": {oidcPassword}})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	resp, _ = browser.do("POST", url.Values{"csrf_token": {csrf}, "email": {oidcEmail}, "password": {oidcPassword}})
+	code := codeFrom(t, resp)
+	session := browser.cookie
+	assert.NotEqual(t, anonymous.Value, session.Value, "signing in replaces the session")
+	assert.NotContains(t, session.Value, ".", "the cookie is an opaque identifier, not a token")
+	assert.True(t, session.HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, session.SameSite)
+	assert.Equal(t, "/oauth", session.Path)
+	assert.Equal(t, http.StatusOK, client.exchange(code, auth.verifier).StatusCode)
+
+	t.Run("with a session the user is asked for consent", func(t *testing.T) {
+		resp, body := browser.do("GET", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Location"), "a GET never issues a code from the cookie")
+		assert.Contains(t, body, `name="consent" value="allow"`)
+		consentCSRF := csrfToken(t, body)
+		assert.NotEqual(t, csrf, consentCSRF)
+
+		resp, _ = browser.do("POST", url.Values{"csrf_token": {csrf}, "consent": {"allow"}})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, "the token of the anonymous session no longer works")
+		assert.Empty(t, resp.Header.Get("Location"))
+
+		resp, _ = browser.do("POST", url.Values{"csrf_token": {consentCSRF}, "consent": {"allow"}})
+		codeFrom(t, resp)
+	})
+
+	t.Run("users with MFA are asked for a code", func(t *testing.T) {
+		browser := &oidcBrowser{client: client, query: query}
+		_, body := browser.do("GET", nil)
+		csrf := csrfToken(t, body)
+
+		resp, body := browser.do("POST", url.Values{"csrf_token": {csrf}, "email": {oidcMFAEmail}, "password": {oidcPassword}})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Location"))
+		challenge := regexp.MustCompile(`name="mfa_challenge" value="([^"]+)"`).FindStringSubmatch(body)
+		require.Len(t, challenge, 2)
+
+		resp, body = browser.do("POST", url.Values{"csrf_token": {csrf}, "mfa_challenge": {challenge[1]}, "code": {"000000"}})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Contains(t, body, `name="mfa_challenge"`, "a wrong code can be retried")
+
+		resp, _ = browser.do("POST", url.Values{"csrf_token": {csrf}, "mfa_challenge": {challenge[1]}, "code": {totpCode(t, mfaSecret, 1)}})
+		codeFrom(t, resp)
+	})
+
+	t.Run("an invalid session goes back to the login", func(t *testing.T) {
+		browser := &oidcBrowser{client: client, query: query, cookie: &http.Cookie{Name: "oidc_session", Value: "forged"}}
+		resp, body := browser.do("POST", url.Values{"csrf_token": {csrf}, "consent": {"allow"}})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Location"))
+		assert.Contains(t, body, `name="password"`)
+		assert.NotEqual(t, "forged", browser.cookie.Value)
+	})
+}
+
+func TestOIDCHandler_RejectsInvalidRequests(t *testing.T) {
+	issuer, userToken, _ := newOIDCProvider(t)
+	client := newOIDCTestClient(t, issuer, true)
+
+	t.Run("wrong PKCE verifier", func(t *testing.T) {
+		_, params := client.authorize(userToken, "openid")
+		resp := client.exchange(params.Get("code"), randomString(t))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "invalid_grant", oauthErrorCode(t, resp))
+	})
+
+	t.Run("wrong client secret", func(t *testing.T) {
+		auth, params := client.authorize(userToken, "openid")
+		secret := client.secret
+		client.secret = "wrong"
+		defer func() { client.secret = secret }()
+
+		resp := client.exchange(params.Get("code"), auth.verifier)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("WWW-Authenticate"), "Basic")
+		assert.Equal(t, "invalid_client", oauthErrorCode(t, resp))
+	})
+
+	t.Run("confidential client without its secret", func(t *testing.T) {
+		auth, params := client.authorize(userToken, "openid")
+		secret := client.secret
+		client.secret = ""
+		defer func() { client.secret = secret }()
+
+		resp := client.exchange(params.Get("code"), auth.verifier)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, "invalid_client", oauthErrorCode(t, resp))
+	})
+
+	t.Run("errors are returned to the client", func(t *testing.T) {
+		_, params := client.authorize(userToken, "profile email")
+		assert.Equal(t, "invalid_scope", params.Get("error"))
+		assert.Empty(t, params.Get("code"))
+	})
+
+	t.Run("unregistered redirect URI is not followed", func(t *testing.T) {
+		query := url.Values{"response_type": {"code"}, "client_id": {client.id}, "scope": {"openid"},
+			"redirect_uri": {"https://evil.example.com/callback"}}
+		resp := client.do("GET", client.config.AuthorizationEndpoint+"?"+query.Encode(), "", nil,
+			"Authorization", "Bearer "+userToken)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Location"))
+	})
+
+	t.Run("browsers are not shown the form for unregistered clients or redirect URIs", func(t *testing.T) {
+		for _, query := range []url.Values{
+			{"response_type": {"code"}, "client_id": {client.id}, "scope": {"openid"}, "redirect_uri": {"https://evil.example.com/callback"}},
+			{"response_type": {"code"}, "client_id": {uuid.NewString()}, "scope": {"openid"}, "redirect_uri": {client.redirectURI}},
+			{"response_type": {"code"}, "client_id": {client.id}, "scope": {"openid"}},
+		} {
+			resp := client.do("GET", client.config.AuthorizationEndpoint+"?"+query.Encode(), "", nil)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			assert.Empty(t, resp.Header.Get("Location"))
+			assert.Empty(t, resp.Cookies())
+			body, _ := io.ReadAll(resp.Body)
+			assert.NotContains(t, string(body), "<form")
+		}
+	})
+
+	t.Run("authorization requires a user", func(t *testing.T) {
+		query := client.authorizeQuery(authorization{verifier: randomString(t)}, "openid")
+		resp := client.do("GET", client.config.AuthorizationEndpoint+"?"+query.Encode(), "", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Location"))
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), `name="password"`, "the browser is asked to sign in")
+
+		resp = client.do("GET", client.config.AuthorizationEndpoint+"?"+query.Encode(), "", nil,
+			"Authorization", "Bearer not-a-token")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Location"))
+	})
+
+	t.Run("userinfo rejects other tokens", func(t *testing.T) {
+		resp := client.do("GET", client.config.UserInfoEndpoint, "", nil)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, `Bearer realm="oauth"`, resp.Header.Get("WWW-Authenticate"))
+
+		resp = client.do("GET", client.config.UserInfoEndpoint, "", nil, "Authorization", "Bearer "+userToken)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("WWW-Authenticate"), `error="invalid_token"`)
+	})
+
+	t.Run("token endpoint only accepts forms", func(t *testing.T) {
+		resp := client.do("POST", client.config.TokenEndpoint, "application/json", strings.NewReader(`{}`))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "invalid_request", oauthErrorCode(t, resp))
+	})
+
+	t.Run("client registration is reserved to administrators", func(t *testing.T) {
+		resp := client.do("GET", issuer+"/api/v1/admin/oauth/clients", "", nil, "Authorization", "Bearer "+userToken)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
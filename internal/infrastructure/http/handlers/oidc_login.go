@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/input"
+)
+
+// oidcSessionCookie guarda el identificador opaco de la sesión del
+// navegador en el formulario de autorización, que sólo vale en /oauth (ver
+// input.OIDCSession). Con SameSite=Lax no acompaña a los POST de otros
+// sitios y, además, cada formulario lleva el token CSRF de la sesión.
+const oidcSessionCookie = "oidc_session"
+
+// ErrInvalidCSRFToken indica que el formulario no trae el token CSRF de la
+// sesión: lo envió otra página o es de una sesión anterior
+var ErrInvalidCSRFToken = errs.New(errs.Forbidden, "invalid_csrf_token", "the form has expired, please try again")
+
+// Pasos del formulario de autorización
+const (
+	authorizeStepLogin   = "login"
+	authorizeStepMFA     = "mfa"
+	authorizeStepConsent = "consent"
+)
+
+// authorizeForm son los campos que envía el formulario de autorización
+// además de los parámetros de OAuth
+type authorizeForm struct {
+	CSRFToken string `form:"csrf_token"`
+	Email     string `form:"email"`
+	Password  string `form:"password"`
+	Challenge string `form:"mfa_challenge"`
+	Code      string `form:"code"`
+	Consent   string `form:"consent"`
+}
+
+// authorizeBrowser atiende al navegador del usuario, que llega sin token:
+// le pide las credenciales (y el código de MFA si lo tiene activado) y,
+// con la sesión ya abierta, el consentimiento. Enviar el login cuenta como
+// consentimiento, porque el formulario ya muestra qué se autoriza. Si el
+// cliente o la redirect_uri no son válidos no se muestra el formulario.
+func (h *OIDCHandler) authorizeBrowser(c *gin.Context, req authorizeRequest) {
+	if err := h.oidc.ValidateAuthorization(c.Request.Context(), input.AuthorizationRequest(req)); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	var form authorizeForm
+	if c.Request.Method == http.MethodPost {
+		if err := c.ShouldBind(&form); err != nil {
+			HandleError(c, badRequest(err))
+			return
+		}
+	}
+	session, ok := h.browserSession(c)
+	if !ok {
+		return
+	}
+	page := authorizePage{Step: authorizeStepLogin, Request: req, CSRFToken: session.CSRFToken}
+
+	if c.Request.Method == http.MethodPost &&
+		subtle.ConstantTimeCompare([]byte(form.CSRFToken), []byte(session.CSRFToken)) != 1 {
+		if session.Principal != nil {
+			page.Step = authorizeStepConsent
+		}
+		h.authorizeFailed(c, page, ErrInvalidCSRFToken)
+		return
+	}
+
+	switch {
+	case form.Email != "" || form.Password != "":
+		result, err := h.auth.Login(c.Request.Context(), form.Email, form.Password, clientInfo(c))
+		if err != nil {
+			h.authorizeFailed(c, page, err)
+			return
+		}
+		if result.Challenge != nil {
+			page.Step, page.Challenge = authorizeStepMFA, result.Challenge.Token
+			renderAuthorizePage(c, http.StatusOK, page)
+			return
+		}
+		h.signIn(c, req, session, result.AccessToken)
+	case form.Challenge != "":
+		token, err := h.auth.VerifyMFA(c.Request.Context(), form.Challenge, form.Code, clientInfo(c))
+		if err != nil {
+			// Un código incorrecto se vuelve a pedir; si el reto ya no vale
+			// hay que iniciar sesión otra vez
+			if errs.Is(err, errs.Forbidden) {
+				page.Step, page.Challenge = authorizeStepMFA, form.Challenge
+			}
+			h.authorizeFailed(c, page, err)
+			return
+		}
+		h.signIn(c, req, session, token)
+	default:
+		switch {
+		case session.Principal == nil:
+			renderAuthorizePage(c, http.StatusOK, page)
+		case c.Request.Method == http.MethodPost && form.Consent == "allow":
+			h.issueCode(c, req, session.Principal)
+		default:
+			page.Step = authorizeStepConsent
+			renderAuthorizePage(c, http.StatusOK, page)
+		}
+	}
+}
+
+// signIn abre la sesión del navegador para el usuario de token, que acaba
+// de iniciar sesión, y emite el código
+func (h *OIDCHandler) signIn(c *gin.Context, req authorizeRequest, session *input.OIDCSession, token *input.AccessToken) {
+	principal, err := h.auth.Authenticate(c.Request.Context(), token.Token)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	ctx := entities.ContextWithPrincipal(c.Request.Context(), principal)
+	if session, err = h.oidc.SignIn(ctx, session.ID); err != nil {
+		HandleError(c, err)
+		return
+	}
+	h.setSessionCookie(c, session)
+	h.issueCode(c, req, principal)
+}
+
+// browserSession devuelve la sesión de la cookie o, si no hay o ya no vale
+// (ha caducado, el usuario cerró todas sus sesiones...), una nueva sin
+// usuario, y actualiza la cookie
+func (h *OIDCHandler) browserSession(c *gin.Context) (*input.OIDCSession, bool) {
+	id, _ := c.Cookie(oidcSessionCookie)
+	session, err := h.oidc.BrowserSession(c.Request.Context(), id)
+	if err != nil {
+		HandleError(c, err)
+		return nil, false
+	}
+	if session.ID != id {
+		h.setSessionCookie(c, session)
+	}
+	return session, true
+}
+
+func (h *OIDCHandler) setSessionCookie(c *gin.Context, session *input.OIDCSession) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcSessionCookie,
+		Value:    session.ID,
+		Path:     "/oauth",
+		Expires:  session.ExpiresAt,
+		Secure:   strings.HasPrefix(h.oidc.Issuer(), "https://"),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// authorizeFailed vuelve a mostrar page con el error; los fallos internos
+// se responden como en el resto de la API
+func (h *OIDCHandler) authorizeFailed(c *gin.Context, page authorizePage, err error) {
+	e, ok := errs.As(err)
+	if !ok || e.Kind == errs.Internal {
+		HandleError(c, err)
+		return
+	}
+
+	page.Error = errs.Localize(err, requestLocale(c))
+	renderAuthorizePage(c, statusByKind[e.Kind], page)
+}
+
+// authorizePage es lo que muestra el formulario de autorización
+type authorizePage struct {
+	Step      string
+	Request   authorizeRequest
+	CSRFToken string
+	Challenge string
+	Error     string
+}
+
+// Params son los parámetros de OAuth, que el formulario reenvía ocultos
+func (p authorizePage) Params() url.Values {
+	params := url.Values{}
+	for name, value := range map[string]string{
+		"client_id":             p.Request.ClientID,
+		"redirect_uri":          p.Request.RedirectURI,
+		"response_type":         p.Request.ResponseType,
+		"scope":                 p.Request.Scope,
+		"state":                 p.Request.State,
+		"nonce":                 p.Request.Nonce,
+		"code_challenge":        p.Request.CodeChallenge,
+		"code_challenge_method": p.Request.CodeChallengeMethod,
+	} {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	return params
+}
+
+func renderAuthorizePage(c *gin.Context, status int, page authorizePage) {
+	c.Header("Cache-Control", "no-store")
+	// El consentimiento no se puede incrustar en otra página
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	if err := authorizeTemplate.Execute(c.Writer, page); err != nil {
+		log.Printf("no se pudo mostrar el formulario de autorización: %v", err)
+	}
+}
+
+var authorizeTemplate = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html lang="es">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>Autorizar el acceso</title>
+</head>
+<body>
+  <main>
+    <h1>Autorizar el acceso</h1>
+    <p>La aplicación <code>{{.Request.ClientID}}</code> quiere acceder a tu cuenta con los permisos: <code>{{.Request.Scope}}</code>.</p>
+    {{with .Error}}<p role="alert">{{.}}</p>{{end}}
+    <form method="post" action="` + oidcAuthorizePath + `">
+      <input type="hidden" name="csrf_token" value="{{.CSRFToken}}">
+      {{range $name, $values := .Params}}<input type="hidden" name="{{$name}}" value="{{index $values 0}}">
+      {{end}}
+      {{- if eq .Step "login"}}
+      <label>Email <input type="email" name="email" autocomplete="username" required autofocus></label>
+      <label>Contraseña <input type="password" name="password" autocomplete="current-password" required></label>
+      <button type="submit">Iniciar sesión y autorizar</button>
+      {{- else if eq .Step "mfa"}}
+      <input type="hidden" name="mfa_challenge" value="{{.Challenge}}">
+      <label>Código de verificación <input name="code" autocomplete="one-time-code" required autofocus></label>
+      <button type="submit">Verificar y autorizar</button>
+      {{- else}}
+      <input type="hidden" name="consent" value="allow">
+      <button type="submit">Autorizar</button>
+      {{- end}}
+    </form>
+  </main>
+</body>
+</html>
+`))
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"user-management/internal/infrastructure/http/docs"
+)
+
+type openAPISpec struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Required   []string                   `json:"required"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadSpec(t *testing.T) openAPISpec {
+	raw, err := docs.Spec()
+	require.NoError(t, err)
+
+	var spec openAPISpec
+	require.NoError(t, json.Unmarshal(raw, &spec))
+	return spec
+}
+
+func TestOpenAPI_SchemasMatchRequestStructs(t *testing.T) {
+	spec := loadSpec(t)
+
+	tests := []struct {
+		schema string
+		value  any
+	}{
+		{"CreateUserRequest", createUserRequest{}},
+		{"UserDocument", userDocument{}},
+		{"LoginRequest", loginRequest{}},
+		{"VerifyEmailRequest", verifyEmailRequest{}},
+		{"ResendVerificationRequest", resendVerificationRequest{}},
+		{"ForgotPasswordRequest", forgotPasswordRequest{}},
+		{"ResetPasswordRequest", resetPasswordRequest{}},
+		{"ChangePasswordRequest", changePasswordRequest{}},
+		{"VerifyMFARequest", verifyMFARequest{}},
+		{"ConfirmMFARequest", confirmMFARequest{}},
+		{"DisableMFARequest", disableMFARequest{}},
+		{"CreateAPIKeyRequest", createAPIKeyRequest{}},
+		{"RefreshRequest", refreshRequest{}},
+		{"ImpersonateRequest", impersonateRequest{}},
+		{"CreateOAuthClientRequest", createOAuthClientRequest{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schema, func(t *testing.T) {
+			schema, ok := spec.Components.Schemas[tt.schema]
+			require.True(t, ok, "falta el esquema %s", tt.schema)
+
+			var properties, required []string
+			typ := reflect.TypeOf(tt.value)
+			for i := 0; i < typ.NumField(); i++ {
+				field := typ.Field(i)
+				name := jsonFieldName(field)
+				properties = append(properties, name)
+				if strings.Contains(field.Tag.Get("binding"), "required") {
+					required = append(required, name)
+				}
+			}
+
+			documented := make([]string, 0, len(schema.Properties))
+			for name := range schema.Properties {
+				documented = append(documented, name)
+			}
+			assert.ElementsMatch(t, properties, documented)
+			assert.ElementsMatch(t, required, schema.Required)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -8,9 +10,11 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
 	"user-management/internal/domain/entities"
 	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
 	"user-management/internal/domain/valueobjects"
 )
 
@@ -28,101 +32,262 @@ func (h *OrderHandler) RegisterRoutes(router *gin.RouterGroup) {
 	router.POST("/orders", h.CreateOrder)
 	router.GET("/orders/:id", h.GetOrder)
 	router.GET("/orders", h.ListOrders)
+	router.GET("/users/:id/orders", h.ListUserOrders)
 	router.POST("/orders/:id/cancel", h.CancelOrder)
 	router.GET("/orders/:id/stream", h.StreamOrderEvents) // Server-Sent Events
 }
 
-// CreateOrder con validación compleja
+// RegisterCurrentUserRoutes registra los pedidos del usuario autenticado;
+// router debe llevar AuthMiddleware
+func (h *OrderHandler) RegisterCurrentUserRoutes(router *gin.RouterGroup) {
+	router.GET("/me/orders", h.ListCurrentUserOrders)
+}
+
+// CreateOrder con validación compleja. El pedido es siempre del usuario
+// autenticado: el user_id del cuerpo se ignora.
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
 	var order entities.Order
 
 	if err := c.ShouldBindJSON(&order); err != nil {
-		ErrorResponse(c, http.StatusBadRequest, err)
+		HandleError(c, requestError(err))
 		return
 	}
+	order.UserID = int(userID.ID())
 
-	// Simular creación
-	order.ID = 1
-	order.Status = valueobjects.OrderStatus(entities.StatusProcessing)
+	if err := order.Validate(); err != nil {
+		HandleError(c, err)
+		return
+	}
 
-	// Procesar concurrentemente
-	go func(o entities.Order) {
-		// Procesamiento en background
-		// h.orderService.ProcessOrder(&o)
-	}(order)
+	created, err := h.orderService.PlaceOrder(c.Request.Context(), userID, order.Items)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusAccepted, Response{
 		Success: true,
-		Data:    order,
+		Data:    created,
 		Message: "Order processing started",
 	})
 }
 
 // GetOrder - Obtener pedido por ID
 func (h *OrderHandler) GetOrder(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
-
+	id, err := orderIDParam(c)
 	if err != nil {
-		ErrorResponse(c, http.StatusBadRequest, err)
+		HandleError(c, err)
 		return
 	}
 
-	// Mock búsqueda
-	order := &entities.Order{
-		ID:     id,
-		Total:  99.99,
-		Status: valueobjects.OrderStatus(entities.StatusCompleted),
+	order, err := h.findOrder(c, id)
+	if err != nil {
+		HandleError(c, err)
+		return
 	}
 
+	setETag(c, order.Version)
 	SuccessResponse(c, order)
 }
 
-// ListOrders - Listar pedidos con paginación
+// findOrder devuelve la orden id si quien llama es su dueño o un
+// administrador. A los demás se les responde que no existe, para no
+// desvelar qué IDs están en uso.
+func (h *OrderHandler) findOrder(c *gin.Context, id uuid.UUID) (*entities.Order, error) {
+	order, err := h.orderService.GetOrderByID(c.Request.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	if isAdmin(c) {
+		return order, nil
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != int(userID.ID()) {
+		return nil, fmt.Errorf("%w: %d", output.ErrOrderNotFound, order.ID)
+	}
+	return order, nil
+}
+
+// orderIDParam lee el ID numérico de la orden de la ruta. Los repositorios
+// indexan las órdenes por los primeros 32 bits de su UUID (id.ID()), así
+// que basta un UUID con esos bits para localizarla.
+func orderIDParam(c *gin.Context) (uuid.UUID, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return uuid.Nil, badRequest(err)
+	}
+	return numericUUID(uint32(id)), nil
+}
+
+// numericUUID construye un UUID cuyo id.ID() es id, para buscar entidades
+// de las que sólo se conoce el ID numérico
+func numericUUID(id uint32) uuid.UUID {
+	var u uuid.UUID
+	binary.BigEndian.PutUint32(u[:4], id)
+	return u
+}
+
+// ListOrders - Listar pedidos con filtros, orden y paginación por cursor.
+// Ejemplo: /orders?user_id=...&status=pending,processing&min_total=10&sort=-total&limit=20
+// Sólo los administradores ven los pedidos de otros: para los demás el
+// filtro user_id es siempre el suyo.
 func (h *OrderHandler) ListOrders(c *gin.Context) {
-	pageStr := c.DefaultQuery("page", "1")
-	limitStr := c.DefaultQuery("limit", "10")
+	query, err := orderQueryFromRequest(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	if !isAdmin(c) {
+		userID, err := currentUserID(c)
+		if err != nil {
+			HandleError(c, err)
+			return
+		}
+		query.Filter.UserID = &userID
+	}
 
-	page, _ := strconv.Atoi(pageStr)
-	limit, _ := strconv.Atoi(limitStr)
+	page, err := h.orderService.ListOrders(c.Request.Context(), query)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
 
-	// Mock listado
-	orders := []entities.Order{}
-	for i := range limit {
-		orderID := (page-1)*limit + i + 1
-		orders = append(orders, entities.Order{
-			ID:     orderID,
-			Total:  float64(20 * orderID),
-			Status: valueobjects.OrderStatus(entities.StatusCompleted),
-		})
+	PagedResponse(c, page.Orders, PageMeta{NextCursor: page.NextCursor, Total: page.Total})
+}
+
+// ListUserOrders - Pedidos de un usuario con los mismos filtros y paginación
+// que ListOrders, más el número de pedidos y el gasto acumulado del usuario
+func (h *OrderHandler) ListUserOrders(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		HandleError(c, badRequest(err))
+		return
+	}
+	if err := authorizeUser(c, userID); err != nil {
+		HandleError(c, err)
+		return
 	}
 
-	SuccessResponse(c, gin.H{
-		"page":   page,
-		"limit":  limit,
-		"orders": orders,
-	})
+	h.listUserOrders(c, userID)
+}
+
+// ListCurrentUserOrders lista, como ListUserOrders, los pedidos del usuario
+// autenticado
+func (h *OrderHandler) ListCurrentUserOrders(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	h.listUserOrders(c, userID)
+}
+
+func (h *OrderHandler) listUserOrders(c *gin.Context, userID uuid.UUID) {
+	query, err := orderQueryFromRequest(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	page, err := h.orderService.ListUserOrders(c.Request.Context(), userID, query)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	PagedResponse(c, gin.H{
+		"user_id":        userID,
+		"order_count":    page.Stats.OrderCount,
+		"lifetime_spend": page.Stats.LifetimeSpend,
+		"orders":         page.Orders,
+	}, PageMeta{NextCursor: page.NextCursor, Total: page.Total})
+}
+
+func orderQueryFromRequest(c *gin.Context) (output.OrderQuery, error) {
+	var (
+		query output.OrderQuery
+		err   error
+	)
+	f := &query.Filter
+
+	if f.UserID, err = queryUUID(c, "user_id"); err != nil {
+		return query, err
+	}
+	for _, status := range queryList(c, "status") {
+		f.Statuses = append(f.Statuses, valueobjects.OrderStatus(status))
+	}
+	if f.CreatedFrom, err = queryTime(c, "created_from"); err != nil {
+		return query, err
+	}
+	if f.CreatedTo, err = queryTime(c, "created_to"); err != nil {
+		return query, err
+	}
+	if f.CompletedFrom, err = queryTime(c, "completed_from"); err != nil {
+		return query, err
+	}
+	if f.CompletedTo, err = queryTime(c, "completed_to"); err != nil {
+		return query, err
+	}
+	if f.MinTotal, err = queryFloat(c, "min_total"); err != nil {
+		return query, err
+	}
+	if f.MaxTotal, err = queryFloat(c, "max_total"); err != nil {
+		return query, err
+	}
+
+	sort, desc := querySort(c)
+	query.Sort, query.Desc = output.OrderSortField(sort), desc
+
+	if query.Limit, err = queryLimit(c); err != nil {
+		return query, err
+	}
+	query.Cursor = c.Query("cursor")
+
+	return query, nil
 }
 
 // CancelOrder - Cancelar un pedido
 func (h *OrderHandler) CancelOrder(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
+	id, err := orderIDParam(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
 
+	order, err := h.findOrder(c, id)
 	if err != nil {
-		ErrorResponse(c, http.StatusBadRequest, err)
+		HandleError(c, err)
+		return
+	}
+
+	if !checkIfMatch(c, order.Version) {
 		return
 	}
 
-	// Simular cancelación
-	order := &entities.Order{
-		ID:     id,
-		Status: valueobjects.OrderStatus(entities.StatusCancelled),
+	cancelled, err := h.orderService.CancelOrder(c.Request.Context(), id, order.Version)
+	if err != nil {
+		if errors.Is(err, output.ErrConcurrentModification) && hasIfMatch(c) {
+			err = fmt.Errorf("%w: %v", ErrPreconditionFailed, err)
+		}
+		HandleError(c, err)
+		return
 	}
 
+	setETag(c, cancelled.Version)
 	SuccessResponse(c, gin.H{
-		"message": fmt.Sprintf("Order %d cancelled", id),
-		"order":   order,
+		"message": fmt.Sprintf("Order %d cancelled", cancelled.ID),
+		"order":   cancelled,
 	})
 }
 
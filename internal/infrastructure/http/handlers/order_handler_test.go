@@ -8,8 +8,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"user-management/internal/application/services"
 	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+	"user-management/internal/domain/valueobjects"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -53,9 +57,28 @@ func (m *MockOrderService) GetAllOrders(ctx context.Context) ([]*entities.Order,
 	return args.Get(0).([]*entities.Order), args.Error(1)
 }
 
-func (m *MockOrderService) CancelOrder(ctx context.Context, id uuid.UUID) error {
-	args := m.Called(ctx, id)
-	return args.Error(0)
+func (m *MockOrderService) ListOrders(ctx context.Context, query output.OrderQuery) (*output.OrderPage, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*output.OrderPage), args.Error(1)
+}
+
+func (m *MockOrderService) ListUserOrders(ctx context.Context, userID uuid.UUID, query output.OrderQuery) (*output.UserOrderPage, error) {
+	args := m.Called(ctx, userID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*output.UserOrderPage), args.Error(1)
+}
+
+func (m *MockOrderService) CancelOrder(ctx context.Context, id uuid.UUID, version int) (*entities.Order, error) {
+	args := m.Called(ctx, id, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Order), args.Error(1)
 }
 
 func (m *MockOrderService) UpdateOrderStatus(ctx context.Context, id uuid.UUID, status string) error {
@@ -104,6 +127,7 @@ func TestOrderHandler_RegisterRoutes(t *testing.T) {
 			"GET /api/orders",
 			"POST /api/orders/:id/cancel",
 			"GET /api/orders/:id/stream",
+			"GET /api/users/:id/orders",
 		}
 
 		for _, expected := range expectedRoutes {
@@ -112,6 +136,19 @@ func TestOrderHandler_RegisterRoutes(t *testing.T) {
 	})
 }
 
+// withPrincipal autentica las peticiones como principal
+func withPrincipal(principal *entities.Principal) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(entities.ContextWithPrincipal(c.Request.Context(), principal))
+	}
+}
+
+var (
+	adminPrincipal = &entities.Principal{Role: entities.RoleAdmin}
+	// customerPrincipal es un usuario normal cuyo ID numérico es 123
+	customerPrincipal = &entities.Principal{UserID: numericUUID(123), Role: entities.RoleUser}
+)
+
 func TestOrderHandler_CreateOrder(t *testing.T) {
 	t.Run("creates order successfully with validation", func(t *testing.T) {
 		// Setup
@@ -120,24 +157,37 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 
 		gin.SetMode(gin.TestMode)
 		router := gin.New()
+		router.Use(withPrincipal(customerPrincipal))
 		router.POST("/orders", handler.CreateOrder)
 
+		items := []entities.OrderItem{
+			{ProductID: 1, Quantity: 2, Price: 10.0},
+			{ProductID: 2, Quantity: 1, Price: 5.0},
+		}
+		mockService.On("PlaceOrder", mock.Anything, numericUUID(123), items).Return(&entities.Order{
+			ID:      7,
+			UserID:  123,
+			Items:   items,
+			Total:   25.0,
+			Status:  valueobjects.StatusPending,
+			Version: 1,
+		}, nil)
+
 		// Request body - usando la estructura que espera el handler
 		orderRequest := map[string]interface{}{
-			"UserID": 123,
-			"Items": []map[string]interface{}{
+			"items": []map[string]interface{}{
 				{
-					"ProductID": 1,
-					"Quantity":  2,
-					"Price":     10.0,
+					"product_id": 1,
+					"quantity":   2,
+					"price":      10.0,
 				},
 				{
-					"ProductID": 2,
-					"Quantity":  1,
-					"Price":     5.0,
+					"product_id": 2,
+					"quantity":   1,
+					"price":      5.0,
 				},
 			},
-			"Total": 25.0,
+			"total": 25.0,
 		}
 
 		body, _ := json.Marshal(orderRequest)
@@ -159,18 +209,32 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 		assert.True(t, response.Success)
 		assert.Equal(t, "Order processing started", response.Message)
 
-		// Verificar que los datos contienen una orden
-		// Gin serializa con campos en minúscula, no "ID" sino "id"
-		assert.NotNil(t, response.Data)
+		// La respuesta es la orden que guardó el servicio
+		assert.Equal(t, float64(7), response.Data["id"])
+		assert.Equal(t, "pending", response.Data["status"])
+		assert.Equal(t, 25.0, response.Data["total"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("returns not found when the user does not exist", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		handler := NewOrderHandler(mockService)
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(withPrincipal(customerPrincipal))
+		router.POST("/orders", handler.CreateOrder)
 
-		// Verificar que la orden tiene campos básicos
-		assert.Contains(t, response.Data, "id")
-		assert.Contains(t, response.Data, "status")
-		assert.Contains(t, response.Data, "items")
+		mockService.On("PlaceOrder", mock.Anything, numericUUID(123), mock.Anything).Return(nil, output.ErrUserNotFound)
 
-		// El handler asigna ID = 1
-		assert.Equal(t, float64(1), response.Data["id"])
-		assert.Equal(t, "processing", response.Data["status"])
+		body := `{"items":[{"product_id":1,"quantity":1,"price":10}]}`
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/orders", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
 
 	t.Run("returns bad request for invalid JSON", func(t *testing.T) {
@@ -179,6 +243,7 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 
 		gin.SetMode(gin.TestMode)
 		router := gin.New()
+		router.Use(withPrincipal(customerPrincipal))
 		router.POST("/orders", handler.CreateOrder)
 
 		w := httptest.NewRecorder()
@@ -189,12 +254,37 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 
-		var response map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
+		var problem Problem
+		err := json.Unmarshal(w.Body.Bytes(), &problem)
 		require.NoError(t, err)
 
-		assert.False(t, response["success"].(bool))
-		assert.Contains(t, response, "error")
+		assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, "bad_request", problem.Code)
+	})
+
+	t.Run("returns 422 with the invalid fields of the order", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		handler := NewOrderHandler(mockService)
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(withPrincipal(customerPrincipal))
+		router.POST("/orders", handler.CreateOrder)
+
+		body := `{"items":[{"product_id":1,"quantity":0,"price":-1}]}`
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/orders", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		var problem Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "invalid_order", problem.Code)
+		assert.Equal(t, "gt", problem.Errors["items[0].quantity"].Rule)
+		assert.Equal(t, "gte", problem.Errors["items[0].price"].Rule)
 	})
 
 	t.Run("handles empty request body", func(t *testing.T) {
@@ -203,6 +293,7 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 
 		gin.SetMode(gin.TestMode)
 		router := gin.New()
+		router.Use(withPrincipal(customerPrincipal))
 		router.POST("/orders", handler.CreateOrder)
 
 		w := httptest.NewRecorder()
@@ -222,41 +313,63 @@ func TestOrderHandler_GetOrder(t *testing.T) {
 
 		gin.SetMode(gin.TestMode)
 		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
 		router.GET("/orders/:id", handler.GetOrder)
 
 		orderID := 123
+		mockService.On("GetOrderByID", mock.Anything, numericUUID(uint32(orderID))).Return(&entities.Order{
+			ID:      orderID,
+			Items:   []entities.OrderItem{},
+			Total:   99.99,
+			Status:  valueobjects.StatusCompleted,
+			Version: 3,
+		}, nil)
 
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", fmt.Sprintf("/orders/%d", orderID), nil)
 
 		router.ServeHTTP(w, req)
 
-		// El handler actual siempre retorna éxito con datos mock
 		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
 
 		var response TestResponse
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		require.NoError(t, err)
 
 		assert.True(t, response.Success)
-
-		// Verificar estructura de datos - Gin usa minúsculas
-		assert.Contains(t, response.Data, "id")
-		assert.Contains(t, response.Data, "total")
-		assert.Contains(t, response.Data, "status")
-
-		// Verificar valores específicos del handler mock
 		assert.Equal(t, float64(orderID), response.Data["id"])
 		assert.Equal(t, 99.99, response.Data["total"])
 		assert.Equal(t, "completed", response.Data["status"])
 	})
 
+	t.Run("returns not found for unknown orders", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		handler := NewOrderHandler(mockService)
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
+		router.GET("/orders/:id", handler.GetOrder)
+
+		mockService.On("GetOrderByID", mock.Anything, mock.Anything).Return(nil, output.ErrOrderNotFound)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/orders/123", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, w.Header().Get("ETag"))
+	})
+
 	t.Run("returns bad request for non-numeric ID", func(t *testing.T) {
 		mockService := new(MockOrderService)
 		handler := NewOrderHandler(mockService)
 
 		gin.SetMode(gin.TestMode)
 		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
 		router.GET("/orders/:id", handler.GetOrder)
 
 		w := httptest.NewRecorder()
@@ -266,20 +379,21 @@ func TestOrderHandler_GetOrder(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 
-		var response map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
+		var problem Problem
+		err := json.Unmarshal(w.Body.Bytes(), &problem)
 		require.NoError(t, err)
 
-		assert.False(t, response["success"].(bool))
-		assert.Contains(t, response, "error")
+		assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, "bad_request", problem.Code)
 	})
 
-	t.Run("handles negative ID (strconv.Atoi lo acepta)", func(t *testing.T) {
+	t.Run("returns bad request for negative ID", func(t *testing.T) {
 		mockService := new(MockOrderService)
 		handler := NewOrderHandler(mockService)
 
 		gin.SetMode(gin.TestMode)
 		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
 		router.GET("/orders/:id", handler.GetOrder)
 
 		w := httptest.NewRecorder()
@@ -287,201 +401,411 @@ func TestOrderHandler_GetOrder(t *testing.T) {
 
 		router.ServeHTTP(w, req)
 
-		// strconv.Atoi acepta números negativos
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "GetOrderByID", mock.Anything, mock.Anything)
 	})
 }
 
 func TestOrderHandler_ListOrders(t *testing.T) {
-	t.Run("returns orders with default pagination", func(t *testing.T) {
-		mockService := new(MockOrderService)
-		handler := NewOrderHandler(mockService)
+	gin.SetMode(gin.TestMode)
 
-		gin.SetMode(gin.TestMode)
+	newRouter := func(service *MockOrderService) *gin.Engine {
 		router := gin.New()
-		router.GET("/orders", handler.ListOrders)
+		router.Use(withPrincipal(adminPrincipal))
+		router.GET("/orders", NewOrderHandler(service).ListOrders)
+		return router
+	}
+
+	t.Run("returns the page with its metadata", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		orders := []*entities.Order{
+			{ID: 1, Total: 20, Status: valueobjects.StatusCompleted},
+			{ID: 2, Total: 40, Status: valueobjects.StatusPending},
+		}
+		mockService.On("ListOrders", mock.Anything, output.OrderQuery{}).
+			Return(&output.OrderPage{Orders: orders, NextCursor: "next", Total: 5}, nil)
 
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/orders", nil)
-
-		router.ServeHTTP(w, req)
+		newRouter(mockService).ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
 
-		var response TestResponse
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		require.NoError(t, err)
+		var response struct {
+			Success bool            `json:"success"`
+			Data    []OrderResponse `json:"data"`
+			Meta    PageMeta        `json:"meta"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
 
 		assert.True(t, response.Success)
-		assert.NotNil(t, response.Data)
+		require.Len(t, response.Data, 2)
+		assert.Equal(t, 1, response.Data[0].ID)
+		assert.Equal(t, "completed", response.Data[0].Status)
+		assert.Equal(t, "next", response.Meta.NextCursor)
+		assert.Equal(t, 5, response.Meta.Total)
+		mockService.AssertExpectations(t)
+	})
 
-		// Verificar campos de paginación (Gin usa minúsculas)
-		assert.Contains(t, response.Data, "page")
-		assert.Contains(t, response.Data, "limit")
-		assert.Contains(t, response.Data, "orders")
-
-		// Valores por defecto
-		assert.Equal(t, float64(1), response.Data["page"])
-		assert.Equal(t, float64(10), response.Data["limit"])
-
-		// Verificar órdenes
-		if orders, ok := response.Data["orders"].([]interface{}); ok {
-			assert.Len(t, orders, 10)
-
-			// Verificar que cada orden tiene estructura básica
-			for i, orderInterface := range orders {
-				if order, ok := orderInterface.(map[string]interface{}); ok {
-					expectedID := i + 1
-					assert.Equal(t, float64(expectedID), order["id"])
-					assert.Equal(t, float64(20*expectedID), order["total"])
-					assert.Equal(t, "completed", order["status"])
-				}
-			}
+	t.Run("passes filters, sort and cursor to the service", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		userID := uuid.New()
+		mockService.On("ListOrders", mock.Anything, mock.MatchedBy(func(q output.OrderQuery) bool {
+			f := q.Filter
+			return *f.UserID == userID &&
+				assert.ObjectsAreEqual([]valueobjects.OrderStatus{"pending", "processing", "shipped"}, f.Statuses) &&
+				*f.MinTotal == 10 && *f.MaxTotal == 99.5 &&
+				f.CompletedFrom.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) &&
+				q.Sort == output.OrderSortTotal && q.Desc && q.Limit == 20 && q.Cursor == "abc"
+		})).Return(&output.OrderPage{Orders: []*entities.Order{}}, nil)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/orders?user_id="+userID.String()+
+			"&status=pending,processing&status=shipped&min_total=10&max_total=99.5"+
+			"&completed_from=2024-03-01&sort=-total&limit=20&cursor=abc", nil)
+		newRouter(mockService).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("rejects malformed parameters", func(t *testing.T) {
+		for _, query := range []string{
+			"?limit=xyz",
+			"?limit=0",
+			"?user_id=42",
+			"?min_total=cheap",
+			"?created_to=tomorrow",
+		} {
+			t.Run(query, func(t *testing.T) {
+				mockService := new(MockOrderService)
+
+				w := httptest.NewRecorder()
+				req, _ := http.NewRequest("GET", "/orders"+query, nil)
+				newRouter(mockService).ServeHTTP(w, req)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				mockService.AssertNotCalled(t, "ListOrders", mock.Anything, mock.Anything)
+			})
 		}
 	})
 
-	t.Run("returns orders with custom pagination", func(t *testing.T) {
-		mockService := new(MockOrderService)
-		handler := NewOrderHandler(mockService)
+	t.Run("maps invalid queries and cursors to 400", func(t *testing.T) {
+		for _, err := range []error{services.ErrInvalidQuery, output.ErrInvalidCursor} {
+			mockService := new(MockOrderService)
+			mockService.On("ListOrders", mock.Anything, mock.Anything).Return(nil, err)
 
-		gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/orders?status=lost", nil)
+			newRouter(mockService).ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestOrderHandler_ListUserOrders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(service *MockOrderService) *gin.Engine {
 		router := gin.New()
-		router.GET("/orders", handler.ListOrders)
+		router.Use(func(c *gin.Context) {
+			principal := &entities.Principal{Role: entities.RoleAdmin}
+			c.Request = c.Request.WithContext(entities.ContextWithPrincipal(c.Request.Context(), principal))
+		})
+		router.GET("/users/:id/orders", NewOrderHandler(service).ListUserOrders)
+		return router
+	}
 
-		w := httptest.NewRecorder()
-		req, _ := http.NewRequest("GET", "/orders?page=2&limit=3", nil)
+	t.Run("returns orders with the user aggregates", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		userID := uuid.New()
+		mockService.On("ListUserOrders", mock.Anything, userID, mock.MatchedBy(func(q output.OrderQuery) bool {
+			return q.Limit == 1 && len(q.Filter.Statuses) == 1
+		})).Return(&output.UserOrderPage{
+			OrderPage: output.OrderPage{
+				Orders:     []*entities.Order{{ID: 7, Total: 30, Status: valueobjects.StatusCompleted}},
+				NextCursor: "next",
+				Total:      2,
+			},
+			Stats: output.OrderStats{OrderCount: 3, LifetimeSpend: 80},
+		}, nil)
 
-		router.ServeHTTP(w, req)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/users/"+userID.String()+"/orders?status=completed&limit=1", nil)
+		newRouter(mockService).ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
 
-		var response TestResponse
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		require.NoError(t, err)
+		var response struct {
+			Data struct {
+				UserID        uuid.UUID       `json:"user_id"`
+				OrderCount    int             `json:"order_count"`
+				LifetimeSpend float64         `json:"lifetime_spend"`
+				Orders        []OrderResponse `json:"orders"`
+			} `json:"data"`
+			Meta PageMeta `json:"meta"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		assert.Equal(t, userID, response.Data.UserID)
+		assert.Equal(t, 3, response.Data.OrderCount)
+		assert.Equal(t, 80.0, response.Data.LifetimeSpend)
+		require.Len(t, response.Data.Orders, 1)
+		assert.Equal(t, 7, response.Data.Orders[0].ID)
+		assert.Equal(t, PageMeta{NextCursor: "next", Total: 2}, response.Meta)
+	})
 
-		assert.True(t, response.Success)
+	t.Run("returns 404 for unknown users", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		userID := uuid.New()
+		mockService.On("ListUserOrders", mock.Anything, userID, mock.Anything).
+			Return(nil, output.ErrUserNotFound)
 
-		if response.Data != nil {
-			// Verificar paginación personalizada
-			assert.Equal(t, float64(2), response.Data["page"])
-			assert.Equal(t, float64(3), response.Data["limit"])
-
-			// Verificar órdenes (cálculo: (page-1)*limit + i + 1)
-			if orders, ok := response.Data["orders"].([]interface{}); ok {
-				assert.Len(t, orders, 3)
-
-				// IDs esperados: (2-1)*3 + 1 = 4, 5, 6
-				expectedIDs := []int{4, 5, 6}
-				for i, orderInterface := range orders {
-					if order, ok := orderInterface.(map[string]interface{}); ok {
-						assert.Equal(t, float64(expectedIDs[i]), order["id"])
-					}
-				}
-			}
-		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/users/"+userID.String()+"/orders", nil)
+		newRouter(mockService).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
 
-	t.Run("handles invalid pagination parameters gracefully", func(t *testing.T) {
+	t.Run("returns 400 for invalid user IDs", func(t *testing.T) {
 		mockService := new(MockOrderService)
-		handler := NewOrderHandler(mockService)
 
-		gin.SetMode(gin.TestMode)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/users/42/orders", nil)
+		newRouter(mockService).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ListUserOrders", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrderHandler_ListUserOrders_Ownership(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockOrderService)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		principal := &entities.Principal{UserID: uuid.New(), Role: entities.RoleUser}
+		c.Request = c.Request.WithContext(entities.ContextWithPrincipal(c.Request.Context(), principal))
+	})
+	router.GET("/users/:id/orders", NewOrderHandler(mockService).ListUserOrders)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/"+uuid.NewString()+"/orders", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertNotCalled(t, "ListUserOrders", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOrderHandler_OrderOwnership(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(service *MockOrderService, principal *entities.Principal) *gin.Engine {
 		router := gin.New()
-		router.GET("/orders", handler.ListOrders)
+		router.Use(withPrincipal(principal))
+		NewOrderHandler(service).RegisterRoutes(router.Group(""))
+		return router
+	}
+	// La orden 7 es de otro usuario (ID numérico 456)
+	foreign := &entities.Order{ID: 7, UserID: 456, Status: valueobjects.StatusPending, Version: 1}
 
-		testCases := []struct {
-			name   string
-			query  string
-			assert func(t *testing.T, response TestResponse)
-		}{
-			{
-				name:  "negative page",
-				query: "?page=-1&limit=5",
-				assert: func(t *testing.T, response TestResponse) {
-					if response.Data != nil {
-						// strconv.Atoi devuelve -1 para "-1"
-						assert.Equal(t, float64(-1), response.Data["page"])
-					}
-				},
-			},
-			{
-				name:  "zero limit",
-				query: "?page=1&limit=0",
-				assert: func(t *testing.T, response TestResponse) {
-					if response.Data != nil {
-						// strconv.Atoi devuelve 0 para "0"
-						assert.Equal(t, float64(0), response.Data["limit"])
-					}
-				},
-			},
-			{
-				name:  "non-numeric values",
-				query: "?page=abc&limit=xyz",
-				assert: func(t *testing.T, response TestResponse) {
-					if response.Data != nil {
-						// strconv.Atoi devuelve 0 para strings no numéricas
-						assert.Equal(t, float64(0), response.Data["page"])
-						assert.Equal(t, float64(0), response.Data["limit"])
-					}
-				},
-			},
+	t.Run("hides other users' orders as not found", func(t *testing.T) {
+		for _, req := range []*http.Request{
+			httptest.NewRequest(http.MethodGet, "/orders/7", nil),
+			httptest.NewRequest(http.MethodPost, "/orders/7/cancel", nil),
+		} {
+			mockService := new(MockOrderService)
+			mockService.On("GetOrderByID", mock.Anything, numericUUID(7)).Return(foreign, nil)
+
+			w := httptest.NewRecorder()
+			newRouter(mockService, customerPrincipal).ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNotFound, w.Code, req.URL.Path)
+			assert.Empty(t, w.Header().Get("ETag"))
+			mockService.AssertNotCalled(t, "CancelOrder", mock.Anything, mock.Anything, mock.Anything)
 		}
+	})
 
-		for _, tc := range testCases {
-			t.Run(tc.name, func(t *testing.T) {
-				w := httptest.NewRecorder()
-				req, _ := http.NewRequest("GET", "/orders"+tc.query, nil)
+	t.Run("admins see any order", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		mockService.On("GetOrderByID", mock.Anything, numericUUID(7)).Return(foreign, nil)
 
-				router.ServeHTTP(w, req)
+		w := httptest.NewRecorder()
+		newRouter(mockService, adminPrincipal).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/7", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
 
-				assert.Equal(t, http.StatusOK, w.Code)
+	t.Run("lists only the caller's orders whatever user_id says", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		mockService.On("ListOrders", mock.Anything, mock.MatchedBy(func(q output.OrderQuery) bool {
+			return q.Filter.UserID != nil && *q.Filter.UserID == customerPrincipal.UserID
+		})).Return(&output.OrderPage{Orders: []*entities.Order{}}, nil)
 
-				var response TestResponse
-				err := json.Unmarshal(w.Body.Bytes(), &response)
-				require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/orders?user_id="+uuid.NewString(), nil)
+		newRouter(mockService, customerPrincipal).ServeHTTP(w, req)
 
-				assert.True(t, response.Success)
-				tc.assert(t, response)
-			})
-		}
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("places orders for the caller, not the user_id in the body", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		mockService.On("PlaceOrder", mock.Anything, customerPrincipal.UserID, mock.Anything).
+			Return(&entities.Order{ID: 8, UserID: 123, Version: 1}, nil)
+
+		body := `{"user_id":456,"items":[{"product_id":1,"quantity":1,"price":10}]}`
+		req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		newRouter(mockService, customerPrincipal).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("placing an order needs a user", func(t *testing.T) {
+		mockService := new(MockOrderService)
+
+		body := `{"items":[{"product_id":1,"quantity":1,"price":10}]}`
+		req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		newRouter(mockService, adminPrincipal).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockService.AssertNotCalled(t, "PlaceOrder", mock.Anything, mock.Anything, mock.Anything)
 	})
 }
 
-func TestOrderHandler_CancelOrder(t *testing.T) {
-	t.Run("cancels order successfully", func(t *testing.T) {
+func TestOrderHandler_ListCurrentUserOrders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(service *MockOrderService, principal *entities.Principal) *gin.Engine {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Request = c.Request.WithContext(entities.ContextWithPrincipal(c.Request.Context(), principal))
+		})
+		NewOrderHandler(service).RegisterCurrentUserRoutes(router.Group("/"))
+		return router
+	}
+
+	t.Run("lists the orders of the authenticated user", func(t *testing.T) {
 		mockService := new(MockOrderService)
-		handler := NewOrderHandler(mockService)
+		userID := uuid.New()
+		mockService.On("ListUserOrders", mock.Anything, userID, mock.MatchedBy(func(q output.OrderQuery) bool {
+			return q.Limit == 5
+		})).Return(&output.UserOrderPage{Stats: output.OrderStats{OrderCount: 2}}, nil)
 
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/me/orders?limit=5", nil)
+		newRouter(mockService, &entities.Principal{UserID: userID, Role: entities.RoleUser}).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("credentials without a user get 401", func(t *testing.T) {
+		mockService := new(MockOrderService)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/me/orders", nil)
+		newRouter(mockService, &entities.Principal{Role: entities.RoleAdmin}).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockService.AssertNotCalled(t, "ListUserOrders", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrderHandler_CancelOrder(t *testing.T) {
+	newRouter := func(service *MockOrderService) *gin.Engine {
 		gin.SetMode(gin.TestMode)
 		router := gin.New()
-		router.POST("/orders/:id/cancel", handler.CancelOrder)
+		router.Use(withPrincipal(adminPrincipal))
+		router.POST("/orders/:id/cancel", NewOrderHandler(service).CancelOrder)
+		return router
+	}
 
-		orderID := 456
+	orderID := 456
+	pending := func() *entities.Order {
+		return &entities.Order{ID: orderID, Items: []entities.OrderItem{}, Status: valueobjects.StatusPending, Version: 2}
+	}
+
+	t.Run("cancels order successfully", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		mockService.On("GetOrderByID", mock.Anything, numericUUID(uint32(orderID))).Return(pending(), nil)
+		mockService.On("CancelOrder", mock.Anything, numericUUID(uint32(orderID)), 2).Return(&entities.Order{
+			ID:      orderID,
+			Items:   []entities.OrderItem{},
+			Status:  valueobjects.StatusCancelled,
+			Version: 3,
+		}, nil)
 
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("POST", fmt.Sprintf("/orders/%d/cancel", orderID), nil)
 
-		router.ServeHTTP(w, req)
+		newRouter(mockService).ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
 
 		var response TestResponse
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		require.NoError(t, err)
 
 		assert.True(t, response.Success)
-		// El handler actual no usa "success" en el message field
-		// assert.Equal(t, "success", response.Message)
-
-		// Verificar datos de respuesta
-		assert.NotNil(t, response.Data)
 		assert.Contains(t, response.Data, "message")
-		assert.Contains(t, response.Data, "order")
+		require.Contains(t, response.Data, "order")
 
-		if order, ok := response.Data["order"].(map[string]interface{}); ok {
-			assert.Equal(t, float64(orderID), order["id"])
-			assert.Equal(t, "cancelled", order["status"])
-		}
+		order := response.Data["order"].(map[string]interface{})
+		assert.Equal(t, float64(orderID), order["id"])
+		assert.Equal(t, "cancelled", order["status"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("returns 412 when If-Match does not match the current version", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		mockService.On("GetOrderByID", mock.Anything, numericUUID(uint32(orderID))).Return(pending(), nil)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/orders/%d/cancel", orderID), nil)
+		req.Header.Set("If-Match", `"1"`)
+
+		newRouter(mockService).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+		mockService.AssertNotCalled(t, "CancelOrder", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns 412 when the order changes after the If-Match check", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		mockService.On("GetOrderByID", mock.Anything, numericUUID(uint32(orderID))).Return(pending(), nil)
+		mockService.On("CancelOrder", mock.Anything, numericUUID(uint32(orderID)), 2).Return(nil, output.ErrConcurrentModification)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/orders/%d/cancel", orderID), nil)
+		req.Header.Set("If-Match", `"2"`)
+
+		newRouter(mockService).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	})
+
+	t.Run("returns not found for unknown orders", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		mockService.On("GetOrderByID", mock.Anything, mock.Anything).Return(nil, output.ErrOrderNotFound)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/orders/%d/cancel", orderID), nil)
+
+		newRouter(mockService).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
 
 	t.Run("returns bad request for invalid ID", func(t *testing.T) {
@@ -490,6 +814,7 @@ func TestOrderHandler_CancelOrder(t *testing.T) {
 
 		gin.SetMode(gin.TestMode)
 		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
 		router.POST("/orders/:id/cancel", handler.CancelOrder)
 
 		w := httptest.NewRecorder()
@@ -499,12 +824,12 @@ func TestOrderHandler_CancelOrder(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 
-		var response map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
+		var problem Problem
+		err := json.Unmarshal(w.Body.Bytes(), &problem)
 		require.NoError(t, err)
 
-		assert.False(t, response["success"].(bool))
-		assert.Contains(t, response, "error")
+		assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, "bad_request", problem.Code)
 	})
 }
 
@@ -558,37 +883,17 @@ func TestResponseHelpers(t *testing.T) {
 		// assert.Equal(t, "success", response.Message)
 		assert.NotNil(t, response.Data)
 	})
-
-	t.Run("ErrorResponse returns JSON with error structure", func(t *testing.T) {
-		gin.SetMode(gin.TestMode)
-		w := httptest.NewRecorder()
-		c, _ := gin.CreateTestContext(w)
-
-		testError := fmt.Errorf("something went wrong")
-
-		ErrorResponse(c, http.StatusBadRequest, testError)
-
-		assert.Equal(t, http.StatusBadRequest, w.Code)
-		assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
-
-		var response map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		require.NoError(t, err)
-
-		assert.False(t, response["success"].(bool))
-		assert.Contains(t, response, "error")
-		assert.Equal(t, testError.Error(), response["error"])
-	})
 }
 
 // Tests de edge cases y validaciones
 func TestOrderHandler_EdgeCases(t *testing.T) {
-	t.Run("handles very large order ID", func(t *testing.T) {
+	t.Run("rejects order IDs out of range", func(t *testing.T) {
 		mockService := new(MockOrderService)
 		handler := NewOrderHandler(mockService)
 
 		gin.SetMode(gin.TestMode)
 		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
 		router.GET("/orders/:id", handler.GetOrder)
 
 		largeID := 999999999999
@@ -598,16 +903,18 @@ func TestOrderHandler_EdgeCases(t *testing.T) {
 
 		router.ServeHTTP(w, req)
 
-		// Verificar que no hay panic
-		assert.Equal(t, http.StatusOK, w.Code)
+		// Los IDs de orden caben en 32 bits
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 
 	t.Run("handles concurrent requests", func(t *testing.T) {
 		mockService := new(MockOrderService)
+		mockService.On("GetOrderByID", mock.Anything, mock.Anything).Return(&entities.Order{Version: 1}, nil)
 		handler := NewOrderHandler(mockService)
 
 		gin.SetMode(gin.TestMode)
 		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
 		router.GET("/orders/:id", handler.GetOrder)
 
 		const concurrentRequests = 5
@@ -641,9 +948,11 @@ func BenchmarkOrderHandler(b *testing.B) {
 
 	b.Run("GetOrder endpoint", func(b *testing.B) {
 		mockService := new(MockOrderService)
+		mockService.On("GetOrderByID", mock.Anything, mock.Anything).Return(&entities.Order{Version: 1}, nil)
 		handler := NewOrderHandler(mockService)
 
 		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
 		router.GET("/orders/:id", handler.GetOrder)
 
 		b.ResetTimer()
@@ -656,21 +965,24 @@ func BenchmarkOrderHandler(b *testing.B) {
 
 	b.Run("ListOrders endpoint with pagination", func(b *testing.B) {
 		mockService := new(MockOrderService)
+		mockService.On("ListOrders", mock.Anything, mock.Anything).
+			Return(&output.OrderPage{Orders: []*entities.Order{}}, nil)
 		handler := NewOrderHandler(mockService)
 
 		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
 		router.GET("/orders", handler.ListOrders)
 
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			w := httptest.NewRecorder()
-			req, _ := http.NewRequest("GET", "/orders?page=1&limit=20", nil)
+			req, _ := http.NewRequest("GET", "/orders?limit=20", nil)
 			router.ServeHTTP(w, req)
 		}
 	})
 }
 
-// Test simplificado para test de integración (que falló porque el handler no usa realmente el service)
+// Test simplificado para test de integración
 func TestOrderHandler_IntegrationWithRealService(t *testing.T) {
 	t.Run("handler can be instantiated with service", func(t *testing.T) {
 		mockService := new(MockOrderService)
@@ -679,8 +991,5 @@ func TestOrderHandler_IntegrationWithRealService(t *testing.T) {
 		// Solo verificar que se puede crear el handler
 		assert.NotNil(t, handler)
 		assert.IsType(t, &OrderHandler{}, handler)
-
-		// El handler actual no usa el servicio, pero la interfaz está ahí
-		// para futuras implementaciones
 	})
 }
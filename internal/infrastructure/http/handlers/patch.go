@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"user-management/internal/domain/errs"
+	"user-management/pkg/jsonpatch"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+var (
+	ErrUnsupportedPatch   = errs.New(errs.Unsupported, "unsupported_patch_format", "PATCH requires application/merge-patch+json or application/json-patch+json")
+	ErrInvalidPatch       = errs.New(errs.BadRequest, "invalid_patch", "invalid patch document")
+	ErrPatchTestFailed    = errs.New(errs.Conflict, "patch_test_failed", "patch test operation failed")
+	ErrPatchNotApplicable = errs.New(errs.Validation, "patch_not_applicable", "patch cannot be applied to the resource")
+)
+
+// patchError traduce los errores de jsonpatch: un parche mal formado es 400,
+// un test que no se cumple 409 y el resto (rutas inexistentes, campos que el
+// recurso no tiene) 422
+func patchError(err error) error {
+	base := ErrPatchNotApplicable
+	switch {
+	case errors.Is(err, jsonpatch.ErrInvalidPatch):
+		base = ErrInvalidPatch
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		base = ErrPatchTestFailed
+	}
+	return fmt.Errorf("%w: %v", base, err)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"user-management/internal/domain/errs"
+	"user-management/internal/infrastructure/i18n"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem es el cuerpo de error definido por RFC 7807. Code es estable y es
+// lo que deben comparar los clientes; Detail puede cambiar. Errors detalla
+// por campo los errores de validación.
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Code     string      `json:"code"`
+	Errors   errs.Fields `json:"errors,omitempty"`
+}
+
+// statusByKind es la única tabla que traduce clases de error a estados HTTP
+var statusByKind = map[errs.Kind]int{
+	errs.BadRequest:   http.StatusBadRequest,
+	errs.Validation:   http.StatusUnprocessableEntity,
+	errs.NotFound:     http.StatusNotFound,
+	errs.Conflict:     http.StatusConflict,
+	errs.Precondition: http.StatusPreconditionFailed,
+	errs.Unauthorized: http.StatusUnauthorized,
+	errs.Forbidden:    http.StatusForbidden,
+	errs.Unavailable:  http.StatusServiceUnavailable,
+	errs.Unsupported:  http.StatusUnsupportedMediaType,
+	errs.RateLimited:  http.StatusTooManyRequests,
+	errs.Internal:     http.StatusInternalServerError,
+}
+
+var (
+	// ErrBadRequest envuelve los errores de formato de la petición (JSON mal
+	// formado, parámetros que no se pueden interpretar)
+	ErrBadRequest = errs.New(errs.BadRequest, "bad_request", "bad request")
+
+	errTimeout = errs.New(errs.Unavailable, "timeout", "request timed out or was cancelled")
+)
+
+// HandleError responde con el problem+json correspondiente a la clase de
+// err, en el idioma que pida Accept-Language. Los errores no tipados se
+// tratan como internos: se registran y su mensaje no se expone al cliente.
+func HandleError(c *gin.Context, err error) {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		err = errTimeout
+	}
+
+	e, ok := errs.As(err)
+	if !ok || e.Kind == errs.Internal {
+		log.Printf("error interno en %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		writeProblem(c, Problem{Status: http.StatusInternalServerError, Code: "internal_error"})
+		return
+	}
+
+	if after, ok := errs.RetryAfter(err); ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(after.Seconds()))))
+	}
+
+	locale := requestLocale(c)
+	problem := Problem{Status: statusByKind[e.Kind], Code: e.Code, Detail: errs.Localize(err, locale)}
+	if ve, ok := errs.AsValidation(err); ok {
+		problem.Errors = ve.Fields.Localize(locale)
+	}
+	writeProblem(c, problem)
+}
+
+// badRequest marca err como error de formato de la petición
+func badRequest(err error) error {
+	return fmt.Errorf("%w: %v", ErrBadRequest, err)
+}
+
+func writeProblem(c *gin.Context, problem Problem) {
+	locale := requestLocale(c)
+	problem.Type = "/problems/" + problem.Code
+	problem.Title = locale.Translate(http.StatusText(problem.Status))
+	problem.Instance = c.Request.URL.Path
+
+	c.Header("Content-Type", problemContentType)
+	c.Header("Content-Language", string(locale))
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// requestLocale negocia el idioma de la respuesta con Accept-Language
+func requestLocale(c *gin.Context) i18n.Locale {
+	return i18n.Negotiate(c.GetHeader("Accept-Language"))
+}
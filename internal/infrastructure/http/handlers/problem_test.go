@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/output"
+)
+
+func serveError(t *testing.T, err error) (*httptest.ResponseRecorder, Problem) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/resource", func(c *gin.Context) { HandleError(c, err) })
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/resource", nil)
+	router.ServeHTTP(w, req)
+
+	var problem Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	return w, problem
+}
+
+func TestHandleError(t *testing.T) {
+	t.Run("maps each kind to its status", func(t *testing.T) {
+		cases := []struct {
+			err    error
+			status int
+		}{
+			{badRequest(errors.New("bad json")), http.StatusBadRequest},
+			{errs.Fields{"name": {Rule: "required"}}.Err(ErrInvalidRequest), http.StatusUnprocessableEntity},
+			{fmt.Errorf("%w: 42", output.ErrUserNotFound), http.StatusNotFound},
+			{output.ErrEmailAlreadyExists, http.StatusConflict},
+			{ErrPreconditionFailed, http.StatusPreconditionFailed},
+			{errs.New(errs.Unauthorized, "unauthorized", "no"), http.StatusUnauthorized},
+			{errs.New(errs.Forbidden, "forbidden", "no"), http.StatusForbidden},
+			{context.DeadlineExceeded, http.StatusServiceUnavailable},
+		}
+		for _, tc := range cases {
+			w, problem := serveError(t, tc.err)
+			assert.Equal(t, tc.status, w.Code, tc.err.Error())
+			assert.Equal(t, tc.status, problem.Status)
+		}
+	})
+
+	t.Run("writes an RFC 7807 body", func(t *testing.T) {
+		w, problem := serveError(t, fmt.Errorf("%w: 42", output.ErrUserNotFound))
+
+		assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, "user_not_found", problem.Code)
+		assert.Equal(t, "/problems/user_not_found", problem.Type)
+		assert.Equal(t, "Not Found", problem.Title)
+		assert.Equal(t, "/resource", problem.Instance)
+		assert.Contains(t, problem.Detail, "42")
+	})
+
+	t.Run("lists the invalid fields of validation errors", func(t *testing.T) {
+		fields := errs.Fields{}
+		fields.Add("email", "email", "email must be a valid email address")
+
+		_, problem := serveError(t, fields.Err(ErrInvalidRequest))
+
+		assert.Equal(t, "validation_failed", problem.Code)
+		assert.Equal(t, fields, problem.Errors)
+	})
+
+	t.Run("renders messages in the requested language", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.GET("/resource", func(c *gin.Context) {
+			fields := errs.Fields{}
+			fields.Add("name", "min", "%s must be at least %s characters", "name", "3")
+			HandleError(c, fields.Err(ErrInvalidRequest))
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/resource", nil)
+		req.Header.Set("Accept-Language", "es-ES,es;q=0.9,en;q=0.8")
+		router.ServeHTTP(w, req)
+
+		var problem Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "es", w.Header().Get("Content-Language"))
+		assert.Equal(t, "validation_failed", problem.Code)
+		assert.Equal(t, "Entidad no procesable", problem.Title)
+		assert.Equal(t, "la petición no supera la validación: name debe tener al menos 3 caracteres", problem.Detail)
+		assert.Equal(t, "name debe tener al menos 3 caracteres", problem.Errors["name"].Message)
+		assert.Equal(t, "min", problem.Errors["name"].Rule)
+	})
+
+	t.Run("translates the typed part of wrapped errors", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.GET("/resource", func(c *gin.Context) {
+			HandleError(c, fmt.Errorf("%w: 42", output.ErrUserNotFound))
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/resource", nil)
+		req.Header.Set("Accept-Language", "es")
+		router.ServeHTTP(w, req)
+
+		var problem Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "usuario no encontrado: 42", problem.Detail)
+		assert.Equal(t, "user_not_found", problem.Code)
+	})
+
+	t.Run("hides the detail of untyped errors", func(t *testing.T) {
+		w, problem := serveError(t, errors.New("connection string user:secret"))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "internal_error", problem.Code)
+		assert.Empty(t, problem.Detail)
+	})
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"user-management/internal/domain/errs"
+)
+
+// Los helpers de este fichero leen parámetros opcionales de la query string.
+// Devuelven nil si el parámetro no está y un ErrBadRequest si está mal
+// formado, para que los handlers respondan 400 en lugar de ignorarlo.
+
+func queryInt(c *gin.Context, name string) (*int, error) {
+	raw, ok := c.GetQuery(name)
+	if !ok || raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, errs.Errorf(ErrBadRequest, "%s must be an integer", name)
+	}
+	return &value, nil
+}
+
+func queryFloat(c *gin.Context, name string) (*float64, error) {
+	raw, ok := c.GetQuery(name)
+	if !ok || raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, errs.Errorf(ErrBadRequest, "%s must be a number", name)
+	}
+	return &value, nil
+}
+
+func queryBool(c *gin.Context, name string) (*bool, error) {
+	raw, ok := c.GetQuery(name)
+	if !ok || raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, errs.Errorf(ErrBadRequest, "%s must be true or false", name)
+	}
+	return &value, nil
+}
+
+// queryTime acepta fechas RFC 3339 o sólo el día (YYYY-MM-DD, en UTC)
+func queryTime(c *gin.Context, name string) (*time.Time, error) {
+	raw, ok := c.GetQuery(name)
+	if !ok || raw == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if value, err := time.Parse(layout, raw); err == nil {
+			return &value, nil
+		}
+	}
+	return nil, errs.Errorf(ErrBadRequest, "%s must be an RFC 3339 date", name)
+}
+
+func queryUUID(c *gin.Context, name string) (*uuid.UUID, error) {
+	raw, ok := c.GetQuery(name)
+	if !ok || raw == "" {
+		return nil, nil
+	}
+	value, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, errs.Errorf(ErrBadRequest, "%s must be a UUID", name)
+	}
+	return &value, nil
+}
+
+// queryList acepta el parámetro repetido (?status=a&status=b) o separado por
+// comas (?status=a,b)
+func queryList(c *gin.Context, name string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(name) {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// queryLimit lee ?limit; 0 indica que no se especificó
+func queryLimit(c *gin.Context) (int, error) {
+	limit, err := queryInt(c, "limit")
+	if err != nil || limit == nil {
+		return 0, err
+	}
+	if *limit <= 0 {
+		return 0, errs.Errorf(ErrBadRequest, "limit must be positive")
+	}
+	return *limit, nil
+}
+
+// querySort lee ?sort=campo o ?sort=-campo (descendente)
+func querySort(c *gin.Context) (field string, desc bool) {
+	sort := c.Query("sort")
+	if rest, ok := strings.CutPrefix(sort, "-"); ok {
+		return rest, true
+	}
+	return sort, false
+}
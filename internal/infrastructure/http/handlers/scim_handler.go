@@ -0,0 +1,600 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/pkg/scim"
+)
+
+const scimContentType = "application/scim+json"
+
+// Tamaño de página de los listados SCIM cuando no se pide count y máximo
+// que se admite, el mismo que el del servicio (services.MaxPageSize)
+const (
+	scimDefaultCount = 100
+	scimMaxCount     = 100
+)
+
+var (
+	ErrInvalidSCIMFilter = errs.New(errs.BadRequest, "invalid_filter", "invalid SCIM filter")
+	ErrInvalidSCIMPath   = errs.New(errs.BadRequest, "invalid_path", "invalid SCIM attribute path")
+	ErrInvalidSCIMSyntax = errs.New(errs.BadRequest, "invalid_syntax", "invalid SCIM request")
+	ErrInvalidSCIMValue  = errs.New(errs.BadRequest, "invalid_value", "invalid SCIM attribute value")
+	ErrSCIMNoTarget      = errs.New(errs.BadRequest, "no_target", "SCIM path did not match any value")
+)
+
+// scimTypes traduce los códigos de error al scimType de RFC 7644, sección
+// 3.12
+var scimTypes = map[string]string{
+	ErrInvalidSCIMFilter.Code:         "invalidFilter",
+	ErrInvalidSCIMPath.Code:           "invalidPath",
+	ErrInvalidSCIMSyntax.Code:         "invalidSyntax",
+	ErrInvalidSCIMValue.Code:          "invalidValue",
+	ErrSCIMNoTarget.Code:              "noTarget",
+	entities.ErrInvalidUser.Code:      "invalidValue",
+	output.ErrEmailAlreadyExists.Code: "uniqueness",
+}
+
+// scimError es el cuerpo de error de SCIM (RFC 7644, sección 3.12)
+type scimError struct {
+	Schemas  []string `json:"schemas"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+	Status   string   `json:"status"`
+}
+
+// HandleSCIMError responde con el error SCIM correspondiente a err. Sigue
+// las reglas de HandleError salvo que los errores de validación son 400,
+// como pide SCIM, en lugar de 422.
+func HandleSCIMError(c *gin.Context, err error) {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		err = errTimeout
+	}
+
+	locale := requestLocale(c)
+	body := scimError{Schemas: []string{scim.ErrorSchema}}
+	status := http.StatusInternalServerError
+	if e, ok := errs.As(err); ok && e.Kind != errs.Internal {
+		if e.Kind != errs.Validation {
+			status = statusByKind[e.Kind]
+		} else {
+			status = http.StatusBadRequest
+		}
+		body.ScimType = scimTypes[e.Code]
+		body.Detail = errs.Localize(err, locale)
+	} else {
+		log.Printf("error interno en %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		body.Detail = locale.Translate(http.StatusText(status))
+	}
+	body.Status = strconv.Itoa(status)
+
+	c.Header("Content-Type", scimContentType)
+	c.Header("Content-Language", string(locale))
+	c.AbortWithStatusJSON(status, body)
+}
+
+// scimRequestError traduce los errores de pkg/scim a su scimType; el resto
+// de fallos al leer el mensaje son invalidSyntax
+func scimRequestError(err error) error {
+	base := ErrInvalidSCIMSyntax
+	switch {
+	case errors.Is(err, scim.ErrInvalidFilter):
+		base = ErrInvalidSCIMFilter
+	case errors.Is(err, scim.ErrInvalidPath):
+		base = ErrInvalidSCIMPath
+	case errors.Is(err, scim.ErrInvalidValue):
+		base = ErrInvalidSCIMValue
+	case errors.Is(err, scim.ErrNoTarget):
+		base = ErrSCIMNoTarget
+	}
+	return fmt.Errorf("%w: %v", base, err)
+}
+
+// scimUser es la representación SCIM de entities.User (RFC 7643, sección
+// 4.1). userName y el email principal son el email del usuario; el nombre
+// se publica completo en name.formatted y displayName y partido en
+// givenName y familyName. Password sólo se escribe.
+type scimUser struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	UserName    string      `json:"userName"`
+	Name        *scimName   `json:"name,omitempty"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Emails      []scimEmail `json:"emails,omitempty"`
+	Active      *bool       `json:"active,omitempty"`
+	Password    string      `json:"password,omitempty"`
+	Meta        *scimMeta   `json:"meta,omitempty"`
+}
+
+type scimName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type scimEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type scimMeta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+	Version      string    `json:"version"`
+}
+
+// scimListResponse es la respuesta de los listados (RFC 7644, sección 3.4.2)
+type scimListResponse struct {
+	Schemas      []string   `json:"schemas"`
+	TotalResults int        `json:"totalResults"`
+	StartIndex   int        `json:"startIndex"`
+	ItemsPerPage int        `json:"itemsPerPage"`
+	Resources    []scimUser `json:"Resources"`
+}
+
+func newSCIMUser(user *entities.User, location string) scimUser {
+	given, family, _ := strings.Cut(user.Name, " ")
+	active := user.Active
+	return scimUser{
+		Schemas:     []string{scim.UserSchema},
+		ID:          user.ID.String(),
+		UserName:    user.Email,
+		Name:        &scimName{Formatted: user.Name, GivenName: given, FamilyName: family},
+		DisplayName: user.Name,
+		Emails:      []scimEmail{{Value: user.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta: &scimMeta{
+			ResourceType: "User",
+			Created:      user.CreatedAt.UTC(),
+			LastModified: user.UpdatedAt.UTC(),
+			Location:     location + "/" + user.ID.String(),
+			Version:      etag(user.Version),
+		},
+	}
+}
+
+// validate comprueba lo que exige RFC 7643 a cualquier User recibido
+func (u scimUser) validate() error {
+	if !scim.HasSchema(u.Schemas, scim.UserSchema) {
+		return errs.Errorf(ErrInvalidSCIMSyntax, "schemas must include %s", scim.UserSchema)
+	}
+	if strings.TrimSpace(u.UserName) == "" {
+		return errs.Errorf(ErrInvalidSCIMValue, "%s is required", "userName")
+	}
+	return nil
+}
+
+// email devuelve el email principal, el primero si ninguno lo es, o
+// userName si no se envía ninguno
+func (u scimUser) email() string {
+	for _, email := range u.Emails {
+		if email.Primary && email.Value != "" {
+			return email.Value
+		}
+	}
+	for _, email := range u.Emails {
+		if email.Value != "" {
+			return email.Value
+		}
+	}
+	return u.UserName
+}
+
+// fullName compone el nombre desde givenName y familyName; si no vienen usa
+// name.formatted y, en último lugar, displayName
+func (u scimUser) fullName() string {
+	if u.Name != nil {
+		if name := strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName); name != "" {
+			return name
+		}
+		if u.Name.Formatted != "" {
+			return u.Name.Formatted
+		}
+	}
+	return u.DisplayName
+}
+
+// SCIMHandler expone los usuarios como recursos SCIM 2.0 para que el
+// directorio corporativo los aprovisione
+type SCIMHandler struct {
+	userService input.UserService
+}
+
+func NewSCIMHandler(userService input.UserService) *SCIMHandler {
+	return &SCIMHandler{userService: userService}
+}
+
+// RegisterRoutes registra /Users bajo router, que es la base SCIM (p. ej.
+// /scim/v2) y debe venir protegido con SCIMAuth
+func (h *SCIMHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/Users", h.ListUsers)
+	router.GET("/Users/:id", h.GetUser)
+	router.POST("/Users", h.CreateUser)
+	router.PUT("/Users/:id", h.ReplaceUser)
+	router.PATCH("/Users/:id", h.PatchUser)
+	router.DELETE("/Users/:id", h.DeleteUser)
+}
+
+// ListUsers filtra con la sintaxis de SCIM (?filter=userName eq "...") y
+// pagina con startIndex, que empieza en 1, y count. El orden es el de
+// creación. El filtro se traduce a la consulta del almacén, así que sólo se
+// admiten las comparaciones que ésta sabe resolver (ver scimUserFilter).
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	var filter output.UserFilter
+	if raw := c.Query("filter"); raw != "" {
+		parsed, err := scim.ParseFilter(raw)
+		if err != nil {
+			HandleSCIMError(c, scimRequestError(err))
+			return
+		}
+		if filter, err = scimUserFilter(parsed, raw); err != nil {
+			HandleSCIMError(c, err)
+			return
+		}
+	}
+	startIndex, err := queryInt(c, "startIndex")
+	if err != nil {
+		HandleSCIMError(c, err)
+		return
+	}
+	count, err := queryInt(c, "count")
+	if err != nil {
+		HandleSCIMError(c, err)
+		return
+	}
+
+	// Fuera de rango no es un error: RFC 7644 pide tratar startIndex < 1
+	// como 1 y count < 0 como 0
+	start, size := 1, scimDefaultCount
+	if startIndex != nil && *startIndex > 1 {
+		start = *startIndex
+	}
+	if count != nil {
+		size = max(0, min(*count, scimMaxCount))
+	}
+
+	// Con count=0 sólo interesa el total, pero el almacén no admite páginas
+	// vacías
+	page, err := h.userService.ListUsers(c.Request.Context(), output.UserQuery{
+		Filter: filter,
+		Sort:   output.UserSortCreatedAt,
+		Limit:  max(size, 1),
+		Offset: start - 1,
+	})
+	if err != nil {
+		HandleSCIMError(c, err)
+		return
+	}
+
+	location := scimLocation(c)
+	resources := []scimUser{}
+	for _, user := range page.Users[:min(size, len(page.Users))] {
+		resources = append(resources, newSCIMUser(user, location))
+	}
+
+	c.Header("Content-Type", scimContentType)
+	c.JSON(http.StatusOK, scimListResponse{
+		Schemas:      []string{scim.ListResponseSchema},
+		TotalResults: page.Total,
+		StartIndex:   start,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// scimUserFilter traduce un filtro SCIM a output.UserFilter. Admite
+// comparaciones unidas con and sobre userName o emails.value (eq y sw),
+// displayName o name.formatted (sw), active (eq) y meta.created (gt, ge,
+// lt y le), cada atributo una sola vez; el resto es invalidFilter.
+func scimUserFilter(parsed scim.Filter, raw string) (output.UserFilter, error) {
+	var filter output.UserFilter
+	unsupported := errs.Errorf(ErrInvalidSCIMFilter, "unsupported filter %q", raw)
+
+	conditions, ok := scim.Conditions(parsed)
+	if !ok {
+		return filter, unsupported
+	}
+	for _, condition := range conditions {
+		text, isText := condition.Value.(string)
+		switch attr := strings.ToLower(condition.Attr); {
+		case (attr == "username" || attr == "emails" || attr == "emails.value") && isText:
+			switch {
+			case condition.Op == "eq" && filter.Email == "":
+				filter.Email = text
+			case condition.Op == "sw" && filter.EmailPrefix == "":
+				filter.EmailPrefix = text
+			default:
+				return filter, unsupported
+			}
+		case (attr == "displayname" || attr == "name.formatted") && isText:
+			if condition.Op != "sw" || filter.NamePrefix != "" {
+				return filter, unsupported
+			}
+			filter.NamePrefix = text
+		case attr == "active":
+			active, isBool := condition.Value.(bool)
+			if condition.Op != "eq" || !isBool || filter.Active != nil {
+				return filter, unsupported
+			}
+			filter.Active = &active
+		case attr == "meta.created" && isText:
+			at, err := time.Parse(time.RFC3339Nano, text)
+			if err != nil {
+				return filter, errs.Errorf(ErrInvalidSCIMFilter, "%s must be an RFC 3339 date", condition.Attr)
+			}
+			// CreatedFrom incluye el límite y CreatedTo lo excluye
+			switch {
+			case condition.Op == "ge" && filter.CreatedFrom == nil:
+				filter.CreatedFrom = &at
+			case condition.Op == "gt" && filter.CreatedFrom == nil:
+				at = at.Add(time.Nanosecond)
+				filter.CreatedFrom = &at
+			case condition.Op == "lt" && filter.CreatedTo == nil:
+				filter.CreatedTo = &at
+			case condition.Op == "le" && filter.CreatedTo == nil:
+				at = at.Add(time.Nanosecond)
+				filter.CreatedTo = &at
+			default:
+				return filter, unsupported
+			}
+		default:
+			return filter, unsupported
+		}
+	}
+	return filter, nil
+}
+
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+
+	writeSCIMUser(c, http.StatusOK, user)
+}
+
+// CreateUser aprovisiona una cuenta. Si no trae password se le asigna una
+// aleatoria que nadie conoce: el usuario fija la suya con la recuperación
+// de contraseña.
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	req, ok := readSCIMUser(c)
+	if !ok {
+		return
+	}
+
+	password := req.Password
+	if password == "" {
+		var err error
+		if password, err = randomPassword(); err != nil {
+			HandleSCIMError(c, err)
+			return
+		}
+	}
+
+	var opts []input.RegisterOption
+	if req.Active != nil && !*req.Active {
+		opts = append(opts, input.RegisterInactive())
+	}
+	user, err := h.userService.RegisterUser(c.Request.Context(), req.fullName(), req.email(), 0, password, opts...)
+	if err != nil {
+		HandleSCIMError(c, err)
+		return
+	}
+
+	c.Header("Location", scimLocation(c)+"/"+user.ID.String())
+	writeSCIMUser(c, http.StatusCreated, user)
+}
+
+// ReplaceUser sustituye el nombre, el email y el estado (PUT). Sin active
+// se mantiene el estado actual; la edad no forma parte del recurso SCIM y
+// no cambia.
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok || !scimIfMatch(c, user) {
+		return
+	}
+
+	req, ok := readSCIMUser(c)
+	if !ok {
+		return
+	}
+
+	h.saveUser(c, user, req)
+}
+
+// PatchUser aplica un mensaje PatchOp al recurso actual y guarda el
+// resultado como lo haría ReplaceUser
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok || !scimIfMatch(c, user) {
+		return
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		HandleSCIMError(c, scimRequestError(err))
+		return
+	}
+	current, err := json.Marshal(newSCIMUser(user, scimLocation(c)))
+	if err != nil {
+		HandleSCIMError(c, err)
+		return
+	}
+	patched, err := scim.ApplyPatch(current, patch)
+	if err != nil {
+		HandleSCIMError(c, scimRequestError(err))
+		return
+	}
+
+	var req scimUser
+	if err := json.Unmarshal(patched, &req); err != nil {
+		HandleSCIMError(c, fmt.Errorf("%w: %v", ErrInvalidSCIMValue, err))
+		return
+	}
+	if err := req.validate(); err != nil {
+		HandleSCIMError(c, err)
+		return
+	}
+
+	h.saveUser(c, user, req)
+}
+
+// DeleteUser realiza un borrado lógico: el usuario deja de existir para
+// SCIM, pero un administrador puede restaurarlo hasta que se purga
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		HandleSCIMError(c, output.ErrUserNotFound)
+		return
+	}
+
+	if err := h.userService.DeleteUser(c.Request.Context(), id); err != nil {
+		HandleSCIMError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// findUser carga el usuario de la ruta. Los id de SCIM son opacos, así que
+// uno que no es un UUID es simplemente un usuario que no existe.
+func (h *SCIMHandler) findUser(c *gin.Context) (*entities.User, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		HandleSCIMError(c, output.ErrUserNotFound)
+		return nil, false
+	}
+
+	user, err := h.userService.GetUserProfile(c.Request.Context(), id)
+	if err != nil {
+		HandleSCIMError(c, err)
+		return nil, false
+	}
+	return user, true
+}
+
+// saveUser aplica req sobre la entidad, que valida el resultado, y lo
+// guarda. Una contraseña nueva cierra las sesiones abiertas.
+func (h *SCIMHandler) saveUser(c *gin.Context, user *entities.User, req scimUser) {
+	active := user.Active
+	if req.Active != nil {
+		active = *req.Active
+	}
+	if err := user.Update(req.fullName(), req.email(), user.Age, active); err != nil {
+		HandleSCIMError(c, err)
+		return
+	}
+	if req.Password != "" {
+		now := time.Now()
+		if err := user.ChangePassword(req.Password, now); err != nil {
+			HandleSCIMError(c, err)
+			return
+		}
+		user.RevokeSessions(now)
+	}
+
+	if err := h.userService.UpdateProfile(c.Request.Context(), user); err != nil {
+		if errors.Is(err, output.ErrConcurrentModification) && hasIfMatch(c) {
+			err = fmt.Errorf("%w: %v", ErrPreconditionFailed, err)
+		}
+		HandleSCIMError(c, err)
+		return
+	}
+
+	writeSCIMUser(c, http.StatusOK, user)
+}
+
+// readSCIMUser lee y valida el User del cuerpo de la petición
+func readSCIMUser(c *gin.Context) (scimUser, bool) {
+	var req scimUser
+	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+		HandleSCIMError(c, scimRequestError(err))
+		return req, false
+	}
+	if err := req.validate(); err != nil {
+		HandleSCIMError(c, err)
+		return req, false
+	}
+	return req, true
+}
+
+// scimIfMatch evalúa If-Match como checkIfMatch, pero responde con un error
+// SCIM
+func scimIfMatch(c *gin.Context, user *entities.User) bool {
+	if ifMatch(c, user.Version) {
+		return true
+	}
+
+	setETag(c, user.Version)
+	HandleSCIMError(c, ErrPreconditionFailed)
+	return false
+}
+
+func writeSCIMUser(c *gin.Context, status int, user *entities.User) {
+	setETag(c, user.Version)
+	c.Header("Content-Type", scimContentType)
+	c.JSON(status, newSCIMUser(user, scimLocation(c)))
+}
+
+// scimLocation devuelve la URL absoluta de la colección /Users de la
+// petición, como pide meta.location (RFC 7643, sección 3.1). El esquema
+// sale de la conexión o, detrás de un proxy que termina TLS, de
+// X-Forwarded-Proto.
+func scimLocation(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := strings.ToLower(c.GetHeader("X-Forwarded-Proto")); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+
+	path := c.Request.URL.Path
+	if id := c.Param("id"); id != "" {
+		path = strings.TrimSuffix(path, "/"+id)
+	}
+	return (&url.URL{Scheme: scheme, Host: c.Request.Host, Path: path}).String()
+}
+
+// toObject convierte un recurso en el mapa JSON sobre el que se evalúan los
+// filtros
+func toObject(resource any) (map[string]any, error) {
+	raw, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]any
+	err = json.Unmarshal(raw, &object)
+	return object, err
+}
+
+// randomPassword genera una contraseña que nadie conoce para las cuentas
+// aprovisionadas sin ella
+func randomPassword() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
@@ -0,0 +1,480 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"user-management/internal/application/services"
+	"user-management/internal/infrastructure/http/handlers"
+	"user-management/internal/infrastructure/http/middlewares"
+	"user-management/internal/infrastructure/persistence/memory"
+)
+
+const scimToken = "scim-secret"
+
+// Mensajes de ejemplo tal y como los envían los proveedores de identidad
+const (
+	// RFC 7643, sección 8.2 (recortado a los atributos que se mapean)
+	rfcUserPayload = `{
+	  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+	  "externalId": "701984",
+	  "userName": "bjensen@example.com",
+	  "name": {
+	    "formatted": "Ms. Barbara J Jensen, III",
+	    "familyName": "Jensen",
+	    "givenName": "Barbara"
+	  },
+	  "displayName": "Babs Jensen",
+	  "emails": [
+	    {"value": "bjensen@example.com", "type": "work", "primary": true},
+	    {"value": "babs@jensen.org", "type": "home"}
+	  ],
+	  "userType": "Employee",
+	  "active": true,
+	  "password": "t1meMa$heen"
+	}`
+
+	// Alta del validador SCIM de Azure AD: extensión enterprise, roles y
+	// meta que el servidor debe ignorar
+	azureUserPayload = `{
+	  "schemas": [
+	    "urn:ietf:params:scim:schemas:core:2.0:User",
+	    "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	  ],
+	  "externalId": "0a21f0f2-8d2a-4f8e-bf98-7363c4aed4ef",
+	  "userName": "Test_User_ab6490ee-1e48-479e-a20b-2d77186b5dd1@testuser.com",
+	  "active": false,
+	  "emails": [{
+	    "primary": true,
+	    "type": "work",
+	    "value": "Test_User_fd0ea19b-0777-472c-9f96-4f70d2226f2e@testuser.com"
+	  }],
+	  "meta": {"resourceType": "User"},
+	  "name": {"formatted": "givenName familyName", "familyName": "familyName", "givenName": "givenName"},
+	  "roles": [],
+	  "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"employeeNumber": "1234"}
+	}`
+
+	// Alta de Okta: sin emails marcados como principales ni password
+	oktaUserPayload = `{
+	  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+	  "userName": "isabella.lopez@example.com",
+	  "name": {"givenName": "Isabella", "familyName": "Lopez"},
+	  "emails": [{"value": "isabella.lopez@example.com", "type": "work"}],
+	  "displayName": "Isabella Lopez",
+	  "locale": "es-ES",
+	  "externalId": "00ujl29u0le5T6Aj10h7",
+	  "groups": [],
+	  "active": true
+	}`
+)
+
+type scimUserResponse struct {
+	Schemas  []string `json:"schemas"`
+	ID       string   `json:"id"`
+	UserName string   `json:"userName"`
+	Name     struct {
+		Formatted  string `json:"formatted"`
+		GivenName  string `json:"givenName"`
+		FamilyName string `json:"familyName"`
+	} `json:"name"`
+	DisplayName string `json:"displayName"`
+	Emails      []struct {
+		Value   string `json:"value"`
+		Primary bool   `json:"primary"`
+	} `json:"emails"`
+	Active   bool   `json:"active"`
+	Password string `json:"password"`
+	Meta     struct {
+		ResourceType string `json:"resourceType"`
+		Created      string `json:"created"`
+		LastModified string `json:"lastModified"`
+		Location     string `json:"location"`
+		Version      string `json:"version"`
+	} `json:"meta"`
+}
+
+type scimListResponse struct {
+	Schemas      []string           `json:"schemas"`
+	TotalResults int                `json:"totalResults"`
+	StartIndex   int                `json:"startIndex"`
+	ItemsPerPage int                `json:"itemsPerPage"`
+	Resources    []scimUserResponse `json:"Resources"`
+}
+
+type scimErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	ScimType string   `json:"scimType"`
+	Detail   string   `json:"detail"`
+	Status   string   `json:"status"`
+}
+
+func newSCIMRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	scim := router.Group("/scim/v2", middlewares.SCIMAuth(scimToken))
+	handlers.NewSCIMHandler(services.NewUserService(memory.NewUserRepository())).RegisterRoutes(scim)
+	return router
+}
+
+func scimCall(router *gin.Engine, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+scimToken)
+	req.Header.Set("Content-Type", "application/scim+json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func decodeSCIM[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	assert.Equal(t, "application/scim+json", w.Header().Get("Content-Type"))
+	var body T
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), w.Body.String())
+	return body
+}
+
+func scimPatch(operations string) string {
+	return `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],"Operations":` + operations + `}`
+}
+
+// createSCIMUser da de alta payload y devuelve el recurso creado
+func createSCIMUser(t *testing.T, router *gin.Engine, payload string) scimUserResponse {
+	t.Helper()
+	w := scimCall(router, "POST", "/scim/v2/Users", payload)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	return decodeSCIM[scimUserResponse](t, w)
+}
+
+func assertSCIMError(t *testing.T, w *httptest.ResponseRecorder, status int, scimType string) {
+	t.Helper()
+	assert.Equal(t, status, w.Code, w.Body.String())
+	body := decodeSCIM[scimErrorResponse](t, w)
+	assert.Equal(t, []string{"urn:ietf:params:scim:api:messages:2.0:Error"}, body.Schemas)
+	assert.Equal(t, strconv.Itoa(status), body.Status)
+	assert.Equal(t, scimType, body.ScimType)
+}
+
+func TestSCIMHandler_Authentication(t *testing.T) {
+	router := newSCIMRouter()
+
+	for name, header := range map[string]string{
+		"missing token": "",
+		"wrong token":   "Bearer nope",
+		"wrong scheme":  "Basic " + scimToken,
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/scim/v2/Users", nil)
+			if header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assertSCIMError(t, w, http.StatusUnauthorized, "")
+			assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
+
+func TestSCIMHandler_CreateUser(t *testing.T) {
+	t.Run("RFC 7643 sample user", func(t *testing.T) {
+		router := newSCIMRouter()
+		w := scimCall(router, "POST", "/scim/v2/Users", rfcUserPayload)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		user := decodeSCIM[scimUserResponse](t, w)
+		assert.Equal(t, []string{"urn:ietf:params:scim:schemas:core:2.0:User"}, user.Schemas)
+		assert.NotEmpty(t, user.ID)
+		assert.Equal(t, "bjensen@example.com", user.UserName)
+		assert.Equal(t, "Barbara Jensen", user.Name.Formatted)
+		assert.Equal(t, "Barbara Jensen", user.DisplayName)
+		require.Len(t, user.Emails, 1)
+		assert.True(t, user.Emails[0].Primary)
+		assert.True(t, user.Active)
+		assert.Empty(t, user.Password, "password is write-only")
+
+		assert.Equal(t, "User", user.Meta.ResourceType)
+		assert.Equal(t, "http://example.com/scim/v2/Users/"+user.ID, user.Meta.Location)
+		assert.Equal(t, user.Meta.Location, w.Header().Get("Location"))
+		assert.Equal(t, w.Header().Get("ETag"), user.Meta.Version)
+		assert.NotEmpty(t, user.Meta.Created)
+	})
+
+	t.Run("Azure AD validator payload", func(t *testing.T) {
+		router := newSCIMRouter()
+		user := createSCIMUser(t, router, azureUserPayload)
+
+		assert.Equal(t, "Test_User_fd0ea19b-0777-472c-9f96-4f70d2226f2e@testuser.com", user.UserName,
+			"the primary email is the account email")
+		assert.Equal(t, "givenName familyName", user.DisplayName)
+		assert.False(t, user.Active)
+		assert.Equal(t, createSCIMUser(t, router, rfcUserPayload).Meta.Version, user.Meta.Version,
+			"an inactive user is created in a single write")
+	})
+
+	t.Run("Okta payload without password", func(t *testing.T) {
+		router := newSCIMRouter()
+		user := createSCIMUser(t, router, oktaUserPayload)
+
+		assert.Equal(t, "isabella.lopez@example.com", user.UserName)
+		assert.Equal(t, "Isabella", user.Name.GivenName)
+		assert.Equal(t, "Lopez", user.Name.FamilyName)
+	})
+
+	t.Run("duplicate userName is a uniqueness error", func(t *testing.T) {
+		router := newSCIMRouter()
+		createSCIMUser(t, router, rfcUserPayload)
+
+		assertSCIMError(t, scimCall(router, "POST", "/scim/v2/Users", rfcUserPayload), http.StatusConflict, "uniqueness")
+	})
+
+	t.Run("rejects invalid users", func(t *testing.T) {
+		router := newSCIMRouter()
+		tests := []struct {
+			name, payload, scimType string
+		}{
+			{"malformed JSON", `{"schemas":`, "invalidSyntax"},
+			{"missing schema", `{"userName":"a@example.com","name":{"formatted":"Ann"}}`, "invalidSyntax"},
+			{"missing userName", `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"name":{"formatted":"Ann"}}`, "invalidValue"},
+			{"userName is not an email", `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"bjensen","name":{"formatted":"Ann"}}`, "invalidValue"},
+			{"wrong attribute type", `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"a@example.com","active":"yes"}`, "invalidSyntax"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assertSCIMError(t, scimCall(router, "POST", "/scim/v2/Users", tt.payload), http.StatusBadRequest, tt.scimType)
+			})
+		}
+	})
+}
+
+func TestSCIMHandler_GetUser(t *testing.T) {
+	router := newSCIMRouter()
+	created := createSCIMUser(t, router, rfcUserPayload)
+
+	w := scimCall(router, "GET", "/scim/v2/Users/"+created.ID, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, created, decodeSCIM[scimUserResponse](t, w))
+
+	t.Run("location follows the scheme seen by the client", func(t *testing.T) {
+		w := scimCall(router, "GET", "/scim/v2/Users/"+created.ID, "", "X-Forwarded-Proto", "https")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://example.com/scim/v2/Users/"+created.ID, decodeSCIM[scimUserResponse](t, w).Meta.Location)
+	})
+
+	assertSCIMError(t, scimCall(router, "GET", "/scim/v2/Users/2819c223-7f76-453a-919d-413861904646", ""), http.StatusNotFound, "")
+	assertSCIMError(t, scimCall(router, "GET", "/scim/v2/Users/not-a-uuid", ""), http.StatusNotFound, "")
+}
+
+func TestSCIMHandler_ListUsers(t *testing.T) {
+	router := newSCIMRouter()
+	bjensen := createSCIMUser(t, router, rfcUserPayload)
+	isabella := createSCIMUser(t, router, oktaUserPayload)
+
+	list := func(t *testing.T, query url.Values) scimListResponse {
+		t.Helper()
+		w := scimCall(router, "GET", "/scim/v2/Users?"+query.Encode(), "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		response := decodeSCIM[scimListResponse](t, w)
+		assert.Equal(t, []string{"urn:ietf:params:scim:api:messages:2.0:ListResponse"}, response.Schemas)
+		return response
+	}
+
+	t.Run("without filter", func(t *testing.T) {
+		response := list(t, nil)
+		assert.Equal(t, 2, response.TotalResults)
+		assert.Equal(t, 1, response.StartIndex)
+		require.Len(t, response.Resources, 2)
+		assert.Equal(t, bjensen.ID, response.Resources[0].ID)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		tests := []struct {
+			filter   string
+			expected []string
+		}{
+			// Lo que envían los proveedores para comprobar si la cuenta existe
+			{`userName eq "BJensen@example.com"`, []string{bjensen.ID}},
+			{`userName eq "nobody@example.com"`, nil},
+			{`emails.value eq "isabella.lopez@example.com"`, []string{isabella.ID}},
+			{`userName sw "ISA"`, []string{isabella.ID}},
+			{`displayName sw "barbara" and active eq true`, []string{bjensen.ID}},
+			{`active eq false`, nil},
+			{`meta.created ge "2000-01-01T00:00:00Z" and meta.created lt "2100-01-01T00:00:00Z"`, []string{bjensen.ID, isabella.ID}},
+			{`meta.created gt "2100-01-01T00:00:00Z"`, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.filter, func(t *testing.T) {
+				response := list(t, url.Values{"filter": {tt.filter}})
+				var ids []string
+				for _, resource := range response.Resources {
+					ids = append(ids, resource.ID)
+				}
+				assert.Equal(t, tt.expected, ids)
+				assert.Equal(t, len(tt.expected), response.TotalResults)
+			})
+		}
+	})
+
+	t.Run("paginates with startIndex and count", func(t *testing.T) {
+		response := list(t, url.Values{"startIndex": {"2"}, "count": {"1"}})
+		assert.Equal(t, 2, response.TotalResults)
+		assert.Equal(t, 2, response.StartIndex)
+		assert.Equal(t, 1, response.ItemsPerPage)
+		require.Len(t, response.Resources, 1)
+		assert.Equal(t, isabella.ID, response.Resources[0].ID)
+
+		response = list(t, url.Values{"startIndex": {"5"}})
+		assert.Equal(t, 2, response.TotalResults)
+		assert.Empty(t, response.Resources)
+		assert.NotNil(t, response.Resources)
+
+		response = list(t, url.Values{"count": {"0"}})
+		assert.Equal(t, 2, response.TotalResults)
+		assert.Empty(t, response.Resources)
+	})
+
+	t.Run("location is the absolute URL of each user", func(t *testing.T) {
+		response := list(t, url.Values{"filter": {`userName eq "bjensen@example.com"`}})
+		require.Len(t, response.Resources, 1)
+		assert.Equal(t, "http://example.com/scim/v2/Users/"+bjensen.ID, response.Resources[0].Meta.Location)
+	})
+
+	t.Run("rejects invalid filters", func(t *testing.T) {
+		w := scimCall(router, "GET", "/scim/v2/Users?"+url.Values{"filter": {`userName eq`}}.Encode(), "")
+		assertSCIMError(t, w, http.StatusBadRequest, "invalidFilter")
+	})
+
+	t.Run("rejects filters the store cannot answer", func(t *testing.T) {
+		for _, filter := range []string{
+			`emails[type eq "work" and value co "lopez"]`,
+			`name.givenName sw "isa" or displayName co "jensen"`,
+			`active eq true and not (userName ew "example.com")`,
+			`title pr`,
+			`userName co "jensen"`,
+			`userName eq "a@example.com" and userName eq "b@example.com"`,
+			`active eq "yes"`,
+			`meta.created ge "yesterday"`,
+		} {
+			w := scimCall(router, "GET", "/scim/v2/Users?"+url.Values{"filter": {filter}}.Encode(), "")
+			assertSCIMError(t, w, http.StatusBadRequest, "invalidFilter")
+		}
+	})
+}
+
+func TestSCIMHandler_ReplaceUser(t *testing.T) {
+	router := newSCIMRouter()
+	created := createSCIMUser(t, router, rfcUserPayload)
+	path := "/scim/v2/Users/" + created.ID
+
+	replacement := `{
+	  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+	  "id": "` + created.ID + `",
+	  "userName": "barbara.jensen@example.com",
+	  "name": {"givenName": "Barbara", "familyName": "Smith"},
+	  "active": false
+	}`
+
+	t.Run("If-Match must carry the current version", func(t *testing.T) {
+		w := scimCall(router, "PUT", path, replacement, "If-Match", `"999"`)
+		assertSCIMError(t, w, http.StatusPreconditionFailed, "")
+		assert.Equal(t, created.Meta.Version, w.Header().Get("ETag"))
+	})
+
+	t.Run("replaces name, email and status", func(t *testing.T) {
+		w := scimCall(router, "PUT", path, replacement, "If-Match", created.Meta.Version)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		user := decodeSCIM[scimUserResponse](t, w)
+		assert.Equal(t, created.ID, user.ID)
+		assert.Equal(t, "barbara.jensen@example.com", user.UserName)
+		assert.Equal(t, "Barbara Smith", user.DisplayName)
+		assert.False(t, user.Active)
+		assert.NotEqual(t, created.Meta.Version, user.Meta.Version)
+	})
+
+	t.Run("rejects invalid replacements", func(t *testing.T) {
+		w := scimCall(router, "PUT", path, `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"x@example.com"}`)
+		assertSCIMError(t, w, http.StatusBadRequest, "invalidValue")
+	})
+}
+
+func TestSCIMHandler_PatchUser(t *testing.T) {
+	router := newSCIMRouter()
+	created := createSCIMUser(t, router, rfcUserPayload)
+	other := createSCIMUser(t, router, oktaUserPayload)
+	path := "/scim/v2/Users/" + created.ID
+
+	patch := func(t *testing.T, operations string) scimUserResponse {
+		t.Helper()
+		w := scimCall(router, "PATCH", path, scimPatch(operations))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		return decodeSCIM[scimUserResponse](t, w)
+	}
+
+	t.Run("deactivates as Azure AD does", func(t *testing.T) {
+		user := patch(t, `[{"op":"Replace","path":"active","value":false}]`)
+		assert.False(t, user.Active)
+	})
+
+	t.Run("replaces without a path as Okta does", func(t *testing.T) {
+		user := patch(t, `[{"op":"replace","value":{"active":true,"name":{"familyName":"Smith"}}}]`)
+		assert.True(t, user.Active)
+		assert.Equal(t, "Barbara Smith", user.DisplayName)
+	})
+
+	t.Run("changes the work email", func(t *testing.T) {
+		user := patch(t, `[{"op":"replace","path":"emails[type eq \"work\"].value","value":"babs@example.com"}]`)
+		assert.Equal(t, "babs@example.com", user.UserName)
+	})
+
+	t.Run("rejects invalid patches", func(t *testing.T) {
+		tests := []struct {
+			name, body string
+			status     int
+			scimType   string
+		}{
+			{"missing PatchOp schema", `{"Operations":[{"op":"replace","path":"active","value":false}]}`, http.StatusBadRequest, "invalidSyntax"},
+			{"unknown operation", scimPatch(`[{"op":"move","path":"active"}]`), http.StatusBadRequest, "invalidSyntax"},
+			{"invalid path", scimPatch(`[{"op":"replace","path":"emails[type eq]","value":"x"}]`), http.StatusBadRequest, "invalidPath"},
+			{"no matching value", scimPatch(`[{"op":"replace","path":"emails[type eq \"home\"].value","value":"x"}]`), http.StatusBadRequest, "noTarget"},
+			{"removes a required attribute", scimPatch(`[{"op":"remove","path":"userName"}]`), http.StatusBadRequest, "invalidValue"},
+			{"wrong value type", scimPatch(`[{"op":"replace","path":"active","value":"yes"}]`), http.StatusBadRequest, "invalidValue"},
+			{"email of another user", scimPatch(`[{"op":"replace","path":"emails[type eq \"work\"].value","value":"` + other.UserName + `"}]`), http.StatusConflict, "uniqueness"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assertSCIMError(t, scimCall(router, "PATCH", path, tt.body), tt.status, tt.scimType)
+			})
+		}
+
+		w := scimCall(router, "GET", path, "")
+		assert.Equal(t, "babs@example.com", decodeSCIM[scimUserResponse](t, w).UserName, "failed patches change nothing")
+	})
+}
+
+func TestSCIMHandler_DeleteUser(t *testing.T) {
+	router := newSCIMRouter()
+	created := createSCIMUser(t, router, rfcUserPayload)
+	path := "/scim/v2/Users/" + created.ID
+
+	w := scimCall(router, "DELETE", path, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	assertSCIMError(t, scimCall(router, "GET", path, ""), http.StatusNotFound, "")
+	assertSCIMError(t, scimCall(router, "DELETE", path, ""), http.StatusNotFound, "")
+	assert.Equal(t, 0, decodeSCIM[scimListResponse](t, scimCall(router, "GET", "/scim/v2/Users", "")).TotalResults)
+}
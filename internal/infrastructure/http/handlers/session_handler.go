@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/input"
+)
+
+type SessionHandler struct {
+	sessions input.SessionService
+}
+
+func NewSessionHandler(sessions input.SessionService) *SessionHandler {
+	return &SessionHandler{sessions: sessions}
+}
+
+// RegisterAuthenticatedRoutes registra las sesiones del usuario
+// autenticado; router debe llevar AuthMiddleware
+func (h *SessionHandler) RegisterAuthenticatedRoutes(router *gin.RouterGroup) {
+	router.GET("/me/sessions", h.ListSessions)
+	router.DELETE("/me/sessions/:id", h.RevokeSession)
+}
+
+// RegisterAdminRoutes registra las rutas reservadas a administradores; el
+// grupo recibido debe venir ya protegido.
+func (h *SessionHandler) RegisterAdminRoutes(router *gin.RouterGroup) {
+	router.POST("/users/:id/logout", h.LogoutUser)
+}
+
+// sessionResponse marca la sesión desde la que se hace la petición
+type sessionResponse struct {
+	*entities.Session
+	Current bool `json:"current"`
+}
+
+// ListSessions devuelve dónde tiene sesión abierta el usuario
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	sessions, err := h.sessions.ListSessions(c.Request.Context(), userID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	var current uuid.UUID
+	if principal, ok := entities.PrincipalFromContext(c.Request.Context()); ok {
+		current = principal.SessionID
+	}
+	response := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, sessionResponse{Session: session, Current: session.ID == current})
+	}
+	SuccessResponse(c, response)
+}
+
+// RevokeSession cierra una sesión; sus tokens dejan de valer en la
+// siguiente petición
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		HandleError(c, badRequest(err))
+		return
+	}
+
+	if err := h.sessions.RevokeSession(c.Request.Context(), userID, id); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Success: true, Message: "Session revoked successfully"})
+}
+
+// LogoutUser cierra todas las sesiones de un usuario (sólo administradores)
+func (h *SessionHandler) LogoutUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		HandleError(c, badRequest(err))
+		return
+	}
+
+	if err := h.sessions.RevokeAllSessions(c.Request.Context(), id); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Success: true, Message: "All sessions of the user were revoked"})
+}
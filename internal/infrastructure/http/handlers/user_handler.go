@@ -1,20 +1,31 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
-	"strconv"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 
-	"user-management/internal/application/services"
 	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
 	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/pkg/jsonpatch"
 )
 
+// ErrActiveRequiresAdmin rechaza que un usuario active o desactive cuentas,
+// incluida la suya
+var ErrActiveRequiresAdmin = errs.New(errs.Forbidden, "active_requires_admin", "only administrators can activate or deactivate accounts")
+
+// ErrListRequiresAdmin rechaza que un usuario liste las cuentas de los demás
+var ErrListRequiresAdmin = errs.New(errs.Forbidden, "list_requires_admin", "only administrators can list users")
+
 type UserHandler struct {
 	userService input.UserService
 	validate    *validator.Validate
@@ -23,7 +34,7 @@ type UserHandler struct {
 func NewUserHandler(userService input.UserService) *UserHandler {
 	return &UserHandler{
 		userService: userService,
-		validate:    validator.New(),
+		validate:    newValidator(),
 	}
 }
 
@@ -33,46 +44,54 @@ func (h *UserHandler) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/users/:id", h.GetUserByID)
 	router.POST("/users", h.CreateUser)
 	router.PUT("/users/:id", h.UpdateUser)
+	router.PATCH("/users/:id", h.PatchUser)
+	router.DELETE("/users/:id", h.DeleteUser)
+}
+
+// RegisterCurrentUserRoutes registra el perfil del usuario autenticado, que
+// se resuelve desde el principal; router debe llevar AuthMiddleware
+func (h *UserHandler) RegisterCurrentUserRoutes(router *gin.RouterGroup) {
+	router.GET("/me", h.GetCurrentUser)
+	router.PATCH("/me", h.PatchCurrentUser)
+}
+
+// RegisterAdminRoutes registra las rutas reservadas a administradores; el
+// grupo recibido debe venir ya protegido.
+func (h *UserHandler) RegisterAdminRoutes(router *gin.RouterGroup) {
+	router.POST("/users/:id/restore", h.RestoreUser)
+}
+
+// createUserRequest es el cuerpo de POST /users
+type createUserRequest struct {
+	Name     string `json:"name" binding:"required,min=3"`
+	Email    string `json:"email" binding:"required,email"`
+	Age      int    `json:"age" binding:"gte=0,lte=120"`
+	Password string `json:"password" binding:"required,min=8"`
 }
 
 // CreateUser demuestra binding y validación
 func (h *UserHandler) CreateUser(c *gin.Context) {
-	var req struct {
-		Name     string `json:"name" binding:"required,min=3"`
-		Email    string `json:"email" binding:"required,email"`
-		Age      int    `json:"age" binding:"gte=0,lte=120"`
-		Password string `json:"password" binding:"required,min=6"`
-	}
+	var req createUserRequest
 
 	// Binding automático con Gin
 	if err := c.ShouldBindJSON(&req); err != nil {
-		ErrorResponse(c, http.StatusBadRequest, err)
+		HandleError(c, requestError(err))
 		return
 	}
 
 	// Validación adicional
 	if err := h.validate.Struct(req); err != nil {
-		ErrorResponse(c, http.StatusBadRequest, err)
+		HandleError(c, requestError(err))
 		return
 	}
 
 	user, err := h.userService.RegisterUser(c.Request.Context(), req.Name, req.Email, req.Age, req.Password)
 	if err != nil {
-		if errors.Is(err, services.ErrEmailAlreadyExists) {
-			ErrorResponse(c, http.StatusConflict, err)
-			return
-		}
-
-		if strings.Contains(err.Error(), "invalid") ||
-			strings.Contains(err.Error(), "must be") ||
-			strings.Contains(err.Error(), "required") {
-			ErrorResponse(c, http.StatusBadRequest, err)
-			return
-		}
-		ErrorResponse(c, http.StatusInternalServerError, err)
+		HandleError(c, err)
 		return
 	}
 
+	setETag(c, user.Version)
 	c.JSON(http.StatusCreated, Response{
 		Success: true,
 		Data:    user,
@@ -80,151 +99,332 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 	})
 }
 
-// GetUserByID con parámetros de ruta
+// GetUserByID con parámetros de ruta; sólo para el dueño de la cuenta o un
+// administrador
 func (h *UserHandler) GetUserByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		ErrorResponse(c, http.StatusBadRequest, err)
+		HandleError(c, badRequest(err))
+		return
+	}
+	if err := authorizeUser(c, id); err != nil {
+		HandleError(c, err)
 		return
 	}
 
+	h.getUser(c, id)
+}
+
+// GetCurrentUser devuelve el perfil del usuario autenticado
+func (h *UserHandler) GetCurrentUser(c *gin.Context) {
+	id, err := currentUserID(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	h.getUser(c, id)
+}
+
+func (h *UserHandler) getUser(c *gin.Context, id uuid.UUID) {
 	user, err := h.userService.GetUserProfile(c.Request.Context(), id)
 	if err != nil {
-		ErrorResponse(c, http.StatusNotFound, err)
+		HandleError(c, err)
 		return
 	}
 
+	setETag(c, user.Version)
 	SuccessResponse(c, user)
 }
 
-// GetAllUsers con query parameters
+// GetAllUsers lista usuarios con filtros, orden y paginación por cursor.
+// Ejemplo: /users?active=true&min_age=18&name=jo&sort=-created_at&limit=20&cursor=...
+// Sólo para administradores.
 func (h *UserHandler) GetAllUsers(c *gin.Context) {
-	// Ejemplo: /users?active=true&limit=10&offset=0
-	// activeStr := c.DefaultQuery("active", "")
-	limitStr := c.DefaultQuery("limit", "50")
-	offsetStr := c.DefaultQuery("offset", "0")
+	if !isAdmin(c) {
+		HandleError(c, ErrListRequiresAdmin)
+		return
+	}
 
-	limit, _ := strconv.Atoi(limitStr)
-	offset, _ := strconv.Atoi(offsetStr)
+	query, err := userQueryFromRequest(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
 
-	// Filtrar usuarios (simplificado)
-	users, _ := h.userService.GetAllUsers(c.Request.Context())
+	page, err := h.userService.ListUsers(c.Request.Context(), query)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	PagedResponse(c, page.Users, PageMeta{NextCursor: page.NextCursor, Total: page.Total})
+}
+
+func userQueryFromRequest(c *gin.Context) (output.UserQuery, error) {
+	var (
+		query output.UserQuery
+		err   error
+	)
+	f := &query.Filter
 
-	// Paginación básica
-	start := offset
-	end := offset + limit
-	if end > len(users) {
-		end = len(users)
+	if f.Active, err = queryBool(c, "active"); err != nil {
+		return query, err
+	}
+	if f.MinAge, err = queryInt(c, "min_age"); err != nil {
+		return query, err
+	}
+	if f.MaxAge, err = queryInt(c, "max_age"); err != nil {
+		return query, err
 	}
+	if f.CreatedFrom, err = queryTime(c, "created_from"); err != nil {
+		return query, err
+	}
+	if f.CreatedTo, err = queryTime(c, "created_to"); err != nil {
+		return query, err
+	}
+	f.NamePrefix = c.Query("name")
+	f.EmailPrefix = c.Query("email")
 
-	if start >= len(users) {
-		SuccessResponse(c, []entities.User{})
-		return
+	sort, desc := querySort(c)
+	query.Sort, query.Desc = output.UserSortField(sort), desc
+
+	if query.Limit, err = queryLimit(c); err != nil {
+		return query, err
 	}
+	query.Cursor = c.Query("cursor")
+
+	return query, nil
+}
+
+// userDocument es la representación editable de un usuario: lo que PUT
+// reemplaza entero y sobre lo que se aplican los PATCH. Los punteros
+// distinguen un campo ausente de su valor cero. Sólo un administrador puede
+// cambiar active.
+type userDocument struct {
+	Name   *string `json:"name" binding:"required,min=3"`
+	Email  *string `json:"email" binding:"required,email"`
+	Age    *int    `json:"age" binding:"required,gte=0,lte=120"`
+	Active *bool   `json:"active" binding:"required"`
+}
+
+func documentOf(user *entities.User) userDocument {
+	return userDocument{Name: &user.Name, Email: &user.Email, Age: &user.Age, Active: &user.Active}
+}
+
+// profileDocument es la parte de userDocument que el propio usuario edita
+// desde PATCH /me; al no incluir active, un parche que lo toque se rechaza
+type profileDocument struct {
+	Name  *string `json:"name"`
+	Email *string `json:"email"`
+	Age   *int    `json:"age"`
+}
 
-	SuccessResponse(c, users[start:end])
+func profileOf(user *entities.User) profileDocument {
+	return profileDocument{Name: &user.Name, Email: &user.Email, Age: &user.Age}
 }
 
-// UpdateUser con actualización parcial (PATCH-like)
+// UpdateUser reemplaza todos los campos editables del usuario (PUT)
 func (h *UserHandler) UpdateUser(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		ErrorResponse(c, http.StatusBadRequest, err)
+		HandleError(c, badRequest(err))
 		return
 	}
 
-	var req struct {
-		Name   *string `json:"name" binding:"omitempty,min=3"`
-		Email  *string `json:"email" binding:"omitempty,email"`
-		Age    *int    `json:"age" binding:"omitempty,gte=0,lte=120"`
-		Active *bool   `json:"active" binding:"omitempty"`
+	if err := authorizeUser(c, id); err != nil {
+		HandleError(c, err)
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		ErrorResponse(c, http.StatusBadRequest, err)
+	var doc userDocument
+	if err := c.ShouldBindJSON(&doc); err != nil {
+		HandleError(c, requestError(err))
 		return
 	}
 
 	user, err := h.userService.GetUserProfile(c.Request.Context(), id)
 	if err != nil {
-		ErrorResponse(c, http.StatusNotFound, err)
+		HandleError(c, err)
 		return
 	}
 
-	// Aplicar actualizaciones parciales
-	if req.Name != nil {
-		user.Name = *req.Name
+	// If-Match con el ETag leído previamente evita pisar cambios ajenos
+	if !checkIfMatch(c, user.Version) {
+		return
 	}
-	if req.Email != nil {
-		user.Email = *req.Email
+
+	h.saveUser(c, user, doc)
+}
+
+// PatchUser aplica un JSON Merge Patch (RFC 7386) o un JSON Patch (RFC 6902)
+// según el Content-Type. El resultado debe seguir siendo un documento
+// completo y válido.
+func (h *UserHandler) PatchUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		HandleError(c, badRequest(err))
+		return
 	}
-	if req.Age != nil {
-		user.Age = *req.Age
+	if err := authorizeUser(c, id); err != nil {
+		HandleError(c, err)
+		return
 	}
-	if req.Active != nil {
-		user.Active = *req.Active
+
+	h.patchUser(c, id, false)
+}
+
+// PatchCurrentUser aplica un parche, como PatchUser, al perfil del usuario
+// autenticado
+func (h *UserHandler) PatchCurrentUser(c *gin.Context) {
+	id, err := currentUserID(c)
+	if err != nil {
+		HandleError(c, err)
+		return
 	}
 
-	updates := make(map[string]interface{})
-	if req.Name != nil {
-		updates["name"] = *req.Name
+	h.patchUser(c, id, true)
+}
+
+// patchUser aplica el parche sobre userDocument o, si self, sobre
+// profileDocument
+func (h *UserHandler) patchUser(c *gin.Context, id uuid.UUID, self bool) {
+	var apply func(doc, patch []byte) ([]byte, error)
+	switch c.ContentType() {
+	case mergePatchContentType:
+		apply = jsonpatch.MergePatch
+	case jsonPatchContentType:
+		apply = jsonpatch.Apply
+	default:
+		HandleError(c, ErrUnsupportedPatch)
+		return
 	}
-	if req.Email != nil {
-		updates["email"] = *req.Email
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		HandleError(c, badRequest(err))
+		return
 	}
-	if req.Age != nil {
-		updates["age"] = *req.Age
+
+	user, err := h.userService.GetUserProfile(c.Request.Context(), id)
+	if err != nil {
+		HandleError(c, err)
+		return
 	}
-	if req.Active != nil {
-		updates["active"] = *req.Active
+
+	if !checkIfMatch(c, user.Version) {
+		return
 	}
 
-	err = h.userService.UpdateProfile(c.Request.Context(), user)
+	var document any = documentOf(user)
+	if self {
+		document = profileOf(user)
+	}
+	current, err := json.Marshal(document)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	patched, err := apply(current, patch)
 	if err != nil {
-		ErrorResponse(c, http.StatusInternalServerError, err)
+		HandleError(c, patchError(err))
 		return
 	}
 
+	var doc userDocument
+	var profile profileDocument
+	var target any = &doc
+	if self {
+		target = &profile
+	}
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		HandleError(c, patchError(err))
+		return
+	}
+	if self {
+		doc = userDocument{Name: profile.Name, Email: profile.Email, Age: profile.Age, Active: &user.Active}
+	}
+	if err := h.validate.Struct(doc); err != nil {
+		HandleError(c, requestError(err))
+		return
+	}
+
+	h.saveUser(c, user, doc)
+}
+
+// saveUser aplica doc sobre la entidad, que valida el resultado, y lo guarda
+func (h *UserHandler) saveUser(c *gin.Context, user *entities.User, doc userDocument) {
+	if *doc.Active != user.Active && !isAdmin(c) {
+		HandleError(c, ErrActiveRequiresAdmin)
+		return
+	}
+
+	if err := user.Update(*doc.Name, *doc.Email, *doc.Age, *doc.Active); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if err := h.userService.UpdateProfile(c.Request.Context(), user); err != nil {
+		if errors.Is(err, output.ErrConcurrentModification) && hasIfMatch(c) {
+			// Otra escritura ganó la carrera entre la lectura y el guardado
+			err = fmt.Errorf("%w: %v", ErrPreconditionFailed, err)
+		}
+		HandleError(c, err)
+		return
+	}
+
+	setETag(c, user.Version)
 	SuccessResponse(c, user)
 }
 
-// // DeleteUser elimina un usuario por ID
-// func (h *UserHandler) DeleteUser(c *gin.Context) {
-// 	idStr := c.Param("id")
-// 	id, err := strconv.Atoi(idStr)
-// 	if err != nil {
-// 		ErrorResponse(c, http.StatusBadRequest, err)
-// 		return
-// 	}
+// DeleteUser realiza un borrado lógico del usuario
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		HandleError(c, badRequest(err))
+		return
+	}
 
-// 	// Aquí iría la lógica de eliminación
-// 	// Por ahora solo retornamos éxito
-// 	SuccessResponse(c, gin.H{
-// 		"id":      id,
-// 		"message": "User deleted",
-// 	})
-// }
+	if err := authorizeUser(c, id); err != nil {
+		HandleError(c, err)
+		return
+	}
 
-// // GetUserOrders muestra nested resources
-// func (h *UserHandler) GetUserOrders(c *gin.Context) {
-// 	idStr := c.Param("id")
-// 	id, err := strconv.Atoi(idStr)
-// 	if err != nil {
-// 		ErrorResponse(c, http.StatusBadRequest, err)
-// 		return
-// 	}
+	if err := h.userService.DeleteUser(c.Request.Context(), id); err != nil {
+		HandleError(c, err)
+		return
+	}
 
-// 	// Simular órdenes
-// 	SuccessResponse(c, gin.H{
-// 		"user_id": id,
-// 		"orders": []gin.H{
-// 			{"id": 1, "total": 99.99, "status": "completed"},
-// 			{"id": 2, "total": 49.99, "status": "processing"},
-// 		},
-// 	})
-// }
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "User deleted successfully",
+	})
+}
+
+// RestoreUser deshace el borrado lógico de un usuario (sólo administradores)
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		HandleError(c, badRequest(err))
+		return
+	}
+
+	user, err := h.userService.RestoreUser(c.Request.Context(), id)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	setETag(c, user.Version)
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    user,
+		Message: "User restored successfully",
+	})
+}
 
 // // NotifyUser con concurrencia en el handler
 // func (h *UserHandler) NotifyUser(c *gin.Context) {
@@ -3,13 +3,15 @@ package handlers_test
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 	"user-management/internal/application/services"
 	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/output"
 	"user-management/internal/infrastructure/http/handlers"
 	"user-management/tests/mocks"
 
@@ -17,6 +19,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type ApiResponse struct {
@@ -42,9 +45,6 @@ func TestUserHandler_CreateUser(t *testing.T) {
 	t.Run("success - creates user", func(t *testing.T) {
 		mockRepo := new(mocks.MockUserRepository)
 
-		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").
-			Return((*entities.User)(nil), nil)
-
 		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("entities.User")).
 			Run(func(args mock.Arguments) {
 				user := args.Get(1).(entities.User)
@@ -71,6 +71,7 @@ func TestUserHandler_CreateUser(t *testing.T) {
 		// ctx, engine := gin.CreateTestContext(w)
 
 		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
 		apiGroup := router.Group("/")
 		handler.RegisterRoutes(apiGroup)
 
@@ -101,15 +102,8 @@ func TestUserHandler_CreateUser(t *testing.T) {
 		mockRepo := new(mocks.MockUserRepository)
 
 		// Simular que el email ya existe
-		existingUser := &entities.User{
-			ID:    uuid.New(),
-			Email: "test@example.com",
-			Name:  "Existing User",
-			Age:   40,
-		}
-
-		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").
-			Return(existingUser, nil)
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("entities.User")).
+			Return(services.ErrEmailAlreadyExists)
 
 		userService := services.NewUserService(mockRepo)
 		handler := handlers.NewUserHandler(userService)
@@ -129,6 +123,7 @@ func TestUserHandler_CreateUser(t *testing.T) {
 		// ctx, _ := gin.CreateTestContext(w)
 
 		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
 		apiGroup := router.Group("/")
 		handler.RegisterRoutes(apiGroup)
 
@@ -138,17 +133,74 @@ func TestUserHandler_CreateUser(t *testing.T) {
 		t.Logf("Response Body: %s", w.Body.String())
 		assert.Equal(t, http.StatusConflict, w.Code)
 
-		var apiResp ApiResponse
-		err := json.Unmarshal(w.Body.Bytes(), &apiResp)
+		var problem handlers.Problem
+		err := json.Unmarshal(w.Body.Bytes(), &problem)
 		assert.NoError(t, err)
-		assert.False(t, apiResp.Success)
-		assert.Contains(t, apiResp.Error, "already")
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		assert.Equal(t, "email_already_exists", problem.Code)
 
 		mockRepo.AssertExpectations(t)
 		mockRepo.AssertNotCalled(t, "Create")
 	})
 }
 
+func TestUserHandler_CreateUser_Validation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		mockRepo := new(mocks.MockUserRepository)
+		handler := handlers.NewUserHandler(services.NewUserService(mockRepo))
+		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
+		handler.RegisterRoutes(router.Group("/"))
+
+		req, _ := http.NewRequest("POST", "/users", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		mockRepo.AssertNotCalled(t, "Save")
+		return w
+	}
+
+	t.Run("reports every invalid field with 422", func(t *testing.T) {
+		w := post(`{"name":"Jo","email":"not-an-email","age":150}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+		var problem handlers.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "validation_failed", problem.Code)
+		assert.Equal(t, errs.Fields{
+			"name":     {Rule: "min", Message: "name must be at least 3 characters"},
+			"email":    {Rule: "email", Message: "email must be a valid email address"},
+			"age":      {Rule: "lte", Message: "age must be less than or equal to 120"},
+			"password": {Rule: "required", Message: "password is required"},
+		}, problem.Errors)
+	})
+
+	t.Run("reports wrong JSON types per field", func(t *testing.T) {
+		w := post(`{"name":"John Doe","email":"john@example.com","age":"thirty","password":"Password123!"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		var problem handlers.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "type", problem.Errors["age"].Rule)
+	})
+
+	t.Run("malformed JSON is a bad request", func(t *testing.T) {
+		w := post(`{"name":`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var problem handlers.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "bad_request", problem.Code)
+		assert.Empty(t, problem.Errors)
+	})
+}
+
 func TestUserHandler_GetUserByID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -175,6 +227,7 @@ func TestUserHandler_GetUserByID(t *testing.T) {
 		w := httptest.NewRecorder()
 
 		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
 		apiGroup := router.Group("/")
 		handler.RegisterRoutes(apiGroup)
 
@@ -207,7 +260,7 @@ func TestUserHandler_GetUserByID(t *testing.T) {
 		userID := uuid.New()
 
 		mockRepo.On("FindByID", mock.Anything, userID).
-			Return((*entities.User)(nil), errors.New("user not found"))
+			Return((*entities.User)(nil), output.ErrUserNotFound)
 
 		userService := services.NewUserService(mockRepo)
 		handler := handlers.NewUserHandler(userService)
@@ -217,6 +270,7 @@ func TestUserHandler_GetUserByID(t *testing.T) {
 		w := httptest.NewRecorder()
 
 		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
 		apiGroup := router.Group("/")
 		handler.RegisterRoutes(apiGroup)
 
@@ -225,14 +279,95 @@ func TestUserHandler_GetUserByID(t *testing.T) {
 		t.Logf("Response Body: %s", w.Body.String())
 		assert.Equal(t, http.StatusNotFound, w.Code)
 
-		var apiResp ApiResponse
-		err := json.Unmarshal(w.Body.Bytes(), &apiResp)
+		var problem handlers.Problem
+		err := json.Unmarshal(w.Body.Bytes(), &problem)
 		assert.NoError(t, err)
-		assert.False(t, apiResp.Success)
-		assert.Contains(t, apiResp.Error, "not found")
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		assert.Equal(t, "user_not_found", problem.Code)
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestUserHandler_CurrentUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(repo *mocks.MockUserRepository, principal *entities.Principal) *gin.Engine {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Request = c.Request.WithContext(entities.ContextWithPrincipal(c.Request.Context(), principal))
+		})
+		handlers.NewUserHandler(services.NewUserService(repo)).RegisterCurrentUserRoutes(router.Group("/"))
+		return router
+	}
+
+	user := &entities.User{ID: uuid.New(), Email: "me@example.com", Name: "Current User", Age: 40, Active: true, Version: 3}
+	principal := &entities.Principal{UserID: user.ID, Role: entities.RoleUser}
+
+	t.Run("GET /me resolves the user from the principal", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		mockRepo.On("FindByID", mock.Anything, user.ID).Return(user.Clone(), nil)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/me", nil)
+		newRouter(mockRepo, principal).ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+		var resp struct {
+			Data UserResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, user.ID, resp.Data.ID)
+	})
 
+	t.Run("PATCH /me updates the profile like PATCH /users/:id", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		mockRepo.On("FindByID", mock.Anything, user.ID).Return(user.Clone(), nil)
+		mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *entities.User) bool {
+			return u.ID == user.ID && u.Name == "Renamed User"
+		})).Return(nil)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PATCH", "/me", bytes.NewBufferString(`{"name":"Renamed User"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		newRouter(mockRepo, principal).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("PATCH /me cannot change active", func(t *testing.T) {
+		for _, tt := range []struct{ contentType, body string }{
+			{"application/merge-patch+json", `{"active":false}`},
+			{"application/json-patch+json", `[{"op":"replace","path":"/active","value":false}]`},
+		} {
+			mockRepo := new(mocks.MockUserRepository)
+			mockRepo.On("FindByID", mock.Anything, user.ID).Return(user.Clone(), nil)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PATCH", "/me", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			newRouter(mockRepo, principal).ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, tt.contentType)
+			mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("credentials without a user get 401", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/me", nil)
+		newRouter(mockRepo, &entities.Principal{Role: entities.RoleAdmin}).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		var problem handlers.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "not_authenticated", problem.Code)
+		mockRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+	})
 }
 
 func TestUserHandler_GetAllUsers(t *testing.T) {
@@ -258,8 +393,8 @@ func TestUserHandler_GetAllUsers(t *testing.T) {
 			},
 		}
 
-		mockRepo.On("GetAllUsers", mock.Anything).
-			Return(users, nil)
+		mockRepo.On("FindUsers", mock.Anything, output.UserQuery{Sort: output.UserSortCreatedAt, Limit: services.DefaultPageSize}).
+			Return(&output.UserPage{Users: users, NextCursor: "next", Total: 3}, nil)
 
 		userService := services.NewUserService(mockRepo)
 		handler := handlers.NewUserHandler(userService)
@@ -269,6 +404,7 @@ func TestUserHandler_GetAllUsers(t *testing.T) {
 		w := httptest.NewRecorder()
 
 		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
 		apiGroup := router.Group("/")
 		handler.RegisterRoutes(apiGroup)
 
@@ -276,10 +412,15 @@ func TestUserHandler_GetAllUsers(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, w.Code)
 
-		var apiResp ApiResponse
+		var apiResp struct {
+			ApiResponse
+			Meta handlers.PageMeta `json:"meta"`
+		}
 		err := json.Unmarshal(w.Body.Bytes(), &apiResp)
 		assert.NoError(t, err)
 		assert.True(t, apiResp.Success)
+		assert.Equal(t, "next", apiResp.Meta.NextCursor)
+		assert.Equal(t, 3, apiResp.Meta.Total)
 
 		dataBytes, _ := json.Marshal(apiResp.Data)
 		var usersResp []UserResponse
@@ -292,8 +433,81 @@ func TestUserHandler_GetAllUsers(t *testing.T) {
 
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("success - passes filters, sort and cursor to the repository", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+
+		mockRepo.On("FindUsers", mock.Anything, mock.MatchedBy(func(q output.UserQuery) bool {
+			return *q.Filter.Active && *q.Filter.MinAge == 18 && q.Filter.NamePrefix == "jo" &&
+				q.Filter.CreatedFrom.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) &&
+				q.Sort == output.UserSortName && q.Desc && q.Limit == 10 && q.Cursor == "abc"
+		})).Return(&output.UserPage{Users: []*entities.User{}}, nil)
+
+		handler := handlers.NewUserHandler(services.NewUserService(mockRepo))
+		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
+		handler.RegisterRoutes(router.Group("/"))
+
+		req, _ := http.NewRequest("GET", "/users?active=true&min_age=18&name=jo&created_from=2024-01-01&sort=-name&limit=10&cursor=abc", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	badRequests := map[string]string{
+		"invalid limit":      "/users?limit=abc",
+		"negative limit":     "/users?limit=-1",
+		"limit too large":    "/users?limit=1000",
+		"invalid active":     "/users?active=maybe",
+		"invalid date":       "/users?created_from=yesterday",
+		"unknown sort field": "/users?sort=password",
+		"inverted age range": "/users?min_age=40&max_age=20",
+	}
+	for name, path := range badRequests {
+		t.Run("failure - "+name, func(t *testing.T) {
+			mockRepo := new(mocks.MockUserRepository)
+			handler := handlers.NewUserHandler(services.NewUserService(mockRepo))
+			router := gin.New()
+			router.Use(withPrincipal(adminPrincipal))
+			handler.RegisterRoutes(router.Group("/"))
+
+			req, _ := http.NewRequest("GET", path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockRepo.AssertNotCalled(t, "FindUsers", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("failure - invalid cursor", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		mockRepo.On("FindUsers", mock.Anything, mock.Anything).Return(nil, output.ErrInvalidCursor)
+
+		handler := handlers.NewUserHandler(services.NewUserService(mockRepo))
+		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
+		handler.RegisterRoutes(router.Group("/"))
+
+		req, _ := http.NewRequest("GET", "/users?cursor=garbage", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// withPrincipal autentica todas las peticiones con principal
+func withPrincipal(principal *entities.Principal) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(entities.ContextWithPrincipal(c.Request.Context(), principal))
+	}
 }
 
+var adminPrincipal = &entities.Principal{Role: entities.RoleAdmin}
+
 func TestUserHandler_UpdateUser(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -337,6 +551,7 @@ func TestUserHandler_UpdateUser(t *testing.T) {
 		w := httptest.NewRecorder()
 
 		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
 		apiGroup := router.Group("/")
 		handler.RegisterRoutes(apiGroup)
 
@@ -363,3 +578,433 @@ func TestUserHandler_UpdateUser(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 }
+
+func TestUserHandler_Ownership(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	owner := &entities.User{ID: uuid.New(), Name: "Account Owner", Email: "owner@example.com", Age: 40, Active: true, Version: 1}
+
+	send := func(mockRepo *mocks.MockUserRepository, principal *entities.Principal, method, contentType, body string) *httptest.ResponseRecorder {
+		router := gin.New()
+		if principal != nil {
+			router.Use(withPrincipal(principal))
+		}
+		handlers.NewUserHandler(services.NewUserService(mockRepo)).RegisterRoutes(router.Group("/"))
+
+		req, _ := http.NewRequest(method, "/users/"+owner.ID.String(), bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	put := `{"name":"Account Owner","email":"owner@example.com","age":41,"active":true}`
+	stranger := &entities.Principal{UserID: uuid.New(), Role: entities.RoleUser}
+
+	t.Run("other users get 403", func(t *testing.T) {
+		for _, tt := range []struct{ method, contentType, body string }{
+			{"PUT", "application/json", put},
+			{"PATCH", "application/merge-patch+json", `{"age":41}`},
+			{"DELETE", "", ""},
+			{"GET", "", ""},
+		} {
+			mockRepo := new(mocks.MockUserRepository)
+
+			w := send(mockRepo, stranger, tt.method, tt.contentType, tt.body)
+
+			assert.Equal(t, http.StatusForbidden, w.Code, tt.method)
+			var problem handlers.Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, "not_account_owner", problem.Code)
+			assert.Empty(t, mockRepo.Calls)
+		}
+	})
+
+	t.Run("only administrators list users", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		router := gin.New()
+		router.Use(withPrincipal(stranger))
+		handlers.NewUserHandler(services.NewUserService(mockRepo)).RegisterRoutes(router.Group("/"))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		var problem handlers.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "list_requires_admin", problem.Code)
+		assert.Empty(t, mockRepo.Calls)
+	})
+
+	t.Run("requests without credentials get 401", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+
+		w := send(mockRepo, nil, "DELETE", "", "")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Empty(t, mockRepo.Calls)
+	})
+
+	t.Run("the owner can edit the profile", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		mockRepo.On("FindByID", mock.Anything, owner.ID).Return(owner.Clone(), nil)
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*entities.User")).Return(nil)
+
+		w := send(mockRepo, &entities.Principal{UserID: owner.ID, Role: entities.RoleUser}, "PUT", "application/json", put)
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("only administrators change active", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		mockRepo.On("FindByID", mock.Anything, owner.ID).Return(owner.Clone(), nil)
+
+		w := send(mockRepo, &entities.Principal{UserID: owner.ID, Role: entities.RoleUser},
+			"PATCH", "application/merge-patch+json", `{"active":false}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		var problem handlers.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "active_requires_admin", problem.Code)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+		mockRepo = new(mocks.MockUserRepository)
+		mockRepo.On("FindByID", mock.Anything, owner.ID).Return(owner.Clone(), nil)
+		mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *entities.User) bool { return !u.Active })).Return(nil)
+
+		w = send(mockRepo, adminPrincipal, "PATCH", "application/merge-patch+json", `{"active":false}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestUserHandler_ReplaceAndPatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	existing := func(id uuid.UUID) *entities.User {
+		return &entities.User{ID: id, Name: "Existing User", Email: "test@example.com", Age: 40, Active: true, Version: 2}
+	}
+
+	send := func(mockRepo *mocks.MockUserRepository, method, path, contentType, body string) *httptest.ResponseRecorder {
+		handler := handlers.NewUserHandler(services.NewUserService(mockRepo))
+		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
+		handler.RegisterRoutes(router.Group("/"))
+
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	saved := func(mockRepo *mocks.MockUserRepository) *entities.User {
+		for _, call := range mockRepo.Calls {
+			if call.Method == "Update" {
+				return call.Arguments.Get(1).(*entities.User)
+			}
+		}
+		return nil
+	}
+
+	problemOf := func(t *testing.T, w *httptest.ResponseRecorder) handlers.Problem {
+		var problem handlers.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		return problem
+	}
+
+	t.Run("put requires the whole document", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		id := uuid.New()
+
+		w := send(mockRepo, "PUT", "/users/"+id.String(), "application/json", `{"name":"Only Name"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		problem := problemOf(t, w)
+		assert.Equal(t, "required", problem.Errors["email"].Rule)
+		assert.Equal(t, "required", problem.Errors["age"].Rule)
+		assert.Equal(t, "required", problem.Errors["active"].Rule)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("merge patch changes only the given fields", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		id := uuid.New()
+		mockRepo.On("FindByID", mock.Anything, id).Return(existing(id), nil)
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*entities.User")).Return(nil)
+
+		w := send(mockRepo, "PATCH", "/users/"+id.String(), "application/merge-patch+json", `{"age":41,"active":false}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		user := saved(mockRepo)
+		require.NotNil(t, user)
+		assert.Equal(t, "Existing User", user.Name)
+		assert.Equal(t, "test@example.com", user.Email)
+		assert.Equal(t, 41, user.Age)
+		assert.False(t, user.Active)
+	})
+
+	t.Run("json patch applies operations after a passing test", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		id := uuid.New()
+		mockRepo.On("FindByID", mock.Anything, id).Return(existing(id), nil)
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*entities.User")).Return(nil)
+
+		patch := `[{"op":"test","path":"/email","value":"test@example.com"},{"op":"replace","path":"/email","value":"new@example.com"}]`
+		w := send(mockRepo, "PATCH", "/users/"+id.String(), "application/json-patch+json", patch)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "new@example.com", saved(mockRepo).Email)
+	})
+
+	t.Run("json patch with a failing test returns 409", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		id := uuid.New()
+		mockRepo.On("FindByID", mock.Anything, id).Return(existing(id), nil)
+
+		patch := `[{"op":"test","path":"/age","value":99},{"op":"replace","path":"/age","value":50}]`
+		w := send(mockRepo, "PATCH", "/users/"+id.String(), "application/json-patch+json", patch)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "patch_test_failed", problemOf(t, w).Code)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("patches that leave an invalid user return 422", func(t *testing.T) {
+		cases := []struct {
+			name, contentType, patch, code string
+		}{
+			{"removed required field", "application/json-patch+json", `[{"op":"remove","path":"/name"}]`, "validation_failed"},
+			{"field out of range", "application/merge-patch+json", `{"age":200}`, "validation_failed"},
+			{"unknown field", "application/merge-patch+json", `{"role":"admin"}`, "patch_not_applicable"},
+			{"read-only field", "application/json-patch+json", `[{"op":"replace","path":"/version","value":9}]`, "patch_not_applicable"},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				mockRepo := new(mocks.MockUserRepository)
+				id := uuid.New()
+				mockRepo.On("FindByID", mock.Anything, id).Return(existing(id), nil)
+
+				w := send(mockRepo, "PATCH", "/users/"+id.String(), tc.contentType, tc.patch)
+
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Equal(t, tc.code, problemOf(t, w).Code)
+				mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("malformed patch returns 400", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		id := uuid.New()
+		mockRepo.On("FindByID", mock.Anything, id).Return(existing(id), nil)
+
+		w := send(mockRepo, "PATCH", "/users/"+id.String(), "application/json-patch+json", `[{"op":"jump","path":"/name"}]`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "invalid_patch", problemOf(t, w).Code)
+	})
+
+	t.Run("other content types return 415", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+
+		w := send(mockRepo, "PATCH", "/users/"+uuid.New().String(), "application/json", `{"age":41}`)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Equal(t, "unsupported_patch_format", problemOf(t, w).Code)
+		mockRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUserHandler_ETags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(mockRepo *mocks.MockUserRepository) *gin.Engine {
+		handler := handlers.NewUserHandler(services.NewUserService(mockRepo))
+		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
+		handler.RegisterRoutes(router.Group("/"))
+		return router
+	}
+
+	putUser := func(router *gin.Engine, id uuid.UUID, ifMatch string) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(map[string]any{
+			"name": "Updated User", "email": "test@example.com", "age": 30, "active": true,
+		})
+		req, _ := http.NewRequest("PUT", "/users/"+id.String(), bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("get returns the version as ETag", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		userID := uuid.New()
+		mockRepo.On("FindByID", mock.Anything, userID).
+			Return(&entities.User{ID: userID, Name: "Existing User", Email: "test@example.com", Version: 3}, nil)
+
+		req, _ := http.NewRequest("GET", "/users/"+userID.String(), nil)
+		w := httptest.NewRecorder()
+		newRouter(mockRepo).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("update with matching If-Match succeeds", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		userID := uuid.New()
+		mockRepo.On("FindByID", mock.Anything, userID).
+			Return(&entities.User{ID: userID, Name: "Existing User", Email: "test@example.com", Version: 3}, nil)
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*entities.User")).
+			Run(func(args mock.Arguments) {
+				args.Get(1).(*entities.User).Version++
+			}).
+			Return(nil)
+
+		w := putUser(newRouter(mockRepo), userID, `"3"`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("update with stale If-Match returns 412", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		userID := uuid.New()
+		mockRepo.On("FindByID", mock.Anything, userID).
+			Return(&entities.User{ID: userID, Name: "Existing User", Email: "test@example.com", Version: 3}, nil)
+
+		w := putUser(newRouter(mockRepo), userID, `"2"`)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("concurrent modification with If-Match returns 412", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		userID := uuid.New()
+		mockRepo.On("FindByID", mock.Anything, userID).
+			Return(&entities.User{ID: userID, Name: "Existing User", Email: "test@example.com", Version: 3}, nil)
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*entities.User")).
+			Return(output.ErrConcurrentModification)
+
+		w := putUser(newRouter(mockRepo), userID, `"3"`)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	})
+
+	t.Run("concurrent modification without If-Match returns 409", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		userID := uuid.New()
+		mockRepo.On("FindByID", mock.Anything, userID).
+			Return(&entities.User{ID: userID, Name: "Existing User", Email: "test@example.com", Version: 3}, nil)
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*entities.User")).
+			Return(output.ErrConcurrentModification)
+
+		w := putUser(newRouter(mockRepo), userID, "")
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestUserHandler_DeleteAndRestore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(mockRepo *mocks.MockUserRepository) *gin.Engine {
+		handler := handlers.NewUserHandler(services.NewUserService(mockRepo))
+		router := gin.New()
+		router.Use(withPrincipal(adminPrincipal))
+		handler.RegisterRoutes(router.Group("/"))
+		handler.RegisterAdminRoutes(router.Group("/admin"))
+		return router
+	}
+
+	serve := func(router *gin.Engine, method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     func(id uuid.UUID) string
+		setup    func(m *mocks.MockUserRepository, id uuid.UUID)
+		expected int
+	}{
+		{
+			name:   "delete soft deletes the user",
+			method: "DELETE",
+			path:   func(id uuid.UUID) string { return "/users/" + id.String() },
+			setup: func(m *mocks.MockUserRepository, id uuid.UUID) {
+				m.On("Delete", mock.Anything, id).Return(nil)
+			},
+			expected: http.StatusOK,
+		},
+		{
+			name:   "delete of unknown user returns 404",
+			method: "DELETE",
+			path:   func(id uuid.UUID) string { return "/users/" + id.String() },
+			setup: func(m *mocks.MockUserRepository, id uuid.UUID) {
+				m.On("Delete", mock.Anything, id).Return(output.ErrUserNotFound)
+			},
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "delete with invalid id returns 400",
+			method:   "DELETE",
+			path:     func(uuid.UUID) string { return "/users/invalid" },
+			setup:    func(*mocks.MockUserRepository, uuid.UUID) {},
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:   "restore returns the user",
+			method: "POST",
+			path:   func(id uuid.UUID) string { return "/admin/users/" + id.String() + "/restore" },
+			setup: func(m *mocks.MockUserRepository, id uuid.UUID) {
+				m.On("Restore", mock.Anything, id).Return(&entities.User{ID: id, Name: "John Doe", Version: 3}, nil)
+			},
+			expected: http.StatusOK,
+		},
+		{
+			name:   "restore of a user that is not deleted returns 409",
+			method: "POST",
+			path:   func(id uuid.UUID) string { return "/admin/users/" + id.String() + "/restore" },
+			setup: func(m *mocks.MockUserRepository, id uuid.UUID) {
+				m.On("Restore", mock.Anything, id).Return(nil, output.ErrUserNotDeleted)
+			},
+			expected: http.StatusConflict,
+		},
+		{
+			name:   "restore of unknown user returns 404",
+			method: "POST",
+			path:   func(id uuid.UUID) string { return "/admin/users/" + id.String() + "/restore" },
+			setup: func(m *mocks.MockUserRepository, id uuid.UUID) {
+				m.On("Restore", mock.Anything, id).Return(nil, output.ErrUserNotFound)
+			},
+			expected: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockUserRepository)
+			userID := uuid.New()
+			tt.setup(mockRepo, userID)
+
+			w := serve(newRouter(mockRepo), tt.method, tt.path(userID))
+
+			assert.Equal(t, tt.expected, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
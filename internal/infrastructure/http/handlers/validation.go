@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"user-management/internal/domain/errs"
+)
+
+// ErrInvalidRequest agrupa los errores por campo de un cuerpo que se pudo
+// leer pero no supera las reglas de validación
+var ErrInvalidRequest = errs.New(errs.Validation, "validation_failed", "request validation failed")
+
+func init() {
+	// Gin valida las etiquetas binding con su propio validador; se le
+	// enseña a nombrar los campos como en el JSON
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonFieldName)
+	}
+}
+
+// newValidator crea un validador con las mismas reglas que el binding de
+// Gin (etiquetas binding) y que nombra los campos como en el JSON
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	v.RegisterTagNameFunc(jsonFieldName)
+	return v
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+// requestError clasifica un error de binding o validación de la petición:
+// las reglas incumplidas y los tipos incorrectos se devuelven por campo
+// (422) y el resto como petición mal formada (400)
+func requestError(err error) error {
+	fields := errs.Fields{}
+
+	var invalid validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &invalid):
+		for _, fe := range invalid {
+			addFieldError(fields, fieldPath(fe), fe)
+		}
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		fields.Add(typeErr.Field, "type", "%s must be of type %s", typeErr.Field, typeErr.Type.String())
+	default:
+		return badRequest(err)
+	}
+	return fields.Err(ErrInvalidRequest)
+}
+
+// fieldPath quita del espacio de nombres del validador el tipo raíz:
+// "CreateUserRequest.items[0].price" -> "items[0].price"
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if _, path, found := strings.Cut(namespace, "."); found {
+		return path
+	}
+	return namespace
+}
+
+// addFieldError registra fe con un mensaje traducible; el nombre del campo y
+// el parámetro de la regla van como argumentos para que el catálogo no
+// dependa de ellos
+func addFieldError(fields errs.Fields, field string, fe validator.FieldError) {
+	param := fe.Param()
+	switch fe.Tag() {
+	case "required":
+		fields.Add(field, fe.Tag(), "%s is required", field)
+	case "email":
+		fields.Add(field, fe.Tag(), "%s must be a valid email address", field)
+	case "min":
+		switch fe.Kind() {
+		case reflect.String:
+			fields.Add(field, fe.Tag(), "%s must be at least %s characters", field, param)
+		case reflect.Slice, reflect.Map, reflect.Array:
+			fields.Add(field, fe.Tag(), "%s must have at least %s items", field, param)
+		default:
+			fields.Add(field, fe.Tag(), "%s must be at least %s", field, param)
+		}
+	case "max":
+		switch fe.Kind() {
+		case reflect.String:
+			fields.Add(field, fe.Tag(), "%s must be at most %s characters", field, param)
+		case reflect.Slice, reflect.Map, reflect.Array:
+			fields.Add(field, fe.Tag(), "%s must have at most %s items", field, param)
+		default:
+			fields.Add(field, fe.Tag(), "%s must be at most %s", field, param)
+		}
+	case "gt":
+		fields.Add(field, fe.Tag(), "%s must be greater than %s", field, param)
+	case "gte":
+		fields.Add(field, fe.Tag(), "%s must be greater than or equal to %s", field, param)
+	case "lt":
+		fields.Add(field, fe.Tag(), "%s must be less than %s", field, param)
+	case "lte":
+		fields.Add(field, fe.Tag(), "%s must be less than or equal to %s", field, param)
+	case "oneof":
+		fields.Add(field, fe.Tag(), "%s must be one of: %s", field, strings.ReplaceAll(param, " ", ", "))
+	default:
+		fields.Add(field, fe.Tag(), "%s does not satisfy the %s rule", field, fe.Tag())
+	}
+}
@@ -1,18 +1,92 @@
 package middlewares
 
 import (
-	"net/http"
+	"crypto/subtle"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/infrastructure/http/handlers"
+)
+
+const (
+	// RoleKey es la clave del contexto de Gin con el rol del llamador
+	RoleKey = "role"
+
+	RoleUser  = entities.RoleUser
+	RoleAdmin = entities.RoleAdmin
+)
+
+var (
+	ErrUnauthorized = errs.New(errs.Unauthorized, "unauthorized", "missing or invalid credentials")
+	ErrForbidden    = errs.New(errs.Forbidden, "forbidden", "insufficient role for this resource")
 )
 
 // AuthMiddleware es un middleware simple de autenticación que verifica
-// la presencia de un token en el encabezado Authorization.
-func AuthMiddleware() gin.HandlerFunc {
+// la presencia de un token en el encabezado Authorization. Si adminToken no
+// está vacío, ese token autentica con rol de administrador. El resto de
+// tokens Bearer se prueban con authenticators, en orden; el principal que
+// devuelvan queda en el contexto de la petición. Si un middleware anterior
+// (p. ej. APIKeyAuth) ya autenticó la petición, no se vuelve a comprobar.
+func AuthMiddleware(adminToken string, authenticators ...input.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := entities.PrincipalFromContext(c.Request.Context()); ok {
+			c.Next()
+			return
+		}
+
+		header := c.GetHeader("Authorization")
+		token, isBearer := strings.CutPrefix(header, "Bearer ")
+
+		var principal *entities.Principal
+		switch {
+		case isBearer && adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1:
+			principal = &entities.Principal{Role: RoleAdmin}
+		case isBearer && token != "":
+			var err error
+			if principal, err = authenticate(c, token, authenticators); err != nil {
+				handlers.HandleError(c, err)
+				return
+			}
+		}
+		if principal == nil {
+			handlers.HandleError(c, ErrUnauthorized)
+			return
+		}
+
+		c.Set(RoleKey, principal.Role)
+		c.Request = c.Request.WithContext(entities.ContextWithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// authenticate devuelve el principal del primer authenticator que acepta el
+// token. Si ninguno lo acepta se devuelve el error del último, siempre que
+// sea de autenticación; cualquier otro fallo se trata como credencial
+// inválida.
+func authenticate(c *gin.Context, token string, authenticators []input.Authenticator) (*entities.Principal, error) {
+	var err error = ErrUnauthorized
+	for _, authenticator := range authenticators {
+		var principal *entities.Principal
+		if principal, err = authenticator.Authenticate(c.Request.Context(), token); err == nil {
+			return principal, nil
+		}
+	}
+	if errs.Is(err, errs.Unauthorized) || errs.Is(err, errs.Forbidden) {
+		return nil, err
+	}
+	return nil, ErrUnauthorized
+}
+
+// RequireRole rechaza con 403 las peticiones autenticadas con otro rol. Debe
+// ir después de AuthMiddleware.
+func RequireRole(role string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.GetHeader("Authorization")
-		if token != "Bearer valid-token" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		if c.GetString(RoleKey) != role {
+			handlers.HandleError(c, ErrForbidden)
 			return
 		}
 		c.Next()
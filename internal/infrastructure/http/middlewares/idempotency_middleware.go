@@ -0,0 +1,190 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/errs"
+	"user-management/internal/domain/ports/output"
+	"user-management/internal/infrastructure/http/handlers"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// DefaultIdempotencyTTL es cuánto se recuerda una clave si no se configura
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	maxIdempotencyKeyLength = 255
+)
+
+var (
+	ErrInvalidIdempotencyKey = errs.New(errs.BadRequest, "invalid_idempotency_key", "Idempotency-Key must be between 1 and 255 characters")
+	ErrIdempotencyKeyReused  = errs.New(errs.Conflict, "idempotency_key_reused", "Idempotency-Key was already used with a different request")
+	ErrIdempotencyInProgress = errs.New(errs.Conflict, "idempotency_request_in_progress", "a request with this Idempotency-Key is still being processed")
+)
+
+// replayedHeaders son las cabeceras de la respuesta original que se repiten
+// al reenviarla
+var replayedHeaders = []string{"Content-Type", "Content-Language", "ETag", "Location"}
+
+// Idempotency hace que los POST con cabecera Idempotency-Key se ejecuten una
+// sola vez por clave y llamador: los reintentos reciben la respuesta
+// guardada, y reutilizar la clave con otra petición devuelve 409. Las
+// respuestas 5xx no se guardan para que el cliente pueda reintentar. Debe ir
+// después de AuthMiddleware; las peticiones sin principal se ejecutan sin
+// idempotencia.
+func Idempotency(store output.IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		principal, authenticated := entities.PrincipalFromContext(c.Request.Context())
+		// Sin principal no hay a quién acotar la clave
+		if c.Request.Method != http.MethodPost || key == "" || !authenticated {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			handlers.HandleError(c, ErrInvalidIdempotencyKey)
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			handlers.HandleError(c, err)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		record := output.IdempotencyRecord{
+			Key:         scopedKey(principal, key),
+			Fingerprint: fingerprint(c, body),
+			ExpiresAt:   time.Now().Add(ttl),
+		}
+
+		existing, reserved, err := store.Reserve(ctx, record)
+		if err != nil {
+			handlers.HandleError(c, err)
+			return
+		}
+		if !reserved {
+			replay(c, existing, record.Fingerprint)
+			return
+		}
+
+		// Si el handler falla o hace panic se libera la reserva
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := store.Release(ctx, record.Key); err != nil {
+				log.Printf("error liberando la clave de idempotencia: %v", err)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		record.Completed = true
+		record.Status = status
+		record.Body = recorder.body.Bytes()
+		record.Header = make(map[string][]string)
+		for _, name := range replayedHeaders {
+			if values := recorder.Header().Values(name); len(values) > 0 {
+				record.Header[name] = values
+			}
+		}
+		if err := store.Complete(ctx, record); err != nil {
+			log.Printf("error guardando la respuesta idempotente: %v", err)
+			return
+		}
+		completed = true
+	}
+}
+
+func replay(c *gin.Context, record *output.IdempotencyRecord, fingerprint string) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		handlers.HandleError(c, ErrIdempotencyKeyReused)
+	case !record.Completed:
+		handlers.HandleError(c, ErrIdempotencyInProgress)
+	default:
+		for name, values := range record.Header {
+			for _, value := range values {
+				c.Writer.Header().Add(name, value)
+			}
+		}
+		c.Header(IdempotentReplayedHeader, "true")
+		c.AbortWithStatus(record.Status)
+		_, _ = c.Writer.Write(record.Body)
+	}
+}
+
+// scopedKey acota la clave al principal para que dos llamadores no
+// compartan respuestas, y para que un mismo usuario las conserve aunque
+// renueve su token.
+func scopedKey(principal *entities.Principal, key string) string {
+	return hash(callerScope(principal), key)
+}
+
+// callerScope identifica al llamador: la API key usada, el usuario o, para el
+// token estático de administración, su rol. Al suplantar se añade el
+// administrador para que no comparta claves con el usuario suplantado.
+func callerScope(principal *entities.Principal) string {
+	switch {
+	case principal.APIKeyID != uuid.Nil:
+		return "api_key:" + principal.APIKeyID.String()
+	case principal.UserID != uuid.Nil && principal.Actor != nil:
+		return "user:" + principal.UserID.String() + "/actor:" + callerScope(principal.Actor)
+	case principal.UserID != uuid.Nil:
+		return "user:" + principal.UserID.String()
+	default:
+		return "role:" + principal.Role
+	}
+}
+
+func fingerprint(c *gin.Context, body []byte) string {
+	return hash(c.Request.Method, c.Request.URL.RequestURI(), string(body))
+}
+
+func hash(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder copia el cuerpo de la respuesta mientras se escribe
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
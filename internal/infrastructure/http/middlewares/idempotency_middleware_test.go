@@ -0,0 +1,154 @@
+package middlewares_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"user-management/internal/domain/entities"
+	"user-management/internal/infrastructure/http/handlers"
+	"user-management/internal/infrastructure/http/middlewares"
+	"user-management/internal/infrastructure/persistence/memory"
+)
+
+var (
+	alice, bob = uuid.New(), uuid.New()
+
+	// idempotencyPrincipals son los principales que autentica cada token en
+	// newIdempotentRouter
+	idempotencyPrincipals = map[string]*entities.Principal{
+		"Bearer a":         {UserID: alice, Role: entities.RoleUser, SessionID: uuid.New()},
+		"Bearer a-renewed": {UserID: alice, Role: entities.RoleUser, SessionID: uuid.New()},
+		"Bearer b":         {UserID: bob, Role: entities.RoleUser},
+		"Bearer a-key":     {UserID: alice, Role: entities.RoleUser, APIKeyID: uuid.New()},
+		"Bearer as-a":      {UserID: alice, Role: entities.RoleUser, Actor: &entities.Principal{UserID: bob, Role: entities.RoleAdmin}},
+	}
+)
+
+func newIdempotentRouter(status int) (*gin.Engine, *int32) {
+	gin.SetMode(gin.TestMode)
+	var calls int32
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if principal, ok := idempotencyPrincipals[c.GetHeader("Authorization")]; ok {
+			c.Request = c.Request.WithContext(entities.ContextWithPrincipal(c.Request.Context(), principal))
+		}
+	})
+	router.Use(middlewares.Idempotency(memory.NewIdempotencyStore(), time.Hour))
+	router.POST("/users", func(c *gin.Context) {
+		n := atomic.AddInt32(&calls, 1)
+		c.Header("ETag", `"1"`)
+		c.JSON(status, gin.H{"call": n})
+	})
+	return router, &calls
+}
+
+func post(router *gin.Engine, key, token, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/users", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", token)
+	if key != "" {
+		req.Header.Set(middlewares.IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency(t *testing.T) {
+	t.Run("replays the first response for the same key", func(t *testing.T) {
+		router, calls := newIdempotentRouter(http.StatusCreated)
+
+		first := post(router, "key-1", "Bearer a", `{"name":"John"}`)
+		retry := post(router, "key-1", "Bearer a", `{"name":"John"}`)
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+		assert.Equal(t, http.StatusCreated, retry.Code)
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+		assert.Equal(t, `"1"`, retry.Header().Get("ETag"))
+		assert.Equal(t, "application/json; charset=utf-8", retry.Header().Get("Content-Type"))
+		assert.Equal(t, "true", retry.Header().Get(middlewares.IdempotentReplayedHeader))
+		assert.Empty(t, first.Header().Get(middlewares.IdempotentReplayedHeader))
+	})
+
+	t.Run("rejects the same key with a different body", func(t *testing.T) {
+		router, calls := newIdempotentRouter(http.StatusCreated)
+
+		post(router, "key-1", "Bearer a", `{"name":"John"}`)
+		w := post(router, "key-1", "Bearer a", `{"name":"Jane"}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		var problem handlers.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "idempotency_key_reused", problem.Code)
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	})
+
+	t.Run("keys are scoped to the caller", func(t *testing.T) {
+		for _, other := range []string{"Bearer b", "Bearer a-key", "Bearer as-a"} {
+			router, calls := newIdempotentRouter(http.StatusCreated)
+
+			post(router, "key-1", "Bearer a", `{}`)
+			w := post(router, "key-1", other, `{}`)
+
+			assert.Empty(t, w.Header().Get(middlewares.IdempotentReplayedHeader), other)
+			assert.Equal(t, int32(2), atomic.LoadInt32(calls), other)
+		}
+	})
+
+	t.Run("keys survive a renewed token of the same user", func(t *testing.T) {
+		router, calls := newIdempotentRouter(http.StatusCreated)
+
+		post(router, "key-1", "Bearer a", `{}`)
+		w := post(router, "key-1", "Bearer a-renewed", `{}`)
+
+		assert.Equal(t, "true", w.Header().Get(middlewares.IdempotentReplayedHeader))
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	})
+
+	t.Run("unauthenticated requests run every time", func(t *testing.T) {
+		router, calls := newIdempotentRouter(http.StatusCreated)
+
+		post(router, "key-1", "", `{}`)
+		post(router, "key-1", "", `{}`)
+
+		assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	})
+
+	t.Run("does not store server errors", func(t *testing.T) {
+		router, calls := newIdempotentRouter(http.StatusInternalServerError)
+
+		post(router, "key-1", "Bearer a", `{}`)
+		post(router, "key-1", "Bearer a", `{}`)
+
+		assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	})
+
+	t.Run("requests without a key run every time", func(t *testing.T) {
+		router, calls := newIdempotentRouter(http.StatusCreated)
+
+		post(router, "", "Bearer a", `{}`)
+		post(router, "", "Bearer a", `{}`)
+
+		assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	})
+
+	t.Run("rejects keys that are too long", func(t *testing.T) {
+		router, calls := newIdempotentRouter(http.StatusCreated)
+
+		w := post(router, string(bytes.Repeat([]byte("k"), 256)), "Bearer a", `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, int32(0), atomic.LoadInt32(calls))
+	})
+}
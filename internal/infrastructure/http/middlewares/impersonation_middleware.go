@@ -0,0 +1,50 @@
+package middlewares
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+)
+
+// ImpersonatedByHeader va en las respuestas a peticiones hechas suplantando
+// a un usuario, con el identificador del administrador ("admin" si es el
+// token estático)
+const ImpersonatedByHeader = "X-Impersonated-By"
+
+// Impersonation marca y audita las peticiones con token de suplantación:
+// añade ImpersonatedByHeader a la respuesta y, al terminar, registra en audit
+// qué hizo el administrador y con qué resultado. Debe ir después de
+// AuthMiddleware.
+func Impersonation(audit output.AuditLog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := entities.PrincipalFromContext(c.Request.Context())
+		if !ok || !principal.Impersonated() {
+			c.Next()
+			return
+		}
+
+		actor := "admin"
+		if principal.Actor.UserID != uuid.Nil {
+			actor = principal.Actor.UserID.String()
+		}
+		c.Header(ImpersonatedByHeader, actor)
+
+		c.Next()
+
+		entry := entities.NewAuditEntry(entities.AuditImpersonatedRequest, principal.Actor.UserID, "user:"+principal.UserID.String())
+		entry.IP = c.ClientIP()
+		entry.Details = map[string]string{
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+			"status": strconv.Itoa(c.Writer.Status()),
+		}
+		if err := audit.Record(c.Request.Context(), entry); err != nil {
+			log.Printf("no se pudo registrar %s de %s en la auditoría: %v", entry.Action, entry.Target, err)
+		}
+	}
+}
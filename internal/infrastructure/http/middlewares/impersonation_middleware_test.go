@@ -0,0 +1,61 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"user-management/internal/domain/entities"
+	"user-management/internal/infrastructure/http/middlewares"
+	"user-management/tests/mocks"
+)
+
+func TestImpersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(principal *entities.Principal) (*httptest.ResponseRecorder, *mocks.AuditLogFake) {
+		audit := &mocks.AuditLogFake{}
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Request = c.Request.WithContext(entities.ContextWithPrincipal(c.Request.Context(), principal))
+		}, middlewares.Impersonation(audit))
+		router.GET("/me", func(c *gin.Context) { c.Status(http.StatusTeapot) })
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/me", nil)
+		router.ServeHTTP(w, req)
+		return w, audit
+	}
+
+	t.Run("impersonated requests are marked and audited", func(t *testing.T) {
+		userID, adminID := uuid.New(), uuid.New()
+		w, audit := serve(&entities.Principal{
+			UserID: userID, Role: entities.RoleUser,
+			Actor: &entities.Principal{UserID: adminID, Role: entities.RoleAdmin},
+		})
+
+		assert.Equal(t, adminID.String(), w.Header().Get(middlewares.ImpersonatedByHeader))
+		entries := audit.Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, entities.AuditImpersonatedRequest, entries[0].Action)
+		assert.Equal(t, adminID, entries[0].ActorID)
+		assert.Equal(t, "user:"+userID.String(), entries[0].Target)
+		assert.Equal(t, map[string]string{"method": "GET", "path": "/me", "status": "418"}, entries[0].Details)
+	})
+
+	t.Run("the static admin token shows as admin", func(t *testing.T) {
+		w, _ := serve(&entities.Principal{UserID: uuid.New(), Actor: &entities.Principal{Role: entities.RoleAdmin}})
+		assert.Equal(t, "admin", w.Header().Get(middlewares.ImpersonatedByHeader))
+	})
+
+	t.Run("regular requests are left alone", func(t *testing.T) {
+		w, audit := serve(&entities.Principal{UserID: uuid.New(), Role: entities.RoleUser})
+		assert.Empty(t, w.Header().Get(middlewares.ImpersonatedByHeader))
+		assert.Empty(t, audit.Entries())
+	})
+}
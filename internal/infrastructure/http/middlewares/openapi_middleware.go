@@ -0,0 +1,124 @@
+package middlewares
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"user-management/internal/domain/errs"
+	"user-management/internal/infrastructure/http/handlers"
+	"user-management/pkg/openapi"
+)
+
+var (
+	ErrInvalidParameters    = errs.New(errs.BadRequest, "invalid_parameters", "invalid path or query parameters")
+	ErrUnsupportedMediaType = errs.New(errs.Unsupported, "unsupported_media_type", "unsupported Content-Type for this operation")
+)
+
+// OpenAPIOptions configura OpenAPIValidator
+type OpenAPIOptions struct {
+	// ValidateResponses comprueba también las respuestas contra la
+	// especificación. Obliga a copiar cada cuerpo, así que está pensado para
+	// tests y entornos de prueba.
+	ValidateResponses bool
+	// OnResponseViolation recibe las respuestas que no cumplen la
+	// especificación; por defecto se registran en el log
+	OnResponseViolation func(c *gin.Context, err error)
+}
+
+// routeParam reconoce los parámetros de las rutas de Gin (:id, *path)
+var routeParam = regexp.MustCompile(`[:*]([^/]+)`)
+
+// OpenAPIValidator rechaza las peticiones cuyos parámetros de ruta y query
+// (400) o cuerpo JSON (422) no cumplen la operación documentada en doc. Las
+// rutas que el documento no describe pasan sin comprobar. Debe ir después de
+// AuthMiddleware para no adelantar errores de validación a los 401.
+func OpenAPIValidator(doc *openapi.Document, opts OpenAPIOptions) gin.HandlerFunc {
+	if opts.OnResponseViolation == nil {
+		opts.OnResponseViolation = func(c *gin.Context, err error) {
+			log.Printf("respuesta fuera de contrato en %s %s: %v", c.Request.Method, c.FullPath(), err)
+		}
+	}
+
+	return func(c *gin.Context) {
+		path, ok := strings.CutPrefix(c.FullPath(), doc.BasePath())
+		if !ok {
+			c.Next()
+			return
+		}
+		op, ok := doc.Operation(c.Request.Method, routeParam.ReplaceAllString(path, "{$1}"))
+		if !ok {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				handlers.HandleError(c, err)
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		req := openapi.Request{
+			PathParams:  make(map[string]string, len(c.Params)),
+			Query:       c.Request.URL.Query(),
+			ContentType: c.GetHeader("Content-Type"),
+			Body:        body,
+		}
+		for _, param := range c.Params {
+			req.PathParams[param.Key] = param.Value
+		}
+
+		if err := op.ValidateParameters(req); err != nil {
+			handlers.HandleError(c, contractError(ErrInvalidParameters, err))
+			return
+		}
+		if err := op.ValidateBody(req); err != nil {
+			handlers.HandleError(c, contractError(handlers.ErrInvalidRequest, err))
+			return
+		}
+
+		if !opts.ValidateResponses {
+			c.Next()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		err := op.ValidateResponse(recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+		if err != nil {
+			opts.OnResponseViolation(c, err)
+		}
+	}
+}
+
+// contractError traduce un error de openapi al error tipado que ve el
+// cliente; los incumplimientos del esquema se devuelven por campo con la
+// clase de base
+func contractError(base *errs.Error, err error) error {
+	var invalid *openapi.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		fields := errs.Fields{}
+		for _, v := range invalid.Violations {
+			fields.Add(v.Field, v.Rule, v.Message, v.Args...)
+		}
+		return fields.Err(base)
+	case errors.Is(err, openapi.ErrUnsupportedMediaType):
+		return fmt.Errorf("%w: %v", ErrUnsupportedMediaType, err)
+	case errors.Is(err, openapi.ErrMalformedBody):
+		return fmt.Errorf("%w: %v", handlers.ErrBadRequest, err)
+	}
+	return err
+}
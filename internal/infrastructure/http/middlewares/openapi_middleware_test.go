@@ -0,0 +1,685 @@
+package middlewares_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"user-management/internal/application/services"
+	"user-management/internal/infrastructure/http/docs"
+	"user-management/internal/infrastructure/http/handlers"
+	"user-management/internal/infrastructure/http/middlewares"
+	"user-management/internal/infrastructure/persistence/memory"
+	"user-management/internal/infrastructure/workers"
+	"user-management/pkg/jwt"
+	"user-management/pkg/totp"
+	"user-management/tests/mocks"
+)
+
+// newContractRouter monta la API como cmd/api/main.go con el validador
+// OpenAPI comprobando también las respuestas: cualquier respuesta fuera de
+// contrato hace fallar el test
+func newContractRouter(t *testing.T) *gin.Engine {
+	return newContractRouterWith(t, &mocks.NotifierMock{})
+}
+
+// oidcKey firma los tokens del proveedor OpenID Connect de todos los
+// routers; generar una clave RSA por test ralentiza la suite
+var oidcKey = sync.OnceValue(func() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+})
+
+// newContractRouterWith es newContractRouter con los emails enviados a
+// notifier
+func newContractRouterWith(t *testing.T, notifier *mocks.NotifierMock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	doc, err := docs.Document()
+	require.NoError(t, err)
+	contract := middlewares.OpenAPIValidator(doc, middlewares.OpenAPIOptions{
+		ValidateResponses: true,
+		OnResponseViolation: func(c *gin.Context, err error) {
+			t.Errorf("%s %s no cumple la especificación: %v", c.Request.Method, c.Request.URL, err)
+		},
+	})
+
+	users := memory.NewUserRepository()
+	orders := memory.NewOrderRepository()
+	worker := workers.NewWorkerPool(1, 10)
+	orderService := services.NewOrderService(orders, users, worker, memory.NewUnitOfWork(users, orders))
+	require.NoError(t, orderService.Start(t.Context()))
+	t.Cleanup(func() { worker.Stop(t.Context()) })
+
+	signer := jwt.HS256([]byte("test-secret"))
+	tokens, limiter := memory.NewOneTimeTokenStore(), memory.NewRateLimiter()
+	verification := services.NewEmailVerificationService(users, tokens, limiter, notifier, signer, services.EmailVerificationOptions{})
+	passwords := services.NewPasswordService(users, tokens, limiter, notifier, services.PasswordOptions{})
+	guard := services.NewLoginGuard(memory.NewLoginAttemptStore(), users, memory.NewAuditLog(100), services.LoginGuardOptions{})
+	sessions := services.NewSessionService(memory.NewSessionStore(), users, memory.NewAuditLog(100), services.SessionOptions{})
+	authService := services.NewAuthService(users, limiter, guard, sessions, signer, services.AuthOptions{})
+	authHandler := handlers.NewAuthHandler(authService, verification, passwords)
+	mfaHandler := handlers.NewMFAHandler(services.NewMFAService(users, services.MFAOptions{}))
+	apiKeys := services.NewAPIKeyService(memory.NewAPIKeyRepository(), users, memory.NewAuditLog(100))
+
+	router := gin.New()
+	public := router.Group("/api/v1", contract)
+	handlers.NewHealthHandler().RegisterRoutes(public)
+	authHandler.RegisterRoutes(public)
+
+	audit := memory.NewAuditLog(100)
+	impersonation := services.NewImpersonationService(users, signer, audit, services.ImpersonationOptions{})
+	oidc := services.NewOIDCService(memory.NewOAuthClientRepository(), users, tokens, memory.NewOIDCSessionStore(), oidcKey(),
+		services.OIDCOptions{Issuer: "http://localhost:8080"})
+
+	api := router.Group("/api/v1", middlewares.APIKeyAuth(apiKeys), middlewares.AuthMiddleware("admin-token", authService),
+		middlewares.RequireScope(), middlewares.Impersonation(audit), contract)
+	authHandler.RegisterAuthenticatedRoutes(api)
+	mfaHandler.RegisterAuthenticatedRoutes(api)
+	handlers.NewAPIKeyHandler(apiKeys).RegisterAuthenticatedRoutes(api)
+	sessionHandler := handlers.NewSessionHandler(sessions)
+	sessionHandler.RegisterAuthenticatedRoutes(api)
+	userHandler := handlers.NewUserHandler(services.NewUserService(users, services.WithEmailVerification(verification)))
+	userHandler.RegisterRoutes(api)
+	userHandler.RegisterCurrentUserRoutes(api)
+	admin := api.Group("/admin", middlewares.RequireRole(middlewares.RoleAdmin))
+	userHandler.RegisterAdminRoutes(admin)
+	handlers.NewLockoutHandler(guard).RegisterAdminRoutes(admin)
+	sessionHandler.RegisterAdminRoutes(admin)
+	handlers.NewImpersonationHandler(impersonation).RegisterAdminRoutes(admin)
+	handlers.NewOIDCHandler(oidc, authService).RegisterAdminRoutes(admin)
+	orderHandler := handlers.NewOrderHandler(orderService)
+	orderHandler.RegisterRoutes(api)
+	orderHandler.RegisterCurrentUserRoutes(api)
+	return router
+}
+
+// call hace la petición con el token de administración
+func call(router *gin.Engine, method, path, contentType, body string) *httptest.ResponseRecorder {
+	return callAs(router, "admin-token", method, path, contentType, body)
+}
+
+// callAs hace la petición con token como credencial Bearer
+func callAs(router *gin.Engine, token, method, path, contentType, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) handlers.Problem {
+	var problem handlers.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	return problem
+}
+
+func TestOpenAPIValidator_ResponsesMatchSpec(t *testing.T) {
+	router := newContractRouter(t)
+
+	created := call(router, "POST", "/api/v1/users", "application/json",
+		`{"name":"John Doe","email":"john@example.com","age":30,"password":"secret123"}`)
+	require.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+
+	var body struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(created.Body.Bytes(), &body))
+	user := "/api/v1/users/" + body.Data.ID
+
+	// Los pedidos son del usuario que los hace
+	owner := login(t, router, "john@example.com", "secret123")
+	placed := callAs(router, owner, "POST", "/api/v1/orders", "application/json",
+		`{"items":[{"product_id":1,"name":"Book","quantity":2,"price":10}]}`)
+	require.Equal(t, http.StatusAccepted, placed.Code, placed.Body.String())
+	var orderBody struct {
+		Data struct {
+			ID int `json:"id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(placed.Body.Bytes(), &orderBody))
+	order := fmt.Sprintf("/api/v1/orders/%d", orderBody.Data.ID)
+
+	// Las cuentas y los pedidos ajenos sólo los toca un administrador; sin
+	// token se usa el de otro usuario
+	other := signUp(t, router, "Mallory", "mallory@example.com")
+	requests := []struct {
+		token, method, path, contentType, body string
+		status                                 int
+	}{
+		{"", "GET", "/api/v1/health", "", "", http.StatusOK},
+		{"", "GET", "/api/v1/metrics", "", "", http.StatusOK},
+		{"admin-token", "GET", "/api/v1/users?active=true&sort=-name&limit=5", "", "", http.StatusOK},
+		{"", "GET", "/api/v1/users", "", "", http.StatusForbidden},
+		{owner, "GET", user, "", "", http.StatusOK},
+		{"", "GET", user, "", "", http.StatusForbidden},
+		{"admin-token", "GET", "/api/v1/users/7b6d3c1e-2f4a-4d5b-9c8e-1a2b3c4d5e6f", "", "", http.StatusNotFound},
+		{"admin-token", "PUT", user, "application/json", `{"name":"Jane Doe","email":"jane@example.com","age":31,"active":true}`, http.StatusOK},
+		{"", "PUT", user, "application/json", `{"name":"Jane Doe","email":"jane@example.com","age":31,"active":true}`, http.StatusForbidden},
+		{"admin-token", "PATCH", user, "application/merge-patch+json", `{"age":32}`, http.StatusOK},
+		{"admin-token", "PATCH", user, "application/json-patch+json", `[{"op":"test","path":"/age","value":1}]`, http.StatusConflict},
+		{"admin-token", "GET", user + "/orders", "", "", http.StatusOK},
+		{"", "GET", user + "/orders", "", "", http.StatusForbidden},
+		{owner, "GET", "/api/v1/orders?status=pending&sort=-total", "", "", http.StatusOK},
+		{"admin-token", "POST", "/api/v1/orders", "application/json", `{"items":[{"product_id":1,"name":"Book","quantity":2,"price":10}]}`, http.StatusUnauthorized},
+		{owner, "GET", order, "", "", http.StatusOK},
+		{owner, "GET", "/api/v1/orders/1", "", "", http.StatusNotFound},
+		{owner, "POST", order + "/cancel", "", "", http.StatusOK},
+		{"", "POST", "/api/v1/admin/users/" + body.Data.ID + "/restore", "", "", http.StatusForbidden},
+		{"", "DELETE", user, "", "", http.StatusForbidden},
+		{"admin-token", "DELETE", user, "", "", http.StatusOK},
+	}
+
+	for _, r := range requests {
+		token := r.token
+		if token == "" {
+			token = other
+		}
+		w := callAs(router, token, r.method, r.path, r.contentType, r.body)
+		assert.Equal(t, r.status, w.Code, "%s %s: %s", r.method, r.path, w.Body.String())
+	}
+}
+
+func TestOpenAPIValidator_AuthFlow(t *testing.T) {
+	router := newContractRouter(t)
+
+	created := call(router, "POST", "/api/v1/users", "application/json",
+		`{"name":"John Doe","email":"john@example.com","age":30,"password":"secret123"}`)
+	require.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+
+	failed := call(router, "POST", "/api/v1/auth/login", "application/json", `{"email":"john@example.com","password":"wrong"}`)
+	assert.Equal(t, http.StatusUnauthorized, failed.Code)
+	assert.Equal(t, "invalid_credentials", decodeProblem(t, failed).Code)
+
+	login := call(router, "POST", "/api/v1/auth/login", "application/json", `{"email":"john@example.com","password":"secret123"}`)
+	require.Equal(t, http.StatusOK, login.Code, login.Body.String())
+	var token struct {
+		Data struct {
+			AccessToken string `json:"access_token"`
+			TokenType   string `json:"token_type"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(login.Body.Bytes(), &token))
+	assert.Equal(t, "Bearer", token.Data.TokenType)
+
+	authorized := func(header string) int {
+		req, _ := http.NewRequest("GET", "/api/v1/me", nil)
+		req.Header.Set("Authorization", header)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, authorized("Bearer "+token.Data.AccessToken))
+	assert.Equal(t, http.StatusUnauthorized, authorized("Bearer "+token.Data.AccessToken+"x"))
+
+	invalid := call(router, "POST", "/api/v1/auth/verify-email", "application/json", `{"token":"garbage"}`)
+	assert.Equal(t, http.StatusBadRequest, invalid.Code)
+	assert.Equal(t, "invalid_token", decodeProblem(t, invalid).Code)
+
+	resend := call(router, "POST", "/api/v1/auth/verify-email/resend", "application/json", `{"email":"john@example.com"}`)
+	assert.Equal(t, http.StatusAccepted, resend.Code, resend.Body.String())
+}
+
+// signUp da de alta a un usuario con la contraseña secret123 y devuelve su
+// token de acceso
+func signUp(t *testing.T, router *gin.Engine, name, email string) string {
+	w := call(router, "POST", "/api/v1/users", "application/json",
+		`{"name":"`+name+`","email":"`+email+`","age":30,"password":"secret123"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	return login(t, router, email, "secret123")
+}
+
+// login inicia sesión y devuelve el token de acceso
+func login(t *testing.T, router *gin.Engine, email, password string) string {
+	w := call(router, "POST", "/api/v1/auth/login", "application/json",
+		`{"email":"`+email+`","password":"`+password+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var body struct {
+		Data struct {
+			AccessToken string `json:"access_token"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body.Data.AccessToken
+}
+
+func TestOpenAPIValidator_PasswordFlow(t *testing.T) {
+	notifier := &mocks.NotifierMock{}
+	router := newContractRouterWith(t, notifier)
+	created := call(router, "POST", "/api/v1/users", "application/json",
+		`{"name":"John Doe","email":"john@example.com","age":30,"password":"secret123"}`)
+	require.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	session := login(t, router, "john@example.com", "secret123")
+
+	t.Run("forgotten passwords are reset once with the emailed token", func(t *testing.T) {
+		w := call(router, "POST", "/api/v1/auth/password/forgot", "application/json", `{"email":"nobody@example.com"}`)
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		w = call(router, "POST", "/api/v1/auth/password/forgot", "application/json", `{"email":"john@example.com"}`)
+		require.Equal(t, http.StatusAccepted, w.Code)
+
+		sent := notifier.Sent()
+		last := sent[len(sent)-1]
+		require.Equal(t, services.PurposePasswordReset, last.Type)
+		token := last.Message[strings.LastIndex(last.Message, " ")+1:]
+
+		w = call(router, "POST", "/api/v1/auth/password/reset", "application/json", `{"token":"`+token+`","password":"short"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		reset := `{"token":"` + token + `","password":"new-secret123"}`
+		w = call(router, "POST", "/api/v1/auth/password/reset", "application/json", reset)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w = call(router, "POST", "/api/v1/auth/password/reset", "application/json", reset)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "invalid_token", decodeProblem(t, w).Code)
+
+		assert.Equal(t, http.StatusUnauthorized, callAs(router, session, "GET", "/api/v1/users", "", "").Code)
+		session = login(t, router, "john@example.com", "new-secret123")
+	})
+
+	t.Run("changing the password requires the current one and closes sessions", func(t *testing.T) {
+		w := callAs(router, session, "POST", "/api/v1/auth/password/change", "application/json",
+			`{"current_password":"wrong-password","password":"other-secret123"}`)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "wrong_password", decodeProblem(t, w).Code)
+
+		w = callAs(router, session, "POST", "/api/v1/auth/password/change", "application/json",
+			`{"current_password":"new-secret123","password":"other-secret123"}`)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		assert.Equal(t, http.StatusUnauthorized, callAs(router, session, "GET", "/api/v1/users", "", "").Code)
+		login(t, router, "john@example.com", "other-secret123")
+	})
+
+	t.Run("credentials without a user cannot change passwords", func(t *testing.T) {
+		w := callAs(router, "admin-token", "POST", "/api/v1/auth/password/change", "application/json",
+			`{"current_password":"secret123","password":"other-secret123"}`)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "not_authenticated", decodeProblem(t, w).Code)
+	})
+}
+
+func TestOpenAPIValidator_MFAFlow(t *testing.T) {
+	router := newContractRouter(t)
+	created := call(router, "POST", "/api/v1/users", "application/json",
+		`{"name":"John Doe","email":"john@example.com","age":30,"password":"secret123"}`)
+	require.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	session := login(t, router, "john@example.com", "secret123")
+
+	w := callAs(router, session, "POST", "/api/v1/auth/mfa/enroll", "", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var enrollment struct {
+		Data struct {
+			Secret string `json:"secret"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enrollment))
+	secret := enrollment.Data.Secret
+	code := func(steps int64) string {
+		c, err := totp.Code(secret, totp.Step(time.Now())+steps)
+		require.NoError(t, err)
+		return c
+	}
+
+	w = callAs(router, session, "POST", "/api/v1/auth/mfa/confirm", "application/json", `{"code":"`+code(0)+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = call(router, "POST", "/api/v1/auth/login", "application/json", `{"email":"john@example.com","password":"secret123"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var challenge struct {
+		Data struct {
+			MFARequired bool   `json:"mfa_required"`
+			MFAToken    string `json:"mfa_token"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &challenge))
+	assert.True(t, challenge.Data.MFARequired)
+	assert.Equal(t, http.StatusUnauthorized, callAs(router, challenge.Data.MFAToken, "GET", "/api/v1/users", "", "").Code)
+
+	verify := func(code string) *httptest.ResponseRecorder {
+		return call(router, "POST", "/api/v1/auth/mfa/verify", "application/json",
+			`{"mfa_token":"`+challenge.Data.MFAToken+`","code":"`+code+`"}`)
+	}
+	w = verify("000000")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "invalid_mfa_code", decodeProblem(t, w).Code)
+
+	w = verify(code(1))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	user := callAs(router, session, "GET", "/api/v1/me", "", "")
+	assert.Contains(t, user.Body.String(), `"mfa_enabled":true`)
+}
+
+func TestOpenAPIValidator_LoginLockout(t *testing.T) {
+	router := newContractRouter(t)
+	created := call(router, "POST", "/api/v1/users", "application/json",
+		`{"name":"John Doe","email":"john@example.com","age":30,"password":"secret123"}`)
+	require.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	var user struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(created.Body.Bytes(), &user))
+
+	wrong := `{"email":"john@example.com","password":"wrong-password"}`
+	for range services.DefaultAccountPolicy.FreeFailures + 1 {
+		w := call(router, "POST", "/api/v1/auth/login", "application/json", wrong)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	w := call(router, "POST", "/api/v1/auth/login", "application/json", `{"email":"john@example.com","password":"secret123"}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "too_many_requests", decodeProblem(t, w).Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	unlock := "/api/v1/admin/users/" + user.Data.ID + "/unlock"
+	other := signUp(t, router, "Jane Doe", "jane@example.com")
+	assert.Equal(t, http.StatusForbidden, callAs(router, other, "POST", unlock, "", "").Code)
+	w = callAs(router, "admin-token", "POST", unlock, "", "")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	login(t, router, "john@example.com", "secret123")
+}
+
+func TestOpenAPIValidator_APIKeyFlow(t *testing.T) {
+	router := newContractRouter(t)
+	created := call(router, "POST", "/api/v1/users", "application/json",
+		`{"name":"John Doe","email":"john@example.com","age":30,"password":"secret123"}`)
+	require.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	token := login(t, router, "john@example.com", "secret123")
+
+	w := callAs(router, token, "POST", "/api/v1/api-keys", "application/json", `{"name":"ci","scopes":["users:read"]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var key struct {
+		Data struct {
+			ID  string `json:"id"`
+			Key string `json:"key"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &key))
+
+	withKey := func(method, path string, header, value string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set(header, value)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusOK, withKey("GET", "/api/v1/me", "Authorization", "ApiKey "+key.Data.Key).Code)
+	assert.Equal(t, http.StatusOK, withKey("GET", "/api/v1/me", "X-API-Key", key.Data.Key).Code)
+
+	// Fuera de sus scopes, incluida la gestión de keys, la key no sirve
+	w = withKey("GET", "/api/v1/orders", "X-API-Key", key.Data.Key)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "insufficient_scope", decodeProblem(t, w).Code)
+	assert.Equal(t, http.StatusForbidden, withKey("GET", "/api/v1/api-keys", "X-API-Key", key.Data.Key).Code)
+
+	w = callAs(router, token, "GET", "/api/v1/api-keys", "", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), key.Data.Key)
+	assert.Contains(t, w.Body.String(), `"last_used_at"`)
+
+	w = callAs(router, token, "DELETE", "/api/v1/api-keys/"+key.Data.ID, "", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = withKey("GET", "/api/v1/users", "X-API-Key", key.Data.Key)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "api_key_rejected", decodeProblem(t, w).Code)
+}
+
+func TestOpenAPIValidator_SessionFlow(t *testing.T) {
+	router := newContractRouter(t)
+	created := call(router, "POST", "/api/v1/users", "application/json",
+		`{"name":"John Doe","email":"john@example.com","age":30,"password":"secret123"}`)
+	require.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	var user struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(created.Body.Bytes(), &user))
+
+	w := call(router, "POST", "/api/v1/auth/login", "application/json", `{"email":"john@example.com","password":"secret123"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var tokens struct {
+		Data struct {
+			AccessToken  string `json:"access_token"`
+			RefreshToken string `json:"refresh_token"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	other := login(t, router, "john@example.com", "secret123")
+
+	w = call(router, "POST", "/api/v1/auth/refresh", "application/json", `{"refresh_token":"`+tokens.Data.RefreshToken+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = call(router, "POST", "/api/v1/auth/refresh", "application/json", `{"refresh_token":"`+tokens.Data.RefreshToken+`"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "invalid_refresh_token", decodeProblem(t, w).Code)
+	// Canjear dos veces el refresh token cierra la sesión
+	assert.Equal(t, http.StatusUnauthorized, callAs(router, tokens.Data.AccessToken, "GET", "/api/v1/me/sessions", "", "").Code)
+
+	current := login(t, router, "john@example.com", "secret123")
+
+	w = callAs(router, current, "GET", "/api/v1/me/sessions", "", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var sessions struct {
+		Data []struct {
+			ID      string `json:"id"`
+			Current bool   `json:"current"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sessions))
+	require.Len(t, sessions.Data, 2)
+
+	var otherSession string
+	for _, session := range sessions.Data {
+		if !session.Current {
+			otherSession = session.ID
+		}
+	}
+	w = callAs(router, current, "DELETE", "/api/v1/me/sessions/"+otherSession, "", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, callAs(router, other, "GET", "/api/v1/me/sessions", "", "").Code)
+
+	w = callAs(router, "admin-token", "POST", "/api/v1/admin/users/"+user.Data.ID+"/logout", "", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, callAs(router, current, "GET", "/api/v1/me/sessions", "", "").Code)
+}
+
+func TestOpenAPIValidator_CurrentUserFlow(t *testing.T) {
+	router := newContractRouter(t)
+	created := call(router, "POST", "/api/v1/users", "application/json",
+		`{"name":"John Doe","email":"john@example.com","age":30,"password":"secret123"}`)
+	require.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	token := login(t, router, "john@example.com", "secret123")
+
+	w := callAs(router, token, "GET", "/api/v1/me", "", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"john@example.com"`)
+
+	w = callAs(router, token, "PATCH", "/api/v1/me", "application/merge-patch+json", `{"name":"Johnny Doe"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"Johnny Doe"`)
+
+	w = callAs(router, token, "GET", "/api/v1/me/orders", "", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"order_count":0`)
+
+	// El token de admin no corresponde a ningún usuario
+	w = callAs(router, "admin-token", "GET", "/api/v1/me", "", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "not_authenticated", decodeProblem(t, w).Code)
+
+	w = callAs(router, token, "PUT", "/api/v1/me/password", "application/json",
+		`{"current_password":"secret123","password":"new-secret456"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, callAs(router, token, "GET", "/api/v1/me", "", "").Code)
+	login(t, router, "john@example.com", "new-secret456")
+}
+
+func TestOpenAPIValidator_ImpersonationFlow(t *testing.T) {
+	router := newContractRouter(t)
+	created := call(router, "POST", "/api/v1/users", "application/json",
+		`{"name":"John Doe","email":"john@example.com","age":30,"password":"secret123"}`)
+	require.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	var user struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(created.Body.Bytes(), &user))
+	impersonate := "/api/v1/admin/users/" + user.Data.ID + "/impersonate"
+
+	userToken := login(t, router, "john@example.com", "secret123")
+	w := callAs(router, userToken, "POST", impersonate, "application/json", `{"reason":"TICKET-1"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = callAs(router, "admin-token", "POST", impersonate, "application/json", `{"reason":"TICKET-1"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var token struct {
+		Data struct {
+			AccessToken string `json:"access_token"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &token))
+
+	w = callAs(router, token.Data.AccessToken, "GET", "/api/v1/me", "", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "admin", w.Header().Get(middlewares.ImpersonatedByHeader))
+	assert.Contains(t, w.Body.String(), `"john@example.com"`)
+
+	w = callAs(router, token.Data.AccessToken, "PUT", "/api/v1/me/password", "application/json",
+		`{"current_password":"secret123","password":"new-secret456"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "impersonation_restricted", decodeProblem(t, w).Code)
+	assert.Equal(t, http.StatusForbidden, callAs(router, token.Data.AccessToken, "POST", impersonate, "application/json",
+		`{"reason":"again"}`).Code)
+}
+
+func TestOpenAPIValidator_OAuthClientFlow(t *testing.T) {
+	router := newContractRouter(t)
+
+	w := callAs(router, "admin-token", "POST", "/api/v1/admin/oauth/clients", "application/json",
+		`{"name":"Intranet","redirect_uris":["https://intranet.example.com/callback"],"confidential":true}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var client struct {
+		Data struct {
+			ClientID     string `json:"client_id"`
+			ClientSecret string `json:"client_secret"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &client))
+	assert.NotEmpty(t, client.Data.ClientSecret)
+
+	w = callAs(router, "admin-token", "POST", "/api/v1/admin/oauth/clients", "application/json",
+		`{"name":"SPA","redirect_uris":["http://app.example.com/callback"]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "invalid_oauth_client", decodeProblem(t, w).Code)
+
+	w = callAs(router, "admin-token", "GET", "/api/v1/admin/oauth/clients", "", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), client.Data.ClientSecret)
+
+	w = callAs(router, "admin-token", "DELETE", "/api/v1/admin/oauth/clients/"+client.Data.ClientID, "", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = callAs(router, "admin-token", "DELETE", "/api/v1/admin/oauth/clients/"+client.Data.ClientID, "", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	userToken := signUp(t, router, "John Doe", "john@example.com")
+	assert.Equal(t, http.StatusForbidden, callAs(router, userToken, "GET", "/api/v1/admin/oauth/clients", "", "").Code)
+}
+
+func TestOpenAPIValidator_RejectsInvalidRequests(t *testing.T) {
+	router := newContractRouter(t)
+
+	t.Run("invalid parameters are a bad request with per-field errors", func(t *testing.T) {
+		w := call(router, "GET", "/api/v1/users/42?limit=0", "", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		problem := decodeProblem(t, w)
+		assert.Equal(t, "invalid_parameters", problem.Code)
+		assert.Equal(t, "id must be a UUID", problem.Errors["id"].Message)
+	})
+
+	t.Run("invalid query values are reported by name", func(t *testing.T) {
+		w := call(router, "GET", "/api/v1/orders?limit=0&sort=price", "", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		problem := decodeProblem(t, w)
+		assert.Equal(t, "gte", problem.Errors["limit"].Rule)
+		assert.Equal(t, "oneof", problem.Errors["sort"].Rule)
+	})
+
+	t.Run("invalid bodies are unprocessable with per-field errors", func(t *testing.T) {
+		w := call(router, "POST", "/api/v1/orders", "application/json",
+			`{"user_id":1,"items":[{"product_id":0,"quantity":"two","price":1}]}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		problem := decodeProblem(t, w)
+		assert.Equal(t, "validation_failed", problem.Code)
+		assert.Equal(t, "gte", problem.Errors["items[0].product_id"].Rule)
+		assert.Equal(t, "items[0].quantity must be of type integer", problem.Errors["items[0].quantity"].Message)
+	})
+
+	t.Run("messages follow Accept-Language", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/api/v1/users", bytes.NewBufferString(`{"name":"Jo","email":"john@example.com","password":"secret123"}`))
+		req.Header.Set("Authorization", "Bearer admin-token")
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", "es")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "name debe tener al menos 3 caracteres", decodeProblem(t, w).Errors["name"].Message)
+	})
+
+	t.Run("undocumented content types are unsupported", func(t *testing.T) {
+		w := call(router, "POST", "/api/v1/users", "text/plain", `name=John`)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Equal(t, "unsupported_media_type", decodeProblem(t, w).Code)
+	})
+
+	t.Run("malformed JSON is a bad request", func(t *testing.T) {
+		w := call(router, "POST", "/api/v1/users", "application/json", `{"name":`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "bad_request", decodeProblem(t, w).Code)
+	})
+
+	t.Run("only the configured admin token is accepted as is", func(t *testing.T) {
+		for _, token := range []string{"valid-token", "admin-tokenX", "admin-toke"} {
+			w := callAs(router, token, "GET", "/api/v1/users", "", "")
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code, token)
+		}
+	})
+
+	t.Run("authentication runs before validation", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/users?limit=0", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
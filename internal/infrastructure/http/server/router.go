@@ -0,0 +1,154 @@
+// Package server monta la API HTTP: middlewares, grupos de rutas y handlers
+// sobre los servicios de aplicación ya construidos.
+package server
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"user-management/internal/application/services"
+	"user-management/internal/domain/ports/input"
+	"user-management/internal/domain/ports/output"
+	"user-management/internal/infrastructure/http/docs"
+	"user-management/internal/infrastructure/http/handlers"
+	"user-management/internal/infrastructure/http/middlewares"
+)
+
+// Services reúne los servicios que publica la API. OIDC es opcional: con
+// nil no se montan sus rutas.
+type Services struct {
+	Users         input.UserService
+	Orders        input.OrderService
+	Auth          *services.AuthService
+	Verification  *services.EmailVerificationService
+	Passwords     *services.PasswordService
+	MFA           *services.MFAService
+	APIKeys       *services.APIKeyService
+	Sessions      *services.SessionService
+	LoginGuard    *services.LoginGuard
+	Impersonation *services.ImpersonationService
+	OIDC          *services.OIDCService
+
+	AuditLog    output.AuditLog
+	Idempotency output.IdempotencyStore
+}
+
+// Config ajusta el montaje de la API
+type Config struct {
+	// AdminToken autentica como administrador; vacío lo desactiva
+	AdminToken string
+	// SCIMToken protege /scim/v2; vacío no publica SCIM
+	SCIMToken string
+	// IdempotencyTTL es cuánto se recuerdan las Idempotency-Key
+	IdempotencyTTL time.Duration
+	// Contract son los middlewares de validación OpenAPI, si se activan
+	Contract []gin.HandlerFunc
+	// Metrics se publican en /metrics bajo su nombre
+	Metrics map[string]func() any
+}
+
+// NewRouter monta la API completa sobre svc
+func NewRouter(svc Services, cfg Config) (*gin.Engine, error) {
+	// gin.New en lugar de gin.Default: el log de peticiones es el nuestro
+	router := gin.New()
+
+	// Middlewares globales
+	router.Use(middlewares.LoggingMiddleware())
+	router.Use(gin.Recovery()) // Recupera de panics
+
+	// CORS básico
+	router.Use(func(c *gin.Context) {
+		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, If-Match, Idempotency-Key")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag, Idempotent-Replayed, X-Impersonated-By")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	})
+
+	// Rutas públicas
+	public := router.Group("/api/v1")
+	public.Use(cfg.Contract...)
+	{
+		healthHandler := handlers.NewHealthHandler()
+		for name, source := range cfg.Metrics {
+			healthHandler.AddMetrics(name, source)
+		}
+		healthHandler.RegisterRoutes(public)
+
+		authHandler := handlers.NewAuthHandler(svc.Auth, svc.Verification, svc.Passwords)
+		authHandler.RegisterRoutes(public)
+	}
+
+	// Rutas protegidas (con autenticación)
+	api := router.Group("/api/v1")
+	api.Use(middlewares.APIKeyAuth(svc.APIKeys))
+	api.Use(middlewares.AuthMiddleware(cfg.AdminToken, svc.Auth)) // Middleware de auth
+	// Las API keys sólo llegan a los recursos de sus scopes
+	api.Use(middlewares.RequireScope())
+	api.Use(middlewares.Impersonation(svc.AuditLog))
+	api.Use(cfg.Contract...)
+	// Los POST con Idempotency-Key no se repiten al reintentarlos
+	api.Use(middlewares.Idempotency(svc.Idempotency, cfg.IdempotencyTTL))
+	{
+		// Cuenta del usuario autenticado
+		authHandler := handlers.NewAuthHandler(svc.Auth, svc.Verification, svc.Passwords)
+		authHandler.RegisterAuthenticatedRoutes(api)
+		mfaHandler := handlers.NewMFAHandler(svc.MFA)
+		mfaHandler.RegisterAuthenticatedRoutes(api)
+		apiKeyHandler := handlers.NewAPIKeyHandler(svc.APIKeys)
+		apiKeyHandler.RegisterAuthenticatedRoutes(api)
+		sessionHandler := handlers.NewSessionHandler(svc.Sessions)
+		sessionHandler.RegisterAuthenticatedRoutes(api)
+
+		// Users
+		userHandler := handlers.NewUserHandler(svc.Users)
+		userHandler.RegisterRoutes(api)
+		userHandler.RegisterCurrentUserRoutes(api)
+
+		// Administración
+		admin := api.Group("/admin")
+		admin.Use(middlewares.RequireRole(middlewares.RoleAdmin))
+		userHandler.RegisterAdminRoutes(admin)
+		lockoutHandler := handlers.NewLockoutHandler(svc.LoginGuard)
+		lockoutHandler.RegisterAdminRoutes(admin)
+		sessionHandler.RegisterAdminRoutes(admin)
+		impersonationHandler := handlers.NewImpersonationHandler(svc.Impersonation)
+		impersonationHandler.RegisterAdminRoutes(admin)
+		if svc.OIDC != nil {
+			handlers.NewOIDCHandler(svc.OIDC, svc.Auth).RegisterAdminRoutes(admin)
+		}
+
+		// Orders
+		orderHandler := handlers.NewOrderHandler(svc.Orders)
+		orderHandler.RegisterRoutes(api)
+		orderHandler.RegisterCurrentUserRoutes(api)
+	}
+
+	// Aprovisionamiento SCIM 2.0 desde el directorio corporativo
+	if cfg.SCIMToken != "" {
+		scim := router.Group("/scim/v2", middlewares.SCIMAuth(cfg.SCIMToken))
+		scimHandler := handlers.NewSCIMHandler(svc.Users)
+		scimHandler.RegisterRoutes(scim)
+	}
+
+	// Endpoints del proveedor OpenID Connect, en la raíz como espera
+	// /.well-known/openid-configuration. La autorización autentica ella
+	// misma al usuario, con un token Bearer o con su formulario de login.
+	if svc.OIDC != nil {
+		handlers.NewOIDCHandler(svc.OIDC, svc.Auth).RegisterRoutes(router.Group(""))
+	}
+
+	// Servir documentación
+	if err := docs.Register(router); err != nil {
+		return nil, err
+	}
+
+	return router, nil
+}
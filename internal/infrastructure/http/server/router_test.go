@@ -0,0 +1,142 @@
+package server_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"user-management/internal/application/services"
+	"user-management/internal/infrastructure/http/docs"
+	"user-management/internal/infrastructure/http/server"
+	"user-management/internal/infrastructure/persistence/memory"
+)
+
+// newTestRouter monta la API como cmd/api/main.go con todas las partes
+// opcionales activadas. Los servicios que no hacen falta para registrar las
+// rutas se dejan a nil.
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	users := memory.NewUserRepository()
+	oidc := services.NewOIDCService(memory.NewOAuthClientRepository(), users, memory.NewOneTimeTokenStore(),
+		memory.NewOIDCSessionStore(), key, services.OIDCOptions{Issuer: "http://localhost:8080"})
+
+	router, err := server.NewRouter(server.Services{OIDC: oidc}, server.Config{
+		AdminToken: "admin-token",
+		SCIMToken:  "scim-token",
+	})
+	require.NoError(t, err)
+	return router
+}
+
+// undocumented son las rutas que openapi.yaml no describe a propósito
+// porque siguen su propia especificación
+var undocumented = []string{
+	// La propia documentación
+	"GET /docs",
+	"GET /docs/openapi.json",
+
+	// SCIM 2.0 (RFC 7643/7644), publicado en /scim/v2
+	"GET /scim/v2/Users",
+	"POST /scim/v2/Users",
+	"GET /scim/v2/Users/{id}",
+	"PUT /scim/v2/Users/{id}",
+	"PATCH /scim/v2/Users/{id}",
+	"DELETE /scim/v2/Users/{id}",
+
+	// OpenID Connect: descubrimiento y endpoints de OAuth en la raíz;
+	// authorize responde con el formulario HTML de login y consentimiento
+	"GET /.well-known/openid-configuration",
+	"GET /oauth/jwks",
+	"GET /oauth/authorize",
+	"POST /oauth/authorize",
+	"POST /oauth/token",
+	"GET /oauth/userinfo",
+	"POST /oauth/userinfo",
+}
+
+// registeredOperations devuelve las rutas como "MÉTODO /ruta/{param}"; las
+// de la API, relativas a /api/v1 como en openapi.yaml
+func registeredOperations(router *gin.Engine) []string {
+	param := regexp.MustCompile(`:([^/]+)`)
+	var operations []string
+	for _, route := range router.Routes() {
+		path := route.Path
+		if relative, ok := strings.CutPrefix(path, "/api/v1"); ok {
+			path = relative
+		}
+		operations = append(operations, route.Method+" "+param.ReplaceAllString(path, "{$1}"))
+	}
+	sort.Strings(operations)
+	return operations
+}
+
+func TestNewRouter_DocumentsEveryRoute(t *testing.T) {
+	raw, err := docs.Spec()
+	require.NoError(t, err)
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &spec))
+
+	var documented []string
+	for path, item := range spec.Paths {
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(documented)
+
+	registered := registeredOperations(newTestRouter(t))
+	require.NotEmpty(t, registered)
+	for _, op := range registered {
+		if slices.Contains(undocumented, op) {
+			continue
+		}
+		assert.Contains(t, documented, op, "la ruta no está en openapi.yaml ni en undocumented")
+	}
+	for _, op := range documented {
+		assert.Contains(t, registered, op, "openapi.yaml documenta una ruta que no existe")
+	}
+	for _, op := range undocumented {
+		assert.Contains(t, registered, op, "undocumented lista una ruta que no existe")
+		assert.NotContains(t, documented, op, "la ruta está documentada; sobra en undocumented")
+	}
+}
+
+func TestNewRouter_RecoversWithoutGinLogger(t *testing.T) {
+	// gin.Logger escribe en gin.DefaultWriter al crearse: si NewRouter lo
+	// montara, además de LoggingMiddleware, cada petición se registraría dos
+	// veces
+	var ginLog bytes.Buffer
+	defaultWriter := gin.DefaultWriter
+	gin.DefaultWriter = &ginLog
+	t.Cleanup(func() { gin.DefaultWriter = defaultWriter })
+
+	router := newTestRouter(t)
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, ginLog.String())
+}
@@ -0,0 +1,165 @@
+package i18n
+
+// spanish traduce los mensajes de error y validación. Los verbos de fmt deben
+// mantenerse en el mismo orden que en el original.
+var spanish = map[string]string{
+	// Estados HTTP (título de los problem+json)
+	"Bad Request":            "Petición incorrecta",
+	"Unauthorized":           "No autenticado",
+	"Forbidden":              "Prohibido",
+	"Not Found":              "No encontrado",
+	"Conflict":               "Conflicto",
+	"Precondition Failed":    "Precondición fallida",
+	"Unprocessable Entity":   "Entidad no procesable",
+	"Internal Server Error":  "Error interno del servidor",
+	"Service Unavailable":    "Servicio no disponible",
+	"Unsupported Media Type": "Tipo de contenido no soportado",
+	"Too Many Requests":      "Demasiadas peticiones",
+
+	// Errores del dominio y de la aplicación
+	"bad request":                              "petición incorrecta",
+	"request validation failed":                "la petición no supera la validación",
+	"invalid pagination cursor":                "cursor de paginación no válido",
+	"invalid query":                            "consulta no válida",
+	"invalid user ID":                          "ID de usuario no válido",
+	"invalid order ID":                         "ID de pedido no válido",
+	"user not found":                           "usuario no encontrado",
+	"order not found":                          "pedido no encontrado",
+	"email already exists":                     "el email ya existe",
+	"user already exists":                      "el usuario ya existe",
+	"resource was modified concurrently":       "el recurso fue modificado por otra petición",
+	"resource version does not match If-Match": "la versión del recurso no coincide con If-Match",
+	"user is not deleted":                      "el usuario no está borrado",
+	"order already completed":                  "el pedido ya está completado",
+	"order worker unavailable":                 "el procesador de pedidos no está disponible",
+	"request timed out or was cancelled":       "la petición superó el tiempo límite o fue cancelada",
+	"missing or invalid credentials":           "credenciales ausentes o no válidas",
+	"insufficient role for this resource":      "el rol no permite acceder a este recurso",
+	"invalid email format":                     "formato de email no válido",
+	"password hash cannot be empty":            "la contraseña no puede estar vacía",
+	"invalid format":                           "formato no válido",
+	"user cannot be nil":                       "falta el usuario",
+	"invalid user":                             "usuario no válido",
+	"invalid order":                            "pedido no válido",
+	"invalid order item":                       "línea de pedido no válida",
+	"PATCH requires application/merge-patch+json or application/json-patch+json": "PATCH requiere application/merge-patch+json o application/json-patch+json",
+	"invalid patch document":                                "documento de parche no válido",
+	"patch test operation failed":                           "no se cumple una operación test del parche",
+	"patch cannot be applied to the resource":               "el parche no se puede aplicar al recurso",
+	"too many requests, try again later":                    "demasiadas peticiones, inténtelo más tarde",
+	"token is invalid, expired or already used":             "el token no es válido, ha caducado o ya se usó",
+	"invalid email or password":                             "email o contraseña incorrectos",
+	"email address has not been verified":                   "la dirección de email no está verificada",
+	"account is disabled":                                   "la cuenta está desactivada",
+	"access token is invalid or expired":                    "el token de acceso no es válido o ha caducado",
+	"current password is incorrect":                         "la contraseña actual no es correcta",
+	"this operation requires a user account":                "esta operación requiere una cuenta de usuario",
+	"too many failed attempts, try again later":             "demasiados intentos fallidos, inténtelo más tarde",
+	"MFA challenge is invalid or expired":                   "el reto de MFA no es válido o ha caducado",
+	"invalid authentication code":                           "el código de autenticación no es válido",
+	"multi-factor authentication is already enabled":        "la autenticación multifactor ya está activada",
+	"multi-factor authentication is not enabled":            "la autenticación multifactor no está activada",
+	"multi-factor enrollment has not been started":          "no se ha iniciado el alta de la autenticación multifactor",
+	"invalid path or query parameters":                      "parámetros de ruta o de consulta no válidos",
+	"unsupported Content-Type for this operation":           "Content-Type no admitido en esta operación",
+	"API key not found":                                     "API key no encontrada",
+	"invalid API key":                                       "API key no válida",
+	"API key is already revoked":                            "la API key ya está revocada",
+	"API key is invalid, expired or revoked":                "la API key no es válida, ha caducado o está revocada",
+	"scope cannot be granted by this account":               "esta cuenta no puede conceder el scope",
+	"API keys cannot manage API keys":                       "una API key no puede gestionar API keys",
+	"refresh token is invalid or expired":                   "el refresh token no es válido o ha caducado",
+	"session not found":                                     "sesión no encontrada",
+	"this action is not allowed while impersonating a user": "esta acción no está permitida al suplantar a un usuario",
+	"this user cannot be impersonated":                      "no se puede suplantar a este usuario",
+	"invalid SCIM filter":                                   "filtro SCIM no válido",
+	"unsupported filter %q":                                 "filtro no admitido %q",
+	"invalid SCIM attribute path":                           "ruta de atributo SCIM no válida",
+	"invalid SCIM request":                                  "petición SCIM no válida",
+	"invalid SCIM attribute value":                          "valor de atributo SCIM no válido",
+	"SCIM path did not match any value":                     "la ruta SCIM no selecciona ningún valor",
+	"this operation requires authentication":                "esta operación requiere autenticación",
+	"API key lacks the scope required for this resource":    "la API key no tiene el scope que exige este recurso",
+	"invalid OAuth client":                                  "cliente OAuth no válido",
+	"OAuth client not found":                                "cliente OAuth no encontrado",
+	"client_id is not registered":                           "client_id no está registrado",
+	"redirect_uri is not registered for this client":        "redirect_uri no está registrada para este cliente",
+	"the form has expired, please try again":                "el formulario ha caducado, vuelve a intentarlo",
+	"invalid request":                                       "petición no válida",
+	"request is missing a required parameter":               "falta un parámetro obligatorio en la petición",
+	"token request must be form-encoded":                    "la petición de token debe ir codificada como formulario",
+	"client credentials must be sent once":                  "las credenciales del cliente deben enviarse una sola vez",
+	"client authentication failed":                          "no se pudo autenticar al cliente",
+	"authorization code is invalid or already used":         "el código de autorización no es válido o ya se usó",
+	"only the authorization_code grant is supported":        "sólo se admite el grant authorization_code",
+
+	// Idempotencia
+	"Idempotency-Key must be between 1 and 255 characters":         "Idempotency-Key debe tener entre 1 y 255 caracteres",
+	"Idempotency-Key was already used with a different request":    "Idempotency-Key ya se usó con otra petición",
+	"a request with this Idempotency-Key is still being processed": "una petición con esta Idempotency-Key todavía se está procesando",
+
+	// Propiedad de las cuentas
+	"only the account owner or an administrator can access this account": "sólo el dueño de la cuenta o un administrador puede acceder a ella",
+	"only administrators can activate or deactivate accounts":            "sólo un administrador puede activar o desactivar cuentas",
+	"only administrators can list users":                                 "sólo un administrador puede listar los usuarios",
+
+	// Detalle de consultas
+	"%s must be an integer":                "%s debe ser un número entero",
+	"%s must be a number":                  "%s debe ser un número",
+	"%s must be true or false":             "%s debe ser true o false",
+	"%s must be an RFC 3339 date":          "%s debe ser una fecha RFC 3339",
+	"%s must be a UUID":                    "%s debe ser un UUID",
+	"limit must be positive":               "limit debe ser positivo",
+	"limit must be between 1 and %d":       "limit debe estar entre 1 y %d",
+	"unknown sort field %q":                "campo de ordenación desconocido %q",
+	"offset is negative":                   "offset es negativo",
+	"offset and cursor are exclusive":      "offset y cursor son excluyentes",
+	"unknown status %q":                    "estado desconocido %q",
+	"min_age is greater than max_age":      "min_age es mayor que max_age",
+	"min_total is greater than max_total":  "min_total es mayor que max_total",
+	"created_from is after created_to":     "created_from es posterior a created_to",
+	"completed_from is after completed_to": "completed_from es posterior a completed_to",
+	"schemas must include %s":              "schemas debe incluir %s",
+
+	// Reglas de validación de la petición
+	"%s is required":                               "%s es obligatorio",
+	"%s must be a valid email address":             "%s debe ser un email válido",
+	"%s must be at least %s characters":            "%s debe tener al menos %s caracteres",
+	"%s must have at least %s items":               "%s debe tener al menos %s elementos",
+	"%s must be at least %s":                       "%s debe ser como mínimo %s",
+	"%s must be at most %s characters":             "%s debe tener como máximo %s caracteres",
+	"%s must have at most %s items":                "%s debe tener como máximo %s elementos",
+	"%s must be at most %s":                        "%s debe ser como máximo %s",
+	"%s must be greater than %s":                   "%s debe ser mayor que %s",
+	"%s must be greater than or equal to %s":       "%s debe ser mayor o igual que %s",
+	"%s must be less than %s":                      "%s debe ser menor que %s",
+	"%s must be less than or equal to %s":          "%s debe ser menor o igual que %s",
+	"%s must be one of: %s":                        "%s debe ser uno de: %s",
+	"%s does not satisfy the %s rule":              "%s no cumple la regla %s",
+	"%s must be of type %s":                        "%s debe ser de tipo %s",
+	"%s is not allowed":                            "%s no está permitido",
+	"%s does not match any of the allowed schemas": "%s no coincide con ninguno de los esquemas permitidos",
+
+	// Reglas de validación del dominio
+	"name must be at least 2 characters":            "name debe tener al menos 2 caracteres",
+	"name cannot be empty":                          "name no puede estar vacío",
+	"age must be between 0 and 120":                 "age debe estar entre 0 y 120",
+	"email cannot be empty":                         "email no puede estar vacío",
+	"email must be a valid email address":           "email debe ser un email válido",
+	"password is required":                          "password es obligatorio",
+	"password must be at least 8 characters":        "password debe tener al menos 8 caracteres",
+	"password must be at most 72 bytes":             "password debe tener como máximo 72 bytes",
+	"invalid product ID":                            "ID de producto no válido",
+	"quantity must be positive":                     "quantity debe ser positivo",
+	"price cannot be negative":                      "price no puede ser negativo",
+	"order must have at least one item":             "el pedido debe tener al menos una línea",
+	"total cannot be negative":                      "total no puede ser negativo",
+	"completed date cannot be before creation date": "la fecha de completado no puede ser anterior a la de creación",
+	"name is required":                              "name es obligatorio",
+	"name must be at most %d characters":            "name debe tener como máximo %d caracteres",
+	"scopes is required":                            "scopes es obligatorio",
+	"unknown scope %s":                              "scope desconocido %s",
+	"expires_at must be in the future":              "expires_at debe ser una fecha futura",
+	"redirect_uris is required":                     "redirect_uris es obligatorio",
+	"invalid redirect URI %s":                       "URI de redirección no válida %s",
+}
@@ -0,0 +1,57 @@
+// Package i18n traduce los mensajes que la API devuelve a los clientes. Los
+// mensajes se escriben en inglés en el código y son la clave del catálogo
+// de cada idioma; los códigos de error no se traducen.
+package i18n
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+)
+
+// Locale es un idioma soportado por la API
+type Locale string
+
+const (
+	English Locale = "en"
+	Spanish Locale = "es"
+
+	// Default es el idioma de los mensajes en el código y el que se usa si
+	// el cliente no pide ninguno soportado
+	Default = English
+)
+
+var (
+	supported = []Locale{English, Spanish}
+	matcher   = language.NewMatcher([]language.Tag{language.English, language.Spanish})
+
+	catalogs = map[Locale]map[string]string{
+		Spanish: spanish,
+	}
+)
+
+// Negotiate elige el idioma de la respuesta a partir de la cabecera
+// Accept-Language, respetando los pesos q
+func Negotiate(acceptLanguage string) Locale {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+	return supported[index]
+}
+
+// Translate implementa errs.Translator. Los mensajes sin traducción se
+// devuelven en inglés.
+func (l Locale) Translate(format string, args ...any) string {
+	if translated, ok := catalogs[l][format]; ok {
+		format = translated
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,46 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected Locale
+	}{
+		{"", English},
+		{"es", Spanish},
+		{"es-MX,es;q=0.9", Spanish},
+		{"fr-FR, es;q=0.5, en;q=0.4", Spanish},
+		{"en-US,en;q=0.9,es;q=0.8", English},
+		{"de", English},
+		{"not a language header;;", English},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Negotiate(tt.header))
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	assert.Equal(t, "usuario no encontrado", Spanish.Translate("user not found"))
+	assert.Equal(t, "name debe tener al menos 3 caracteres", Spanish.Translate("%s must be at least %s characters", "name", "3"))
+	assert.Equal(t, "name must be at least 3 characters", English.Translate("%s must be at least %s characters", "name", "3"))
+	assert.Equal(t, "untranslated message", Spanish.Translate("untranslated message"))
+}
+
+func TestCatalogsKeepFormatVerbs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[a-z]`)
+	for locale, catalog := range catalogs {
+		for source, translated := range catalog {
+			assert.Equal(t, verbs.FindAllString(source, -1), verbs.FindAllString(translated, -1),
+				"%s: %q", locale, source)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// Package notifications contiene los canales por los que se envían las
+// notificaciones a los usuarios
+package notifications
+
+import (
+	"log"
+	"user-management/internal/domain/entities"
+)
+
+// LogNotifier escribe las notificaciones en el log en lugar de enviarlas.
+// Sirve para desarrollo: permite copiar los enlaces de verificación.
+type LogNotifier struct{}
+
+var _ entities.Notifier = LogNotifier{}
+
+func (LogNotifier) Send(notification *entities.Notification) error {
+	log.Printf("notificación %s para %s: %s - %s",
+		notification.Type, notification.Email, notification.Title, notification.Message)
+	notification.Sent = true
+	return nil
+}
+
+func (LogNotifier) GetType() string {
+	return "log"
+}
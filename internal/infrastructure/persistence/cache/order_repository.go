@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+)
+
+// OrderRepository decora un output.OrderRepository cacheando FindByID. Las
+// escrituras pasan al repositorio decorado e invalidan la entrada afectada.
+// Las entradas se indexan por el ID numérico de la orden, que es el que
+// conocen tanto FindByID (a través de id.ID()) como Update.
+type OrderRepository struct {
+	next output.OrderRepository
+	byID *readThrough[int, entities.Order]
+}
+
+var _ output.OrderRepository = (*OrderRepository)(nil)
+
+func NewOrderRepository(next output.OrderRepository, opts Options) *OrderRepository {
+	return &OrderRepository{
+		next: next,
+		byID: newReadThrough[int](opts, (*entities.Order).Clone),
+	}
+}
+
+// Stats devuelve las métricas de aciertos y fallos de la caché
+func (o *OrderRepository) Stats() Stats {
+	return o.byID.stats()
+}
+
+// FindByID implements [output.OrderRepository].
+func (o *OrderRepository) FindByID(ctx context.Context, id uuid.UUID) (*entities.Order, error) {
+	return o.byID.get(ctx, int(id.ID()), func() (*entities.Order, error) {
+		return o.next.FindByID(ctx, id)
+	})
+}
+
+// GetAllOrders implements [output.OrderRepository].
+func (o *OrderRepository) GetAllOrders(ctx context.Context) ([]*entities.Order, error) {
+	return o.next.GetAllOrders(ctx)
+}
+
+// FindOrders implements [output.OrderRepository].
+func (o *OrderRepository) FindOrders(ctx context.Context, query output.OrderQuery) (*output.OrderPage, error) {
+	return o.next.FindOrders(ctx, query)
+}
+
+// FindByUserID implements [output.OrderRepository].
+func (o *OrderRepository) FindByUserID(ctx context.Context, userID uuid.UUID, query output.OrderQuery) (*output.UserOrderPage, error) {
+	return o.next.FindByUserID(ctx, userID, query)
+}
+
+// Save implements [output.OrderRepository].
+func (o *OrderRepository) Save(ctx context.Context, order entities.Order) error {
+	return o.next.Save(ctx, order)
+}
+
+// Update implements [output.OrderRepository].
+func (o *OrderRepository) Update(ctx context.Context, order *entities.Order) error {
+	defer o.byID.invalidate(ctx, order.ID)
+	return o.next.Update(ctx, order)
+}
+
+// Delete implements [output.OrderRepository].
+func (o *OrderRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	defer o.byID.invalidate(ctx, int(id.ID()))
+	return o.next.Delete(ctx, id)
+}
+
+// DeleteByUserID implements [output.OrderRepository].
+// No se sabe qué órdenes se eliminan, así que se vacía la caché entera.
+func (o *OrderRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	defer o.byID.invalidateAll(ctx)
+	return o.next.DeleteByUserID(ctx, userID)
+}
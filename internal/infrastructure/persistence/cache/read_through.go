@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+	"user-management/internal/domain/ports/output"
+)
+
+// Options configura la caché de un repositorio
+type Options struct {
+	Capacity int           // número máximo de entradas
+	TTL      time.Duration // tiempo máximo que una entrada se considera válida
+}
+
+// Stats resume la actividad de la caché de un repositorio
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Shared    uint64 `json:"shared"` // fallos resueltos por una carga concurrente
+	Evictions uint64 `json:"evictions"`
+	Size      int    `json:"size"`
+}
+
+// readThrough combina el Store, la deduplicación de fallos y las métricas
+// comunes a los decoradores de repositorios.
+type readThrough[K comparable, T any] struct {
+	store *Store[K, *T]
+	loads group[K, *T]
+	clone func(*T) *T
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	shared atomic.Uint64
+	// epoch cambia en cada invalidación para que una carga iniciada antes no
+	// vuelva a guardar en la caché un valor ya obsoleto
+	epoch atomic.Uint64
+}
+
+func newReadThrough[K comparable, T any](opts Options, clone func(*T) *T) *readThrough[K, T] {
+	return &readThrough[K, T]{
+		store: NewStore[K, *T](opts.Capacity, opts.TTL),
+		clone: clone,
+	}
+}
+
+// get devuelve una copia del valor cacheado o lo obtiene con load. Los
+// resultados nil o con error no se guardan.
+func (r *readThrough[K, T]) get(ctx context.Context, key K, load func() (*T, error)) (*T, error) {
+	// Dentro de una transacción se leen datos sin confirmar: no se cachean
+	if output.InTransaction(ctx) {
+		return load()
+	}
+
+	if value, ok := r.store.Get(key); ok {
+		r.hits.Add(1)
+		return r.clone(value), nil
+	}
+	r.misses.Add(1)
+
+	value, shared, err := r.loads.do(key, func() (*T, error) {
+		epoch := r.epoch.Load()
+		value, err := load()
+		if err == nil && value != nil && r.epoch.Load() == epoch {
+			r.store.Set(key, r.clone(value))
+		}
+		return value, err
+	})
+	if shared {
+		r.shared.Add(1)
+	}
+	if err != nil || value == nil {
+		return value, err
+	}
+	return r.clone(value), nil
+}
+
+// invalidate descarta la entrada ahora y, si la escritura ocurre dentro de
+// una transacción, de nuevo al confirmarla.
+func (r *readThrough[K, T]) invalidate(ctx context.Context, key K) {
+	r.evict(key)
+	output.AfterCommit(ctx, func() { r.evict(key) })
+}
+
+// invalidateAll descarta todas las entradas, para escrituras que afectan a
+// claves que el decorador no conoce
+func (r *readThrough[K, T]) invalidateAll(ctx context.Context) {
+	r.evictAll()
+	output.AfterCommit(ctx, r.evictAll)
+}
+
+func (r *readThrough[K, T]) evict(key K) {
+	r.epoch.Add(1)
+	r.store.Delete(key)
+}
+
+func (r *readThrough[K, T]) evictAll() {
+	r.epoch.Add(1)
+	r.store.Clear()
+}
+
+func (r *readThrough[K, T]) stats() Stats {
+	return Stats{
+		Hits:      r.hits.Load(),
+		Misses:    r.misses.Load(),
+		Shared:    r.shared.Load(),
+		Evictions: r.store.Evictions(),
+		Size:      r.store.Len(),
+	}
+}
@@ -0,0 +1,46 @@
+package cache
+
+import "sync"
+
+// call es una carga en curso compartida por todas las peticiones de la misma clave
+type call[V any] struct {
+	wg  sync.WaitGroup
+	val V
+	err error
+}
+
+// group deduplica cargas concurrentes: mientras una clave se está cargando,
+// el resto de peticiones esperan y reciben el mismo resultado.
+type group[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*call[V]
+}
+
+// do ejecuta fn una sola vez por clave en vuelo; shared indica si el
+// resultado se obtuvo de la carga lanzada por otra petición.
+func (g *group[K, V]) do(key K, fn func() (V, error)) (val V, shared bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, true, c.err
+	}
+
+	c := &call[V]{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+
+	c.val, c.err = fn()
+	return c.val, false, c.err
+}
@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Store es una caché LRU con caducidad por entrada. Cuando se alcanza la
+// capacidad se descarta la entrada usada hace más tiempo.
+type Store[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+	entries  map[K]*list.Element
+	order    *list.List // frente = usada más recientemente
+
+	evictions uint64
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+func NewStore[K comparable, V any](capacity int, ttl time.Duration) *Store[K, V] {
+	return &Store[K, V]{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[K]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get devuelve el valor si existe y no ha caducado
+func (s *Store[K, V]) Get(key K) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var zero V
+	elem, ok := s.entries[key]
+	if !ok {
+		return zero, false
+	}
+
+	e := elem.Value.(*entry[K, V])
+	if !s.now().Before(e.expiresAt) {
+		s.removeElement(elem)
+		return zero, false
+	}
+
+	s.order.MoveToFront(elem)
+	return e.value, true
+}
+
+// Set guarda el valor renovando su caducidad
+func (s *Store[K, V]) Set(key K, value V) {
+	if s.capacity <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt := s.now().Add(s.ttl)
+	if elem, ok := s.entries[key]; ok {
+		e := elem.Value.(*entry[K, V])
+		e.value = value
+		e.expiresAt = expiresAt
+		s.order.MoveToFront(elem)
+		return
+	}
+
+	s.entries[key] = s.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	for s.order.Len() > s.capacity {
+		s.removeElement(s.order.Back())
+		s.evictions++
+	}
+}
+
+// Delete elimina la entrada si existe
+func (s *Store[K, V]) Delete(key K) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		s.removeElement(elem)
+	}
+}
+
+// Clear elimina todas las entradas
+func (s *Store[K, V]) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = make(map[K]*list.Element)
+	s.order.Init()
+}
+
+// Len devuelve el número de entradas, incluidas las caducadas aún no purgadas
+func (s *Store[K, V]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// Evictions devuelve cuántas entradas se han descartado por capacidad
+func (s *Store[K, V]) Evictions() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.evictions
+}
+
+func (s *Store[K, V]) removeElement(elem *list.Element) {
+	s.order.Remove(elem)
+	delete(s.entries, elem.Value.(*entry[K, V]).key)
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	t.Run("evicts the least recently used entry", func(t *testing.T) {
+		store := NewStore[string, int](2, time.Minute)
+
+		store.Set("a", 1)
+		store.Set("b", 2)
+		store.Get("a") // "a" pasa a ser la más reciente
+		store.Set("c", 3)
+
+		_, ok := store.Get("b")
+		assert.False(t, ok)
+		value, ok := store.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, value)
+		assert.Equal(t, 2, store.Len())
+		assert.Equal(t, uint64(1), store.Evictions())
+	})
+
+	t.Run("expires entries after the TTL", func(t *testing.T) {
+		now := time.Now()
+		store := NewStore[string, int](10, time.Minute)
+		store.now = func() time.Time { return now }
+
+		store.Set("a", 1)
+
+		now = now.Add(59 * time.Second)
+		_, ok := store.Get("a")
+		assert.True(t, ok)
+
+		now = now.Add(time.Second)
+		_, ok = store.Get("a")
+		assert.False(t, ok)
+		assert.Equal(t, 0, store.Len())
+	})
+
+	t.Run("set renews value and expiration", func(t *testing.T) {
+		now := time.Now()
+		store := NewStore[string, int](10, time.Minute)
+		store.now = func() time.Time { return now }
+
+		store.Set("a", 1)
+		now = now.Add(50 * time.Second)
+		store.Set("a", 2)
+		now = now.Add(50 * time.Second)
+
+		value, ok := store.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 2, value)
+	})
+
+	t.Run("delete removes the entry", func(t *testing.T) {
+		store := NewStore[string, int](10, time.Minute)
+
+		store.Set("a", 1)
+		store.Delete("a")
+
+		_, ok := store.Get("a")
+		assert.False(t, ok)
+	})
+
+	t.Run("zero capacity disables caching", func(t *testing.T) {
+		store := NewStore[string, int](0, time.Minute)
+
+		store.Set("a", 1)
+
+		_, ok := store.Get("a")
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+)
+
+// UserRepository decora un output.UserRepository cacheando FindByID. Las
+// escrituras pasan al repositorio decorado e invalidan la entrada afectada.
+type UserRepository struct {
+	next output.UserRepository
+	byID *readThrough[uuid.UUID, entities.User]
+}
+
+var _ output.UserRepository = (*UserRepository)(nil)
+
+func NewUserRepository(next output.UserRepository, opts Options) *UserRepository {
+	return &UserRepository{
+		next: next,
+		byID: newReadThrough[uuid.UUID](opts, (*entities.User).Clone),
+	}
+}
+
+// Stats devuelve las métricas de aciertos y fallos de la caché
+func (u *UserRepository) Stats() Stats {
+	return u.byID.stats()
+}
+
+// FindByID implements [output.UserRepository].
+func (u *UserRepository) FindByID(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	return u.byID.get(ctx, id, func() (*entities.User, error) {
+		return u.next.FindByID(ctx, id)
+	})
+}
+
+// FindByEmail implements [output.UserRepository].
+func (u *UserRepository) FindByEmail(ctx context.Context, email string) (*entities.User, error) {
+	return u.next.FindByEmail(ctx, email)
+}
+
+// GetAllUsers implements [output.UserRepository].
+func (u *UserRepository) GetAllUsers(ctx context.Context) ([]*entities.User, error) {
+	return u.next.GetAllUsers(ctx)
+}
+
+// FindUsers implements [output.UserRepository].
+func (u *UserRepository) FindUsers(ctx context.Context, query output.UserQuery) (*output.UserPage, error) {
+	return u.next.FindUsers(ctx, query)
+}
+
+// Save implements [output.UserRepository].
+func (u *UserRepository) Save(ctx context.Context, user entities.User) error {
+	defer u.byID.invalidate(ctx, user.ID)
+	return u.next.Save(ctx, user)
+}
+
+// Update implements [output.UserRepository].
+func (u *UserRepository) Update(ctx context.Context, user *entities.User) error {
+	defer u.byID.invalidate(ctx, user.ID)
+	return u.next.Update(ctx, user)
+}
+
+// Delete implements [output.UserRepository].
+func (u *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	defer u.byID.invalidate(ctx, id)
+	return u.next.Delete(ctx, id)
+}
+
+// Restore implements [output.UserRepository].
+func (u *UserRepository) Restore(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	defer u.byID.invalidate(ctx, id)
+	return u.next.Restore(ctx, id)
+}
+
+// FindDeletedBefore implements [output.UserRepository].
+func (u *UserRepository) FindDeletedBefore(ctx context.Context, cutoff time.Time) ([]*entities.User, error) {
+	return u.next.FindDeletedBefore(ctx, cutoff)
+}
+
+// FindAnyByID implements [output.UserRepository].
+// No se cachea: sólo lo usa la purga, que ya lee dentro de una transacción.
+func (u *UserRepository) FindAnyByID(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	return u.next.FindAnyByID(ctx, id)
+}
+
+// Purge implements [output.UserRepository].
+func (u *UserRepository) Purge(ctx context.Context, id uuid.UUID) error {
+	defer u.byID.invalidate(ctx, id)
+	return u.next.Purge(ctx, id)
+}
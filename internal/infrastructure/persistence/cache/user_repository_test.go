@@ -0,0 +1,181 @@
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/infrastructure/persistence/cache"
+	"user-management/internal/infrastructure/persistence/memory"
+	"user-management/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testOptions = cache.Options{Capacity: 100, TTL: time.Minute}
+
+func TestUserRepository_FindByID(t *testing.T) {
+	t.Run("serves repeated reads from the cache", func(t *testing.T) {
+		next := new(mocks.MockUserRepository)
+		repo := cache.NewUserRepository(next, testOptions)
+		ctx := context.Background()
+
+		userID := uuid.New()
+		next.On("FindByID", mock.Anything, userID).
+			Return(&entities.User{ID: userID, Name: "John Doe"}, nil).
+			Once()
+
+		for range 3 {
+			user, err := repo.FindByID(ctx, userID)
+			require.NoError(t, err)
+			assert.Equal(t, "John Doe", user.Name)
+		}
+
+		next.AssertExpectations(t)
+		stats := repo.Stats()
+		assert.Equal(t, uint64(2), stats.Hits)
+		assert.Equal(t, uint64(1), stats.Misses)
+		assert.Equal(t, 1, stats.Size)
+	})
+
+	t.Run("does not cache errors", func(t *testing.T) {
+		next := new(mocks.MockUserRepository)
+		repo := cache.NewUserRepository(next, testOptions)
+		ctx := context.Background()
+
+		userID := uuid.New()
+		next.On("FindByID", mock.Anything, userID).
+			Return(nil, assert.AnError).
+			Twice()
+
+		_, err := repo.FindByID(ctx, userID)
+		assert.Error(t, err)
+		_, err = repo.FindByID(ctx, userID)
+		assert.Error(t, err)
+
+		next.AssertExpectations(t)
+	})
+
+	t.Run("returns copies that callers can modify safely", func(t *testing.T) {
+		next := new(mocks.MockUserRepository)
+		repo := cache.NewUserRepository(next, testOptions)
+		ctx := context.Background()
+
+		userID := uuid.New()
+		next.On("FindByID", mock.Anything, userID).
+			Return(&entities.User{ID: userID, Name: "John Doe"}, nil).
+			Once()
+
+		first, err := repo.FindByID(ctx, userID)
+		require.NoError(t, err)
+		first.Name = "Modified"
+
+		second, err := repo.FindByID(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, "John Doe", second.Name)
+	})
+
+	t.Run("deduplicates concurrent misses", func(t *testing.T) {
+		next := new(mocks.MockUserRepository)
+		repo := cache.NewUserRepository(next, testOptions)
+		ctx := context.Background()
+
+		userID := uuid.New()
+		release := make(chan struct{})
+		next.On("FindByID", mock.Anything, userID).
+			Run(func(mock.Arguments) { <-release }).
+			Return(&entities.User{ID: userID, Name: "John Doe"}, nil).
+			Once()
+
+		const callers = 10
+		var wg sync.WaitGroup
+		for range callers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				user, err := repo.FindByID(ctx, userID)
+				assert.NoError(t, err)
+				assert.Equal(t, "John Doe", user.Name)
+			}()
+		}
+
+		// Esperar a que todas las peticiones hayan registrado su fallo
+		assert.Eventually(t, func() bool { return repo.Stats().Misses == callers }, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		next.AssertExpectations(t)
+		assert.Equal(t, uint64(callers-1), repo.Stats().Shared)
+	})
+}
+
+func TestUserRepository_Invalidation(t *testing.T) {
+	t.Run("update invalidates the cached user", func(t *testing.T) {
+		next := memory.NewUserRepository()
+		repo := cache.NewUserRepository(next, testOptions)
+		ctx := context.Background()
+
+		user, err := entities.NewUser("John Doe", "john@example.com", 30, "Password123!")
+		require.NoError(t, err)
+		require.NoError(t, repo.Save(ctx, *user))
+
+		loaded, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		loaded.Name = "John Updated"
+		require.NoError(t, repo.Update(ctx, loaded))
+
+		found, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "John Updated", found.Name)
+	})
+
+	t.Run("delete invalidates the cached user", func(t *testing.T) {
+		next := memory.NewUserRepository()
+		repo := cache.NewUserRepository(next, testOptions)
+		ctx := context.Background()
+
+		user, err := entities.NewUser("John Doe", "john@example.com", 30, "Password123!")
+		require.NoError(t, err)
+		require.NoError(t, repo.Save(ctx, *user))
+		_, err = repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+
+		require.NoError(t, repo.Delete(ctx, user.ID))
+
+		_, err = repo.FindByID(ctx, user.ID)
+		assert.Error(t, err)
+	})
+
+	t.Run("transactions bypass the cache and invalidate on commit", func(t *testing.T) {
+		users := memory.NewUserRepository()
+		repo := cache.NewUserRepository(users, testOptions)
+		uow := memory.NewUnitOfWork(users, memory.NewOrderRepository())
+		ctx := context.Background()
+
+		user, err := entities.NewUser("John Doe", "john@example.com", 30, "Password123!")
+		require.NoError(t, err)
+		require.NoError(t, repo.Save(ctx, *user))
+
+		err = uow.Do(ctx, func(txCtx context.Context) error {
+			loaded, err := repo.FindByID(txCtx, user.ID)
+			require.NoError(t, err)
+			loaded.Name = "John Updated"
+			require.NoError(t, repo.Update(txCtx, loaded))
+
+			// Una lectura externa antes del commit no debe dejar en caché el valor antiguo
+			outside, err := repo.FindByID(ctx, user.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "John Doe", outside.Name)
+			return nil
+		})
+		require.NoError(t, err)
+
+		found, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "John Updated", found.Name)
+	})
+}
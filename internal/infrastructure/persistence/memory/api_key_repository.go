@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyRepository implementa output.APIKeyRepository en memoria con un
+// índice por hash
+type APIKeyRepository struct {
+	mutex  sync.RWMutex
+	keys   map[uuid.UUID]*entities.APIKey
+	byHash map[string]uuid.UUID
+}
+
+var _ output.APIKeyRepository = (*APIKeyRepository)(nil)
+
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{
+		keys:   make(map[uuid.UUID]*entities.APIKey),
+		byHash: make(map[string]uuid.UUID),
+	}
+}
+
+// Save implements [output.APIKeyRepository].
+func (r *APIKeyRepository) Save(ctx context.Context, key *entities.APIKey) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.keys[key.ID] = key.Clone()
+	r.byHash[key.Hash] = key.ID
+	return nil
+}
+
+// FindByID implements [output.APIKeyRepository].
+func (r *APIKeyRepository) FindByID(ctx context.Context, id uuid.UUID) (*entities.APIKey, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, output.ErrAPIKeyNotFound
+	}
+	return key.Clone(), nil
+}
+
+// FindByHash implements [output.APIKeyRepository].
+func (r *APIKeyRepository) FindByHash(ctx context.Context, hash string) (*entities.APIKey, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	id, ok := r.byHash[hash]
+	if !ok {
+		return nil, output.ErrAPIKeyNotFound
+	}
+	return r.keys[id].Clone(), nil
+}
+
+// FindByOwner implements [output.APIKeyRepository].
+func (r *APIKeyRepository) FindByOwner(ctx context.Context, userID uuid.UUID) ([]*entities.APIKey, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	keys := []*entities.APIKey{}
+	for _, key := range r.keys {
+		if key.UserID == userID {
+			keys = append(keys, key.Clone())
+		}
+	}
+	slices.SortFunc(keys, func(a, b *entities.APIKey) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return keys, nil
+}
+
+// Update implements [output.APIKeyRepository].
+func (r *APIKeyRepository) Update(ctx context.Context, key *entities.APIKey) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.keys[key.ID]; !ok {
+		return output.ErrAPIKeyNotFound
+	}
+	r.keys[key.ID] = key.Clone()
+	return nil
+}
+
+// TouchLastUsed implements [output.APIKeyRepository].
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time, ip string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return output.ErrAPIKeyNotFound
+	}
+	key.MarkUsed(at, ip)
+	return nil
+}
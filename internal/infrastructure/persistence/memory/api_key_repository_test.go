@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyRepository(t *testing.T) {
+	ctx := context.Background()
+	owner := uuid.New()
+
+	newKey := func(t *testing.T, owner uuid.UUID, hash string) *entities.APIKey {
+		key, err := entities.NewAPIKey(owner, entities.RoleUser, "ci", []string{entities.ScopeUsersRead}, nil, "umk_abcdefgh", hash)
+		require.NoError(t, err)
+		return key
+	}
+
+	t.Run("keys are found by ID and by hash", func(t *testing.T) {
+		repo := NewAPIKeyRepository()
+		key := newKey(t, owner, "h1")
+		require.NoError(t, repo.Save(ctx, key))
+
+		found, err := repo.FindByID(ctx, key.ID)
+		require.NoError(t, err)
+		assert.Equal(t, key, found)
+
+		found, err = repo.FindByHash(ctx, "h1")
+		require.NoError(t, err)
+		assert.Equal(t, key.ID, found.ID)
+
+		_, err = repo.FindByHash(ctx, "other")
+		assert.ErrorIs(t, err, output.ErrAPIKeyNotFound)
+	})
+
+	t.Run("returned keys are copies", func(t *testing.T) {
+		repo := NewAPIKeyRepository()
+		key := newKey(t, owner, "h1")
+		require.NoError(t, repo.Save(ctx, key))
+
+		found, err := repo.FindByID(ctx, key.ID)
+		require.NoError(t, err)
+		found.Scopes[0] = entities.ScopeAdminWrite
+		require.NoError(t, found.Revoke(time.Now()))
+
+		stored, err := repo.FindByID(ctx, key.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{entities.ScopeUsersRead}, stored.Scopes)
+		assert.Nil(t, stored.RevokedAt)
+	})
+
+	t.Run("owners only see their keys, oldest first", func(t *testing.T) {
+		repo := NewAPIKeyRepository()
+		first, second := newKey(t, owner, "h1"), newKey(t, owner, "h2")
+		second.CreatedAt = first.CreatedAt.Add(time.Second)
+		require.NoError(t, repo.Save(ctx, second))
+		require.NoError(t, repo.Save(ctx, first))
+		require.NoError(t, repo.Save(ctx, newKey(t, uuid.New(), "h3")))
+
+		keys, err := repo.FindByOwner(ctx, owner)
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.Equal(t, first.ID, keys[0].ID)
+		assert.Equal(t, second.ID, keys[1].ID)
+	})
+
+	t.Run("updating a missing key fails", func(t *testing.T) {
+		repo := NewAPIKeyRepository()
+		assert.ErrorIs(t, repo.Update(ctx, newKey(t, owner, "h1")), output.ErrAPIKeyNotFound)
+		assert.ErrorIs(t, repo.TouchLastUsed(ctx, uuid.New(), time.Now(), "10.0.0.1"), output.ErrAPIKeyNotFound)
+	})
+
+	t.Run("recording a use keeps a concurrent revocation", func(t *testing.T) {
+		repo := NewAPIKeyRepository()
+		key := newKey(t, owner, "h1")
+		require.NoError(t, repo.Save(ctx, key))
+
+		// Se revoca la key después de que otra petición la leyera
+		revoked, err := repo.FindByID(ctx, key.ID)
+		require.NoError(t, err)
+		require.NoError(t, revoked.Revoke(time.Now()))
+		require.NoError(t, repo.Update(ctx, revoked))
+
+		used := time.Now()
+		require.NoError(t, repo.TouchLastUsed(ctx, key.ID, used, "10.0.0.1"))
+
+		stored, err := repo.FindByID(ctx, key.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, stored.RevokedAt)
+		require.NotNil(t, stored.LastUsedAt)
+		assert.True(t, used.Equal(*stored.LastUsedAt))
+		assert.Equal(t, "10.0.0.1", stored.LastUsedIP)
+	})
+}
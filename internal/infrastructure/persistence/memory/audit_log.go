@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+)
+
+// AuditLog implementa output.AuditLog en memoria conservando las últimas
+// capacity entradas
+type AuditLog struct {
+	mutex    sync.RWMutex
+	entries  []entities.AuditEntry
+	capacity int
+}
+
+var _ output.AuditLog = (*AuditLog)(nil)
+
+func NewAuditLog(capacity int) *AuditLog {
+	return &AuditLog{capacity: capacity}
+}
+
+// Record implements [output.AuditLog].
+func (l *AuditLog) Record(ctx context.Context, entry *entities.AuditEntry) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.entries = append(l.entries, *entry)
+	if over := len(l.entries) - l.capacity; l.capacity > 0 && over > 0 {
+		l.entries = append([]entities.AuditEntry(nil), l.entries[over:]...)
+	}
+	return nil
+}
+
+// Entries devuelve las entradas conservadas, de la más antigua a la más
+// reciente
+func (l *AuditLog) Entries() []entities.AuditEntry {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return append([]entities.AuditEntry(nil), l.entries...)
+}
@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+	"user-management/internal/domain/ports/output"
+)
+
+// IdempotencyStore implementa output.IdempotencyStore en memoria. Los
+// registros caducados se descartan al consultarlos y, en bloque, como mucho
+// una vez por minuto.
+type IdempotencyStore struct {
+	mutex     sync.Mutex
+	records   map[string]output.IdempotencyRecord
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+var _ output.IdempotencyStore = (*IdempotencyStore)(nil)
+
+const idempotencySweepInterval = time.Minute
+
+func NewIdempotencyStore() *IdempotencyStore {
+	return &IdempotencyStore{
+		records: make(map[string]output.IdempotencyRecord),
+		now:     time.Now,
+	}
+}
+
+// Reserve implements [output.IdempotencyStore].
+func (s *IdempotencyStore) Reserve(ctx context.Context, record output.IdempotencyRecord) (*output.IdempotencyRecord, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	if existing, ok := s.records[record.Key]; ok && now.Before(existing.ExpiresAt) {
+		return &existing, false, nil
+	}
+	s.records[record.Key] = record
+	return nil, true, nil
+}
+
+// Complete implements [output.IdempotencyStore].
+func (s *IdempotencyStore) Complete(ctx context.Context, record output.IdempotencyRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.records[record.Key] = record
+	return nil
+}
+
+// Release implements [output.IdempotencyStore].
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// Len devuelve el número de registros, incluidos los caducados aún no purgados
+func (s *IdempotencyStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.records)
+}
+
+func (s *IdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < idempotencySweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	newStore := func() *IdempotencyStore {
+		store := NewIdempotencyStore()
+		store.now = func() time.Time { return now }
+		return store
+	}
+
+	t.Run("reserves a key only once", func(t *testing.T) {
+		store := newStore()
+		record := output.IdempotencyRecord{Key: "k", Fingerprint: "f", ExpiresAt: now.Add(time.Hour)}
+
+		_, reserved, err := store.Reserve(ctx, record)
+		require.NoError(t, err)
+		assert.True(t, reserved)
+
+		record.Completed, record.Status = true, 201
+		require.NoError(t, store.Complete(ctx, record))
+
+		existing, reserved, err := store.Reserve(ctx, output.IdempotencyRecord{Key: "k", Fingerprint: "other"})
+		require.NoError(t, err)
+		assert.False(t, reserved)
+		assert.Equal(t, 201, existing.Status)
+		assert.Equal(t, "f", existing.Fingerprint)
+	})
+
+	t.Run("expired records can be reserved again", func(t *testing.T) {
+		store := newStore()
+		_, _, err := store.Reserve(ctx, output.IdempotencyRecord{Key: "k", ExpiresAt: now.Add(time.Minute)})
+		require.NoError(t, err)
+
+		now = now.Add(time.Minute)
+		_, reserved, err := store.Reserve(ctx, output.IdempotencyRecord{Key: "k", ExpiresAt: now.Add(time.Minute)})
+		require.NoError(t, err)
+		assert.True(t, reserved)
+	})
+
+	t.Run("sweeps expired records", func(t *testing.T) {
+		store := newStore()
+		for _, key := range []string{"a", "b", "c"} {
+			_, _, err := store.Reserve(ctx, output.IdempotencyRecord{Key: key, ExpiresAt: now.Add(time.Second)})
+			require.NoError(t, err)
+		}
+
+		now = now.Add(2 * idempotencySweepInterval)
+		_, _, err := store.Reserve(ctx, output.IdempotencyRecord{Key: "d", ExpiresAt: now.Add(time.Hour)})
+		require.NoError(t, err)
+		assert.Equal(t, 1, store.Len())
+	})
+
+	t.Run("released keys can be reserved again", func(t *testing.T) {
+		store := newStore()
+		record := output.IdempotencyRecord{Key: "k", ExpiresAt: now.Add(time.Hour)}
+		_, _, err := store.Reserve(ctx, record)
+		require.NoError(t, err)
+
+		require.NoError(t, store.Release(ctx, "k"))
+		_, reserved, err := store.Reserve(ctx, record)
+		require.NoError(t, err)
+		assert.True(t, reserved)
+	})
+}
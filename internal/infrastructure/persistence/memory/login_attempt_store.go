@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+	"user-management/internal/domain/ports/output"
+)
+
+// LoginAttemptStore implementa output.LoginAttemptStore en memoria. Las
+// claves caducadas se descartan como mucho una vez por minuto.
+type LoginAttemptStore struct {
+	mutex     sync.Mutex
+	attempts  map[string]*loginAttempts
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+type loginAttempts struct {
+	output.LoginAttempts
+	expires time.Time
+}
+
+var _ output.LoginAttemptStore = (*LoginAttemptStore)(nil)
+
+func NewLoginAttemptStore() *LoginAttemptStore {
+	return &LoginAttemptStore{
+		attempts: make(map[string]*loginAttempts),
+		now:      time.Now,
+	}
+}
+
+// Get implements [output.LoginAttemptStore].
+func (s *LoginAttemptStore) Get(ctx context.Context, key string) (output.LoginAttempts, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if a := s.current(key, s.now()); a != nil {
+		return a.LoginAttempts, nil
+	}
+	return output.LoginAttempts{}, nil
+}
+
+// RecordAttempt implements [output.LoginAttemptStore].
+func (s *LoginAttemptStore) RecordAttempt(ctx context.Context, key string, window time.Duration,
+	allow func(output.LoginAttempts) error) (output.LoginAttempts, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	a := s.current(key, now)
+	var previous output.LoginAttempts
+	if a != nil {
+		previous = a.LoginAttempts
+	}
+	if err := allow(previous); err != nil {
+		return previous, err
+	}
+	if a == nil {
+		a = &loginAttempts{}
+		s.attempts[key] = a
+	}
+	a.Failures++
+	a.LastFailure = now
+	a.expires = later(now.Add(window), a.LockedUntil)
+	return a.LoginAttempts, nil
+}
+
+// ForgetAttempt implements [output.LoginAttemptStore].
+func (s *LoginAttemptStore) ForgetAttempt(ctx context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if a := s.current(key, s.now()); a != nil && a.Failures > 0 {
+		a.Failures--
+	}
+	return nil
+}
+
+// Lock implements [output.LoginAttemptStore].
+func (s *LoginAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	a := s.current(key, s.now())
+	if a == nil {
+		a = &loginAttempts{}
+		s.attempts[key] = a
+	}
+	a.LockedUntil = until
+	a.expires = later(a.expires, until)
+	return nil
+}
+
+// Reset implements [output.LoginAttemptStore].
+func (s *LoginAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+// current devuelve los intentos vigentes de key o nil si no hay o caducaron
+func (s *LoginAttemptStore) current(key string, now time.Time) *loginAttempts {
+	a, ok := s.attempts[key]
+	if !ok || !now.Before(a.expires) {
+		return nil
+	}
+	return a
+}
+
+func (s *LoginAttemptStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, a := range s.attempts {
+		if !now.Before(a.expires) {
+			delete(s.attempts, key)
+		}
+	}
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+)
+
+func TestLoginAttemptStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewLoginAttemptStore()
+	store.now = func() time.Time { return now }
+	allow := func(output.LoginAttempts) error { return nil }
+
+	t.Run("counts failures within the window", func(t *testing.T) {
+		for i := 1; i <= 3; i++ {
+			attempts, err := store.RecordAttempt(ctx, "k", time.Minute, allow)
+			require.NoError(t, err)
+			assert.Equal(t, i, attempts.Failures)
+			assert.Equal(t, now, attempts.LastFailure)
+		}
+
+		attempts, err := store.Get(ctx, "other")
+		require.NoError(t, err)
+		assert.Equal(t, output.LoginAttempts{}, attempts, "las claves no comparten fallos")
+
+		now = now.Add(time.Minute)
+		attempts, _ = store.Get(ctx, "k")
+		assert.Zero(t, attempts.Failures, "sin fallos en la ventana se vuelve a cero")
+	})
+
+	t.Run("a lock outlives the window", func(t *testing.T) {
+		_, err := store.RecordAttempt(ctx, "locked", time.Minute, allow)
+		require.NoError(t, err)
+		require.NoError(t, store.Lock(ctx, "locked", now.Add(time.Hour)))
+
+		now = now.Add(30 * time.Minute)
+		attempts, _ := store.Get(ctx, "locked")
+		assert.Equal(t, 1, attempts.Failures)
+		assert.True(t, now.Before(attempts.LockedUntil))
+
+		require.NoError(t, store.Reset(ctx, "locked"))
+		attempts, _ = store.Get(ctx, "locked")
+		assert.Equal(t, output.LoginAttempts{}, attempts)
+	})
+
+	t.Run("attempts are only counted if allowed", func(t *testing.T) {
+		_, err := store.RecordAttempt(ctx, "gated", time.Minute, allow)
+		require.NoError(t, err)
+
+		denied := errors.New("wait")
+		attempts, err := store.RecordAttempt(ctx, "gated", time.Minute, func(seen output.LoginAttempts) error {
+			assert.Equal(t, 1, seen.Failures)
+			return denied
+		})
+		assert.ErrorIs(t, err, denied)
+		assert.Equal(t, 1, attempts.Failures)
+
+		require.NoError(t, store.ForgetAttempt(ctx, "gated"))
+		attempts, _ = store.Get(ctx, "gated")
+		assert.Zero(t, attempts.Failures)
+		require.NoError(t, store.ForgetAttempt(ctx, "gated"))
+		attempts, _ = store.Get(ctx, "gated")
+		assert.Zero(t, attempts.Failures, "no baja de cero")
+	})
+
+	t.Run("concurrent attempts see distinct counts", func(t *testing.T) {
+		const n = 20
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			seen = map[int]bool{}
+		)
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := store.RecordAttempt(ctx, "race", time.Minute, func(a output.LoginAttempts) error {
+					mu.Lock()
+					defer mu.Unlock()
+					seen[a.Failures] = true
+					return nil
+				})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, seen, n)
+	})
+}
+
+func TestAuditLog_KeepsTheLatestEntries(t *testing.T) {
+	ctx := context.Background()
+	log := NewAuditLog(2)
+
+	for _, target := range []string{"a", "b", "c"} {
+		require.NoError(t, log.Record(ctx, entities.NewAuditEntry(entities.AuditAccountLocked, uuid.Nil, target)))
+	}
+
+	entries := log.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "b", entries[0].Target)
+	assert.Equal(t, "c", entries[1].Target)
+}
@@ -0,0 +1,15 @@
+package memory
+
+import (
+	"os"
+	"testing"
+	"user-management/internal/domain/valueobjects"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMain(m *testing.M) {
+	// Los tests crean cientos de usuarios; el coste real de bcrypt no aporta
+	valueobjects.PasswordCost = bcrypt.MinCost
+	os.Exit(m.Run())
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+)
+
+// OAuthClientRepository implementa output.OAuthClientRepository en memoria
+type OAuthClientRepository struct {
+	mutex   sync.RWMutex
+	clients map[uuid.UUID]*entities.OAuthClient
+}
+
+var _ output.OAuthClientRepository = (*OAuthClientRepository)(nil)
+
+func NewOAuthClientRepository() *OAuthClientRepository {
+	return &OAuthClientRepository{clients: make(map[uuid.UUID]*entities.OAuthClient)}
+}
+
+// Save implements [output.OAuthClientRepository].
+func (r *OAuthClientRepository) Save(ctx context.Context, client *entities.OAuthClient) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.clients[client.ID] = client.Clone()
+	return nil
+}
+
+// FindByID implements [output.OAuthClientRepository].
+func (r *OAuthClientRepository) FindByID(ctx context.Context, id uuid.UUID) (*entities.OAuthClient, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	client, ok := r.clients[id]
+	if !ok {
+		return nil, output.ErrOAuthClientNotFound
+	}
+	return client.Clone(), nil
+}
+
+// FindAll implements [output.OAuthClientRepository].
+func (r *OAuthClientRepository) FindAll(ctx context.Context) ([]*entities.OAuthClient, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	clients := make([]*entities.OAuthClient, 0, len(r.clients))
+	for _, client := range r.clients {
+		clients = append(clients, client.Clone())
+	}
+	slices.SortFunc(clients, func(a, b *entities.OAuthClient) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return clients, nil
+}
+
+// Delete implements [output.OAuthClientRepository].
+func (r *OAuthClientRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.clients[id]; !ok {
+		return output.ErrOAuthClientNotFound
+	}
+	delete(r.clients, id)
+	return nil
+}
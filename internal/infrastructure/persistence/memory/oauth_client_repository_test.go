@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthClientRepository(t *testing.T) {
+	ctx := context.Background()
+
+	newClient := func(t *testing.T, name string) *entities.OAuthClient {
+		client, err := entities.NewOAuthClient(name, []string{"https://app.example.com/cb"}, "")
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("saves, lists oldest first and deletes", func(t *testing.T) {
+		repo := NewOAuthClientRepository()
+		first, second := newClient(t, "first"), newClient(t, "second")
+		second.CreatedAt = first.CreatedAt.Add(1)
+		require.NoError(t, repo.Save(ctx, second))
+		require.NoError(t, repo.Save(ctx, first))
+
+		found, err := repo.FindByID(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, first, found)
+
+		all, err := repo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+		assert.Equal(t, first.ID, all[0].ID)
+
+		require.NoError(t, repo.Delete(ctx, first.ID))
+		_, err = repo.FindByID(ctx, first.ID)
+		assert.ErrorIs(t, err, output.ErrOAuthClientNotFound)
+		assert.ErrorIs(t, repo.Delete(ctx, first.ID), output.ErrOAuthClientNotFound)
+	})
+
+	t.Run("returned clients are copies", func(t *testing.T) {
+		repo := NewOAuthClientRepository()
+		client := newClient(t, "app")
+		require.NoError(t, repo.Save(ctx, client))
+
+		found, err := repo.FindByID(ctx, client.ID)
+		require.NoError(t, err)
+		found.RedirectURIs[0] = "https://evil.example.com/cb"
+
+		stored, err := repo.FindByID(ctx, client.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://app.example.com/cb"}, stored.RedirectURIs)
+	})
+
+	t.Run("unknown clients are not found", func(t *testing.T) {
+		_, err := NewOAuthClientRepository().FindByID(ctx, uuid.New())
+		assert.ErrorIs(t, err, output.ErrOAuthClientNotFound)
+	})
+}
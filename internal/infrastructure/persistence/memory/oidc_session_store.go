@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+	"user-management/internal/domain/ports/output"
+)
+
+// OIDCSessionStore implementa output.OIDCSessionStore en memoria. Las
+// sesiones caducadas se descartan al consultarlas y, en bloque, como mucho
+// una vez por minuto.
+type OIDCSessionStore struct {
+	mutex     sync.Mutex
+	sessions  map[string]output.OIDCSession // por hash
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+var _ output.OIDCSessionStore = (*OIDCSessionStore)(nil)
+
+func NewOIDCSessionStore() *OIDCSessionStore {
+	return &OIDCSessionStore{
+		sessions: make(map[string]output.OIDCSession),
+		now:      time.Now,
+	}
+}
+
+// Save implements [output.OIDCSessionStore].
+func (s *OIDCSessionStore) Save(ctx context.Context, session output.OIDCSession) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sweep(s.now())
+	s.sessions[session.Hash] = session
+	return nil
+}
+
+// Find implements [output.OIDCSessionStore].
+func (s *OIDCSessionStore) Find(ctx context.Context, hash string) (*output.OIDCSession, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, ok := s.sessions[hash]
+	if !ok {
+		return nil, output.ErrSessionNotFound
+	}
+	if !s.now().Before(session.ExpiresAt) {
+		delete(s.sessions, hash)
+		return nil, output.ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+// Delete implements [output.OIDCSessionStore].
+func (s *OIDCSessionStore) Delete(ctx context.Context, hash string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.sessions, hash)
+	return nil
+}
+
+func (s *OIDCSessionStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for hash, session := range s.sessions {
+		if !now.Before(session.ExpiresAt) {
+			delete(s.sessions, hash)
+		}
+	}
+}
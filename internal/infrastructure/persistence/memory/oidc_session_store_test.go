@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCSessionStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	newStore := func() *OIDCSessionStore {
+		store := NewOIDCSessionStore()
+		store.now = func() time.Time { return now }
+		return store
+	}
+	session := output.OIDCSession{Hash: "h", UserID: uuid.New(), CSRFToken: "csrf", ExpiresAt: now.Add(time.Hour)}
+
+	t.Run("finds a session until it is deleted", func(t *testing.T) {
+		store := newStore()
+		require.NoError(t, store.Save(ctx, session))
+
+		found, err := store.Find(ctx, "h")
+		require.NoError(t, err)
+		assert.Equal(t, session, *found)
+
+		require.NoError(t, store.Delete(ctx, "h"))
+		_, err = store.Find(ctx, "h")
+		assert.ErrorIs(t, err, output.ErrSessionNotFound)
+	})
+
+	t.Run("expired sessions are not found", func(t *testing.T) {
+		store := newStore()
+		expired := session
+		expired.ExpiresAt = now
+
+		require.NoError(t, store.Save(ctx, expired))
+		_, err := store.Find(ctx, "h")
+		assert.ErrorIs(t, err, output.ErrSessionNotFound)
+	})
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+)
+
+// OneTimeTokenStore implementa output.OneTimeTokenStore en memoria. Los
+// tokens caducados se descartan al consultarlos y, en bloque, como mucho una
+// vez por minuto.
+type OneTimeTokenStore struct {
+	mutex     sync.Mutex
+	tokens    map[string]output.OneTimeToken // por propósito y hash
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+var _ output.OneTimeTokenStore = (*OneTimeTokenStore)(nil)
+
+func NewOneTimeTokenStore() *OneTimeTokenStore {
+	return &OneTimeTokenStore{
+		tokens: make(map[string]output.OneTimeToken),
+		now:    time.Now,
+	}
+}
+
+// Save implements [output.OneTimeTokenStore].
+func (s *OneTimeTokenStore) Save(ctx context.Context, token output.OneTimeToken) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sweep(s.now())
+	s.tokens[tokenKey(token.Purpose, token.Hash)] = token
+	return nil
+}
+
+// Consume implements [output.OneTimeTokenStore].
+func (s *OneTimeTokenStore) Consume(ctx context.Context, purpose, hash string) (*output.OneTimeToken, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := tokenKey(purpose, hash)
+	token, ok := s.tokens[key]
+	if !ok {
+		return nil, output.ErrTokenNotFound
+	}
+	delete(s.tokens, key)
+	if !s.now().Before(token.ExpiresAt) {
+		return nil, output.ErrTokenNotFound
+	}
+	return &token, nil
+}
+
+// RevokeAll implements [output.OneTimeTokenStore].
+func (s *OneTimeTokenStore) RevokeAll(ctx context.Context, purpose string, userID uuid.UUID) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key, token := range s.tokens {
+		if token.Purpose == purpose && token.UserID == userID {
+			delete(s.tokens, key)
+		}
+	}
+	return nil
+}
+
+func (s *OneTimeTokenStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, token := range s.tokens {
+		if !now.Before(token.ExpiresAt) {
+			delete(s.tokens, key)
+		}
+	}
+}
+
+func tokenKey(purpose, hash string) string {
+	return purpose + ":" + hash
+}
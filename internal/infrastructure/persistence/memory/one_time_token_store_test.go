@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOneTimeTokenStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+
+	newStore := func() *OneTimeTokenStore {
+		store := NewOneTimeTokenStore()
+		store.now = func() time.Time { return now }
+		return store
+	}
+	token := output.OneTimeToken{Hash: "h", Purpose: "verify", UserID: userID, ExpiresAt: now.Add(time.Hour)}
+
+	t.Run("a token can be consumed once", func(t *testing.T) {
+		store := newStore()
+		require.NoError(t, store.Save(ctx, token))
+
+		consumed, err := store.Consume(ctx, "verify", "h")
+		require.NoError(t, err)
+		assert.Equal(t, userID, consumed.UserID)
+
+		_, err = store.Consume(ctx, "verify", "h")
+		assert.ErrorIs(t, err, output.ErrTokenNotFound)
+	})
+
+	t.Run("tokens are scoped to their purpose", func(t *testing.T) {
+		store := newStore()
+		require.NoError(t, store.Save(ctx, token))
+
+		_, err := store.Consume(ctx, "reset", "h")
+		assert.ErrorIs(t, err, output.ErrTokenNotFound)
+	})
+
+	t.Run("expired tokens are not found", func(t *testing.T) {
+		store := newStore()
+		expired := token
+		expired.ExpiresAt = now
+
+		require.NoError(t, store.Save(ctx, expired))
+		_, err := store.Consume(ctx, "verify", "h")
+		assert.ErrorIs(t, err, output.ErrTokenNotFound)
+	})
+
+	t.Run("revokes every token of a user and purpose", func(t *testing.T) {
+		store := newStore()
+		other := output.OneTimeToken{Hash: "o", Purpose: "verify", UserID: uuid.New(), ExpiresAt: now.Add(time.Hour)}
+		require.NoError(t, store.Save(ctx, token))
+		require.NoError(t, store.Save(ctx, other))
+
+		require.NoError(t, store.RevokeAll(ctx, "verify", userID))
+
+		_, err := store.Consume(ctx, "verify", "h")
+		assert.ErrorIs(t, err, output.ErrTokenNotFound)
+		_, err = store.Consume(ctx, "verify", "o")
+		assert.NoError(t, err)
+	})
+}
+
+func TestRateLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter()
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := limiter.Allow(ctx, "k", 2, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+
+	now = now.Add(20 * time.Second)
+	allowed, retryAfter, err := limiter.Allow(ctx, "k", 2, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 40*time.Second, retryAfter)
+
+	allowed, _, _ = limiter.Allow(ctx, "other", 2, time.Minute)
+	assert.True(t, allowed, "las claves no comparten ventana")
+
+	now = now.Add(40 * time.Second)
+	allowed, _, _ = limiter.Allow(ctx, "k", 2, time.Minute)
+	assert.True(t, allowed, "una ventana nueva empieza de cero")
+}
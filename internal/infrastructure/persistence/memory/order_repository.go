@@ -2,81 +2,246 @@ package memory
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"sync"
 	"user-management/internal/domain/entities"
 	"user-management/internal/domain/ports/output"
+	"user-management/internal/domain/valueobjects"
 
 	"github.com/google/uuid"
 )
 
+// OrderRepository implementa output.OrderRepository. Las lecturas devuelven
+// copias para que los cambios del llamador sólo se guarden a través de Update.
 type OrderRepository struct {
-	mutex sync.RWMutex
+	mutex  sync.RWMutex
+	orders table[entities.Order]
 }
 
-var orders = map[int]*entities.Order{}
-
 var _ output.OrderRepository = (*OrderRepository)(nil)
 
 func NewOrderRepository() *OrderRepository {
-	return &OrderRepository{}
+	return &OrderRepository{
+		orders: newTable[entities.Order](),
+	}
+}
+
+// lock devuelve la tabla sobre la que opera la llamada: la de la transacción
+// activa en ctx o los datos del repositorio.
+func (o *OrderRepository) lock(ctx context.Context) (*table[entities.Order], func()) {
+	if tx := txFromContext(ctx); tx != nil {
+		tx.mu.Lock()
+		return tx.orders, tx.mu.Unlock
+	}
+	o.mutex.Lock()
+	return &o.orders, o.mutex.Unlock
+}
+
+// rlock bloquea en exclusiva la transacción porque sus lecturas también
+// registran las versiones leídas.
+func (o *OrderRepository) rlock(ctx context.Context) (*table[entities.Order], func()) {
+	if tx := txFromContext(ctx); tx != nil {
+		tx.mu.Lock()
+		return tx.orders, tx.mu.Unlock
+	}
+	o.mutex.RLock()
+	return &o.orders, o.mutex.RUnlock
 }
 
 // Delete implements [output.OrderRepository].
 func (o *OrderRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	o.mutex.Lock()
-	defer o.mutex.Unlock()
+	orders, unlock := o.lock(ctx)
+	defer unlock()
 
-	_, exists := orders[int(id.ID())]
+	_, exists := orders.get(int(id.ID()))
 	if !exists {
 		return nil
 	}
 
-	delete(orders, int(id.ID()))
+	orders.remove(int(id.ID()))
+	return nil
+}
+
+// DeleteByUserID implements [output.OrderRepository].
+func (o *OrderRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	orders, unlock := o.lock(ctx)
+	defer unlock()
+
+	var owned []int
+	orders.each(func(id int, order *entities.Order) {
+		if order.UserID == int(userID.ID()) {
+			owned = append(owned, id)
+		}
+	})
+	for _, id := range owned {
+		orders.remove(id)
+	}
 	return nil
 }
 
 // FindByID implements [output.OrderRepository].
 func (o *OrderRepository) FindByID(ctx context.Context, id uuid.UUID) (*entities.Order, error) {
-	o.mutex.RLock()
-	defer o.mutex.RUnlock()
+	orders, unlock := o.rlock(ctx)
+	defer unlock()
 
-	order, exists := orders[int(id.ID())]
+	order, exists := orders.get(int(id.ID()))
 	if !exists {
-		return nil, nil
+		return nil, fmt.Errorf("%w: %s", output.ErrOrderNotFound, id.String())
 	}
 
-	return order, nil
+	return order.Clone(), nil
 }
 
 // GetAllOrders implements [output.OrderRepository].
 func (o *OrderRepository) GetAllOrders(ctx context.Context) ([]*entities.Order, error) {
-	o.mutex.RLock()
-	defer o.mutex.RUnlock()
+	orders, unlock := o.rlock(ctx)
+	defer unlock()
 
 	var allOrders []*entities.Order
-	for _, order := range orders {
-		allOrders = append(allOrders, order)
-	}
+	orders.each(func(_ int, order *entities.Order) {
+		allOrders = append(allOrders, order.Clone())
+	})
 
 	return allOrders, nil
 }
 
 // Save implements [output.OrderRepository].
 func (o *OrderRepository) Save(ctx context.Context, order entities.Order) error {
-	o.mutex.Lock()
-	defer o.mutex.Unlock()
+	orders, unlock := o.lock(ctx)
+	defer unlock()
 
-	order.ID = int(uuid.New().ID())
+	if order.ID == 0 {
+		order.ID = int(uuid.New().ID())
+	}
+	if order.Version == 0 {
+		order.Version = 1
+	}
 
-	orders[int(order.ID)] = &order
+	orders.put(order.ID, &order)
 	return nil
 }
 
 // Update implements [output.OrderRepository].
+// Rechaza la escritura con output.ErrConcurrentModification si la versión de
+// la orden no coincide con la almacenada; si se acepta, la versión se incrementa.
 func (o *OrderRepository) Update(ctx context.Context, order *entities.Order) error {
-	o.mutex.Lock()
-	defer o.mutex.Unlock()
+	orders, unlock := o.lock(ctx)
+	defer unlock()
+
+	stored, exists := orders.get(order.ID)
+	if !exists {
+		return fmt.Errorf("%w: %d", output.ErrOrderNotFound, order.ID)
+	}
+	if stored.Version != order.Version {
+		return output.ErrConcurrentModification
+	}
 
-	orders[int(order.ID)] = order
+	order.Version++
+	orders.put(order.ID, order.Clone())
 	return nil
 }
+
+// FindOrders implements [output.OrderRepository].
+func (o *OrderRepository) FindOrders(ctx context.Context, query output.OrderQuery) (*output.OrderPage, error) {
+	orders, unlock := o.rlock(ctx)
+	defer unlock()
+
+	return findOrders(orders, query)
+}
+
+// FindByUserID implements [output.OrderRepository].
+func (o *OrderRepository) FindByUserID(ctx context.Context, userID uuid.UUID, query output.OrderQuery) (*output.UserOrderPage, error) {
+	orders, unlock := o.rlock(ctx)
+	defer unlock()
+
+	query.Filter.UserID = &userID
+	page, err := findOrders(orders, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &output.UserOrderPage{OrderPage: *page}
+	orders.each(func(_ int, order *entities.Order) {
+		if order.UserID != int(userID.ID()) {
+			return
+		}
+		result.Stats.OrderCount++
+		if order.Status != valueobjects.StatusCancelled {
+			result.Stats.LifetimeSpend += order.Total
+		}
+	})
+	return result, nil
+}
+
+func findOrders(orders *table[entities.Order], query output.OrderQuery) (*output.OrderPage, error) {
+	var matches []*entities.Order
+	orders.each(func(_ int, order *entities.Order) {
+		if matchesOrderFilter(order, query.Filter) {
+			matches = append(matches, order)
+		}
+	})
+
+	paginator := keyset[entities.Order]{
+		sort:  query.SortKey(),
+		desc:  query.Desc,
+		key:   orderSortKey(query.Sort),
+		id:    func(order *entities.Order) string { return fmt.Sprintf("%020d", order.ID) },
+		limit: query.Limit,
+	}
+	page, next, err := paginator.page(matches, query.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &output.OrderPage{Orders: make([]*entities.Order, 0, len(page)), NextCursor: next, Total: len(matches)}
+	for _, order := range page {
+		result.Orders = append(result.Orders, order.Clone())
+	}
+	return result, nil
+}
+
+func matchesOrderFilter(order *entities.Order, filter output.OrderFilter) bool {
+	if filter.UserID != nil && order.UserID != int(filter.UserID.ID()) {
+		return false
+	}
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, order.Status) {
+		return false
+	}
+	if filter.CreatedFrom != nil && order.CreatedAt.Before(*filter.CreatedFrom) {
+		return false
+	}
+	if filter.CreatedTo != nil && !order.CreatedAt.Before(*filter.CreatedTo) {
+		return false
+	}
+	if filter.CompletedFrom != nil || filter.CompletedTo != nil {
+		if order.CompletedAt.IsZero() {
+			return false
+		}
+		if filter.CompletedFrom != nil && order.CompletedAt.Before(*filter.CompletedFrom) {
+			return false
+		}
+		if filter.CompletedTo != nil && !order.CompletedAt.Before(*filter.CompletedTo) {
+			return false
+		}
+	}
+	if filter.MinTotal != nil && order.Total < *filter.MinTotal {
+		return false
+	}
+	if filter.MaxTotal != nil && order.Total > *filter.MaxTotal {
+		return false
+	}
+	return true
+}
+
+func orderSortKey(field output.OrderSortField) func(*entities.Order) string {
+	switch field {
+	case output.OrderSortCompletedAt:
+		return func(order *entities.Order) string { return sortableTime(order.CompletedAt) }
+	case output.OrderSortTotal:
+		// Los importes son no negativos: con ancho fijo ordenan como texto
+		return func(order *entities.Order) string { return fmt.Sprintf("%024.4f", order.Total) }
+	default:
+		return func(order *entities.Order) string { return sortableTime(order.CreatedAt) }
+	}
+}
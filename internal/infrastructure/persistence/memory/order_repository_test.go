@@ -0,0 +1,195 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+	"user-management/internal/domain/valueobjects"
+	"user-management/internal/infrastructure/persistence/memory"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderRepository_Update(t *testing.T) {
+	t.Run("rejects stale writes", func(t *testing.T) {
+		repo := memory.NewOrderRepository()
+		ctx := context.Background()
+
+		orderID := uuid.New()
+		order := entities.Order{ID: int(orderID.ID()), UserID: 1, Status: valueobjects.StatusPending, Version: 1}
+		require.NoError(t, repo.Save(ctx, order))
+
+		first, err := repo.FindByID(ctx, orderID)
+		require.NoError(t, err)
+		second, err := repo.FindByID(ctx, orderID)
+		require.NoError(t, err)
+
+		first.Status = valueobjects.StatusShipped
+		require.NoError(t, repo.Update(ctx, first))
+		assert.Equal(t, first.Version, second.Version+1)
+
+		second.Status = valueobjects.StatusCancelled
+		err = repo.Update(ctx, second)
+		assert.ErrorIs(t, err, output.ErrConcurrentModification)
+
+		stored, err := repo.FindByID(ctx, orderID)
+		require.NoError(t, err)
+		assert.Equal(t, valueobjects.StatusShipped, stored.Status)
+	})
+
+	t.Run("returns not found for unknown orders", func(t *testing.T) {
+		repo := memory.NewOrderRepository()
+		ctx := context.Background()
+
+		err := repo.Update(ctx, &entities.Order{ID: 42, UserID: 1, Version: 1})
+		assert.ErrorIs(t, err, output.ErrOrderNotFound)
+
+		all, err := repo.GetAllOrders(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, all)
+	})
+}
+
+func TestOrderRepository_DeleteByUserID(t *testing.T) {
+	repo := memory.NewOrderRepository()
+	ctx := context.Background()
+
+	userID, otherID := uuid.New(), uuid.New()
+	require.NoError(t, repo.Save(ctx, entities.Order{UserID: int(userID.ID())}))
+	require.NoError(t, repo.Save(ctx, entities.Order{UserID: int(userID.ID())}))
+	require.NoError(t, repo.Save(ctx, entities.Order{UserID: int(otherID.ID())}))
+
+	require.NoError(t, repo.DeleteByUserID(ctx, userID))
+
+	all, err := repo.GetAllOrders(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, int(otherID.ID()), all[0].UserID)
+}
+
+func TestOrderRepository_FindOrders(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	alice, bob := uuid.New(), uuid.New()
+
+	seed := func(t *testing.T) *memory.OrderRepository {
+		t.Helper()
+		repo := memory.NewOrderRepository()
+		for i, spec := range []struct {
+			user   uuid.UUID
+			total  float64
+			status valueobjects.OrderStatus
+		}{
+			{alice, 10, valueobjects.StatusCompleted},
+			{alice, 250, valueobjects.StatusPending},
+			{bob, 75.5, valueobjects.StatusCompleted},
+			{bob, 9.99, valueobjects.StatusCancelled},
+			{alice, 75.5, valueobjects.StatusProcessing},
+		} {
+			order := entities.Order{
+				UserID:    int(spec.user.ID()),
+				Total:     spec.total,
+				Status:    spec.status,
+				CreatedAt: base.Add(time.Duration(i) * time.Hour),
+			}
+			if spec.status == valueobjects.StatusCompleted {
+				order.CompletedAt = order.CreatedAt.Add(24 * time.Hour)
+			}
+			require.NoError(t, repo.Save(ctx, order))
+		}
+		return repo
+	}
+
+	totals := func(page *output.OrderPage) []float64 {
+		var result []float64
+		for _, order := range page.Orders {
+			result = append(result, order.Total)
+		}
+		return result
+	}
+
+	ptr := func(v float64) *float64 { return &v }
+	completedFrom := base.Add(25 * time.Hour)
+
+	tests := []struct {
+		name     string
+		query    output.OrderQuery
+		expected []float64
+	}{
+		{"defaults to creation order", output.OrderQuery{}, []float64{10, 250, 75.5, 9.99, 75.5}},
+		{"filters by user", output.OrderQuery{Filter: output.OrderFilter{UserID: &bob}}, []float64{75.5, 9.99}},
+		{"filters by several statuses", output.OrderQuery{Filter: output.OrderFilter{
+			Statuses: []valueobjects.OrderStatus{valueobjects.StatusPending, valueobjects.StatusCancelled},
+		}}, []float64{250, 9.99}},
+		{"filters by total range inclusively", output.OrderQuery{Filter: output.OrderFilter{MinTotal: ptr(10), MaxTotal: ptr(75.5)}}, []float64{10, 75.5, 75.5}},
+		{"completed range skips open orders", output.OrderQuery{Filter: output.OrderFilter{CompletedFrom: &completedFrom}}, []float64{75.5}},
+		{"sorts by total descending", output.OrderQuery{Sort: output.OrderSortTotal, Desc: true}, []float64{250, 75.5, 75.5, 10, 9.99}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := seed(t).FindOrders(ctx, tt.query)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, totals(page))
+			assert.Equal(t, len(tt.expected), page.Total)
+		})
+	}
+
+	t.Run("paginates with cursors across equal sort keys", func(t *testing.T) {
+		repo := seed(t)
+		query := output.OrderQuery{Sort: output.OrderSortTotal, Limit: 2}
+
+		var all []int
+		for {
+			page, err := repo.FindOrders(ctx, query)
+			require.NoError(t, err)
+			for _, order := range page.Orders {
+				all = append(all, order.ID)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+
+		full, err := repo.FindOrders(ctx, output.OrderQuery{Sort: output.OrderSortTotal})
+		require.NoError(t, err)
+		require.Len(t, all, 5)
+		for i, order := range full.Orders {
+			assert.Equal(t, order.ID, all[i])
+		}
+	})
+}
+
+func TestOrderRepository_FindByUserID(t *testing.T) {
+	repo := memory.NewOrderRepository()
+	ctx := context.Background()
+
+	userID, otherID := uuid.New(), uuid.New()
+	for _, order := range []entities.Order{
+		{UserID: int(userID.ID()), Total: 10, Status: valueobjects.StatusCompleted},
+		{UserID: int(userID.ID()), Total: 25, Status: valueobjects.StatusPending},
+		{UserID: int(userID.ID()), Total: 99, Status: valueobjects.StatusCancelled},
+		{UserID: int(otherID.ID()), Total: 500, Status: valueobjects.StatusCompleted},
+	} {
+		require.NoError(t, repo.Save(ctx, order))
+	}
+
+	// El filtro por usuario de la consulta se ignora; los agregados no
+	// dependen del resto de filtros ni de la paginación
+	page, err := repo.FindByUserID(ctx, userID, output.OrderQuery{
+		Filter: output.OrderFilter{UserID: &otherID, Statuses: []valueobjects.OrderStatus{valueobjects.StatusPending}},
+		Limit:  10,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, page.Orders, 1)
+	assert.Equal(t, 25.0, page.Orders[0].Total)
+	assert.Equal(t, 1, page.Total)
+	assert.Equal(t, output.OrderStats{OrderCount: 3, LifetimeSpend: 35}, page.Stats)
+}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"user-management/internal/domain/ports/output"
+)
+
+// sortableTime formatea t con ancho fijo para que el orden lexicográfico
+// coincida con el cronológico
+func sortableTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000000000Z")
+}
+
+// keyset implementa la paginación por clave sobre filas ya filtradas, igual
+// que lo haría un ORDER BY key, id con WHERE (key, id) > cursor en SQL.
+type keyset[T any] struct {
+	sort   string          // ordenación de la consulta, se guarda en el cursor
+	desc   bool            // orden descendente
+	key    func(*T) string // clave de ordenación comparable como texto
+	id     func(*T) string // desempate único
+	limit  int             // <= 0 devuelve todas las filas
+	offset int             // filas que se saltan tras el cursor, como OFFSET
+}
+
+// page ordena rows, descarta las filas hasta el cursor incluido y las offset
+// siguientes y devuelve como mucho limit filas junto con el cursor de la página siguiente
+func (k keyset[T]) page(rows []*T, encoded string) ([]*T, string, error) {
+	cursor, err := output.DecodeCursor(encoded, k.sort)
+	if err != nil {
+		return nil, "", err
+	}
+
+	compare := func(aKey, aID, bKey, bID string) int {
+		c := strings.Compare(aKey, bKey)
+		if c == 0 {
+			c = strings.Compare(aID, bID)
+		}
+		if k.desc {
+			return -c
+		}
+		return c
+	}
+
+	slices.SortFunc(rows, func(a, b *T) int {
+		return compare(k.key(a), k.id(a), k.key(b), k.id(b))
+	})
+
+	start := 0
+	if cursor != nil {
+		start, _ = slices.BinarySearchFunc(rows, cursor, func(row *T, c *output.Cursor) int {
+			// Las filas iguales al cursor quedan antes para empezar después de él
+			if cmp := compare(k.key(row), k.id(row), c.Key, c.ID); cmp != 0 {
+				return cmp
+			}
+			return -1
+		})
+	}
+
+	rows = rows[min(start+max(k.offset, 0), len(rows)):]
+	if k.limit <= 0 || len(rows) <= k.limit {
+		return rows, "", nil
+	}
+
+	rows = rows[:k.limit]
+	last := rows[len(rows)-1]
+	next := output.Cursor{Sort: k.sort, Key: k.key(last), ID: k.id(last)}
+	return rows, next.Encode(), nil
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+	"user-management/internal/domain/ports/output"
+)
+
+// RateLimiter implementa output.RateLimiter en memoria con ventanas fijas.
+// Las ventanas terminadas se descartan como mucho una vez por minuto.
+type RateLimiter struct {
+	mutex     sync.Mutex
+	windows   map[string]*rateWindow
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+type rateWindow struct {
+	count int
+	ends  time.Time
+}
+
+var _ output.RateLimiter = (*RateLimiter)(nil)
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		windows: make(map[string]*rateWindow),
+		now:     time.Now,
+	}
+}
+
+// Allow implements [output.RateLimiter].
+func (l *RateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	w, ok := l.windows[key]
+	if !ok || !now.Before(w.ends) {
+		w = &rateWindow{ends: now.Add(window)}
+		l.windows[key] = w
+	}
+	if w.count >= limit {
+		return false, w.ends.Sub(now), nil
+	}
+	w.count++
+	return true, 0, nil
+}
+
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, w := range l.windows {
+		if !now.Before(w.ends) {
+			delete(l.windows, key)
+		}
+	}
+}
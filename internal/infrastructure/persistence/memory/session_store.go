@@ -0,0 +1,199 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+)
+
+// SessionStore implementa output.SessionStore en memoria. Las sesiones
+// caducadas se descartan al consultarlas y, en bloque, como mucho una vez
+// por minuto.
+type SessionStore struct {
+	mutex    sync.Mutex
+	sessions map[uuid.UUID]*entities.Session
+	byHash   map[string]uuid.UUID
+	// spent son los refresh tokens ya canjeados de cada sesión, que se
+	// olvidan al cerrarla
+	spent     map[string]uuid.UUID
+	spentBy   map[uuid.UUID][]string
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+var _ output.SessionStore = (*SessionStore)(nil)
+
+func NewSessionStore() *SessionStore {
+	return &SessionStore{
+		sessions: make(map[uuid.UUID]*entities.Session),
+		byHash:   make(map[string]uuid.UUID),
+		spent:    make(map[string]uuid.UUID),
+		spentBy:  make(map[uuid.UUID][]string),
+		now:      time.Now,
+	}
+}
+
+// Save implements [output.SessionStore].
+func (s *SessionStore) Save(ctx context.Context, session *entities.Session) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sweep(s.now())
+	s.put(session)
+	return nil
+}
+
+// FindByID implements [output.SessionStore].
+func (s *SessionStore) FindByID(ctx context.Context, id uuid.UUID) (*entities.Session, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.get(id)
+}
+
+// FindByRefreshHash implements [output.SessionStore].
+func (s *SessionStore) FindByRefreshHash(ctx context.Context, hash string) (*entities.Session, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id, ok := s.byHash[hash]
+	if !ok {
+		return nil, output.ErrSessionNotFound
+	}
+	return s.get(id)
+}
+
+// FindByUser implements [output.SessionStore].
+func (s *SessionStore) FindByUser(ctx context.Context, userID uuid.UUID) ([]*entities.Session, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	sessions := []*entities.Session{}
+	for _, session := range s.sessions {
+		if session.UserID == userID && now.Before(session.ExpiresAt) {
+			c := *session
+			sessions = append(sessions, &c)
+		}
+	}
+	slices.SortFunc(sessions, func(a, b *entities.Session) int {
+		return b.LastSeenAt.Compare(a.LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// FindBySpentRefreshHash implements [output.SessionStore].
+func (s *SessionStore) FindBySpentRefreshHash(ctx context.Context, hash string) (*entities.Session, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id, ok := s.spent[hash]
+	if !ok {
+		return nil, output.ErrSessionNotFound
+	}
+	return s.get(id)
+}
+
+// Touch implements [output.SessionStore].
+func (s *SessionStore) Touch(ctx context.Context, id uuid.UUID, at time.Time, ip string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := s.get(id); err != nil {
+		return err
+	}
+	s.sessions[id].Touch(at, ip)
+	return nil
+}
+
+// Rotate implements [output.SessionStore].
+func (s *SessionStore) Rotate(ctx context.Context, session *entities.Session, previousHash string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := s.get(session.ID); err != nil {
+		return err
+	}
+	if s.sessions[session.ID].RefreshHash != previousHash {
+		return output.ErrConcurrentModification
+	}
+	delete(s.byHash, previousHash)
+	s.spent[previousHash] = session.ID
+	s.spentBy[session.ID] = append(s.spentBy[session.ID], previousHash)
+	s.put(session)
+	return nil
+}
+
+// Delete implements [output.SessionStore].
+func (s *SessionStore) Delete(ctx context.Context, id uuid.UUID) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.sessions[id]; !ok {
+		return output.ErrSessionNotFound
+	}
+	s.remove(id)
+	return nil
+}
+
+// DeleteByUser implements [output.SessionStore].
+func (s *SessionStore) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for id, session := range s.sessions {
+		if session.UserID == userID {
+			s.remove(id)
+		}
+	}
+	return nil
+}
+
+// get devuelve una copia de la sesión si está vigente; las caducadas se
+// borran
+func (s *SessionStore) get(id uuid.UUID) (*entities.Session, error) {
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, output.ErrSessionNotFound
+	}
+	if !s.now().Before(session.ExpiresAt) {
+		s.remove(id)
+		return nil, output.ErrSessionNotFound
+	}
+	c := *session
+	return &c, nil
+}
+
+func (s *SessionStore) put(session *entities.Session) {
+	c := *session
+	s.sessions[c.ID] = &c
+	s.byHash[c.RefreshHash] = c.ID
+}
+
+func (s *SessionStore) remove(id uuid.UUID) {
+	if session, ok := s.sessions[id]; ok {
+		delete(s.byHash, session.RefreshHash)
+		delete(s.sessions, id)
+	}
+	for _, hash := range s.spentBy[id] {
+		delete(s.spent, hash)
+	}
+	delete(s.spentBy, id)
+}
+
+func (s *SessionStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for id, session := range s.sessions {
+		if !now.Before(session.ExpiresAt) {
+			s.remove(id)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	user, err := entities.NewUser("John Doe", "john@example.com", 30, "Password123!")
+	require.NoError(t, err)
+
+	newStore := func() *SessionStore {
+		store := NewSessionStore()
+		store.now = func() time.Time { return now }
+		return store
+	}
+
+	t.Run("sessions are found by ID and refresh hash", func(t *testing.T) {
+		store := newStore()
+		session := entities.NewSession(user, "h1", "curl/8.4.0", "10.0.0.1", time.Hour)
+		require.NoError(t, store.Save(ctx, session))
+
+		found, err := store.FindByID(ctx, session.ID)
+		require.NoError(t, err)
+		assert.Equal(t, session, found)
+
+		found, err = store.FindByRefreshHash(ctx, "h1")
+		require.NoError(t, err)
+		assert.Equal(t, session.ID, found.ID)
+	})
+
+	t.Run("rotating the refresh token spends the old hash", func(t *testing.T) {
+		store := newStore()
+		session := entities.NewSession(user, "h1", "", "", time.Hour)
+		require.NoError(t, store.Save(ctx, session))
+
+		session.Rotate("h2", now, time.Hour)
+		require.NoError(t, store.Rotate(ctx, session, "h1"))
+
+		_, err := store.FindByRefreshHash(ctx, "h1")
+		assert.ErrorIs(t, err, output.ErrSessionNotFound)
+		_, err = store.FindByRefreshHash(ctx, "h2")
+		assert.NoError(t, err)
+		spent, err := store.FindBySpentRefreshHash(ctx, "h1")
+		require.NoError(t, err)
+		assert.Equal(t, session.ID, spent.ID)
+		_, err = store.FindBySpentRefreshHash(ctx, "h2")
+		assert.ErrorIs(t, err, output.ErrSessionNotFound)
+
+		require.NoError(t, store.Delete(ctx, session.ID))
+		_, err = store.FindBySpentRefreshHash(ctx, "h1")
+		assert.ErrorIs(t, err, output.ErrSessionNotFound)
+	})
+
+	t.Run("only one rotation of the same refresh token wins", func(t *testing.T) {
+		store := newStore()
+		session := entities.NewSession(user, "h1", "", "", time.Hour)
+		require.NoError(t, store.Save(ctx, session))
+
+		first, second := *session, *session
+		first.Rotate("h2", now, time.Hour)
+		second.Rotate("h3", now, time.Hour)
+		require.NoError(t, store.Rotate(ctx, &first, "h1"))
+		assert.ErrorIs(t, store.Rotate(ctx, &second, "h1"), output.ErrConcurrentModification)
+
+		found, err := store.FindByID(ctx, session.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "h2", found.RefreshHash)
+	})
+
+	t.Run("touching a session only records activity", func(t *testing.T) {
+		store := newStore()
+		session := entities.NewSession(user, "h1", "", "10.0.0.1", time.Hour)
+		require.NoError(t, store.Save(ctx, session))
+
+		// Una copia leída antes de la renovación no la deshace
+		stale := *session
+		session.Rotate("h2", now, time.Hour)
+		require.NoError(t, store.Rotate(ctx, session, "h1"))
+		seen := now.Add(time.Minute)
+		require.NoError(t, store.Touch(ctx, stale.ID, seen, "10.0.0.2"))
+
+		found, err := store.FindByID(ctx, session.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "h2", found.RefreshHash)
+		assert.Equal(t, seen, found.LastSeenAt)
+		assert.Equal(t, "10.0.0.2", found.IP)
+		assert.ErrorIs(t, store.Touch(ctx, uuid.New(), seen, ""), output.ErrSessionNotFound)
+	})
+
+	t.Run("expired sessions are not found", func(t *testing.T) {
+		store := newStore()
+		session := entities.NewSession(user, "h1", "", "", time.Hour)
+		require.NoError(t, store.Save(ctx, session))
+
+		now = now.Add(2 * time.Hour)
+		defer func() { now = now.Add(-2 * time.Hour) }()
+
+		_, err := store.FindByID(ctx, session.ID)
+		assert.ErrorIs(t, err, output.ErrSessionNotFound)
+		sessions, err := store.FindByUser(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+
+	t.Run("sessions are listed per user, most recent first, and deleted together", func(t *testing.T) {
+		store := newStore()
+		older := entities.NewSession(user, "h1", "", "", time.Hour)
+		newer := entities.NewSession(user, "h2", "", "", time.Hour)
+		newer.Touch(older.LastSeenAt.Add(time.Second), "")
+		other := entities.NewSession(&entities.User{ID: uuid.New()}, "h3", "", "", time.Hour)
+		for _, session := range []*entities.Session{older, newer, other} {
+			require.NoError(t, store.Save(ctx, session))
+		}
+
+		sessions, err := store.FindByUser(ctx, user.ID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.Equal(t, newer.ID, sessions[0].ID)
+
+		require.NoError(t, store.DeleteByUser(ctx, user.ID))
+		sessions, err = store.FindByUser(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+		_, err = store.FindByRefreshHash(ctx, "h1")
+		assert.ErrorIs(t, err, output.ErrSessionNotFound)
+		_, err = store.FindByID(ctx, other.ID)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,237 @@
+package memory
+
+import (
+	"sync"
+	"user-management/internal/domain/ports/output"
+)
+
+// table es el conjunto de filas sobre el que opera una llamada a un
+// repositorio. Las filas guardadas nunca se modifican en sitio: cada escritura
+// guarda un puntero nuevo, así que el puntero identifica la versión de la fila.
+//
+// La tabla de una transacción no copia los datos: rows sólo contiene las
+// claves escritas en ella (nil si se borraron) y el resto se lee de base. read
+// guarda la fila de base vista la primera vez que se tocó cada clave para
+// detectar al confirmar si alguien la cambió entretanto.
+type table[T any] struct {
+	rows  map[int]*T
+	index *uniqueIndex[T]
+
+	base   *table[T]
+	baseMu *sync.RWMutex
+	read   map[int]*T
+}
+
+// uniqueIndex mantiene un índice único sobre un valor derivado de cada fila.
+// violation es el error que se devuelve cuando una escritura lo incumple.
+type uniqueIndex[T any] struct {
+	keys      map[string]int
+	keyOf     func(*T) string
+	violation error
+}
+
+func newTable[T any]() table[T] {
+	return table[T]{rows: make(map[int]*T)}
+}
+
+// newIndexedTable crea una tabla con un índice único sobre keyOf
+func newIndexedTable[T any](keyOf func(*T) string, violation error) table[T] {
+	t := newTable[T]()
+	t.index = &uniqueIndex[T]{keys: make(map[string]int), keyOf: keyOf, violation: violation}
+	return t
+}
+
+// overlay crea la tabla de una transacción sobre base, protegida por mu
+func (t *table[T]) overlay(mu *sync.RWMutex) *table[T] {
+	return &table[T]{
+		rows:   make(map[int]*T),
+		index:  t.index,
+		base:   t,
+		baseMu: mu,
+		read:   make(map[int]*T),
+	}
+}
+
+// get devuelve la fila guardada bajo key
+func (t *table[T]) get(key int) (*T, bool) {
+	if t.base == nil {
+		row, ok := t.rows[key]
+		return row, ok
+	}
+	if row, written := t.rows[key]; written {
+		return row, row != nil
+	}
+
+	t.baseMu.RLock()
+	row := t.base.rows[key]
+	t.baseMu.RUnlock()
+
+	row = t.see(key, row)
+	return row, row != nil
+}
+
+// see registra la versión de base de key si es la primera vez que la
+// transacción la toca y devuelve la registrada, de modo que las lecturas
+// repetidas dentro de la transacción son estables.
+func (t *table[T]) see(key int, row *T) *T {
+	if seen, ok := t.read[key]; ok {
+		return seen
+	}
+	t.read[key] = row
+	return row
+}
+
+// each recorre las filas vivas. Dentro de una transacción, todas las filas
+// recorridas pasan a formar parte de las lecturas a validar.
+func (t *table[T]) each(fn func(key int, row *T)) {
+	if t.base == nil {
+		for key, row := range t.rows {
+			fn(key, row)
+		}
+		return
+	}
+
+	for key, row := range t.rows {
+		if row != nil {
+			fn(key, row)
+		}
+	}
+
+	t.baseMu.RLock()
+	visible := make(map[int]*T, len(t.base.rows))
+	for key, row := range t.base.rows {
+		if _, written := t.rows[key]; !written {
+			visible[key] = row
+		}
+	}
+	t.baseMu.RUnlock()
+
+	for key, row := range visible {
+		if row = t.see(key, row); row != nil {
+			fn(key, row)
+		}
+	}
+}
+
+func (t *table[T]) put(key int, row *T) {
+	if t.base != nil {
+		t.get(key)
+		t.rows[key] = row
+		return
+	}
+
+	if t.index != nil {
+		if old, ok := t.rows[key]; ok {
+			delete(t.index.keys, t.index.keyOf(old))
+		}
+		t.index.keys[t.index.keyOf(row)] = key
+	}
+	t.rows[key] = row
+}
+
+func (t *table[T]) remove(key int) {
+	if t.base != nil {
+		t.get(key)
+		t.rows[key] = nil
+		return
+	}
+
+	if old, ok := t.rows[key]; ok && t.index != nil {
+		delete(t.index.keys, t.index.keyOf(old))
+	}
+	delete(t.rows, key)
+}
+
+// lookup busca una fila por el valor del índice único
+func (t *table[T]) lookup(value string) (*T, bool) {
+	if t.base == nil {
+		key, ok := t.index.keys[value]
+		if !ok {
+			return nil, false
+		}
+		return t.rows[key], true
+	}
+
+	for _, row := range t.rows {
+		if row != nil && t.index.keyOf(row) == value {
+			return row, true
+		}
+	}
+
+	t.baseMu.RLock()
+	key, ok := t.base.index.keys[value]
+	t.baseMu.RUnlock()
+	if _, written := t.rows[key]; !ok || written {
+		return nil, false
+	}
+	return t.get(key)
+}
+
+// check devuelve el error del índice si guardar row bajo key lo violaría
+func (t *table[T]) check(key int, row *T) error {
+	if t.index == nil {
+		return nil
+	}
+	value := t.index.keyOf(row)
+
+	if t.base == nil {
+		if existing, ok := t.index.keys[value]; ok && existing != key {
+			return t.index.violation
+		}
+		return nil
+	}
+
+	for other, written := range t.rows {
+		if other != key && written != nil && t.index.keyOf(written) == value {
+			return t.index.violation
+		}
+	}
+
+	t.baseMu.RLock()
+	owner, taken := t.base.index.keys[value]
+	t.baseMu.RUnlock()
+	if _, rewritten := t.rows[owner]; taken && owner != key && !rewritten {
+		return t.index.violation
+	}
+	return nil
+}
+
+// validate comprueba, con base bloqueada, que ninguna fila leída o escrita en
+// la transacción cambió fuera de ella (output.ErrConcurrentModification) y
+// que las claves escritas no violan el índice de base. Los conflictos entre
+// filas de la propia transacción ya los evitó check.
+func (t *table[T]) validate() error {
+	for key, seen := range t.read {
+		if t.base.rows[key] != seen {
+			return output.ErrConcurrentModification
+		}
+	}
+
+	if t.index == nil {
+		return nil
+	}
+	for key, row := range t.rows {
+		if row == nil {
+			continue
+		}
+		owner, taken := t.base.index.keys[t.index.keyOf(row)]
+		if _, rewritten := t.rows[owner]; taken && owner != key && !rewritten {
+			return t.index.violation
+		}
+	}
+	return nil
+}
+
+// apply vuelca en base las claves escritas en la transacción. Primero retira
+// del índice los valores antiguos para que un intercambio de valores entre
+// filas no pise entradas recién escritas.
+func (t *table[T]) apply() {
+	for key := range t.rows {
+		t.base.remove(key)
+	}
+	for key, row := range t.rows {
+		if row != nil {
+			t.base.put(key, row)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+)
+
+type txKey struct{}
+
+// transaction guarda las escrituras de una transacción sobre los datos de los
+// repositorios y las versiones de las filas que leyó. Al confirmar sólo se
+// vuelcan las claves escritas, y únicamente si ninguna fila tocada cambió.
+type transaction struct {
+	mu     sync.Mutex
+	users  *table[entities.User]
+	orders *table[entities.Order]
+}
+
+func txFromContext(ctx context.Context) *transaction {
+	tx, _ := ctx.Value(txKey{}).(*transaction)
+	return tx
+}
+
+// UnitOfWork implementa output.UnitOfWork sobre los repositorios en memoria.
+// Las transacciones se serializan entre sí; las lecturas fuera de una
+// transacción nunca ven cambios sin confirmar, y una escritura externa sobre
+// una fila que la transacción leyó la hace fallar con
+// output.ErrConcurrentModification.
+type UnitOfWork struct {
+	mu     sync.Mutex
+	users  *UserRepository
+	orders *OrderRepository
+}
+
+var _ output.UnitOfWork = (*UnitOfWork)(nil)
+
+func NewUnitOfWork(users *UserRepository, orders *OrderRepository) *UnitOfWork {
+	return &UnitOfWork{users: users, orders: orders}
+}
+
+// Do implements [output.UnitOfWork].
+func (w *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Una llamada anidada se une a la transacción en curso
+	if txFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	tx := w.begin()
+	txCtx, committed := output.WithTransaction(context.WithValue(ctx, txKey{}, tx))
+	if err := fn(txCtx); err != nil {
+		return err
+	}
+
+	if err := w.commit(tx); err != nil {
+		return err
+	}
+	committed()
+	return nil
+}
+
+func (w *UnitOfWork) begin() *transaction {
+	return &transaction{
+		users:  w.users.users.overlay(&w.users.mutex),
+		orders: w.orders.orders.overlay(&w.orders.mutex),
+	}
+}
+
+// commit bloquea ambos repositorios para que los cambios sean visibles a la
+// vez. Si una escritura externa cambió una fila tocada u ocupó un valor único
+// mientras tanto, la transacción entera se descarta.
+func (w *UnitOfWork) commit(tx *transaction) error {
+	w.users.mutex.Lock()
+	defer w.users.mutex.Unlock()
+	w.orders.mutex.Lock()
+	defer w.orders.mutex.Unlock()
+
+	if err := tx.users.validate(); err != nil {
+		return err
+	}
+	if err := tx.orders.validate(); err != nil {
+		return err
+	}
+
+	tx.users.apply()
+	tx.orders.apply()
+	return nil
+}
@@ -0,0 +1,285 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+	"user-management/internal/domain/valueobjects"
+	"user-management/internal/infrastructure/persistence/memory"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestUser(t *testing.T, email string) *entities.User {
+	t.Helper()
+	user, err := entities.NewUser("John Doe", email, 30, "Password123!")
+	require.NoError(t, err)
+	return user
+}
+
+func TestUnitOfWork_Do(t *testing.T) {
+	t.Run("commits changes to every repository", func(t *testing.T) {
+		users := memory.NewUserRepository()
+		orders := memory.NewOrderRepository()
+		uow := memory.NewUnitOfWork(users, orders)
+		ctx := context.Background()
+
+		user := newTestUser(t, "john@example.com")
+
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			if err := users.Save(ctx, *user); err != nil {
+				return err
+			}
+			return orders.Save(ctx, entities.Order{UserID: int(user.ID.ID())})
+		})
+		require.NoError(t, err)
+
+		found, err := users.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, user.Email, found.Email)
+
+		all, err := orders.GetAllOrders(ctx)
+		require.NoError(t, err)
+		assert.Len(t, all, 1)
+	})
+
+	t.Run("rolls back every repository on error", func(t *testing.T) {
+		users := memory.NewUserRepository()
+		orders := memory.NewOrderRepository()
+		uow := memory.NewUnitOfWork(users, orders)
+		ctx := context.Background()
+
+		user := newTestUser(t, "john@example.com")
+		expectedErr := errors.New("boom")
+
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			require.NoError(t, users.Save(ctx, *user))
+			require.NoError(t, orders.Save(ctx, entities.Order{UserID: int(user.ID.ID())}))
+			return expectedErr
+		})
+		assert.Equal(t, expectedErr, err)
+
+		_, err = users.FindByID(ctx, user.ID)
+		assert.Error(t, err)
+
+		all, err := orders.GetAllOrders(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, all)
+	})
+
+	t.Run("discards in-place changes to loaded entities on rollback", func(t *testing.T) {
+		users := memory.NewUserRepository()
+		orders := memory.NewOrderRepository()
+		uow := memory.NewUnitOfWork(users, orders)
+		ctx := context.Background()
+
+		orderID := uuid.New()
+		order := entities.Order{ID: int(orderID.ID()), UserID: 1, Status: valueobjects.StatusPending}
+		require.NoError(t, orders.Save(ctx, order))
+
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			loaded, err := orders.FindByID(ctx, orderID)
+			require.NoError(t, err)
+			loaded.Status = valueobjects.StatusCancelled
+			require.NoError(t, orders.Update(ctx, loaded))
+			return assert.AnError
+		})
+		assert.Error(t, err)
+
+		stored, err := orders.FindByID(ctx, orderID)
+		require.NoError(t, err)
+		assert.Equal(t, valueobjects.StatusPending, stored.Status)
+	})
+
+	t.Run("hides uncommitted changes from readers outside the transaction", func(t *testing.T) {
+		users := memory.NewUserRepository()
+		orders := memory.NewOrderRepository()
+		uow := memory.NewUnitOfWork(users, orders)
+		ctx := context.Background()
+
+		user := newTestUser(t, "john@example.com")
+
+		err := uow.Do(ctx, func(txCtx context.Context) error {
+			require.NoError(t, users.Save(txCtx, *user))
+
+			_, err := users.FindByID(ctx, user.ID)
+			assert.Error(t, err, "el usuario no debe ser visible antes del commit")
+
+			_, err = users.FindByID(txCtx, user.ID)
+			assert.NoError(t, err)
+			return nil
+		})
+		require.NoError(t, err)
+
+		_, err = users.FindByID(ctx, user.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("nested calls join the outer transaction", func(t *testing.T) {
+		users := memory.NewUserRepository()
+		orders := memory.NewOrderRepository()
+		uow := memory.NewUnitOfWork(users, orders)
+		ctx := context.Background()
+
+		user := newTestUser(t, "john@example.com")
+
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			require.NoError(t, uow.Do(ctx, func(ctx context.Context) error {
+				return users.Save(ctx, *user)
+			}))
+			return assert.AnError
+		})
+		assert.Error(t, err)
+
+		_, err = users.FindByID(ctx, user.ID)
+		assert.Error(t, err)
+	})
+
+	t.Run("keeps writes made outside the transaction", func(t *testing.T) {
+		users := memory.NewUserRepository()
+		orders := memory.NewOrderRepository()
+		uow := memory.NewUnitOfWork(users, orders)
+		ctx := context.Background()
+
+		inside := newTestUser(t, "inside@example.com")
+		outside := newTestUser(t, "outside@example.com")
+
+		err := uow.Do(ctx, func(txCtx context.Context) error {
+			require.NoError(t, users.Save(txCtx, *inside))
+			require.NoError(t, users.Save(ctx, *outside))
+			return nil
+		})
+		require.NoError(t, err)
+
+		all, err := users.GetAllUsers(ctx)
+		require.NoError(t, err)
+		assert.Len(t, all, 2)
+	})
+}
+
+func TestUnitOfWork_Conflicts(t *testing.T) {
+	t.Run("rejects commit when a row read in the transaction was deleted outside", func(t *testing.T) {
+		users := memory.NewUserRepository()
+		orders := memory.NewOrderRepository()
+		uow := memory.NewUnitOfWork(users, orders)
+		ctx := context.Background()
+
+		user := newTestUser(t, "john@example.com")
+		require.NoError(t, users.Save(ctx, *user))
+
+		err := uow.Do(ctx, func(txCtx context.Context) error {
+			_, err := users.FindByID(txCtx, user.ID)
+			require.NoError(t, err)
+			require.NoError(t, users.Delete(ctx, user.ID))
+			return orders.Save(txCtx, entities.Order{UserID: int(user.ID.ID())})
+		})
+		assert.ErrorIs(t, err, output.ErrConcurrentModification)
+
+		all, err := orders.GetAllOrders(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, all)
+	})
+
+	t.Run("rejects commit when a written row was updated outside", func(t *testing.T) {
+		users := memory.NewUserRepository()
+		uow := memory.NewUnitOfWork(users, memory.NewOrderRepository())
+		ctx := context.Background()
+
+		user := newTestUser(t, "john@example.com")
+		require.NoError(t, users.Save(ctx, *user))
+
+		err := uow.Do(ctx, func(txCtx context.Context) error {
+			inside, err := users.FindByID(txCtx, user.ID)
+			require.NoError(t, err)
+
+			outside, err := users.FindByID(ctx, user.ID)
+			require.NoError(t, err)
+			outside.Name = "Outside"
+			require.NoError(t, users.Update(ctx, outside))
+
+			inside.Name = "Inside"
+			return users.Update(txCtx, inside)
+		})
+		assert.ErrorIs(t, err, output.ErrConcurrentModification)
+
+		found, err := users.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Outside", found.Name)
+	})
+
+	t.Run("ignores outside writes to rows the transaction never touched", func(t *testing.T) {
+		users := memory.NewUserRepository()
+		uow := memory.NewUnitOfWork(users, memory.NewOrderRepository())
+		ctx := context.Background()
+
+		touched := newTestUser(t, "touched@example.com")
+		other := newTestUser(t, "other@example.com")
+		require.NoError(t, users.Save(ctx, *touched))
+		require.NoError(t, users.Save(ctx, *other))
+
+		err := uow.Do(ctx, func(txCtx context.Context) error {
+			_, err := users.FindByID(txCtx, touched.ID)
+			require.NoError(t, err)
+			return users.Delete(ctx, other.ID)
+		})
+		assert.NoError(t, err)
+	})
+}
+
+func TestUnitOfWork_EmailIndex(t *testing.T) {
+	t.Run("rejects commit when the email was taken outside the transaction", func(t *testing.T) {
+		users := memory.NewUserRepository()
+		uow := memory.NewUnitOfWork(users, memory.NewOrderRepository())
+		ctx := context.Background()
+
+		err := uow.Do(ctx, func(txCtx context.Context) error {
+			require.NoError(t, users.Save(txCtx, *newTestUser(t, "juan@test.com")))
+			require.NoError(t, users.Save(ctx, *newTestUser(t, "JUAN@test.com")))
+			return nil
+		})
+		assert.ErrorIs(t, err, output.ErrEmailAlreadyExists)
+
+		all, err := users.GetAllUsers(ctx)
+		require.NoError(t, err)
+		assert.Len(t, all, 1)
+	})
+
+	t.Run("allows swapping emails between users", func(t *testing.T) {
+		users := memory.NewUserRepository()
+		uow := memory.NewUnitOfWork(users, memory.NewOrderRepository())
+		ctx := context.Background()
+
+		juan := newTestUser(t, "juan@test.com")
+		maria := newTestUser(t, "maria@test.com")
+		require.NoError(t, users.Save(ctx, *juan))
+		require.NoError(t, users.Save(ctx, *maria))
+
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			first, _ := users.FindByID(ctx, juan.ID)
+			second, _ := users.FindByID(ctx, maria.ID)
+
+			first.Email = "tmp@test.com"
+			require.NoError(t, users.Update(ctx, first))
+			second.Email = "juan@test.com"
+			require.NoError(t, users.Update(ctx, second))
+			first.Email = "maria@test.com"
+			return users.Update(ctx, first)
+		})
+		require.NoError(t, err)
+
+		found, err := users.FindByEmail(ctx, "juan@test.com")
+		require.NoError(t, err)
+		assert.Equal(t, maria.ID, found.ID)
+
+		found, err = users.FindByEmail(ctx, "maria@test.com")
+		require.NoError(t, err)
+		assert.Equal(t, juan.ID, found.ID)
+
+		_, err = users.FindByEmail(ctx, "tmp@test.com")
+		assert.Error(t, err)
+	})
+}
@@ -3,107 +3,301 @@ package memory
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 	"user-management/internal/domain/entities"
 	"user-management/internal/domain/ports/output"
+	"user-management/internal/domain/valueobjects"
 
 	"github.com/google/uuid"
 )
 
-// UserRepository implementa output
+// UserRepository implementa output. Las lecturas devuelven copias para que
+// los cambios hechos por el llamador no lleguen al almacén sin pasar por Update.
 type UserRepository struct {
 	mutex sync.RWMutex
+	users table[entities.User] // índice único por email normalizado
 }
 
-var users = map[int]*entities.User{}
-
 // Garantiza que UserRepository cumple con la interfaz
 var _ output.UserRepository = (*UserRepository)(nil)
 
 func NewUserRepository() *UserRepository {
-	return &UserRepository{}
+	return &UserRepository{
+		users: newIndexedTable(normalizedEmail, output.ErrEmailAlreadyExists),
+	}
+}
+
+func normalizedEmail(user *entities.User) string {
+	return valueobjects.NormalizeEmail(user.Email)
+}
+
+// lock devuelve la tabla sobre la que opera la llamada: la de la transacción
+// activa en ctx o los datos del repositorio.
+func (u *UserRepository) lock(ctx context.Context) (*table[entities.User], func()) {
+	if tx := txFromContext(ctx); tx != nil {
+		tx.mu.Lock()
+		return tx.users, tx.mu.Unlock
+	}
+	u.mutex.Lock()
+	return &u.users, u.mutex.Unlock
+}
+
+// rlock bloquea en exclusiva la transacción porque sus lecturas también
+// registran las versiones leídas.
+func (u *UserRepository) rlock(ctx context.Context) (*table[entities.User], func()) {
+	if tx := txFromContext(ctx); tx != nil {
+		tx.mu.Lock()
+		return tx.users, tx.mu.Unlock
+	}
+	u.mutex.RLock()
+	return &u.users, u.mutex.RUnlock
 }
 
 // Create implements output.UserPort.
 func (u *UserRepository) Save(ctx context.Context, user entities.User) error {
-	u.mutex.Lock()
-	defer u.mutex.Unlock()
+	users, unlock := u.lock(ctx)
+	defer unlock()
+
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	}
+	if user.Version == 0 {
+		user.Version = 1
+	}
 
-	user.ID = uuid.New()
+	// La comprobación y la inserción ocurren bajo el mismo lock
+	if _, exists := users.get(int(user.ID.ID())); exists {
+		return fmt.Errorf("%w: %s", output.ErrUserAlreadyExists, user.ID.String())
+	}
+	if err := users.check(int(user.ID.ID()), &user); err != nil {
+		return err
+	}
 
-	users[int(user.ID.ID())] = &user
+	users.put(int(user.ID.ID()), &user)
 	return nil
 }
 
 // Delete implements output.UserPort.
+// Es un borrado lógico: el usuario deja de ser visible pero se conserva, con
+// su email reservado, hasta que se purga.
 func (u *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	u.mutex.Lock()
-	defer u.mutex.Unlock()
+	users, unlock := u.lock(ctx)
+	defer unlock()
+
+	stored, exists := users.get(int(id.ID()))
+	if !exists || stored.IsDeleted() {
+		return fmt.Errorf("%w: %s", output.ErrUserNotFound, id.String())
+	}
+
+	user := stored.Clone()
+	user.SoftDelete(time.Now())
+	user.Version++
+
+	users.put(int(id.ID()), user)
+	return nil
+}
+
+// Restore implements output.UserPort.
+func (u *UserRepository) Restore(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	users, unlock := u.lock(ctx)
+	defer unlock()
+
+	stored, exists := users.get(int(id.ID()))
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", output.ErrUserNotFound, id.String())
+	}
+	if !stored.IsDeleted() {
+		return nil, output.ErrUserNotDeleted
+	}
+
+	user := stored.Clone()
+	user.Restore(time.Now())
+	user.Version++
+
+	users.put(int(id.ID()), user)
+	return user.Clone(), nil
+}
+
+// FindDeletedBefore implements output.UserPort.
+func (u *UserRepository) FindDeletedBefore(ctx context.Context, cutoff time.Time) ([]*entities.User, error) {
+	users, unlock := u.rlock(ctx)
+	defer unlock()
+
+	var deleted []*entities.User
+	users.each(func(_ int, user *entities.User) {
+		if user.IsDeleted() && user.DeletedAt.Before(cutoff) {
+			deleted = append(deleted, user.Clone())
+		}
+	})
+
+	return deleted, nil
+}
+
+// FindAnyByID implements output.UserPort.
+func (u *UserRepository) FindAnyByID(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	users, unlock := u.rlock(ctx)
+	defer unlock()
 
-	_, exists := users[int(id.ID())]
+	user, exists := users.get(int(id.ID()))
 	if !exists {
-		return fmt.Errorf("user with id %s not found", id.String())
+		return nil, fmt.Errorf("%w: %s", output.ErrUserNotFound, id.String())
 	}
 
-	delete(users, int(id.ID()))
+	return user.Clone(), nil
+}
+
+// Purge implements output.UserPort.
+func (u *UserRepository) Purge(ctx context.Context, id uuid.UUID) error {
+	users, unlock := u.lock(ctx)
+	defer unlock()
+
+	if _, exists := users.get(int(id.ID())); !exists {
+		return fmt.Errorf("%w: %s", output.ErrUserNotFound, id.String())
+	}
+
+	users.remove(int(id.ID()))
 	return nil
 }
 
 // FindByEmail implements output.UserPort.
 func (u *UserRepository) FindByEmail(ctx context.Context, email string) (*entities.User, error) {
-	u.mutex.RLock()
-	defer u.mutex.RUnlock()
+	users, unlock := u.rlock(ctx)
+	defer unlock()
 
-	// search user by email
-	for _, user := range users {
-		if user.Email == email {
-			return user, nil
-		}
+	if user, ok := users.lookup(valueobjects.NormalizeEmail(email)); ok && !user.IsDeleted() {
+		return user.Clone(), nil
 	}
 
-	return nil, fmt.Errorf("user with email %s not found", email)
+	return nil, fmt.Errorf("%w: %s", output.ErrUserNotFound, email)
 }
 
 // FindByID implements output.UserPort.
 func (u *UserRepository) FindByID(ctx context.Context, id uuid.UUID) (*entities.User, error) {
-	u.mutex.RLock()
-	defer u.mutex.RUnlock()
+	users, unlock := u.rlock(ctx)
+	defer unlock()
 
-	user, exists := users[int(id.ID())]
-	if !exists {
-		return nil, fmt.Errorf("user with id %s not found", id.String())
+	user, exists := users.get(int(id.ID()))
+	if !exists || user.IsDeleted() {
+		return nil, fmt.Errorf("%w: %s", output.ErrUserNotFound, id.String())
 	}
 
-	return user, nil
+	return user.Clone(), nil
 }
 
 // Update implements output.UserPort.
+// Rechaza la escritura con output.ErrConcurrentModification si la versión del
+// usuario no coincide con la almacenada; si se acepta, la versión se incrementa.
 func (u *UserRepository) Update(ctx context.Context, user *entities.User) error {
-	u.mutex.Lock()
-	defer u.mutex.Unlock()
+	users, unlock := u.lock(ctx)
+	defer unlock()
 
-	_, exists := users[int(user.ID.ID())]
-	if !exists {
-		return fmt.Errorf("user with id %s not found", user.ID.String())
+	stored, exists := users.get(int(user.ID.ID()))
+	if !exists || stored.IsDeleted() {
+		return fmt.Errorf("%w: %s", output.ErrUserNotFound, user.ID.String())
+	}
+	if stored.Version != user.Version {
+		return output.ErrConcurrentModification
 	}
 
 	if err := user.Update(user.Name, user.Email, user.Age, user.Active); err != nil {
-		return fmt.Errorf("fail validation: %w", err)
+		return err
+	}
+	if err := users.check(int(user.ID.ID()), user); err != nil {
+		return err
 	}
 	user.SetUpdatedAt(time.Now())
+	user.Version++
+
+	users.put(int(user.ID.ID()), user.Clone())
 	return nil
 }
 
 // GetAllUsers implements output.UserPort.
 func (u *UserRepository) GetAllUsers(ctx context.Context) ([]*entities.User, error) {
-	u.mutex.RLock()
-	defer u.mutex.RUnlock()
+	users, unlock := u.rlock(ctx)
+	defer unlock()
 
 	var allUsers []*entities.User
-	for _, user := range users {
-		allUsers = append(allUsers, user)
-	}
+	users.each(func(_ int, user *entities.User) {
+		if !user.IsDeleted() {
+			allUsers = append(allUsers, user.Clone())
+		}
+	})
 
 	return allUsers, nil
 }
+
+// FindUsers implements output.UserPort.
+func (u *UserRepository) FindUsers(ctx context.Context, query output.UserQuery) (*output.UserPage, error) {
+	users, unlock := u.rlock(ctx)
+	defer unlock()
+
+	var matches []*entities.User
+	users.each(func(_ int, user *entities.User) {
+		if !user.IsDeleted() && matchesUserFilter(user, query.Filter) {
+			matches = append(matches, user)
+		}
+	})
+
+	paginator := keyset[entities.User]{
+		sort:   query.SortKey(),
+		desc:   query.Desc,
+		key:    userSortKey(query.Sort),
+		id:     func(user *entities.User) string { return user.ID.String() },
+		limit:  query.Limit,
+		offset: query.Offset,
+	}
+	page, next, err := paginator.page(matches, query.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &output.UserPage{Users: make([]*entities.User, 0, len(page)), NextCursor: next, Total: len(matches)}
+	for _, user := range page {
+		result.Users = append(result.Users, user.Clone())
+	}
+	return result, nil
+}
+
+func matchesUserFilter(user *entities.User, filter output.UserFilter) bool {
+	if filter.Active != nil && user.Active != *filter.Active {
+		return false
+	}
+	if filter.MinAge != nil && user.Age < *filter.MinAge {
+		return false
+	}
+	if filter.MaxAge != nil && user.Age > *filter.MaxAge {
+		return false
+	}
+	if filter.NamePrefix != "" && !strings.HasPrefix(strings.ToLower(user.Name), strings.ToLower(filter.NamePrefix)) {
+		return false
+	}
+	if filter.EmailPrefix != "" && !strings.HasPrefix(normalizedEmail(user), valueobjects.NormalizeEmail(filter.EmailPrefix)) {
+		return false
+	}
+	if filter.Email != "" && normalizedEmail(user) != valueobjects.NormalizeEmail(filter.Email) {
+		return false
+	}
+	if filter.CreatedFrom != nil && user.CreatedAt.Before(*filter.CreatedFrom) {
+		return false
+	}
+	if filter.CreatedTo != nil && !user.CreatedAt.Before(*filter.CreatedTo) {
+		return false
+	}
+	return true
+}
+
+func userSortKey(field output.UserSortField) func(*entities.User) string {
+	switch field {
+	case output.UserSortName:
+		return func(user *entities.User) string { return strings.ToLower(user.Name) }
+	case output.UserSortEmail:
+		return normalizedEmail
+	case output.UserSortAge:
+		return func(user *entities.User) string { return fmt.Sprintf("%03d", user.Age) }
+	default:
+		return func(user *entities.User) string { return sortableTime(user.CreatedAt) }
+	}
+}
@@ -0,0 +1,430 @@
+package memory_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"user-management/internal/domain/ports/output"
+	"user-management/internal/infrastructure/persistence/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRepository_Save(t *testing.T) {
+	t.Run("does not overwrite an existing user", func(t *testing.T) {
+		repo := memory.NewUserRepository()
+		ctx := context.Background()
+
+		user := newTestUser(t, "john@example.com")
+		require.NoError(t, repo.Save(ctx, *user))
+
+		replacement := *user
+		replacement.Name = "Mallory"
+		replacement.Email = "mallory@example.com"
+		err := repo.Save(ctx, replacement)
+		assert.ErrorIs(t, err, output.ErrUserAlreadyExists)
+
+		stored, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "John Doe", stored.Name)
+		_, err = repo.FindByEmail(ctx, "mallory@example.com")
+		assert.ErrorIs(t, err, output.ErrUserNotFound)
+	})
+
+	t.Run("does not reuse the ID of a deleted user", func(t *testing.T) {
+		repo := memory.NewUserRepository()
+		ctx := context.Background()
+
+		user := newTestUser(t, "john@example.com")
+		require.NoError(t, repo.Save(ctx, *user))
+		require.NoError(t, repo.Delete(ctx, user.ID))
+
+		replacement := *user
+		replacement.Email = "other@example.com"
+		assert.ErrorIs(t, repo.Save(ctx, replacement), output.ErrUserAlreadyExists)
+	})
+}
+
+func TestUserRepository_Update(t *testing.T) {
+	t.Run("increments version on each successful update", func(t *testing.T) {
+		repo := memory.NewUserRepository()
+		ctx := context.Background()
+
+		user := newTestUser(t, "john@example.com")
+		require.NoError(t, repo.Save(ctx, *user))
+
+		loaded, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, loaded.Version)
+
+		loaded.Name = "John Updated"
+		require.NoError(t, repo.Update(ctx, loaded))
+		assert.Equal(t, 2, loaded.Version)
+
+		stored, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, stored.Version)
+		assert.Equal(t, "John Updated", stored.Name)
+	})
+
+	t.Run("rejects stale writes", func(t *testing.T) {
+		repo := memory.NewUserRepository()
+		ctx := context.Background()
+
+		user := newTestUser(t, "john@example.com")
+		require.NoError(t, repo.Save(ctx, *user))
+
+		first, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		second, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+
+		first.Name = "First Writer"
+		require.NoError(t, repo.Update(ctx, first))
+
+		second.Name = "Second Writer"
+		err = repo.Update(ctx, second)
+		assert.ErrorIs(t, err, output.ErrConcurrentModification)
+
+		stored, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "First Writer", stored.Name)
+	})
+
+	t.Run("changes to loaded users are not stored without Update", func(t *testing.T) {
+		repo := memory.NewUserRepository()
+		ctx := context.Background()
+
+		user := newTestUser(t, "john@example.com")
+		require.NoError(t, repo.Save(ctx, *user))
+
+		loaded, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		loaded.Name = "Not Saved"
+
+		stored, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "John Doe", stored.Name)
+	})
+}
+
+func TestUserRepository_EmailIndex(t *testing.T) {
+	t.Run("finds users regardless of email case", func(t *testing.T) {
+		repo := memory.NewUserRepository()
+		ctx := context.Background()
+
+		user := newTestUser(t, "Juan@Test.com")
+		require.NoError(t, repo.Save(ctx, *user))
+
+		found, err := repo.FindByEmail(ctx, "juan@test.com")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, found.ID)
+		assert.Equal(t, "Juan@Test.com", found.Email)
+	})
+
+	t.Run("save rejects emails that differ only in case", func(t *testing.T) {
+		repo := memory.NewUserRepository()
+		ctx := context.Background()
+
+		require.NoError(t, repo.Save(ctx, *newTestUser(t, "juan@test.com")))
+
+		err := repo.Save(ctx, *newTestUser(t, "Juan@Test.com"))
+		assert.ErrorIs(t, err, output.ErrEmailAlreadyExists)
+
+		all, err := repo.GetAllUsers(ctx)
+		require.NoError(t, err)
+		assert.Len(t, all, 1)
+	})
+
+	t.Run("update rejects taking another user's email", func(t *testing.T) {
+		repo := memory.NewUserRepository()
+		ctx := context.Background()
+
+		require.NoError(t, repo.Save(ctx, *newTestUser(t, "juan@test.com")))
+		other := newTestUser(t, "maria@test.com")
+		require.NoError(t, repo.Save(ctx, *other))
+
+		loaded, err := repo.FindByID(ctx, other.ID)
+		require.NoError(t, err)
+		loaded.Email = "JUAN@test.com"
+
+		err = repo.Update(ctx, loaded)
+		assert.ErrorIs(t, err, output.ErrEmailAlreadyExists)
+
+		found, err := repo.FindByEmail(ctx, "maria@test.com")
+		require.NoError(t, err)
+		assert.Equal(t, other.ID, found.ID)
+	})
+
+	t.Run("changing email frees the previous one", func(t *testing.T) {
+		repo := memory.NewUserRepository()
+		ctx := context.Background()
+
+		user := newTestUser(t, "juan@test.com")
+		require.NoError(t, repo.Save(ctx, *user))
+
+		loaded, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		loaded.Email = "juan.perez@test.com"
+		require.NoError(t, repo.Update(ctx, loaded))
+
+		_, err = repo.FindByEmail(ctx, "juan@test.com")
+		assert.Error(t, err)
+		assert.NoError(t, repo.Save(ctx, *newTestUser(t, "juan@test.com")))
+	})
+
+	t.Run("concurrent saves of the same email register only one user", func(t *testing.T) {
+		repo := memory.NewUserRepository()
+		ctx := context.Background()
+
+		const attempts = 20
+		var wg sync.WaitGroup
+		var created atomic.Int32
+		for range attempts {
+			user := newTestUser(t, "juan@test.com")
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if repo.Save(ctx, *user) == nil {
+					created.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), created.Load())
+	})
+}
+
+func TestUserRepository_SoftDelete(t *testing.T) {
+	t.Run("hides deleted users from reads and updates", func(t *testing.T) {
+		repo := memory.NewUserRepository()
+		ctx := context.Background()
+
+		user := newTestUser(t, "john@example.com")
+		require.NoError(t, repo.Save(ctx, *user))
+		require.NoError(t, repo.Delete(ctx, user.ID))
+
+		_, err := repo.FindByID(ctx, user.ID)
+		assert.ErrorIs(t, err, output.ErrUserNotFound)
+		_, err = repo.FindByEmail(ctx, "john@example.com")
+		assert.ErrorIs(t, err, output.ErrUserNotFound)
+		assert.ErrorIs(t, repo.Update(ctx, user), output.ErrUserNotFound)
+		assert.ErrorIs(t, repo.Delete(ctx, user.ID), output.ErrUserNotFound)
+
+		all, err := repo.GetAllUsers(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, all)
+	})
+
+	t.Run("keeps the email reserved until purged", func(t *testing.T) {
+		repo := memory.NewUserRepository()
+		ctx := context.Background()
+
+		user := newTestUser(t, "john@example.com")
+		require.NoError(t, repo.Save(ctx, *user))
+		require.NoError(t, repo.Delete(ctx, user.ID))
+
+		err := repo.Save(ctx, *newTestUser(t, "john@example.com"))
+		assert.ErrorIs(t, err, output.ErrEmailAlreadyExists)
+
+		require.NoError(t, repo.Purge(ctx, user.ID))
+		assert.NoError(t, repo.Save(ctx, *newTestUser(t, "john@example.com")))
+	})
+
+	t.Run("restores deleted users", func(t *testing.T) {
+		repo := memory.NewUserRepository()
+		ctx := context.Background()
+
+		user := newTestUser(t, "john@example.com")
+		require.NoError(t, repo.Save(ctx, *user))
+		require.NoError(t, repo.Delete(ctx, user.ID))
+
+		restored, err := repo.Restore(ctx, user.ID)
+		require.NoError(t, err)
+		assert.False(t, restored.IsDeleted())
+		assert.Equal(t, 3, restored.Version)
+
+		found, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, user.Email, found.Email)
+
+		_, err = repo.Restore(ctx, user.ID)
+		assert.ErrorIs(t, err, output.ErrUserNotDeleted)
+	})
+
+	t.Run("finds users deleted before the cutoff", func(t *testing.T) {
+		repo := memory.NewUserRepository()
+		ctx := context.Background()
+
+		deleted := newTestUser(t, "deleted@example.com")
+		active := newTestUser(t, "active@example.com")
+		require.NoError(t, repo.Save(ctx, *deleted))
+		require.NoError(t, repo.Save(ctx, *active))
+		require.NoError(t, repo.Delete(ctx, deleted.ID))
+
+		expired, err := repo.FindDeletedBefore(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		require.Len(t, expired, 1)
+		assert.Equal(t, deleted.ID, expired[0].ID)
+
+		expired, err = repo.FindDeletedBefore(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, expired)
+	})
+
+	t.Run("finds deleted users by ID until purged", func(t *testing.T) {
+		repo := memory.NewUserRepository()
+		ctx := context.Background()
+
+		user := newTestUser(t, "john@example.com")
+		require.NoError(t, repo.Save(ctx, *user))
+		require.NoError(t, repo.Delete(ctx, user.ID))
+
+		found, err := repo.FindAnyByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.True(t, found.IsDeleted())
+
+		require.NoError(t, repo.Purge(ctx, user.ID))
+		_, err = repo.FindAnyByID(ctx, user.ID)
+		assert.ErrorIs(t, err, output.ErrUserNotFound)
+	})
+}
+
+func TestUserRepository_FindUsers(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	seed := func(t *testing.T) *memory.UserRepository {
+		t.Helper()
+		repo := memory.NewUserRepository()
+		for i, spec := range []struct {
+			name   string
+			age    int
+			active bool
+		}{
+			{"Ana", 30, true},
+			{"bruno", 25, false},
+			{"Carla", 41, true},
+			{"Anabel", 19, true},
+			{"Diego", 30, true},
+		} {
+			user := newTestUser(t, strings.ToLower(spec.name)+"@example.com")
+			user.Name, user.Age, user.Active = spec.name, spec.age, spec.active
+			user.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+			require.NoError(t, repo.Save(ctx, *user))
+		}
+		return repo
+	}
+
+	names := func(page *output.UserPage) []string {
+		var result []string
+		for _, user := range page.Users {
+			result = append(result, user.Name)
+		}
+		return result
+	}
+
+	ptr := func(v int) *int { return &v }
+	active := true
+	createdTo := base.Add(3 * time.Hour)
+
+	tests := []struct {
+		name     string
+		query    output.UserQuery
+		expected []string
+	}{
+		{"defaults to creation order", output.UserQuery{}, []string{"Ana", "bruno", "Carla", "Anabel", "Diego"}},
+		{"sorts descending", output.UserQuery{Sort: output.UserSortCreatedAt, Desc: true}, []string{"Diego", "Anabel", "Carla", "bruno", "Ana"}},
+		{"sorts names case-insensitively", output.UserQuery{Sort: output.UserSortName}, []string{"Ana", "Anabel", "bruno", "Carla", "Diego"}},
+		{"breaks ties by ID", output.UserQuery{Sort: output.UserSortAge, Filter: output.UserFilter{MinAge: ptr(30), MaxAge: ptr(30)}}, nil},
+		{"filters by active", output.UserQuery{Filter: output.UserFilter{Active: &active}}, []string{"Ana", "Carla", "Anabel", "Diego"}},
+		{"filters by name prefix", output.UserQuery{Filter: output.UserFilter{NamePrefix: "an"}}, []string{"Ana", "Anabel"}},
+		{"filters by email prefix", output.UserQuery{Filter: output.UserFilter{EmailPrefix: "CAR"}}, []string{"Carla"}},
+		{"filters by exact email", output.UserQuery{Filter: output.UserFilter{Email: "ANA@example.com"}}, []string{"Ana"}},
+		{"filters by created range", output.UserQuery{Filter: output.UserFilter{CreatedFrom: &base, CreatedTo: &createdTo}}, []string{"Ana", "bruno", "Carla"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := seed(t)
+
+			page, err := repo.FindUsers(ctx, tt.query)
+			require.NoError(t, err)
+
+			if tt.expected == nil {
+				// Mismo valor de ordenación: el resultado debe ser estable
+				again, err := repo.FindUsers(ctx, tt.query)
+				require.NoError(t, err)
+				assert.Len(t, page.Users, 2)
+				assert.Equal(t, names(page), names(again))
+				return
+			}
+			assert.Equal(t, tt.expected, names(page))
+			assert.Equal(t, len(tt.expected), page.Total)
+			assert.Empty(t, page.NextCursor)
+		})
+	}
+
+	t.Run("paginates with cursors", func(t *testing.T) {
+		repo := seed(t)
+		query := output.UserQuery{Sort: output.UserSortName, Limit: 2}
+
+		var all []string
+		for range 3 {
+			page, err := repo.FindUsers(ctx, query)
+			require.NoError(t, err)
+			assert.Equal(t, 5, page.Total)
+			all = append(all, names(page)...)
+			query.Cursor = page.NextCursor
+		}
+
+		assert.Equal(t, []string{"Ana", "Anabel", "bruno", "Carla", "Diego"}, all)
+		assert.Empty(t, query.Cursor)
+	})
+
+	t.Run("paginates with offsets", func(t *testing.T) {
+		repo := seed(t)
+
+		page, err := repo.FindUsers(ctx, output.UserQuery{Sort: output.UserSortName, Limit: 2, Offset: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bruno", "Carla"}, names(page))
+		assert.Equal(t, 5, page.Total)
+
+		page, err = repo.FindUsers(ctx, output.UserQuery{Sort: output.UserSortName, Limit: 2, Offset: 10})
+		require.NoError(t, err)
+		assert.Empty(t, page.Users)
+		assert.Equal(t, 5, page.Total)
+	})
+
+	t.Run("cursor survives deletion of the last returned row", func(t *testing.T) {
+		repo := seed(t)
+		query := output.UserQuery{Sort: output.UserSortName, Limit: 2}
+
+		page, err := repo.FindUsers(ctx, query)
+		require.NoError(t, err)
+		require.NoError(t, repo.Delete(ctx, page.Users[1].ID))
+
+		query.Cursor = page.NextCursor
+		next, err := repo.FindUsers(ctx, query)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bruno", "Carla"}, names(next))
+	})
+
+	t.Run("rejects cursors from another sort", func(t *testing.T) {
+		repo := seed(t)
+
+		page, err := repo.FindUsers(ctx, output.UserQuery{Sort: output.UserSortName, Limit: 2})
+		require.NoError(t, err)
+
+		_, err = repo.FindUsers(ctx, output.UserQuery{Sort: output.UserSortAge, Limit: 2, Cursor: page.NextCursor})
+		assert.ErrorIs(t, err, output.ErrInvalidCursor)
+
+		_, err = repo.FindUsers(ctx, output.UserQuery{Cursor: "not-a-cursor"})
+		assert.ErrorIs(t, err, output.ErrInvalidCursor)
+	})
+}
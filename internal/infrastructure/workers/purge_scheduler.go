@@ -0,0 +1,91 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+	"user-management/internal/domain/ports/input"
+)
+
+// PurgeScheduler ejecuta periódicamente la purga de usuarios borrados
+type PurgeScheduler struct {
+	retention input.UserRetentionService
+	interval  time.Duration
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+	stopped bool
+}
+
+func NewPurgeScheduler(retention input.UserRetentionService, interval time.Duration) *PurgeScheduler {
+	return &PurgeScheduler{
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Start lanza la purga cada intervalo hasta que se llame a Stop o se cancele
+// ctx. Llamarlo más de una vez no tiene efecto.
+func (s *PurgeScheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done != nil || s.stopped {
+		return nil
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+
+	go s.loop(ctx)
+	log.Printf("PurgeScheduler iniciado cada %s", s.interval)
+	return nil
+}
+
+func (s *PurgeScheduler) loop(ctx context.Context) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.retention.PurgeDeletedUsers(ctx)
+			if err != nil {
+				log.Printf("PurgeScheduler: error purgando usuarios: %v", err)
+			}
+			if purged > 0 {
+				log.Printf("PurgeScheduler: %d usuarios purgados", purged)
+			}
+		}
+	}
+}
+
+// Stop detiene el scheduler y espera a que termine la purga en curso
+func (s *PurgeScheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return nil
+	}
+	s.stopped = true
+
+	if s.done == nil {
+		return nil
+	}
+
+	s.cancel()
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	log.Println("PurgeScheduler detenido")
+	return nil
+}
@@ -0,0 +1,53 @@
+package workers
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type retentionStub struct {
+	calls atomic.Int32
+}
+
+func (r *retentionStub) PurgeDeletedUsers(ctx context.Context) (int, error) {
+	r.calls.Add(1)
+	return 0, nil
+}
+
+func TestPurgeScheduler(t *testing.T) {
+	t.Run("runs the purge on every tick", func(t *testing.T) {
+		retention := &retentionStub{}
+		scheduler := NewPurgeScheduler(retention, 5*time.Millisecond)
+
+		require.NoError(t, scheduler.Start(context.Background()))
+		assert.Eventually(t, func() bool { return retention.calls.Load() >= 2 }, time.Second, time.Millisecond)
+		require.NoError(t, scheduler.Stop(context.Background()))
+
+		calls := retention.calls.Load()
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, calls, retention.calls.Load(), "no debe purgar tras Stop")
+	})
+
+	t.Run("stop without start is a no-op", func(t *testing.T) {
+		scheduler := NewPurgeScheduler(&retentionStub{}, time.Hour)
+
+		assert.NoError(t, scheduler.Stop(context.Background()))
+		assert.NoError(t, scheduler.Stop(context.Background()))
+	})
+
+	t.Run("start twice launches a single loop", func(t *testing.T) {
+		scheduler := NewPurgeScheduler(&retentionStub{}, time.Hour)
+
+		require.NoError(t, scheduler.Start(context.Background()))
+		done := scheduler.done
+		require.NoError(t, scheduler.Start(context.Background()))
+
+		assert.Equal(t, done, scheduler.done)
+		assert.NoError(t, scheduler.Stop(context.Background()))
+	})
+}
@@ -0,0 +1,293 @@
+// Package jsonpatch aplica parches a documentos JSON: JSON Merge Patch
+// (RFC 7386) y JSON Patch (RFC 6902) con punteros JSON (RFC 6901).
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch indica un parche mal formado: JSON inválido, operación
+	// desconocida o puntero mal escrito
+	ErrInvalidPatch = errors.New("invalid patch document")
+	// ErrPathNotFound indica que una operación apunta a una ruta que no existe
+	ErrPathNotFound = errors.New("path not found")
+	// ErrTestFailed indica que una operación test no se cumplió
+	ErrTestFailed = errors.New("test operation failed")
+)
+
+// MergePatch aplica un JSON Merge Patch (RFC 7386): los miembros del parche
+// sustituyen a los del documento, null los elimina y los objetos se mezclan
+// recursivamente.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var target, changes any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(merge(target, changes))
+}
+
+func merge(target, patch any) any {
+	changes, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = map[string]any{}
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(object, key)
+			continue
+		}
+		object[key] = merge(object[key], value)
+	}
+	return object
+}
+
+// Operation es una operación de JSON Patch
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Apply aplica un JSON Patch (RFC 6902). Las operaciones se aplican en orden
+// y de forma atómica: si una falla se devuelve el error y ningún cambio.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var target any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	var operations []Operation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	for i, op := range operations {
+		var err error
+		if target, err = op.apply(target); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func (op Operation) apply(doc any) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			if doc, _, err = remove(doc, path); err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		}
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+
+	case "remove":
+		doc, _, err = remove(doc, path)
+		return doc, err
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		var value any
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, fmt.Errorf("%w: cannot move %q into itself", ErrInvalidPatch, op.From)
+			}
+			doc, value, err = remove(doc, from)
+		} else {
+			value, err = get(doc, from)
+			if err == nil {
+				value, err = deepCopy(value)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	}
+	return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, op.Op)
+}
+
+func (op Operation) value() (any, error) {
+	if op.Value == nil {
+		return nil, fmt.Errorf("%w: %s requires a value", ErrInvalidPatch, op.Op)
+	}
+	var value any
+	if err := json.Unmarshal(op.Value, &value); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return value, nil
+}
+
+// parsePointer divide un puntero JSON en sus claves ya sin escapar
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		var err error
+		if doc, err = child(doc, token); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+func child(doc any, token string) (any, error) {
+	switch container := doc.(type) {
+	case map[string]any:
+		if value, ok := container[token]; ok {
+			return value, nil
+		}
+	case []any:
+		if i, err := arrayIndex(token, len(container)-1); err == nil {
+			return container[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrPathNotFound, token)
+}
+
+// arrayIndex interpreta token como índice en [0, max]
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: %q", ErrPathNotFound, token)
+	}
+	return i, nil
+}
+
+// update aplica fn al contenedor padre de path y devuelve el documento con
+// el contenedor resultante en su sitio
+func update(doc any, path []string, fn func(parent any, key string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+
+	next, err := child(doc, path[0])
+	if err != nil {
+		return nil, err
+	}
+	updated, err := update(next, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch container := doc.(type) {
+	case map[string]any:
+		container[path[0]] = updated
+	case []any:
+		i, _ := arrayIndex(path[0], len(container)-1)
+		container[i] = updated
+	}
+	return doc, nil
+}
+
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(parent any, key string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			container[key] = value
+			return container, nil
+		case []any:
+			if key == "-" {
+				return append(container, value), nil
+			}
+			i, err := arrayIndex(key, len(container))
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[i+1:], container[i:])
+			container[i] = value
+			return container, nil
+		}
+		return nil, fmt.Errorf("%w: %q", ErrPathNotFound, key)
+	})
+}
+
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+	var removed any
+	doc, err := update(doc, path, func(parent any, key string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			value, ok := container[key]
+			if !ok {
+				break
+			}
+			removed = value
+			delete(container, key)
+			return container, nil
+		case []any:
+			i, err := arrayIndex(key, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = container[i]
+			return append(container[:i], container[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("%w: %q", ErrPathNotFound, key)
+	})
+	return doc, removed, err
+}
+
+func deepCopy(value any) (any, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var copied any
+	err = json.Unmarshal(raw, &copied)
+	return copied, err
+}
@@ -0,0 +1,102 @@
+package jsonpatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	// Ejemplos del apéndice A de RFC 7386
+	tests := []struct {
+		doc, patch, expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.patch, func(t *testing.T) {
+			result, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+
+	t.Run("rejects malformed patches", func(t *testing.T) {
+		_, err := MergePatch([]byte(`{}`), []byte(`{"a":`))
+		assert.ErrorIs(t, err, ErrInvalidPatch)
+	})
+}
+
+func TestApply(t *testing.T) {
+	// Ejemplos del apéndice A de RFC 6902
+	tests := []struct {
+		name, doc, patch, expected string
+	}{
+		{"add object member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"append to array", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"baz"}]`, `{"foo":["bar","baz"]}`},
+		{"remove object member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace value", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"copy value", `{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"}]`, `{"foo":{"bar":1},"baz":{"bar":1}}`},
+		{"test then replace", `{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2},{"op":"replace","path":"/baz","value":null}]`,
+			`{"baz":null,"foo":["a",2,"c"]}`},
+		{"escaped pointer", `{"a/b":1,"m~n":2}`, `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`, `{"a/b":3}`},
+		{"replace whole document", `{"a":1}`, `[{"op":"replace","path":"","value":{"b":2}}]`, `{"b":2}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+
+	failures := []struct {
+		name, patch string
+		expected    error
+	}{
+		{"failed test", `[{"op":"test","path":"/baz","value":"bar"}]`, ErrTestFailed},
+		{"test on missing path", `[{"op":"test","path":"/nope","value":1}]`, ErrPathNotFound},
+		{"remove missing member", `[{"op":"remove","path":"/nope"}]`, ErrPathNotFound},
+		{"replace missing member", `[{"op":"replace","path":"/nope","value":1}]`, ErrPathNotFound},
+		{"add past the end of an array", `[{"op":"add","path":"/list/5","value":1}]`, ErrPathNotFound},
+		{"add under a missing parent", `[{"op":"add","path":"/a/b","value":1}]`, ErrPathNotFound},
+		{"leading zero index", `[{"op":"remove","path":"/list/01"}]`, ErrPathNotFound},
+		{"unknown operation", `[{"op":"frobnicate","path":"/baz"}]`, ErrInvalidPatch},
+		{"missing value", `[{"op":"add","path":"/x"}]`, ErrInvalidPatch},
+		{"relative pointer", `[{"op":"remove","path":"baz"}]`, ErrInvalidPatch},
+		{"move into itself", `[{"op":"move","from":"/obj","path":"/obj/child"}]`, ErrInvalidPatch},
+		{"not an array", `{"op":"remove","path":"/baz"}`, ErrInvalidPatch},
+	}
+
+	for _, tt := range failures {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Apply([]byte(`{"baz":"qux","list":[1,2],"obj":{}}`), []byte(tt.patch))
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+
+	t.Run("applies nothing when an operation fails", func(t *testing.T) {
+		doc := []byte(`{"a":1}`)
+		_, err := Apply(doc, []byte(`[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":1}]`))
+		assert.ErrorIs(t, err, ErrTestFailed)
+		assert.JSONEq(t, `{"a":1}`, string(doc))
+	})
+}
@@ -0,0 +1,170 @@
+// Package jwt firma y verifica JSON Web Tokens (RFC 7519) en formato JWS
+// compacto. Sólo admite algoritmos explícitos: el de la cabecera debe
+// coincidir con el del Signer, de modo que un token "none" o firmado con otro
+// algoritmo se rechaza.
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken indica un token mal formado o con firma incorrecta
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrExpired indica que el token ya no es válido por su fecha
+	ErrExpired = errors.New("token expired")
+)
+
+// Signer firma y verifica la parte firmada de un token
+type Signer interface {
+	Algorithm() string
+	Sign(data []byte) ([]byte, error)
+	Verify(data, signature []byte) error
+}
+
+type hs256 struct {
+	key []byte
+}
+
+// HS256 firma con HMAC-SHA256 y la clave compartida key
+func HS256(key []byte) Signer {
+	return hs256{key: key}
+}
+
+func (s hs256) Algorithm() string {
+	return "HS256"
+}
+
+func (s hs256) Sign(data []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(data)
+	return mac.Sum(nil), nil
+}
+
+func (s hs256) Verify(data, signature []byte) error {
+	expected, _ := s.Sign(data)
+	if !hmac.Equal(expected, signature) {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// Claims son los campos registrados de RFC 7519 que se comprueban al
+// decodificar. Se incrustan en las estructuras de claims de cada token.
+type Claims struct {
+	Issuer    string `json:"iss,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Audience  string `json:"aud,omitempty"`
+	ID        string `json:"jti,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+// NewClaims devuelve claims emitidos ahora para subject que caducan tras ttl
+func NewClaims(subject string, ttl time.Duration) Claims {
+	now := time.Now()
+	return Claims{Subject: subject, IssuedAt: now.Unix(), ExpiresAt: now.Add(ttl).Unix()}
+}
+
+// Valid comprueba las fechas de los claims en el instante now
+func (c Claims) Valid(now time.Time) error {
+	if c.ExpiresAt != 0 && now.Unix() >= c.ExpiresAt {
+		return ErrExpired
+	}
+	if c.NotBefore != 0 && now.Unix() < c.NotBefore {
+		return fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+	return nil
+}
+
+type validator interface {
+	Valid(now time.Time) error
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid,omitempty"`
+}
+
+// Encode serializa claims y los firma con s
+func Encode(s Signer, claims any) (string, error) {
+	return EncodeWithKeyID(s, "", claims)
+}
+
+// EncodeWithKeyID es Encode indicando en la cabecera el identificador de la
+// clave (kid) para que el receptor sepa con cuál verificar
+func EncodeWithKeyID(s Signer, keyID string, claims any) (string, error) {
+	head, err := json.Marshal(header{Algorithm: s.Algorithm(), Type: "JWT", KeyID: keyID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signed := encode(head) + "." + encode(payload)
+	signature, err := s.Sign([]byte(signed))
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + encode(signature), nil
+}
+
+// Decode verifica la firma de token con s y vuelca su contenido en claims.
+// Si claims tiene un método Valid(time.Time) error (p. ej. por incrustar
+// Claims) también se comprueban sus fechas.
+func Decode(s Signer, token string, claims any) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+
+	var head header
+	if err := decodeJSON(parts[0], &head); err != nil {
+		return err
+	}
+	if head.Algorithm != s.Algorithm() {
+		return fmt.Errorf("%w: unexpected algorithm %q", ErrInvalidToken, head.Algorithm)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if err := s.Verify([]byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	if err := decodeJSON(parts[1], claims); err != nil {
+		return err
+	}
+	if v, ok := claims.(validator); ok {
+		return v.Valid(time.Now())
+	}
+	return nil
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeJSON(part string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package jwt_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"user-management/pkg/jwt"
+)
+
+type testClaims struct {
+	jwt.Claims
+	Role string `json:"role"`
+}
+
+func TestEncodeDecode(t *testing.T) {
+	signer := jwt.HS256([]byte("secret"))
+
+	token, err := jwt.Encode(signer, testClaims{Claims: jwt.NewClaims("user-1", time.Minute), Role: "admin"})
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(token, "."), 3)
+
+	var claims testClaims
+	require.NoError(t, jwt.Decode(signer, token, &claims))
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, "admin", claims.Role)
+
+	t.Run("rejects another key", func(t *testing.T) {
+		err := jwt.Decode(jwt.HS256([]byte("other")), token, &testClaims{})
+		assert.ErrorIs(t, err, jwt.ErrInvalidToken)
+	})
+
+	t.Run("rejects a tampered payload", func(t *testing.T) {
+		parts := strings.Split(token, ".")
+		forged, err := jwt.Encode(jwt.HS256([]byte("other")), testClaims{Claims: jwt.NewClaims("user-2", time.Minute)})
+		require.NoError(t, err)
+		parts[1] = strings.Split(forged, ".")[1]
+
+		err = jwt.Decode(signer, strings.Join(parts, "."), &testClaims{})
+		assert.ErrorIs(t, err, jwt.ErrInvalidToken)
+	})
+
+	t.Run("rejects unsigned tokens", func(t *testing.T) {
+		parts := strings.Split(token, ".")
+		none := "eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0" // {"alg":"none","typ":"JWT"}
+
+		err := jwt.Decode(signer, none+"."+parts[1]+".", &testClaims{})
+		assert.ErrorIs(t, err, jwt.ErrInvalidToken)
+	})
+
+	t.Run("rejects malformed tokens", func(t *testing.T) {
+		assert.ErrorIs(t, jwt.Decode(signer, "not-a-token", &testClaims{}), jwt.ErrInvalidToken)
+	})
+
+	t.Run("rejects expired tokens", func(t *testing.T) {
+		expired, err := jwt.Encode(signer, testClaims{Claims: jwt.NewClaims("user-1", -time.Second)})
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, jwt.Decode(signer, expired, &testClaims{}), jwt.ErrExpired)
+	})
+}
+
+func TestRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	signer := jwt.RS256(key)
+
+	token, err := jwt.EncodeWithKeyID(signer, "key-1", testClaims{Claims: jwt.NewClaims("user-1", time.Minute), Role: "admin"})
+	require.NoError(t, err)
+
+	t.Run("verifies with the public key from the JWK", func(t *testing.T) {
+		jwk := jwt.NewRSAJWK(&key.PublicKey)
+		assert.Equal(t, "RSA", jwk.KeyType)
+		assert.Equal(t, "RS256", jwk.Algorithm)
+		assert.Equal(t, "AQAB", jwk.E)
+		assert.NotEmpty(t, jwk.KeyID)
+
+		public, err := jwk.PublicKey()
+		require.NoError(t, err)
+
+		var claims testClaims
+		require.NoError(t, jwt.Decode(jwt.RS256PublicKey(public), token, &claims))
+		assert.Equal(t, "user-1", claims.Subject)
+		assert.Equal(t, "admin", claims.Role)
+	})
+
+	t.Run("the key ID is the RFC 7638 thumbprint", func(t *testing.T) {
+		// Ejemplo de RFC 7638, sección 3.1
+		jwk := jwt.JWK{KeyType: "RSA", N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw", E: "AQAB"}
+		public, err := jwk.PublicKey()
+		require.NoError(t, err)
+		assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", jwt.NewRSAJWK(public).KeyID)
+	})
+
+	t.Run("rejects another key", func(t *testing.T) {
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		assert.ErrorIs(t, jwt.Decode(jwt.RS256PublicKey(&other.PublicKey), token, &testClaims{}), jwt.ErrInvalidToken)
+	})
+
+	t.Run("rejects HS256 tokens signed with the public key", func(t *testing.T) {
+		forged, err := jwt.Encode(jwt.HS256(key.PublicKey.N.Bytes()), testClaims{Claims: jwt.NewClaims("user-2", time.Minute)})
+		require.NoError(t, err)
+		assert.ErrorIs(t, jwt.Decode(signer, forged, &testClaims{}), jwt.ErrInvalidToken)
+	})
+
+	t.Run("a public key cannot sign", func(t *testing.T) {
+		_, err := jwt.Encode(jwt.RS256PublicKey(&key.PublicKey), testClaims{})
+		assert.ErrorIs(t, err, jwt.ErrVerifyOnly)
+	})
+}
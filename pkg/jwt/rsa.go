@@ -0,0 +1,110 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrVerifyOnly lo devuelve Sign en un Signer creado sólo con la clave
+// pública
+var ErrVerifyOnly = errors.New("signer has no private key")
+
+type rs256 struct {
+	private *rsa.PrivateKey
+	public  *rsa.PublicKey
+}
+
+// RS256 firma con RSASSA-PKCS1-v1_5 y SHA-256. A diferencia de HS256 los
+// tokens se verifican con la clave pública, que se puede publicar (JWKS).
+func RS256(key *rsa.PrivateKey) Signer {
+	return rs256{private: key, public: &key.PublicKey}
+}
+
+// RS256PublicKey sólo verifica tokens RS256 con la clave pública key
+func RS256PublicKey(key *rsa.PublicKey) Signer {
+	return rs256{public: key}
+}
+
+func (s rs256) Algorithm() string {
+	return "RS256"
+}
+
+func (s rs256) Sign(data []byte) ([]byte, error) {
+	if s.private == nil {
+		return nil, ErrVerifyOnly
+	}
+	digest := sha256.Sum256(data)
+	return rsa.SignPKCS1v15(nil, s.private, crypto.SHA256, digest[:])
+}
+
+func (s rs256) Verify(data, signature []byte) error {
+	digest := sha256.Sum256(data)
+	if err := rsa.VerifyPKCS1v15(s.public, crypto.SHA256, digest[:], signature); err != nil {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// JWK es una clave pública RSA en formato JSON Web Key (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	KeyID     string `json:"kid,omitempty"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+}
+
+// JWKS es el conjunto de claves que publica un emisor
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewRSAJWK describe key como clave de firma RS256. Su kid es el thumbprint
+// de RFC 7638, así que cambia si y sólo si cambia la clave.
+func NewRSAJWK(key *rsa.PublicKey) JWK {
+	jwk := JWK{
+		KeyType:   "RSA",
+		Use:       "sig",
+		Algorithm: "RS256",
+		N:         base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+
+	// El thumbprint se calcula sobre los miembros obligatorios en orden
+	// lexicográfico y sin espacios
+	canonical, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{jwk.E, jwk.KeyType, jwk.N})
+	thumbprint := sha256.Sum256(canonical)
+	jwk.KeyID = base64.RawURLEncoding.EncodeToString(thumbprint[:])
+	return jwk
+}
+
+// PublicKey reconstruye la clave RSA de la JWK
+func (k JWK) PublicKey() (*rsa.PublicKey, error) {
+	if k.KeyType != "RSA" {
+		return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
@@ -0,0 +1,269 @@
+// Package openapi valida peticiones y respuestas HTTP contra un documento
+// OpenAPI 3.1. Implementa el subconjunto de JSON Schema que usa la API:
+// tipos, required, properties, additionalProperties, items, enum, const,
+// límites de longitud, tamaño y valor, allOf/anyOf/oneOf, $ref locales y los
+// formatos email, uuid y date-time.
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidDocument indica que el documento no es un OpenAPI utilizable
+	ErrInvalidDocument = errors.New("invalid OpenAPI document")
+	// ErrUnsupportedMediaType indica un Content-Type que la operación no acepta
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	// ErrMalformedBody indica un cuerpo ausente o que no es JSON válido
+	ErrMalformedBody = errors.New("malformed body")
+	// ErrUndocumentedResponse indica un estado que la operación no documenta
+	ErrUndocumentedResponse = errors.New("undocumented response")
+)
+
+// Document es un documento OpenAPI ya indexado por operación
+type Document struct {
+	root       map[string]any
+	basePath   string
+	operations map[string]*Operation
+}
+
+// Load interpreta un documento OpenAPI en JSON. Las rutas se resuelven
+// relativas a la URL del primer servidor (p. ej. /api/v1).
+func Load(raw []byte) (*Document, error) {
+	var root map[string]any
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+
+	doc := &Document{root: root, operations: make(map[string]*Operation)}
+	if servers, _ := root["servers"].([]any); len(servers) > 0 {
+		server, _ := servers[0].(map[string]any)
+		if u, err := url.Parse(stringOf(server["url"])); err == nil {
+			doc.basePath = strings.TrimSuffix(u.Path, "/")
+		}
+	}
+
+	paths, ok := root["paths"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing paths", ErrInvalidDocument)
+	}
+	for path, rawItem := range paths {
+		item, _ := rawItem.(map[string]any)
+		shared := doc.parameters(item["parameters"], nil)
+		for method, rawOp := range item {
+			op, ok := rawOp.(map[string]any)
+			if !ok || method == "parameters" {
+				continue
+			}
+			doc.operations[strings.ToUpper(method)+" "+path] = &Operation{
+				doc:         doc,
+				parameters:  doc.parameters(op["parameters"], shared),
+				requestBody: doc.resolve(op["requestBody"]),
+				responses:   mapOf(op["responses"]),
+			}
+		}
+	}
+	return doc, nil
+}
+
+// BasePath es el prefijo común de las rutas, tomado del primer servidor
+func (d *Document) BasePath() string {
+	return d.basePath
+}
+
+// Operation busca la operación de method en path, escrito como en el
+// documento ("/users/{id}") y sin el prefijo de BasePath
+func (d *Document) Operation(method, path string) (*Operation, bool) {
+	op, ok := d.operations[strings.ToUpper(method)+" "+path]
+	return op, ok
+}
+
+// parameters resuelve una lista de parámetros; los de la operación
+// sustituyen a los de la ruta con el mismo nombre y ubicación
+func (d *Document) parameters(raw any, shared []parameter) []parameter {
+	params := append([]parameter(nil), shared...)
+	list, _ := raw.([]any)
+	for _, item := range list {
+		p := mapOf(d.resolve(item))
+		param := parameter{
+			Name:     stringOf(p["name"]),
+			In:       stringOf(p["in"]),
+			Required: p["required"] == true,
+			Schema:   p["schema"],
+		}
+		replaced := false
+		for i := range params {
+			if params[i].Name == param.Name && params[i].In == param.In {
+				params[i], replaced = param, true
+			}
+		}
+		if !replaced {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// resolve sigue las referencias locales ($ref: "#/...") hasta el objeto
+// referenciado
+func (d *Document) resolve(node any) any {
+	for i := 0; i < 32; i++ {
+		ref, ok := mapOf(node)["$ref"].(string)
+		if !ok {
+			return node
+		}
+		node = d.lookup(ref)
+	}
+	return nil
+}
+
+func (d *Document) lookup(ref string) any {
+	pointer, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil
+	}
+	var node any = d.root
+	for _, token := range strings.Split(pointer, "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		node = mapOf(node)[token]
+	}
+	return node
+}
+
+type parameter struct {
+	Name     string
+	In       string
+	Required bool
+	Schema   any
+}
+
+// Operation es una operación del documento
+type Operation struct {
+	doc         *Document
+	parameters  []parameter
+	requestBody any
+	responses   map[string]any
+}
+
+// Request es lo que se valida de una petición
+type Request struct {
+	PathParams  map[string]string
+	Query       url.Values
+	ContentType string
+	Body        []byte
+}
+
+// ValidateParameters comprueba los parámetros de ruta y de query. Devuelve
+// nil o un *ValidationError.
+func (op *Operation) ValidateParameters(req Request) error {
+	v := validator{doc: op.doc}
+	for _, param := range op.parameters {
+		var (
+			value   string
+			present bool
+		)
+		switch param.In {
+		case "path":
+			value, present = req.PathParams[param.Name]
+		case "query":
+			present = req.Query.Has(param.Name)
+			value = req.Query.Get(param.Name)
+		default:
+			continue
+		}
+
+		if !present || value == "" {
+			if param.Required {
+				v.add(param.Name, "required", "%s is required")
+			}
+			continue
+		}
+		if typed, ok := v.parseParameter(param.Name, value, param.Schema); ok {
+			v.validate(param.Name, typed, param.Schema)
+		}
+	}
+	return v.err()
+}
+
+// ValidateBody comprueba el Content-Type y el cuerpo. Devuelve
+// ErrUnsupportedMediaType, ErrMalformedBody o un *ValidationError.
+func (op *Operation) ValidateBody(req Request) error {
+	body := mapOf(op.requestBody)
+	if body == nil {
+		return nil
+	}
+	if len(req.Body) == 0 {
+		if body["required"] == true {
+			return fmt.Errorf("%w: request body is required", ErrMalformedBody)
+		}
+		return nil
+	}
+
+	media, ok := mapOf(body["content"])[mediaType(req.ContentType)]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnsupportedMediaType, req.ContentType)
+	}
+	return op.validateContent("body", req.ContentType, req.Body, media)
+}
+
+// ValidateResponse comprueba que status esté documentado y que el cuerpo
+// cumpla su esquema. Devuelve ErrUndocumentedResponse,
+// ErrUnsupportedMediaType, ErrMalformedBody o un *ValidationError.
+func (op *Operation) ValidateResponse(status int, contentType string, body []byte) error {
+	response, ok := op.responses[strconv.Itoa(status)]
+	if !ok {
+		if response, ok = op.responses["default"]; !ok {
+			return fmt.Errorf("%w: status %d", ErrUndocumentedResponse, status)
+		}
+	}
+
+	content := mapOf(mapOf(op.doc.resolve(response))["content"])
+	if len(body) == 0 || content == nil {
+		return nil
+	}
+	media, ok := content[mediaType(contentType)]
+	if !ok {
+		return fmt.Errorf("%w: %q for status %d", ErrUnsupportedMediaType, contentType, status)
+	}
+	return op.validateContent("response", contentType, body, media)
+}
+
+// validateContent valida contra el esquema de media los cuerpos JSON; el
+// resto de tipos (p. ej. text/event-stream) no se inspecciona
+func (op *Operation) validateContent(root, contentType string, body []byte, media any) error {
+	if !isJSON(contentType) {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedBody, err)
+	}
+	v := validator{doc: op.doc, root: root}
+	v.validate("", value, mapOf(media)["schema"])
+	return v.err()
+}
+
+func mediaType(contentType string) string {
+	media, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(media))
+}
+
+func isJSON(contentType string) bool {
+	media := mediaType(contentType)
+	return media == "application/json" || strings.HasSuffix(media, "+json")
+}
+
+func mapOf(node any) map[string]any {
+	m, _ := node.(map[string]any)
+	return m
+}
+
+func stringOf(node any) string {
+	s, _ := node.(string)
+	return s
+}
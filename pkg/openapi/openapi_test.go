@@ -0,0 +1,167 @@
+package openapi_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"user-management/pkg/openapi"
+)
+
+const spec = `{
+  "openapi": "3.1.0",
+  "servers": [{"url": "/api/v1"}],
+  "paths": {
+    "/things/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string", "format": "uuid"}}],
+      "put": {
+        "parameters": [
+          {"name": "limit", "in": "query", "schema": {"type": "integer", "minimum": 1}},
+          {"$ref": "#/components/parameters/Sort"}
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Thing"}}}
+        },
+        "responses": {
+          "200": {"description": "ok", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Thing"}}}},
+          "204": {"description": "sin cuerpo"}
+        }
+      }
+    }
+  },
+  "components": {
+    "parameters": {
+      "Sort": {"name": "sort", "in": "query", "schema": {"type": "string", "enum": ["name", "-name"]}}
+    },
+    "schemas": {
+      "Thing": {
+        "type": "object",
+        "required": ["name", "items"],
+        "additionalProperties": false,
+        "properties": {
+          "name": {"type": "string", "minLength": 3},
+          "email": {"type": "string", "format": "email"},
+          "age": {"type": "integer", "minimum": 0, "maximum": 120},
+          "items": {"type": "array", "minItems": 1, "items": {"$ref": "#/components/schemas/Item"}}
+        }
+      },
+      "Item": {
+        "type": "object",
+        "required": ["price"],
+        "properties": {"price": {"type": "number", "exclusiveMinimum": 0}}
+      }
+    }
+  }
+}`
+
+func loadOperation(t *testing.T) *openapi.Operation {
+	doc, err := openapi.Load([]byte(spec))
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v1", doc.BasePath())
+
+	op, ok := doc.Operation("PUT", "/things/{id}")
+	require.True(t, ok)
+	return op
+}
+
+func violations(t *testing.T, err error) map[string]string {
+	var invalid *openapi.ValidationError
+	require.ErrorAs(t, err, &invalid)
+
+	byField := make(map[string]string)
+	for _, v := range invalid.Violations {
+		byField[v.Field] = v.String()
+	}
+	return byField
+}
+
+func TestValidateParameters(t *testing.T) {
+	op := loadOperation(t)
+
+	valid := openapi.Request{
+		PathParams: map[string]string{"id": "7b6d3c1e-2f4a-4d5b-9c8e-1a2b3c4d5e6f"},
+		Query:      url.Values{"limit": {"10"}, "sort": {"-name"}},
+	}
+	assert.NoError(t, op.ValidateParameters(valid))
+
+	err := op.ValidateParameters(openapi.Request{
+		PathParams: map[string]string{"id": "42"},
+		Query:      url.Values{"limit": {"0"}, "sort": {"age"}},
+	})
+	assert.Equal(t, map[string]string{
+		"id":    "id must be a UUID",
+		"limit": "limit must be greater than or equal to 1",
+		"sort":  "sort must be one of: name, -name",
+	}, violations(t, err))
+
+	err = op.ValidateParameters(openapi.Request{
+		PathParams: map[string]string{"id": "7b6d3c1e-2f4a-4d5b-9c8e-1a2b3c4d5e6f"},
+		Query:      url.Values{"limit": {"ten"}},
+	})
+	assert.Equal(t, map[string]string{"limit": "limit must be an integer"}, violations(t, err))
+}
+
+func TestValidateBody(t *testing.T) {
+	op := loadOperation(t)
+	request := func(contentType, body string) openapi.Request {
+		return openapi.Request{ContentType: contentType, Body: []byte(body)}
+	}
+
+	t.Run("accepts a valid body", func(t *testing.T) {
+		err := op.ValidateBody(request("application/json; charset=utf-8",
+			`{"name":"John","email":"john@example.com","age":30,"items":[{"price":1.5}]}`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("reports every invalid field", func(t *testing.T) {
+		err := op.ValidateBody(request("application/json",
+			`{"name":"Jo","email":"nope","age":30.5,"items":[{"price":0},{}],"extra":true}`))
+		assert.Equal(t, map[string]string{
+			"name":           "name must be at least 3 characters",
+			"email":          "email must be a valid email address",
+			"age":            "age must be of type integer",
+			"items[0].price": "items[0].price must be greater than 0",
+			"items[1].price": "items[1].price is required",
+			"extra":          "extra is not allowed",
+		}, violations(t, err))
+	})
+
+	t.Run("reports missing required fields", func(t *testing.T) {
+		err := op.ValidateBody(request("application/json", `{}`))
+		assert.Equal(t, map[string]string{
+			"name":  "name is required",
+			"items": "items is required",
+		}, violations(t, err))
+	})
+
+	t.Run("names the root value", func(t *testing.T) {
+		err := op.ValidateBody(request("application/json", `[]`))
+		assert.Equal(t, map[string]string{"body": "body must be of type object"}, violations(t, err))
+	})
+
+	t.Run("rejects malformed and missing bodies", func(t *testing.T) {
+		assert.ErrorIs(t, op.ValidateBody(request("application/json", `{"name":`)), openapi.ErrMalformedBody)
+		assert.ErrorIs(t, op.ValidateBody(request("application/json", ``)), openapi.ErrMalformedBody)
+	})
+
+	t.Run("rejects undocumented content types", func(t *testing.T) {
+		err := op.ValidateBody(request("text/plain", `hello`))
+		assert.ErrorIs(t, err, openapi.ErrUnsupportedMediaType)
+	})
+}
+
+func TestValidateResponse(t *testing.T) {
+	op := loadOperation(t)
+
+	assert.NoError(t, op.ValidateResponse(200, "application/json", []byte(`{"name":"John","items":[{"price":2}]}`)))
+	assert.NoError(t, op.ValidateResponse(204, "", nil))
+
+	err := op.ValidateResponse(200, "application/json", []byte(`{"name":"John"}`))
+	assert.Equal(t, map[string]string{"items": "items is required"}, violations(t, err))
+
+	assert.ErrorIs(t, op.ValidateResponse(500, "application/json", []byte(`{}`)), openapi.ErrUndocumentedResponse)
+	assert.ErrorIs(t, op.ValidateResponse(200, "text/html", []byte(`<p>`)), openapi.ErrUnsupportedMediaType)
+}
@@ -0,0 +1,343 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Violation es un incumplimiento del esquema. Field es la ruta del valor
+// ("items[0].price"); Message lleva verbos de fmt que completa Args, cuyo
+// primer elemento es siempre Field, para poder traducirlo.
+type Violation struct {
+	Field   string
+	Rule    string
+	Message string
+	Args    []any
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf(v.Message, v.Args...)
+}
+
+// ValidationError reúne todos los incumplimientos de un mismo valor
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.String())
+	}
+	return "schema validation failed: " + strings.Join(messages, "; ")
+}
+
+// validator acumula los incumplimientos de un valor. root nombra el valor
+// completo cuando el problema está en la raíz ("body").
+type validator struct {
+	doc        *Document
+	root       string
+	violations []Violation
+}
+
+func (v *validator) err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: v.violations}
+}
+
+func (v *validator) add(field, rule, message string, args ...any) {
+	if field == "" {
+		field = v.root
+	}
+	v.violations = append(v.violations, Violation{
+		Field:   field,
+		Rule:    rule,
+		Message: message,
+		Args:    append([]any{field}, args...),
+	})
+}
+
+// validate comprueba value contra schema. Se detiene en el primer problema
+// de cada valor para no repetir errores derivados, pero sigue con el resto
+// de propiedades y elementos.
+func (v *validator) validate(field string, value any, rawSchema any) {
+	schema := mapOf(v.doc.resolve(rawSchema))
+	if schema == nil {
+		return
+	}
+
+	for _, sub := range listOf(schema["allOf"]) {
+		v.validate(field, value, sub)
+	}
+	for _, key := range []string{"anyOf", "oneOf"} {
+		if alternatives := listOf(schema[key]); len(alternatives) > 0 && !v.matchesAny(value, alternatives) {
+			v.add(field, strings.ToLower(key), "%s does not match any of the allowed schemas")
+			return
+		}
+	}
+
+	if types := typesOf(schema["type"]); len(types) > 0 && !hasType(value, types) {
+		v.add(field, "type", "%s must be of type %s", strings.Join(types, " or "))
+		return
+	}
+	if enum, ok := schema["enum"].([]any); ok && !contains(enum, value) {
+		v.add(field, "oneof", "%s must be one of: %s", joinValues(enum))
+		return
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, value) {
+		v.add(field, "oneof", "%s must be one of: %s", joinValues([]any{constant}))
+		return
+	}
+
+	switch value := value.(type) {
+	case string:
+		v.validateString(field, value, schema)
+	case float64:
+		v.validateNumber(field, value, schema)
+	case []any:
+		v.validateArray(field, value, schema)
+	case map[string]any:
+		v.validateObject(field, value, schema)
+	}
+}
+
+func (v *validator) matchesAny(value any, alternatives []any) bool {
+	for _, alternative := range alternatives {
+		probe := validator{doc: v.doc}
+		probe.validate("", value, alternative)
+		if len(probe.violations) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validator) validateString(field, value string, schema map[string]any) {
+	length := float64(utf8.RuneCountInString(value))
+	if min, ok := schema["minLength"].(float64); ok && length < min {
+		v.add(field, "min", "%s must be at least %s characters", formatNumber(min))
+		return
+	}
+	if max, ok := schema["maxLength"].(float64); ok && length > max {
+		v.add(field, "max", "%s must be at most %s characters", formatNumber(max))
+		return
+	}
+
+	switch schema["format"] {
+	case "email":
+		if addr, err := mail.ParseAddress(value); err != nil || addr.Address != value {
+			v.add(field, "email", "%s must be a valid email address")
+		}
+	case "uuid":
+		if _, err := uuid.Parse(value); err != nil {
+			v.add(field, "uuid", "%s must be a UUID")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			v.add(field, "datetime", "%s must be an RFC 3339 date")
+		}
+	}
+}
+
+func (v *validator) validateNumber(field string, value float64, schema map[string]any) {
+	switch {
+	case isSet(schema, "minimum") && value < number(schema, "minimum"):
+		v.add(field, "gte", "%s must be greater than or equal to %s", formatNumber(number(schema, "minimum")))
+	case isSet(schema, "maximum") && value > number(schema, "maximum"):
+		v.add(field, "lte", "%s must be less than or equal to %s", formatNumber(number(schema, "maximum")))
+	case isSet(schema, "exclusiveMinimum") && value <= number(schema, "exclusiveMinimum"):
+		v.add(field, "gt", "%s must be greater than %s", formatNumber(number(schema, "exclusiveMinimum")))
+	case isSet(schema, "exclusiveMaximum") && value >= number(schema, "exclusiveMaximum"):
+		v.add(field, "lt", "%s must be less than %s", formatNumber(number(schema, "exclusiveMaximum")))
+	}
+}
+
+func (v *validator) validateArray(field string, value []any, schema map[string]any) {
+	count := float64(len(value))
+	if min, ok := schema["minItems"].(float64); ok && count < min {
+		v.add(field, "min", "%s must have at least %s items", formatNumber(min))
+		return
+	}
+	if max, ok := schema["maxItems"].(float64); ok && count > max {
+		v.add(field, "max", "%s must have at most %s items", formatNumber(max))
+		return
+	}
+
+	if items, ok := schema["items"]; ok {
+		for i, item := range value {
+			v.validate(fmt.Sprintf("%s[%d]", field, i), item, items)
+		}
+	}
+}
+
+func (v *validator) validateObject(field string, value map[string]any, schema map[string]any) {
+	properties := mapOf(schema["properties"])
+
+	for _, name := range listOf(schema["required"]) {
+		name := stringOf(name)
+		if _, ok := value[name]; !ok {
+			v.add(join(field, name), "required", "%s is required")
+		}
+	}
+
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if property, ok := properties[name]; ok {
+			v.validate(join(field, name), value[name], property)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.add(join(field, name), "unknown", "%s is not allowed")
+			}
+		case map[string]any:
+			v.validate(join(field, name), value[name], additional)
+		}
+	}
+}
+
+// parseParameter convierte el texto de un parámetro al tipo de su esquema
+func (v *validator) parseParameter(name, value string, rawSchema any) (any, bool) {
+	schema := mapOf(v.doc.resolve(rawSchema))
+	types := typesOf(schema["type"])
+	if len(types) == 0 {
+		return value, true
+	}
+
+	switch types[0] {
+	case "integer":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			v.add(name, "type", "%s must be an integer")
+			return nil, false
+		}
+		return float64(n), true
+	case "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			v.add(name, "type", "%s must be a number")
+			return nil, false
+		}
+		return n, true
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			v.add(name, "type", "%s must be true or false")
+			return nil, false
+		}
+		return b, true
+	}
+	return value, true
+}
+
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+func typesOf(node any) []string {
+	switch t := node.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, item := range t {
+			types = append(types, stringOf(item))
+		}
+		return types
+	}
+	return nil
+}
+
+func hasType(value any, types []string) bool {
+	for _, t := range types {
+		switch value := value.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case float64:
+			if t == "number" || (t == "integer" && value == math.Trunc(value)) {
+				return true
+			}
+		case []any:
+			if t == "array" {
+				return true
+			}
+		case map[string]any:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func contains(values []any, value any) bool {
+	for _, candidate := range values {
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinValues(values []any) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			parts = append(parts, s)
+			continue
+		}
+		raw, _ := json.Marshal(value)
+		parts = append(parts, string(raw))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func listOf(node any) []any {
+	list, _ := node.([]any)
+	return list
+}
+
+func isSet(schema map[string]any, key string) bool {
+	_, ok := schema[key].(float64)
+	return ok
+}
+
+func number(schema map[string]any, key string) float64 {
+	n, _ := schema[key].(float64)
+	return n
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
@@ -0,0 +1,542 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter es un filtro SCIM ya interpretado (RFC 7644, sección 3.4.2.2)
+type Filter interface {
+	// Match indica si el recurso, serializado a JSON y decodificado, cumple
+	// el filtro
+	Match(resource map[string]any) bool
+}
+
+// ParseFilter interpreta filter. Admite los operadores eq, ne, co, sw, ew,
+// gt, ge, lt, le y pr, los lógicos and, or y not con paréntesis y los
+// filtros sobre atributos multivaluados (emails[type eq "work"]).
+func ParseFilter(filter string) (Filter, error) {
+	p, err := newParser(filter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+	}
+	f, err := p.parseOr()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+	}
+	return f, nil
+}
+
+// Comparison es una comparación simple de un filtro: Attr es la ruta sin
+// prefijo de esquema (name.givenName), Op el operador en minúsculas y Value
+// un string, float64, bool o nil
+type Comparison struct {
+	Attr  string
+	Op    string
+	Value any
+}
+
+// Conditions devuelve las comparaciones de f cuando f es una comparación o
+// varias unidas con and, para traducirlo a la consulta de un almacén. ok es
+// false si usa or, not, pr o filtros sobre valores multivaluados.
+func Conditions(f Filter) (conditions []Comparison, ok bool) {
+	switch f := f.(type) {
+	case compare:
+		return []Comparison{{Attr: strings.Join(f.path, "."), Op: f.op, Value: f.value}}, true
+	case and:
+		left, ok := Conditions(f.left)
+		if !ok {
+			return nil, false
+		}
+		right, ok := Conditions(f.right)
+		if !ok {
+			return nil, false
+		}
+		return append(left, right...), true
+	}
+	return nil, false
+}
+
+// Path es la ruta de una operación PATCH: atributo, filtro opcional sobre
+// sus valores y subatributo opcional, p. ej. emails[type eq "work"].value
+type Path struct {
+	Attr   string
+	Filter Filter
+	Sub    string
+}
+
+// ParsePath interpreta la ruta de una operación PATCH (RFC 7644, sección
+// 3.5.2)
+func ParsePath(path string) (Path, error) {
+	p, err := newParser(path)
+	if err != nil {
+		return Path{}, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+
+	attr := p.next()
+	if attr.kind != tokenWord {
+		return Path{}, fmt.Errorf("%w: %q", ErrInvalidPath, path)
+	}
+	segments := attrPath(attr.text)
+	if len(segments) > 2 || segments[0] == "" {
+		return Path{}, fmt.Errorf("%w: %q", ErrInvalidPath, path)
+	}
+
+	result := Path{Attr: segments[0]}
+	if len(segments) == 2 {
+		result.Sub = segments[1]
+	}
+
+	if p.peek().kind == tokenLBracket && result.Sub == "" {
+		p.next()
+		if result.Filter, err = p.parseOr(); err != nil {
+			return Path{}, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+		}
+		if err := p.expect(tokenRBracket); err != nil {
+			return Path{}, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+		}
+		if sub := p.peek(); sub.kind == tokenWord && strings.HasPrefix(sub.text, ".") && len(sub.text) > 1 {
+			p.next()
+			result.Sub = sub.text[1:]
+		}
+	}
+
+	if p.peek().kind != tokenEOF {
+		return Path{}, fmt.Errorf("%w: unexpected %q", ErrInvalidPath, p.peek().text)
+	}
+	return result, nil
+}
+
+// attrPath separa un atributo, sin prefijo de esquema, en sus segmentos:
+// "name.givenName" -> ["name", "givenName"]
+func attrPath(name string) []string {
+	return strings.Split(attrName(name), ".")
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		switch ch := input[i]; {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '(' || ch == ')' || ch == '[' || ch == ']':
+			kind := map[byte]tokenKind{'(': tokenLParen, ')': tokenRParen, '[': tokenLBracket, ']': tokenRBracket}[ch]
+			tokens = append(tokens, token{kind: kind, text: string(ch)})
+			i++
+		case ch == '"':
+			end := i + 1
+			for end < len(input) && input[end] != '"' {
+				if input[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(input) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			var value string
+			if err := json.Unmarshal([]byte(input[i:end+1]), &value); err != nil {
+				return nil, fmt.Errorf("invalid string at %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value})
+			i = end + 1
+		default:
+			end := i
+			for end < len(input) && !strings.ContainsRune(" \t\n\r()[]\"", rune(input[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: input[i:end]})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func newParser(input string) (*parser, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return &parser{tokens: tokens}, nil
+}
+
+func (p *parser) peek() token {
+	if p.pos >= len(p.tokens) {
+		return token{kind: tokenEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind) error {
+	if t := p.next(); t.kind != kind {
+		if t.kind == tokenEOF {
+			return fmt.Errorf("unexpected end of expression")
+		}
+		return fmt.Errorf("unexpected %q", t.text)
+	}
+	return nil
+}
+
+// keyword indica si el siguiente token es la palabra reservada word
+func (p *parser) keyword(word string) bool {
+	t := p.peek()
+	return t.kind == tokenWord && strings.EqualFold(t.text, word)
+}
+
+// parseOr, parseAnd y parseUnary siguen la precedencia de RFC 7644: not
+// antes que and y and antes que or
+func (p *parser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.keyword("or") {
+		p.next()
+		var right Filter
+		if right, err = p.parseAnd(); err == nil {
+			left = or{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseAnd() (Filter, error) {
+	left, err := p.parseUnary()
+	for err == nil && p.keyword("and") {
+		p.next()
+		var right Filter
+		if right, err = p.parseUnary(); err == nil {
+			left = and{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseUnary() (Filter, error) {
+	negate := p.keyword("not")
+	if negate {
+		p.next()
+		if p.peek().kind != tokenLParen {
+			return nil, fmt.Errorf("not requires a parenthesized filter")
+		}
+	}
+
+	var f Filter
+	var err error
+	if p.peek().kind == tokenLParen {
+		p.next()
+		if f, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenRParen); err != nil {
+			return nil, err
+		}
+	} else if f, err = p.parseAttrExp(); err != nil {
+		return nil, err
+	}
+
+	if negate {
+		return not{f}, nil
+	}
+	return f, nil
+}
+
+func (p *parser) parseAttrExp() (Filter, error) {
+	attr := p.next()
+	if attr.kind != tokenWord {
+		return nil, fmt.Errorf("expected an attribute, got %q", attr.text)
+	}
+	path := attrPath(attr.text)
+
+	if p.peek().kind == tokenLBracket {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenRBracket); err != nil {
+			return nil, err
+		}
+		return valuePath{path: path, filter: inner}, nil
+	}
+
+	op := p.next()
+	if op.kind != tokenWord {
+		return nil, fmt.Errorf("expected an operator after %q", attr.text)
+	}
+	switch operator := strings.ToLower(op.text); operator {
+	case "pr":
+		return present{path: path}, nil
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return compare{path: path, op: operator, value: value}, nil
+	default:
+		return nil, fmt.Errorf("unknown operator %q", op.text)
+	}
+}
+
+func (p *parser) parseValue() (any, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return t.text, nil
+	case tokenWord:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		if number, err := strconv.ParseFloat(t.text, 64); err == nil {
+			return number, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid comparison value %q", t.text)
+}
+
+type and struct{ left, right Filter }
+
+func (f and) Match(resource map[string]any) bool {
+	return f.left.Match(resource) && f.right.Match(resource)
+}
+
+type or struct{ left, right Filter }
+
+func (f or) Match(resource map[string]any) bool {
+	return f.left.Match(resource) || f.right.Match(resource)
+}
+
+type not struct{ filter Filter }
+
+func (f not) Match(resource map[string]any) bool {
+	return !f.filter.Match(resource)
+}
+
+type present struct{ path []string }
+
+// Match comprueba que el atributo tenga algún valor no vacío
+func (f present) Match(resource map[string]any) bool {
+	for _, value := range values(resource, f.path) {
+		if s, ok := value.(string); !ok || s != "" {
+			return true
+		}
+	}
+	return false
+}
+
+type compare struct {
+	path  []string
+	op    string
+	value any
+}
+
+// Match se cumple si algún valor del atributo cumple la comparación; ne se
+// cumple si ninguno es igual y "eq null" si el atributo no tiene valor
+func (f compare) Match(resource map[string]any) bool {
+	actual := values(resource, f.path)
+	if f.value == nil {
+		switch f.op {
+		case "eq":
+			return len(actual) == 0
+		case "ne":
+			return len(actual) > 0
+		}
+		return false
+	}
+
+	op := f.op
+	if op == "ne" {
+		op = "eq"
+	}
+	matched := false
+	for _, value := range actual {
+		if compareValue(value, op, f.value) {
+			matched = true
+			break
+		}
+	}
+	if f.op == "ne" {
+		return !matched
+	}
+	return matched
+}
+
+func compareValue(actual any, op string, expected any) bool {
+	switch expected := expected.(type) {
+	case string:
+		actual, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		a, e := strings.ToLower(actual), strings.ToLower(expected)
+		switch op {
+		case "eq":
+			return a == e
+		case "co":
+			return strings.Contains(a, e)
+		case "sw":
+			return strings.HasPrefix(a, e)
+		case "ew":
+			return strings.HasSuffix(a, e)
+		}
+		return ordered(op, compareStrings(actual, expected))
+	case float64:
+		actual, ok := actual.(float64)
+		if !ok {
+			return false
+		}
+		switch {
+		case actual < expected:
+			return ordered(op, -1)
+		case actual > expected:
+			return ordered(op, 1)
+		}
+		return ordered(op, 0)
+	case bool:
+		actual, ok := actual.(bool)
+		return ok && op == "eq" && actual == expected
+	}
+	return false
+}
+
+// compareStrings ordena las fechas (RFC 3339) por su instante y el resto de
+// cadenas sin distinguir mayúsculas
+func compareStrings(a, b string) int {
+	ta, errA := time.Parse(time.RFC3339Nano, a)
+	tb, errB := time.Parse(time.RFC3339Nano, b)
+	if errA == nil && errB == nil {
+		return ta.Compare(tb)
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// ordered traduce el resultado de una comparación (-1, 0, 1) según op
+func ordered(op string, cmp int) bool {
+	switch op {
+	case "eq":
+		return cmp == 0
+	case "gt":
+		return cmp > 0
+	case "ge":
+		return cmp >= 0
+	case "lt":
+		return cmp < 0
+	case "le":
+		return cmp <= 0
+	}
+	return false
+}
+
+type valuePath struct {
+	path   []string
+	filter Filter
+}
+
+// Match se cumple si algún valor del atributo multivaluado cumple el filtro
+func (f valuePath) Match(resource map[string]any) bool {
+	for _, element := range elements(resource, f.path) {
+		if f.filter.Match(element) {
+			return true
+		}
+	}
+	return false
+}
+
+// values devuelve los valores simples que alcanza path, aplanando los
+// atributos multivaluados. Un valor complejo al final de la ruta se compara
+// por su subatributo "value", como pide RFC 7644 para emails, phoneNumbers...
+func values(value any, path []string) []any {
+	switch value := value.(type) {
+	case []any:
+		var result []any
+		for _, element := range value {
+			result = append(result, values(element, path)...)
+		}
+		return result
+	case map[string]any:
+		if len(path) == 0 {
+			if inner, ok := value[lookup(value, "value")]; ok {
+				return values(inner, nil)
+			}
+			return []any{value}
+		}
+		child, ok := value[lookup(value, path[0])]
+		if !ok {
+			return nil
+		}
+		return values(child, path[1:])
+	case nil:
+		return nil
+	}
+	if len(path) > 0 {
+		return nil
+	}
+	return []any{value}
+}
+
+// elements devuelve los valores complejos que alcanza path
+func elements(value map[string]any, path []string) []map[string]any {
+	var current any = value
+	for _, segment := range path {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = object[lookup(object, segment)]
+	}
+
+	switch current := current.(type) {
+	case map[string]any:
+		return []map[string]any{current}
+	case []any:
+		var result []map[string]any
+		for _, element := range current {
+			if object, ok := element.(map[string]any); ok {
+				result = append(result, object)
+			}
+		}
+		return result
+	}
+	return nil
+}
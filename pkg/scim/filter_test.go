@@ -0,0 +1,156 @@
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bjensen es un extracto del usuario de ejemplo de RFC 7643, sección 8.2
+const bjensen = `{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "id": "2819c223-7f76-453a-919d-413861904646",
+  "userName": "bjensen@example.com",
+  "name": {"formatted": "Ms. Barbara J Jensen III", "familyName": "Jensen", "givenName": "Barbara"},
+  "displayName": "Babs Jensen",
+  "title": "Tour Guide",
+  "userType": "Employee",
+  "active": true,
+  "emails": [
+    {"value": "bjensen@example.com", "type": "work", "primary": true},
+    {"value": "babs@jensen.org", "type": "home"}
+  ],
+  "meta": {"resourceType": "User", "created": "2010-01-23T04:56:22Z", "lastModified": "2011-05-13T04:42:34Z"}
+}`
+
+func resource(t *testing.T, doc string) map[string]any {
+	var r map[string]any
+	require.NoError(t, json.Unmarshal([]byte(doc), &r))
+	return r
+}
+
+func TestParseFilter(t *testing.T) {
+	user := resource(t, bjensen)
+
+	// Los filtros de ejemplo de RFC 7644, sección 3.4.2.2, más algunos casos
+	// límite
+	tests := []struct {
+		filter string
+		match  bool
+	}{
+		{`userName eq "bjensen@example.com"`, true},
+		{`userName Eq "BJENSEN@EXAMPLE.COM"`, true},
+		{`userName eq "jsmith@example.com"`, false},
+		{`name.familyName co "ense"`, true},
+		{`userName sw "bj"`, true},
+		{`userName ew "example.com"`, true},
+		{`title pr`, true},
+		{`nickName pr`, false},
+		{`meta.lastModified gt "2011-05-13T04:42:34Z"`, false},
+		{`meta.lastModified ge "2011-05-13T04:42:34Z"`, true},
+		{`meta.lastModified lt "2011-05-13T04:42:34.5Z"`, true},
+		{`title pr and userType eq "Employee"`, true},
+		{`title pr or userType eq "Intern"`, true},
+		{`userType eq "Employee" and (emails co "example.com" or emails.value co "example.org")`, true},
+		{`userType ne "Employee" and not (emails co "example.com" or emails.value co "example.org")`, false},
+		{`userType eq "Employee" and (emails.type eq "work")`, true},
+		{`userType eq "Employee" and emails[type eq "work" and value co "@example.com"]`, true},
+		{`emails[type eq "home" and value co "@example.com"]`, false},
+		{`emails[type eq "work" and value co "@example.com"] or ims[type eq "xmpp" and value co "@foo.com"]`, true},
+		{`active eq true`, true},
+		{`active ne true`, false},
+		{`nickName eq null`, true},
+		{`not (active eq false)`, true},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName sw "BJ"`, true},
+		{`id eq "2819c223-7f76-453a-919d-413861904646" or title eq "x" and active eq false`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			filter, err := ParseFilter(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.match, filter.Match(user))
+		})
+	}
+
+	t.Run("rejects malformed filters", func(t *testing.T) {
+		for _, filter := range []string{
+			``,
+			`userName`,
+			`userName eq`,
+			`userName like "x"`,
+			`userName eq "unterminated`,
+			`userName eq bjensen`,
+			`(userName eq "x"`,
+			`userName eq "x" and`,
+			`not userName eq "x"`,
+			`emails[type eq "work"`,
+		} {
+			_, err := ParseFilter(filter)
+			assert.ErrorIs(t, err, ErrInvalidFilter, filter)
+		}
+	})
+}
+
+func TestConditions(t *testing.T) {
+	t.Run("flattens comparisons joined with and", func(t *testing.T) {
+		filter, err := ParseFilter(`urn:ietf:params:scim:schemas:core:2.0:User:userName Sw "bj" and (active eq true and meta.created ge "2010-01-01T00:00:00Z")`)
+		require.NoError(t, err)
+
+		conditions, ok := Conditions(filter)
+
+		require.True(t, ok)
+		assert.Equal(t, []Comparison{
+			{Attr: "userName", Op: "sw", Value: "bj"},
+			{Attr: "active", Op: "eq", Value: true},
+			{Attr: "meta.created", Op: "ge", Value: "2010-01-01T00:00:00Z"},
+		}, conditions)
+	})
+
+	t.Run("rejects filters that are not a conjunction of comparisons", func(t *testing.T) {
+		for _, raw := range []string{
+			`title pr`,
+			`title pr and active eq true`,
+			`userName eq "x" or active eq true`,
+			`not (active eq false)`,
+			`emails[type eq "work"]`,
+		} {
+			filter, err := ParseFilter(raw)
+			require.NoError(t, err)
+
+			_, ok := Conditions(filter)
+			assert.False(t, ok, raw)
+		}
+	})
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path string
+		attr string
+		sub  string
+	}{
+		{`active`, "active", ""},
+		{`name.givenName`, "name", "givenName"},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:name.familyName`, "name", "familyName"},
+		{`emails[type eq "work"]`, "emails", ""},
+		{`emails[type eq "work"].value`, "emails", "value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := ParsePath(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.attr, path.Attr)
+			assert.Equal(t, tt.sub, path.Sub)
+		})
+	}
+
+	t.Run("rejects malformed paths", func(t *testing.T) {
+		for _, path := range []string{`name.givenName.x`, `emails[type eq]`, `emails[type eq "work"] value`, `"active"`} {
+			_, err := ParsePath(path)
+			assert.ErrorIs(t, err, ErrInvalidPath, path)
+		}
+	})
+}
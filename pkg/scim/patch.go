@@ -0,0 +1,190 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PatchOp es el cuerpo de una petición PATCH (RFC 7644, sección 3.5.2)
+type PatchOp struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// PatchOperation es una operación add, replace o remove. Sin Path, Value es
+// un objeto cuyas claves son a su vez rutas.
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyPatch aplica un mensaje PatchOp a doc, el recurso serializado. Las
+// operaciones se aplican en orden y de forma atómica: si una falla se
+// devuelve el error y ningún cambio. Los nombres de operación no distinguen
+// mayúsculas porque algunos proveedores envían "Replace".
+func ApplyPatch(doc, patch []byte) ([]byte, error) {
+	var resource map[string]any
+	if err := json.Unmarshal(doc, &resource); err != nil {
+		return nil, err
+	}
+
+	var request PatchOp
+	if err := json.Unmarshal(patch, &request); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSyntax, err)
+	}
+	if !HasSchema(request.Schemas, PatchOpSchema) {
+		return nil, fmt.Errorf("%w: schemas must include %s", ErrInvalidSyntax, PatchOpSchema)
+	}
+	if len(request.Operations) == 0 {
+		return nil, fmt.Errorf("%w: no operations", ErrInvalidSyntax)
+	}
+
+	for i, op := range request.Operations {
+		if err := op.apply(resource); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(resource)
+}
+
+func (op PatchOperation) apply(resource map[string]any) error {
+	name := strings.ToLower(op.Op)
+	var value any
+	switch name {
+	case "add", "replace":
+		if len(op.Value) == 0 {
+			return fmt.Errorf("%w: %s requires a value", ErrInvalidValue, name)
+		}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSyntax, err)
+		}
+	case "remove":
+	default:
+		return fmt.Errorf("%w: unknown operation %q", ErrInvalidSyntax, op.Op)
+	}
+
+	if op.Path != "" {
+		path, err := ParsePath(op.Path)
+		if err != nil {
+			return err
+		}
+		return applyPath(resource, name, path, value)
+	}
+
+	if name == "remove" {
+		return fmt.Errorf("%w: remove requires a path", ErrNoTarget)
+	}
+	attributes, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%w: without a path the value must be an object", ErrInvalidValue)
+	}
+	for attribute, value := range attributes {
+		path, err := ParsePath(attribute)
+		if err != nil {
+			return err
+		}
+		if err := applyPath(resource, name, path, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyPath(resource map[string]any, op string, path Path, value any) error {
+	key := lookup(resource, path.Attr)
+	current, exists := resource[key]
+
+	if path.Filter != nil {
+		list, _ := current.([]any)
+		kept := make([]any, 0, len(list))
+		matched := false
+		for _, element := range list {
+			object, ok := element.(map[string]any)
+			if !ok || !path.Filter.Match(object) {
+				kept = append(kept, element)
+				continue
+			}
+			matched = true
+
+			switch {
+			case path.Sub != "":
+				set(object, op, path.Sub, value)
+				kept = append(kept, object)
+			case op == "replace":
+				kept = append(kept, value)
+			case op == "add":
+				kept = append(kept, addValue(object, value))
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%w: %s", ErrNoTarget, path.Attr)
+		}
+
+		if len(kept) == 0 {
+			delete(resource, key)
+		} else {
+			resource[key] = kept
+		}
+		return nil
+	}
+
+	if path.Sub != "" {
+		if !exists || current == nil {
+			if op == "remove" {
+				return nil
+			}
+			current = map[string]any{}
+			resource[key] = current
+		}
+		object, ok := current.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%w: %s has no sub-attributes", ErrInvalidPath, path.Attr)
+		}
+		set(object, op, path.Sub, value)
+		return nil
+	}
+
+	set(resource, op, path.Attr, value)
+	return nil
+}
+
+// set aplica op sobre el atributo name de object
+func set(object map[string]any, op, name string, value any) {
+	key := lookup(object, name)
+	switch op {
+	case "remove":
+		delete(object, key)
+	case "add":
+		object[key] = addValue(object[key], value)
+	case "replace":
+		object[key] = replaceValue(object[key], value)
+	}
+}
+
+// addValue añade value a los valores de un atributo multivaluado y mezcla
+// los subatributos de uno complejo; en el resto de casos lo sustituye
+func addValue(current, value any) any {
+	if list, ok := current.([]any); ok {
+		if values, ok := value.([]any); ok {
+			return append(list, values...)
+		}
+		return append(list, value)
+	}
+	return replaceValue(current, value)
+}
+
+// replaceValue sustituye el valor, salvo en los atributos complejos, donde
+// sólo cambian los subatributos de value (RFC 7644, sección 3.5.2.3)
+func replaceValue(current, value any) any {
+	object, ok := current.(map[string]any)
+	changes, isObject := value.(map[string]any)
+	if !ok || !isObject {
+		return value
+	}
+	for name, sub := range changes {
+		object[lookup(object, name)] = sub
+	}
+	return object
+}
@@ -0,0 +1,108 @@
+package scim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPatch(t *testing.T) {
+	const doc = `{
+	  "userName": "bjensen@example.com",
+	  "name": {"givenName": "Barbara", "familyName": "Jensen"},
+	  "active": true,
+	  "emails": [
+	    {"value": "bjensen@example.com", "type": "work", "primary": true},
+	    {"value": "babs@jensen.org", "type": "home"}
+	  ]
+	}`
+	patch := func(operations string) string {
+		return `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],"Operations":` + operations + `}`
+	}
+
+	tests := []struct {
+		name       string
+		operations string
+		expected   string
+	}{
+		{
+			"replace a simple attribute",
+			`[{"op":"replace","path":"active","value":false}]`,
+			`{"active":false}`,
+		},
+		{
+			"replace without a path, as Azure AD sends it",
+			`[{"op":"Replace","value":{"active":false,"name.givenName":"Babs"}}]`,
+			`{"active":false,"name":{"givenName":"Babs","familyName":"Jensen"}}`,
+		},
+		{
+			"replace a sub-attribute",
+			`[{"op":"replace","path":"name.familyName","value":"Smith"}]`,
+			`{"name":{"givenName":"Barbara","familyName":"Smith"}}`,
+		},
+		{
+			"replace merges complex attributes",
+			`[{"op":"replace","path":"name","value":{"formatted":"Babs Jensen"}}]`,
+			`{"name":{"givenName":"Barbara","familyName":"Jensen","formatted":"Babs Jensen"}}`,
+		},
+		{
+			"replace a filtered sub-attribute",
+			`[{"op":"replace","path":"emails[type eq \"work\"].value","value":"babs@example.com"}]`,
+			`{"emails":[{"value":"babs@example.com","type":"work","primary":true},{"value":"babs@jensen.org","type":"home"}]}`,
+		},
+		{
+			"add to a multi-valued attribute",
+			`[{"op":"add","path":"emails","value":[{"value":"b@example.org","type":"other"}]}]`,
+			`{"emails":[{"value":"bjensen@example.com","type":"work","primary":true},{"value":"babs@jensen.org","type":"home"},{"value":"b@example.org","type":"other"}]}`,
+		},
+		{
+			"remove filtered values",
+			`[{"op":"remove","path":"emails[type eq \"home\"]"}]`,
+			`{"emails":[{"value":"bjensen@example.com","type":"work","primary":true}]}`,
+		},
+		{
+			"remove an attribute",
+			`[{"op":"remove","path":"name.givenName"},{"op":"remove","path":"userName"}]`,
+			`{"userName":null,"name":{"familyName":"Jensen"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ApplyPatch([]byte(doc), []byte(patch(tt.operations)))
+			require.NoError(t, err)
+
+			expected := resource(t, doc)
+			for key, value := range resource(t, tt.expected) {
+				if value == nil {
+					delete(expected, key)
+					continue
+				}
+				expected[key] = value
+			}
+			assert.Equal(t, expected, resource(t, string(result)))
+		})
+	}
+
+	t.Run("rejects invalid patches", func(t *testing.T) {
+		tests := []struct {
+			patch string
+			err   error
+		}{
+			{`{"Operations":[{"op":"replace","path":"active","value":false}]}`, ErrInvalidSyntax},
+			{patch(`[]`), ErrInvalidSyntax},
+			{patch(`[{"op":"move","path":"active"}]`), ErrInvalidSyntax},
+			{patch(`[{"op":"replace","path":"active"}]`), ErrInvalidValue},
+			{patch(`[{"op":"replace","value":false}]`), ErrInvalidValue},
+			{patch(`[{"op":"remove"}]`), ErrNoTarget},
+			{patch(`[{"op":"replace","path":"emails[type eq \"fax\"].value","value":"x"}]`), ErrNoTarget},
+			{patch(`[{"op":"replace","path":"emails[type eq]","value":"x"}]`), ErrInvalidPath},
+			{patch(`[{"op":"replace","path":"active.value","value":"x"}]`), ErrInvalidPath},
+		}
+		for _, tt := range tests {
+			_, err := ApplyPatch([]byte(doc), []byte(tt.patch))
+			assert.ErrorIs(t, err, tt.err, tt.patch)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+// Package scim implementa las partes genéricas del protocolo SCIM 2.0
+// (RFC 7644): la sintaxis de filtros, las rutas de atributos y las
+// operaciones PATCH. Trabaja sobre los recursos ya serializados a JSON, de
+// modo que no depende de ningún esquema concreto.
+//
+// Los nombres de atributo no distinguen mayúsculas y las cadenas se comparan
+// sin distinguirlas (caseExact=false), que es lo que define RFC 7643 para
+// los atributos de User que se suelen filtrar (userName, emails...).
+package scim
+
+import (
+	"errors"
+	"strings"
+)
+
+// URNs de los esquemas y mensajes de SCIM
+const (
+	UserSchema         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	ListResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	PatchOpSchema      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	ErrorSchema        = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// Cada error corresponde a un scimType de la sección 3.12 de RFC 7644
+var (
+	// ErrInvalidFilter indica un filtro mal escrito
+	ErrInvalidFilter = errors.New("invalid filter")
+	// ErrInvalidPath indica una ruta de atributo mal escrita
+	ErrInvalidPath = errors.New("invalid path")
+	// ErrInvalidSyntax indica un mensaje que no sigue el esquema esperado
+	ErrInvalidSyntax = errors.New("invalid syntax")
+	// ErrInvalidValue indica una operación sin valor o con uno que no encaja
+	// en el atributo
+	ErrInvalidValue = errors.New("invalid value")
+	// ErrNoTarget indica que la ruta de una operación no seleccionó ningún
+	// valor
+	ErrNoTarget = errors.New("no target")
+)
+
+// HasSchema indica si schemas incluye urn, sin distinguir mayúsculas
+func HasSchema(schemas []string, urn string) bool {
+	for _, schema := range schemas {
+		if strings.EqualFold(schema, urn) {
+			return true
+		}
+	}
+	return false
+}
+
+// attrName quita el prefijo de esquema de un nombre de atributo:
+// "urn:ietf:params:scim:schemas:core:2.0:User:userName" -> "userName"
+func attrName(name string) string {
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// lookup devuelve la clave de object que coincide con name sin distinguir
+// mayúsculas, o name si no hay ninguna
+func lookup(object map[string]any, name string) string {
+	if _, ok := object[name]; ok {
+		return name
+	}
+	for key := range object {
+		if strings.EqualFold(key, name) {
+			return key
+		}
+	}
+	return name
+}
@@ -0,0 +1,89 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"user-management/pkg/totp"
+)
+
+// rfcSecret es la clave SHA1 de los vectores de prueba de RFC 6238
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	// Los vectores del RFC son de 8 dígitos; aquí se comparan sus 6 últimos
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		code, err := totp.Code(rfcSecret, totp.Step(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tt.code, code, "T=%d", tt.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, err := totp.Code(rfcSecret, totp.Step(now))
+	require.NoError(t, err)
+
+	step, ok := totp.Validate(rfcSecret, code, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, totp.Step(now), step)
+
+	t.Run("accepts codes within the drift window", func(t *testing.T) {
+		_, ok := totp.Validate(rfcSecret, code, now.Add(totp.Period), 1)
+		assert.True(t, ok)
+		_, ok = totp.Validate(rfcSecret, code, now.Add(-totp.Period), 1)
+		assert.True(t, ok)
+	})
+
+	t.Run("rejects codes outside the window", func(t *testing.T) {
+		_, ok := totp.Validate(rfcSecret, code, now.Add(2*totp.Period), 1)
+		assert.False(t, ok)
+		_, ok = totp.Validate(rfcSecret, code, now.Add(totp.Period), 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("rejects malformed codes and secrets", func(t *testing.T) {
+		for _, code := range []string{"", "12345", "1234567", "abcdef"} {
+			_, ok := totp.Validate(rfcSecret, code, now, 1)
+			assert.False(t, ok, code)
+		}
+		_, ok := totp.Validate("not base32!", code, now, 1)
+		assert.False(t, ok)
+	})
+}
+
+func TestGenerateSecretAndURI(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	_, ok := totp.Validate(secret, code, time.Now(), 1)
+	assert.True(t, ok)
+
+	uri, err := url.Parse(totp.URI("User Management", "john@example.com", secret))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/User Management:john@example.com", uri.Path)
+	assert.Equal(t, secret, uri.Query().Get("secret"))
+	assert.Equal(t, "User Management", uri.Query().Get("issuer"))
+}
@@ -0,0 +1,88 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyRepositoryFake implementa output.APIKeyRepository en memoria y
+// cuenta las escrituras para comprobar cuándo se registra el uso
+type APIKeyRepositoryFake struct {
+	mu      sync.Mutex
+	keys    []*entities.APIKey
+	Updates int
+	Touches int
+}
+
+func (f *APIKeyRepositoryFake) Save(ctx context.Context, key *entities.APIKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys = append(f.keys, key.Clone())
+	return nil
+}
+
+func (f *APIKeyRepositoryFake) FindByID(ctx context.Context, id uuid.UUID) (*entities.APIKey, error) {
+	return f.find(func(k *entities.APIKey) bool { return k.ID == id })
+}
+
+func (f *APIKeyRepositoryFake) FindByHash(ctx context.Context, hash string) (*entities.APIKey, error) {
+	return f.find(func(k *entities.APIKey) bool { return k.Hash == hash })
+}
+
+func (f *APIKeyRepositoryFake) FindByOwner(ctx context.Context, userID uuid.UUID) ([]*entities.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := []*entities.APIKey{}
+	for _, key := range f.keys {
+		if key.UserID == userID {
+			keys = append(keys, key.Clone())
+		}
+	}
+	return keys, nil
+}
+
+func (f *APIKeyRepositoryFake) Update(ctx context.Context, key *entities.APIKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, k := range f.keys {
+		if k.ID == key.ID {
+			f.keys[i] = key.Clone()
+			f.Updates++
+			return nil
+		}
+	}
+	return output.ErrAPIKeyNotFound
+}
+
+func (f *APIKeyRepositoryFake) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time, ip string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, k := range f.keys {
+		if k.ID == id {
+			k.MarkUsed(at, ip)
+			f.Touches++
+			return nil
+		}
+	}
+	return output.ErrAPIKeyNotFound
+}
+
+func (f *APIKeyRepositoryFake) find(match func(*entities.APIKey) bool) (*entities.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, key := range f.keys {
+		if match(key) {
+			return key.Clone(), nil
+		}
+	}
+	return nil, output.ErrAPIKeyNotFound
+}
@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+	"user-management/internal/domain/entities"
+)
+
+// AuditLogFake implementa output.AuditLog guardando las entradas
+type AuditLogFake struct {
+	mu      sync.Mutex
+	entries []entities.AuditEntry
+}
+
+func (f *AuditLogFake) Record(ctx context.Context, entry *entities.AuditEntry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entries = append(f.entries, *entry)
+	return nil
+}
+
+// Entries devuelve las entradas registradas
+func (f *AuditLogFake) Entries() []entities.AuditEntry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]entities.AuditEntry(nil), f.entries...)
+}
@@ -0,0 +1,82 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+	"user-management/internal/domain/ports/output"
+)
+
+// LoginAttemptStoreFake implementa output.LoginAttemptStore en memoria, sin
+// caducidad; Age simula el paso del tiempo
+type LoginAttemptStoreFake struct {
+	mu       sync.Mutex
+	attempts map[string]output.LoginAttempts
+}
+
+func NewLoginAttemptStoreFake() *LoginAttemptStoreFake {
+	return &LoginAttemptStoreFake{attempts: make(map[string]output.LoginAttempts)}
+}
+
+func (f *LoginAttemptStoreFake) Get(ctx context.Context, key string) (output.LoginAttempts, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.attempts[key], nil
+}
+
+func (f *LoginAttemptStoreFake) RecordAttempt(ctx context.Context, key string, window time.Duration,
+	allow func(output.LoginAttempts) error) (output.LoginAttempts, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a := f.attempts[key]
+	if err := allow(a); err != nil {
+		return a, err
+	}
+	a.Failures++
+	a.LastFailure = time.Now()
+	f.attempts[key] = a
+	return a, nil
+}
+
+func (f *LoginAttemptStoreFake) ForgetAttempt(ctx context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if a, ok := f.attempts[key]; ok && a.Failures > 0 {
+		a.Failures--
+		f.attempts[key] = a
+	}
+	return nil
+}
+
+func (f *LoginAttemptStoreFake) Lock(ctx context.Context, key string, until time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a := f.attempts[key]
+	a.LockedUntil = until
+	f.attempts[key] = a
+	return nil
+}
+
+func (f *LoginAttemptStoreFake) Reset(ctx context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.attempts, key)
+	return nil
+}
+
+// Age adelanta d el reloj de key: su último fallo y su bloqueo quedan d más
+// en el pasado
+func (f *LoginAttemptStoreFake) Age(key string, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a := f.attempts[key]
+	a.LastFailure = a.LastFailure.Add(-d)
+	if !a.LockedUntil.IsZero() {
+		a.LockedUntil = a.LockedUntil.Add(-d)
+	}
+	f.attempts[key] = a
+}
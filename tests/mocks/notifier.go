@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"sync"
+	"user-management/internal/domain/entities"
+)
+
+// NotifierMock implementa entities.Notifier guardando lo enviado
+type NotifierMock struct {
+	mu   sync.Mutex
+	sent []entities.Notification
+	Err  error
+}
+
+func (m *NotifierMock) Send(notification *entities.Notification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Err != nil {
+		return m.Err
+	}
+	notification.Sent = true
+	m.sent = append(m.sent, *notification)
+	return nil
+}
+
+func (m *NotifierMock) GetType() string {
+	return "mock"
+}
+
+// Sent devuelve las notificaciones enviadas, en orden
+func (m *NotifierMock) Sent() []entities.Notification {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]entities.Notification(nil), m.sent...)
+}
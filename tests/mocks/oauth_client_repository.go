@@ -0,0 +1,60 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+)
+
+// OAuthClientRepositoryFake implementa output.OAuthClientRepository en
+// memoria
+type OAuthClientRepositoryFake struct {
+	mu      sync.Mutex
+	clients []*entities.OAuthClient
+}
+
+func (f *OAuthClientRepositoryFake) Save(ctx context.Context, client *entities.OAuthClient) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clients = append(f.clients, client.Clone())
+	return nil
+}
+
+func (f *OAuthClientRepositoryFake) FindByID(ctx context.Context, id uuid.UUID) (*entities.OAuthClient, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, client := range f.clients {
+		if client.ID == id {
+			return client.Clone(), nil
+		}
+	}
+	return nil, output.ErrOAuthClientNotFound
+}
+
+func (f *OAuthClientRepositoryFake) FindAll(ctx context.Context) ([]*entities.OAuthClient, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	clients := []*entities.OAuthClient{}
+	for _, client := range f.clients {
+		clients = append(clients, client.Clone())
+	}
+	return clients, nil
+}
+
+func (f *OAuthClientRepositoryFake) Delete(ctx context.Context, id uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, client := range f.clients {
+		if client.ID == id {
+			f.clients = append(f.clients[:i], f.clients[i+1:]...)
+			return nil
+		}
+	}
+	return output.ErrOAuthClientNotFound
+}
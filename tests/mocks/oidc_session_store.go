@@ -0,0 +1,50 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+	"user-management/internal/domain/ports/output"
+)
+
+// OIDCSessionStoreFake implementa output.OIDCSessionStore en memoria, sin
+// caducidad
+type OIDCSessionStoreFake struct {
+	mu       sync.Mutex
+	sessions map[string]output.OIDCSession
+}
+
+func NewOIDCSessionStoreFake() *OIDCSessionStoreFake {
+	return &OIDCSessionStoreFake{sessions: make(map[string]output.OIDCSession)}
+}
+
+func (f *OIDCSessionStoreFake) Save(ctx context.Context, session output.OIDCSession) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions[session.Hash] = session
+	return nil
+}
+
+func (f *OIDCSessionStoreFake) Find(ctx context.Context, hash string) (*output.OIDCSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	session, ok := f.sessions[hash]
+	if !ok {
+		return nil, output.ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+func (f *OIDCSessionStoreFake) Delete(ctx context.Context, hash string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.sessions, hash)
+	return nil
+}
+
+// Len devuelve el número de sesiones guardadas
+func (f *OIDCSessionStoreFake) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.sessions)
+}
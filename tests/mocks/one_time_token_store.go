@@ -0,0 +1,58 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+)
+
+// OneTimeTokenStoreFake implementa output.OneTimeTokenStore en memoria, sin
+// caducidad: los tests controlan la validez de los tokens por su firma
+type OneTimeTokenStoreFake struct {
+	mu     sync.Mutex
+	tokens map[string]output.OneTimeToken
+}
+
+func NewOneTimeTokenStoreFake() *OneTimeTokenStoreFake {
+	return &OneTimeTokenStoreFake{tokens: make(map[string]output.OneTimeToken)}
+}
+
+func (f *OneTimeTokenStoreFake) Save(ctx context.Context, token output.OneTimeToken) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokens[token.Purpose+":"+token.Hash] = token
+	return nil
+}
+
+func (f *OneTimeTokenStoreFake) Consume(ctx context.Context, purpose, hash string) (*output.OneTimeToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	token, ok := f.tokens[purpose+":"+hash]
+	if !ok {
+		return nil, output.ErrTokenNotFound
+	}
+	delete(f.tokens, purpose+":"+hash)
+	return &token, nil
+}
+
+func (f *OneTimeTokenStoreFake) RevokeAll(ctx context.Context, purpose string, userID uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for key, token := range f.tokens {
+		if token.Purpose == purpose && token.UserID == userID {
+			delete(f.tokens, key)
+		}
+	}
+	return nil
+}
+
+// Len devuelve el número de tokens vigentes
+func (f *OneTimeTokenStoreFake) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.tokens)
+}
@@ -4,6 +4,7 @@ package mocks
 import (
 	"context"
 	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -42,6 +43,28 @@ func (m *OrderRepositoryMock) GetAllOrders(ctx context.Context) ([]*entities.Ord
 	return args.Get(0).([]*entities.Order), args.Error(1)
 }
 
+// FindOrders implementa output.OrderRepository
+func (m *OrderRepositoryMock) FindOrders(ctx context.Context, query output.OrderQuery) (*output.OrderPage, error) {
+	args := m.Called(ctx, query)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*output.OrderPage), args.Error(1)
+}
+
+// FindByUserID implementa output.OrderRepository
+func (m *OrderRepositoryMock) FindByUserID(ctx context.Context, userID uuid.UUID, query output.OrderQuery) (*output.UserOrderPage, error) {
+	args := m.Called(ctx, userID, query)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*output.UserOrderPage), args.Error(1)
+}
+
 // Save implementa output.OrderRepository
 func (m *OrderRepositoryMock) Save(ctx context.Context, order entities.Order) error {
 	args := m.Called(ctx, order)
@@ -54,6 +77,12 @@ func (m *OrderRepositoryMock) Update(ctx context.Context, order *entities.Order)
 	return args.Error(0)
 }
 
+// DeleteByUserID implementa output.OrderRepository
+func (m *OrderRepositoryMock) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
 // Métodos helper para facilitar la configuración de tests
 
 // SetupDelete configura el mock para el método Delete
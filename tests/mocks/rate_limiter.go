@@ -0,0 +1,25 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// RateLimiterMock implementa output.RateLimiter
+type RateLimiterMock struct {
+	mock.Mock
+}
+
+// AllowAll devuelve un limitador que acepta cualquier intento
+func AllowAll() *RateLimiterMock {
+	m := new(RateLimiterMock)
+	m.On("Allow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, time.Duration(0), nil)
+	return m
+}
+
+func (m *RateLimiterMock) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	args := m.Called(ctx, key, limit, window)
+	return args.Bool(0), args.Get(1).(time.Duration), args.Error(2)
+}
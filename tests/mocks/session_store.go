@@ -0,0 +1,140 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+	"user-management/internal/domain/entities"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/google/uuid"
+)
+
+// SessionStoreFake implementa output.SessionStore en memoria; las sesiones
+// caducan según el reloj real, como en el almacén de memoria
+type SessionStoreFake struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]entities.Session
+	spent    map[string]uuid.UUID
+}
+
+func NewSessionStoreFake() *SessionStoreFake {
+	return &SessionStoreFake{sessions: make(map[uuid.UUID]entities.Session), spent: make(map[string]uuid.UUID)}
+}
+
+func (f *SessionStoreFake) Save(ctx context.Context, session *entities.Session) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions[session.ID] = *session
+	return nil
+}
+
+func (f *SessionStoreFake) FindByID(ctx context.Context, id uuid.UUID) (*entities.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	session, ok := f.sessions[id]
+	if !ok || !time.Now().Before(session.ExpiresAt) {
+		return nil, output.ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+func (f *SessionStoreFake) FindByRefreshHash(ctx context.Context, hash string) (*entities.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, session := range f.sessions {
+		if session.RefreshHash == hash && time.Now().Before(session.ExpiresAt) {
+			return &session, nil
+		}
+	}
+	return nil, output.ErrSessionNotFound
+}
+
+func (f *SessionStoreFake) FindByUser(ctx context.Context, userID uuid.UUID) ([]*entities.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sessions := []*entities.Session{}
+	for _, session := range f.sessions {
+		if session.UserID == userID && time.Now().Before(session.ExpiresAt) {
+			sessions = append(sessions, &session)
+		}
+	}
+	return sessions, nil
+}
+
+func (f *SessionStoreFake) FindBySpentRefreshHash(ctx context.Context, hash string) (*entities.Session, error) {
+	f.mu.Lock()
+	id, ok := f.spent[hash]
+	f.mu.Unlock()
+
+	if !ok {
+		return nil, output.ErrSessionNotFound
+	}
+	return f.FindByID(ctx, id)
+}
+
+func (f *SessionStoreFake) Touch(ctx context.Context, id uuid.UUID, at time.Time, ip string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	session, ok := f.sessions[id]
+	if !ok {
+		return output.ErrSessionNotFound
+	}
+	session.Touch(at, ip)
+	f.sessions[id] = session
+	return nil
+}
+
+func (f *SessionStoreFake) Rotate(ctx context.Context, session *entities.Session, previousHash string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, ok := f.sessions[session.ID]
+	if !ok {
+		return output.ErrSessionNotFound
+	}
+	if stored.RefreshHash != previousHash {
+		return output.ErrConcurrentModification
+	}
+	f.spent[previousHash] = session.ID
+	f.sessions[session.ID] = *session
+	return nil
+}
+
+func (f *SessionStoreFake) Delete(ctx context.Context, id uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.sessions[id]; !ok {
+		return output.ErrSessionNotFound
+	}
+	delete(f.sessions, id)
+	f.forget(id)
+	return nil
+}
+
+func (f *SessionStoreFake) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for id, session := range f.sessions {
+		if session.UserID == userID {
+			delete(f.sessions, id)
+			f.forget(id)
+		}
+	}
+	return nil
+}
+
+// forget olvida los refresh tokens gastados de la sesión id
+func (f *SessionStoreFake) forget(id uuid.UUID) {
+	for hash, session := range f.spent {
+		if session == id {
+			delete(f.spent, hash)
+		}
+	}
+}
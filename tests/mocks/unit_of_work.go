@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+	"user-management/internal/domain/ports/output"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// UnitOfWorkMock implementa output.UnitOfWork. Ejecuta fn directamente con
+// el contexto recibido salvo que se configure un error para Do.
+type UnitOfWorkMock struct {
+	mock.Mock
+}
+
+var _ output.UnitOfWork = (*UnitOfWorkMock)(nil)
+
+// Do implementa output.UnitOfWork.Do
+func (m *UnitOfWorkMock) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	args := m.Called(ctx)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	return fn(ctx)
+}
+
+// SetupDo configura Do para ejecutar la función recibida
+func (m *UnitOfWorkMock) SetupDo() *mock.Call {
+	return m.On("Do", mock.Anything).Return(nil)
+}
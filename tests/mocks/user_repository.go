@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 	"user-management/internal/domain/entities"
 	"user-management/internal/domain/ports/output"
 
@@ -56,3 +57,41 @@ func (m *MockUserRepository) GetAllUsers(ctx context.Context) ([]*entities.User,
 	}
 	return args.Get(0).([]*entities.User), args.Error(1)
 }
+
+func (m *MockUserRepository) FindUsers(ctx context.Context, query output.UserQuery) (*output.UserPage, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*output.UserPage), args.Error(1)
+}
+
+func (m *MockUserRepository) Restore(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindDeletedBefore(ctx context.Context, cutoff time.Time) ([]*entities.User, error) {
+	args := m.Called(ctx, cutoff)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindAnyByID(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Purge(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+
+	return args.Error(0)
+}